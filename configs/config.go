@@ -0,0 +1,819 @@
+package configs
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+const MinSecretLength = 32
+
+// Config holds every setting of the application. Values are resolved in the
+// following order, later sources overriding earlier ones:
+//
+//  1. defaults (see Default)
+//  2. the YAML or TOML file given by -config or CONFIG_FILE
+//  3. environment variables, including those from an optional .env file
+//  4. command line flags
+//
+// Each field is addressed by its `key` tag in files and flags (e.g.
+// -server.read_timeout=20s) and by its `env` tag in the environment. Fields
+// without an `env` tag use their upper-cased key path instead, e.g.
+// RATE_LIMIT_AUTH_LIMIT for rate_limit.auth.limit.
+type Config struct {
+	GinMode        string              `key:"gin_mode" env:"GIN_MODE"`
+	TrustedProxies []string            `key:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	Server         ServerConfig        `key:"server"`
+	Mongo          MongoConfig         `key:"mongo"`
+	Auth           AuthConfig          `key:"auth"`
+	RateLimit      RateLimitConfig     `key:"rate_limit"`
+	CORS           CORSConfig          `key:"cors"`
+	Security       SecurityConfig      `key:"security_headers"`
+	Cookie         CookieConfig        `key:"cookie"`
+	CSRF           CSRFConfig          `key:"csrf"`
+	Catalog        CatalogConfig       `key:"catalog"`
+	Images         ImagesConfig        `key:"images"`
+	Imports        ImportsConfig       `key:"imports"`
+	Idempotency    IdempotencyConfig   `key:"idempotency"`
+	Webhooks       WebhooksConfig      `key:"webhooks"`
+	Events         EventsConfig        `key:"events"`
+	ProductEvents  ProductEventsConfig `key:"product_events"`
+	Jobs           JobsConfig          `key:"jobs"`
+	GraphQL        GraphQLConfig       `key:"graphql"`
+	GRPC           GRPCConfig          `key:"grpc"`
+	Validation     ValidationConfig    `key:"validation"`
+}
+
+type ServerConfig struct {
+	Port              string        `key:"port" env:"PORT"`
+	ReadTimeout       time.Duration `key:"read_timeout" env:"SERVER_READ_TIMEOUT"`
+	ReadHeaderTimeout time.Duration `key:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT"`
+	WriteTimeout      time.Duration `key:"write_timeout" env:"SERVER_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `key:"idle_timeout" env:"SERVER_IDLE_TIMEOUT"`
+	MaxHeaderBytes    int           `key:"max_header_bytes" env:"SERVER_MAX_HEADER_BYTES"`
+	ShutdownTimeout   time.Duration `key:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT"`
+	// ShutdownHookTimeout is the time the workers and connections get to stop
+	// once the requests drained
+	ShutdownHookTimeout time.Duration `key:"shutdown_hook_timeout" env:"SERVER_SHUTDOWN_HOOK_TIMEOUT"`
+}
+
+type MongoConfig struct {
+	URI            string        `key:"uri" env:"MONGO_URI"`
+	Database       string        `key:"database" env:"MONGO_DB_NAME"`
+	ConnectTimeout time.Duration `key:"connect_timeout" env:"MONGO_CONNECT_TIMEOUT"`
+	// Transactions is auto to use them when the deployment supports them, or
+	// off to never use them, e.g. behind a proxy that doesn't forward sessions
+	Transactions string `key:"transactions" env:"MONGO_TRANSACTIONS"`
+}
+
+type AuthConfig struct {
+	AccessTokenSecret  string        `key:"access_token_secret" env:"ACCESS_TOKEN_SECRET"`
+	RefreshTokenSecret string        `key:"refresh_token_secret" env:"REFRESH_TOKEN_SECRET"`
+	AccessTokenTTL     time.Duration `key:"access_token_ttl" env:"ACCESS_TOKEN_TTL"`
+	RefreshTokenTTL    time.Duration `key:"refresh_token_ttl" env:"REFRESH_TOKEN_TTL"`
+	BcryptCost         int           `key:"bcrypt_cost" env:"BCRYPT_COST"`
+}
+
+type RateLimitConfig struct {
+	Enabled     bool                  `key:"enabled" env:"RATE_LIMIT_ENABLED"`
+	Store       string                `key:"store" env:"RATE_LIMIT_STORE"`
+	ExemptRoles []string              `key:"exempt_roles" env:"RATE_LIMIT_EXEMPT_ROLES"`
+	Auth        RateLimitPolicyConfig `key:"auth"`
+	Users       RateLimitPolicyConfig `key:"users"`
+	Products    RateLimitPolicyConfig `key:"products"`
+}
+
+// RateLimitPolicyConfig allows Limit requests per Period with bursts of up to Burst requests
+type RateLimitPolicyConfig struct {
+	Limit  int           `key:"limit"`
+	Burst  int           `key:"burst"`
+	Period time.Duration `key:"period"`
+}
+
+type CORSConfig struct {
+	// AllowedOrigins accepts exact origins, "*" or wildcard subdomains such as https://*.example.com
+	AllowedOrigins   []string      `key:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowedMethods   []string      `key:"allowed_methods" env:"CORS_ALLOWED_METHODS"`
+	AllowedHeaders   []string      `key:"allowed_headers" env:"CORS_ALLOWED_HEADERS"`
+	ExposedHeaders   []string      `key:"exposed_headers" env:"CORS_EXPOSED_HEADERS"`
+	AllowCredentials bool          `key:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration `key:"max_age" env:"CORS_MAX_AGE"`
+}
+
+type SecurityConfig struct {
+	// HSTSMaxAge of zero disables the Strict-Transport-Security header
+	HSTSMaxAge            time.Duration `key:"hsts_max_age" env:"HSTS_MAX_AGE"`
+	HSTSIncludeSubdomains bool          `key:"hsts_include_subdomains" env:"HSTS_INCLUDE_SUBDOMAINS"`
+	FrameOptions          string        `key:"frame_options" env:"FRAME_OPTIONS"`
+	ContentSecurityPolicy string        `key:"content_security_policy" env:"CONTENT_SECURITY_POLICY"`
+	ReferrerPolicy        string        `key:"referrer_policy" env:"REFERRER_POLICY"`
+}
+
+type CookieConfig struct {
+	Domain   string `key:"domain" env:"COOKIE_DOMAIN"`
+	Secure   bool   `key:"secure" env:"COOKIE_SECURE"`
+	SameSite string `key:"same_site" env:"COOKIE_SAME_SITE"`
+}
+
+func (c CookieConfig) SameSiteMode() http.SameSite {
+	switch strings.ToLower(c.SameSite) {
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		return http.SameSiteLaxMode
+	}
+}
+
+type CSRFConfig struct {
+	Enabled bool   `key:"enabled" env:"CSRF_ENABLED"`
+	Secret  string `key:"secret" env:"CSRF_SECRET"`
+}
+
+type CatalogConfig struct {
+	// LegacyCurrency is assigned to prices stored before prices had a currency
+	LegacyCurrency string `key:"legacy_currency" env:"CATALOG_LEGACY_CURRENCY"`
+}
+
+type ImagesConfig struct {
+	// Store is gridfs or filesystem, Dir is only used by the filesystem store
+	Store          string `key:"store" env:"IMAGES_STORE"`
+	Dir            string `key:"dir" env:"IMAGES_DIR"`
+	MaxUploadBytes int    `key:"max_upload_bytes" env:"IMAGES_MAX_UPLOAD_BYTES"`
+	// MaxDimension bounds the width and height, MaxPixels their product. They're
+	// checked before decoding, a small file can declare a huge canvas.
+	MaxDimension int `key:"max_dimension" env:"IMAGES_MAX_DIMENSION"`
+	MaxPixels    int `key:"max_pixels" env:"IMAGES_MAX_PIXELS"`
+	// ThumbnailSizes are the longest edges in pixels of the generated thumbnails
+	ThumbnailSizes []int `key:"thumbnail_sizes" env:"IMAGES_THUMBNAIL_SIZES"`
+}
+
+type ImportsConfig struct {
+	MaxUploadBytes int `key:"max_upload_bytes" env:"IMPORTS_MAX_UPLOAD_BYTES"`
+	// BatchSize is the number of rows written with one bulk write
+	BatchSize int `key:"batch_size" env:"IMPORTS_BATCH_SIZE"`
+	// BackgroundRows is the row count above which an import runs as a background job
+	BackgroundRows int `key:"background_rows" env:"IMPORTS_BACKGROUND_ROWS"`
+}
+
+// IdempotencyConfig controls the Idempotency-Key header on unsafe requests.
+// Responses are kept for TTL, a request holds its key for at most LockTimeout
+// and retries wait up to WaitTimeout for the original request to finish.
+type IdempotencyConfig struct {
+	Enabled     bool          `key:"enabled" env:"IDEMPOTENCY_ENABLED"`
+	Store       string        `key:"store" env:"IDEMPOTENCY_STORE"`
+	TTL         time.Duration `key:"ttl" env:"IDEMPOTENCY_TTL"`
+	LockTimeout time.Duration `key:"lock_timeout" env:"IDEMPOTENCY_LOCK_TIMEOUT"`
+	WaitTimeout time.Duration `key:"wait_timeout" env:"IDEMPOTENCY_WAIT_TIMEOUT"`
+}
+
+// WebhooksConfig controls webhook deliveries. Failed deliveries are retried
+// up to MaxAttempts times, waiting InitialBackoff after the first failure and
+// twice as long after every further one, up to MaxBackoff.
+type WebhooksConfig struct {
+	Workers        int           `key:"workers" env:"WEBHOOKS_WORKERS"`
+	Timeout        time.Duration `key:"timeout" env:"WEBHOOKS_TIMEOUT"`
+	MaxAttempts    int           `key:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS"`
+	InitialBackoff time.Duration `key:"initial_backoff" env:"WEBHOOKS_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `key:"max_backoff" env:"WEBHOOKS_MAX_BACKOFF"`
+	PollInterval   time.Duration `key:"poll_interval" env:"WEBHOOKS_POLL_INTERVAL"`
+	// DeliveryRetention is how long finished deliveries are kept in the delivery log
+	DeliveryRetention time.Duration `key:"delivery_retention" env:"WEBHOOKS_DELIVERY_RETENTION"`
+	// AllowPrivateNetworks lets webhooks target loopback, private and link-local
+	// addresses, for local development only
+	AllowPrivateNetworks bool `key:"allow_private_networks" env:"WEBHOOKS_ALLOW_PRIVATE_NETWORKS"`
+}
+
+// EventsConfig controls the outbox relay that dispatches domain events to
+// their subscribers. Events whose subscribers fail are retried like webhook
+// deliveries, dispatched events are kept for Retention.
+type EventsConfig struct {
+	Timeout        time.Duration `key:"timeout" env:"EVENTS_TIMEOUT"`
+	MaxAttempts    int           `key:"max_attempts" env:"EVENTS_MAX_ATTEMPTS"`
+	InitialBackoff time.Duration `key:"initial_backoff" env:"EVENTS_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `key:"max_backoff" env:"EVENTS_MAX_BACKOFF"`
+	PollInterval   time.Duration `key:"poll_interval" env:"EVENTS_POLL_INTERVAL"`
+	Retention      time.Duration `key:"retention" env:"EVENTS_RETENTION"`
+}
+
+// ProductEventsConfig controls the product event stream. The last
+// ReplayBuffer changes are kept for clients resuming with Last-Event-ID.
+type ProductEventsConfig struct {
+	ReplayBuffer int           `key:"replay_buffer" env:"PRODUCT_EVENTS_REPLAY_BUFFER"`
+	Heartbeat    time.Duration `key:"heartbeat" env:"PRODUCT_EVENTS_HEARTBEAT"`
+}
+
+// JobsConfig controls the background job runner. Each instance runs up to
+// Workers jobs at once, a running job is leased for Lease and the lease is
+// renewed while it runs. Failed jobs are retried like webhook deliveries,
+// finished jobs are kept for Retention.
+type JobsConfig struct {
+	Workers        int           `key:"workers" env:"JOBS_WORKERS"`
+	PollInterval   time.Duration `key:"poll_interval" env:"JOBS_POLL_INTERVAL"`
+	Lease          time.Duration `key:"lease" env:"JOBS_LEASE"`
+	MaxAttempts    int           `key:"max_attempts" env:"JOBS_MAX_ATTEMPTS"`
+	InitialBackoff time.Duration `key:"initial_backoff" env:"JOBS_INITIAL_BACKOFF"`
+	MaxBackoff     time.Duration `key:"max_backoff" env:"JOBS_MAX_BACKOFF"`
+	Retention      time.Duration `key:"retention" env:"JOBS_RETENTION"`
+}
+
+// GraphQLConfig controls the GraphQL endpoint. Queries nested deeper than
+// MaxDepth or costing more than MaxComplexity are rejected before they run.
+// The playground is only served outside release mode.
+type GraphQLConfig struct {
+	Enabled       bool `key:"enabled" env:"GRAPHQL_ENABLED"`
+	Playground    bool `key:"playground" env:"GRAPHQL_PLAYGROUND"`
+	MaxDepth      int  `key:"max_depth" env:"GRAPHQL_MAX_DEPTH"`
+	MaxComplexity int  `key:"max_complexity" env:"GRAPHQL_MAX_COMPLEXITY"`
+}
+
+// GRPCConfig controls the gRPC server, it listens on its own port next to
+// the REST API. Reflection lets tools like grpcurl list the services, it's
+// only allowed outside release mode.
+type GRPCConfig struct {
+	Enabled    bool   `key:"enabled" env:"GRPC_ENABLED"`
+	Port       string `key:"port" env:"GRPC_PORT"`
+	Reflection bool   `key:"reflection" env:"GRPC_REFLECTION"`
+}
+
+// ValidationConfig checks requests against the OpenAPI document before they
+// reach the handlers. Checking responses is meant for development and tests,
+// it isn't allowed in release mode.
+type ValidationConfig struct {
+	Requests  bool `key:"requests" env:"VALIDATION_REQUESTS"`
+	Responses bool `key:"responses" env:"VALIDATION_RESPONSES"`
+}
+
+func Default() *Config {
+	return &Config{
+		GinMode: "debug",
+		Server: ServerConfig{
+			Port:                "5000",
+			ReadTimeout:         15 * time.Second,
+			ReadHeaderTimeout:   5 * time.Second,
+			WriteTimeout:        30 * time.Second,
+			IdleTimeout:         60 * time.Second,
+			MaxHeaderBytes:      1 << 20, // 1 MB
+			ShutdownTimeout:     15 * time.Second,
+			ShutdownHookTimeout: 10 * time.Second,
+		},
+		Mongo: MongoConfig{
+			ConnectTimeout: 10 * time.Second,
+			Transactions:   "auto",
+		},
+		Auth: AuthConfig{
+			AccessTokenTTL:  15 * time.Minute,
+			RefreshTokenTTL: 7 * 24 * time.Hour,
+			BcryptCost:      14,
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:     true,
+			Store:       "memory",
+			ExemptRoles: []string{"admin"},
+			Auth:        RateLimitPolicyConfig{Limit: 10, Burst: 10, Period: time.Minute},
+			Users:       RateLimitPolicyConfig{Limit: 60, Burst: 20, Period: time.Minute},
+			Products:    RateLimitPolicyConfig{Limit: 120, Burst: 40, Period: time.Minute},
+		},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+			AllowedHeaders: []string{"Content-Type", "Authorization", "X-CSRF-Token", "Idempotency-Key", "Last-Event-ID"},
+			ExposedHeaders: []string{"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "Retry-After", "Idempotent-Replayed"},
+			MaxAge:         10 * time.Minute,
+		},
+		Security: SecurityConfig{
+			HSTSMaxAge:            365 * 24 * time.Hour,
+			HSTSIncludeSubdomains: true,
+			FrameOptions:          "DENY",
+			ContentSecurityPolicy: "default-src 'none'; frame-ancestors 'none'",
+			ReferrerPolicy:        "no-referrer",
+		},
+		Cookie: CookieConfig{
+			Secure:   true,
+			SameSite: "lax",
+		},
+		CSRF: CSRFConfig{
+			Enabled: true,
+		},
+		Catalog: CatalogConfig{
+			LegacyCurrency: "USD",
+		},
+		Images: ImagesConfig{
+			Store:          "gridfs",
+			Dir:            "uploads",
+			MaxUploadBytes: 10 << 20, // 10 MB
+			MaxDimension:   10000,
+			MaxPixels:      40_000_000,
+			ThumbnailSizes: []int{160, 480, 960},
+		},
+		Imports: ImportsConfig{
+			MaxUploadBytes: 32 << 20, // 32 MB
+			BatchSize:      500,
+			BackgroundRows: 1000,
+		},
+		Idempotency: IdempotencyConfig{
+			Enabled:     true,
+			Store:       "mongo",
+			TTL:         24 * time.Hour,
+			LockTimeout: 2 * time.Minute,
+			WaitTimeout: 10 * time.Second,
+		},
+		Webhooks: WebhooksConfig{
+			Workers:           4,
+			Timeout:           10 * time.Second,
+			MaxAttempts:       8,
+			InitialBackoff:    30 * time.Second,
+			MaxBackoff:        time.Hour,
+			PollInterval:      5 * time.Second,
+			DeliveryRetention: 30 * 24 * time.Hour,
+		},
+		Events: EventsConfig{
+			Timeout:        30 * time.Second,
+			MaxAttempts:    10,
+			InitialBackoff: 5 * time.Second,
+			MaxBackoff:     10 * time.Minute,
+			PollInterval:   time.Second,
+			Retention:      7 * 24 * time.Hour,
+		},
+		ProductEvents: ProductEventsConfig{
+			ReplayBuffer: 1000,
+			Heartbeat:    15 * time.Second,
+		},
+		Jobs: JobsConfig{
+			Workers:        4,
+			PollInterval:   time.Second,
+			Lease:          time.Minute,
+			MaxAttempts:    5,
+			InitialBackoff: 10 * time.Second,
+			MaxBackoff:     time.Hour,
+			Retention:      7 * 24 * time.Hour,
+		},
+		GraphQL: GraphQLConfig{
+			Enabled:       true,
+			MaxDepth:      10,
+			MaxComplexity: 1000,
+		},
+		GRPC: GRPCConfig{
+			Enabled: true,
+			Port:    "50051",
+		},
+	}
+}
+
+// Load builds the configuration from the process arguments and environment
+func Load() (*Config, error) {
+	if err := LoadEnv(); err != nil {
+		return nil, fmt.Errorf("loading .env file: %w", err)
+	}
+	return LoadFrom(os.Args[1:], os.LookupEnv)
+}
+
+// LoadFrom builds the configuration from the given flags and environment lookup
+func LoadFrom(args []string, lookupEnv func(string) (string, bool)) (*Config, error) {
+	config := Default()
+	fields := collectFields(reflect.ValueOf(config).Elem(), "")
+
+	// Flags are parsed first so -config is known, but applied last
+	flagSet := flag.NewFlagSet("config", flag.ContinueOnError)
+	flagSet.SetOutput(io.Discard)
+	configFile := flagSet.String("config", "", "path to a YAML or TOML config file")
+	flagValues := make(map[string]string)
+	for _, f := range fields {
+		flagSet.Func(f.key, "overrides "+f.key, func(value string) error {
+			flagValues[f.key] = value
+			return nil
+		})
+	}
+	if err := flagSet.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *configFile == "" {
+		*configFile, _ = lookupEnv("CONFIG_FILE")
+	}
+	if *configFile != "" {
+		fileValues, err := readConfigFile(*configFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := applyValues(fields, fileValues, "config file"); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, f := range fields {
+		if value, ok := lookupEnv(f.env); ok && value != "" {
+			if err := f.set(value); err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", f.env, err)
+			}
+		}
+	}
+
+	if err := applyValues(fields, flagValues, "flag"); err != nil {
+		return nil, err
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// LoadEnv loads variables from a .env file if one exists
+func LoadEnv() error {
+	err := godotenv.Load()
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (c *Config) Validate() error {
+	var errs []error
+
+	switch c.GinMode {
+	case "debug", "release", "test":
+	default:
+		errs = append(errs, fmt.Errorf("gin_mode must be one of debug, release or test, got %q", c.GinMode))
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		errs = append(errs, fmt.Errorf("server.port must be a number between 1 and 65535, got %q", c.Server.Port))
+	}
+	durations := map[string]time.Duration{
+		"server.read_timeout":          c.Server.ReadTimeout,
+		"server.read_header_timeout":   c.Server.ReadHeaderTimeout,
+		"server.write_timeout":         c.Server.WriteTimeout,
+		"server.idle_timeout":          c.Server.IdleTimeout,
+		"server.shutdown_timeout":      c.Server.ShutdownTimeout,
+		"server.shutdown_hook_timeout": c.Server.ShutdownHookTimeout,
+		"mongo.connect_timeout":        c.Mongo.ConnectTimeout,
+		"auth.access_token_ttl":        c.Auth.AccessTokenTTL,
+		"auth.refresh_token_ttl":       c.Auth.RefreshTokenTTL,
+	}
+	for key, duration := range durations {
+		if duration <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", key))
+		}
+	}
+	if c.Server.MaxHeaderBytes <= 0 {
+		errs = append(errs, errors.New("server.max_header_bytes must be positive"))
+	}
+
+	if err := validateMongoURI(c.Mongo.URI); err != nil {
+		errs = append(errs, err)
+	}
+	if c.Mongo.Database == "" {
+		errs = append(errs, errors.New("mongo.database is required"))
+	}
+	if c.Mongo.Transactions != "auto" && c.Mongo.Transactions != "off" {
+		errs = append(errs, fmt.Errorf("mongo.transactions must be auto or off, got %q", c.Mongo.Transactions))
+	}
+
+	if len(c.Auth.AccessTokenSecret) < MinSecretLength {
+		errs = append(errs, fmt.Errorf("auth.access_token_secret must be at least %d characters long", MinSecretLength))
+	}
+	if len(c.Auth.RefreshTokenSecret) < MinSecretLength {
+		errs = append(errs, fmt.Errorf("auth.refresh_token_secret must be at least %d characters long", MinSecretLength))
+	}
+	if c.Auth.AccessTokenSecret != "" && c.Auth.AccessTokenSecret == c.Auth.RefreshTokenSecret {
+		errs = append(errs, errors.New("auth.access_token_secret and auth.refresh_token_secret must differ"))
+	}
+	if c.Auth.BcryptCost < 4 || c.Auth.BcryptCost > 31 {
+		errs = append(errs, errors.New("auth.bcrypt_cost must be between 4 and 31"))
+	}
+
+	if c.RateLimit.Store != "memory" && c.RateLimit.Store != "mongo" {
+		errs = append(errs, fmt.Errorf("rate_limit.store must be memory or mongo, got %q", c.RateLimit.Store))
+	}
+	policies := map[string]RateLimitPolicyConfig{
+		"rate_limit.auth":     c.RateLimit.Auth,
+		"rate_limit.users":    c.RateLimit.Users,
+		"rate_limit.products": c.RateLimit.Products,
+	}
+	for key, policy := range policies {
+		if policy.Limit <= 0 || policy.Burst <= 0 || policy.Period <= 0 {
+			errs = append(errs, fmt.Errorf("%s limit, burst and period must be positive", key))
+		}
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		if origin == "*" && c.CORS.AllowCredentials {
+			errs = append(errs, errors.New("cors.allowed_origins can't contain * when cors.allow_credentials is enabled"))
+		}
+	}
+	if c.CORS.MaxAge < 0 {
+		errs = append(errs, errors.New("cors.max_age must not be negative"))
+	}
+	switch strings.ToUpper(c.Security.FrameOptions) {
+	case "", "DENY", "SAMEORIGIN":
+	default:
+		errs = append(errs, fmt.Errorf("security_headers.frame_options must be DENY or SAMEORIGIN, got %q", c.Security.FrameOptions))
+	}
+
+	switch strings.ToLower(c.Cookie.SameSite) {
+	case "lax", "strict":
+	case "none":
+		if !c.Cookie.Secure {
+			errs = append(errs, errors.New("cookie.secure must be enabled when cookie.same_site is none"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("cookie.same_site must be lax, strict or none, got %q", c.Cookie.SameSite))
+	}
+
+	if len(c.Catalog.LegacyCurrency) != 3 || strings.ToUpper(c.Catalog.LegacyCurrency) != c.Catalog.LegacyCurrency {
+		errs = append(errs, fmt.Errorf("catalog.legacy_currency must be an upper-case ISO 4217 code, got %q", c.Catalog.LegacyCurrency))
+	}
+
+	if c.CSRF.Enabled && len(c.CSRF.Secret) < MinSecretLength {
+		errs = append(errs, fmt.Errorf("csrf.secret must be at least %d characters long", MinSecretLength))
+	}
+
+	switch c.Images.Store {
+	case "gridfs":
+	case "filesystem":
+		if c.Images.Dir == "" {
+			errs = append(errs, errors.New("images.dir is required for the filesystem store"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("images.store must be gridfs or filesystem, got %q", c.Images.Store))
+	}
+	if c.Images.MaxUploadBytes <= 0 {
+		errs = append(errs, errors.New("images.max_upload_bytes must be positive"))
+	}
+	if c.Images.MaxDimension <= 0 || c.Images.MaxPixels <= 0 {
+		errs = append(errs, errors.New("images.max_dimension and images.max_pixels must be positive"))
+	}
+	for _, size := range c.Images.ThumbnailSizes {
+		if size <= 0 {
+			errs = append(errs, fmt.Errorf("images.thumbnail_sizes must be positive, got %d", size))
+		}
+	}
+
+	if c.Imports.MaxUploadBytes <= 0 {
+		errs = append(errs, errors.New("imports.max_upload_bytes must be positive"))
+	}
+	if c.Imports.BatchSize <= 0 || c.Imports.BatchSize > 10000 {
+		errs = append(errs, fmt.Errorf("imports.batch_size must be between 1 and 10000, got %d", c.Imports.BatchSize))
+	}
+	if c.Imports.BackgroundRows < 0 {
+		errs = append(errs, errors.New("imports.background_rows must not be negative"))
+	}
+
+	if c.Idempotency.Store != "memory" && c.Idempotency.Store != "mongo" {
+		errs = append(errs, fmt.Errorf("idempotency.store must be memory or mongo, got %q", c.Idempotency.Store))
+	}
+	if c.Idempotency.LockTimeout <= 0 || c.Idempotency.WaitTimeout <= 0 {
+		errs = append(errs, errors.New("idempotency.lock_timeout and idempotency.wait_timeout must be positive"))
+	}
+	if c.Idempotency.TTL < c.Idempotency.LockTimeout {
+		errs = append(errs, errors.New("idempotency.ttl must not be shorter than idempotency.lock_timeout"))
+	}
+
+	if c.Webhooks.Workers <= 0 || c.Webhooks.MaxAttempts <= 0 {
+		errs = append(errs, errors.New("webhooks.workers and webhooks.max_attempts must be positive"))
+	}
+	if c.Webhooks.Timeout <= 0 || c.Webhooks.InitialBackoff <= 0 || c.Webhooks.PollInterval <= 0 {
+		errs = append(errs, errors.New("webhooks.timeout, webhooks.initial_backoff and webhooks.poll_interval must be positive"))
+	}
+	if c.Webhooks.MaxBackoff < c.Webhooks.InitialBackoff {
+		errs = append(errs, errors.New("webhooks.max_backoff must not be shorter than webhooks.initial_backoff"))
+	}
+	if c.Webhooks.DeliveryRetention <= 0 {
+		errs = append(errs, errors.New("webhooks.delivery_retention must be positive"))
+	}
+	if c.Webhooks.AllowPrivateNetworks && c.GinMode == "release" {
+		errs = append(errs, errors.New("webhooks.allow_private_networks can't be enabled in release mode"))
+	}
+
+	if c.Events.MaxAttempts <= 0 {
+		errs = append(errs, errors.New("events.max_attempts must be positive"))
+	}
+	if c.Events.Timeout <= 0 || c.Events.InitialBackoff <= 0 || c.Events.PollInterval <= 0 || c.Events.Retention <= 0 {
+		errs = append(errs, errors.New("events.timeout, events.initial_backoff, events.poll_interval and events.retention must be positive"))
+	}
+	if c.Events.MaxBackoff < c.Events.InitialBackoff {
+		errs = append(errs, errors.New("events.max_backoff must not be shorter than events.initial_backoff"))
+	}
+
+	if c.ProductEvents.ReplayBuffer <= 0 || c.ProductEvents.Heartbeat <= 0 {
+		errs = append(errs, errors.New("product_events.replay_buffer and product_events.heartbeat must be positive"))
+	}
+
+	if c.Jobs.Workers <= 0 || c.Jobs.MaxAttempts <= 0 {
+		errs = append(errs, errors.New("jobs.workers and jobs.max_attempts must be positive"))
+	}
+	if c.Jobs.PollInterval <= 0 || c.Jobs.InitialBackoff <= 0 || c.Jobs.Retention <= 0 {
+		errs = append(errs, errors.New("jobs.poll_interval, jobs.initial_backoff and jobs.retention must be positive"))
+	}
+	if c.Jobs.Lease < 10*time.Second {
+		errs = append(errs, errors.New("jobs.lease must be at least 10s"))
+	}
+	if c.Jobs.MaxBackoff < c.Jobs.InitialBackoff {
+		errs = append(errs, errors.New("jobs.max_backoff must not be shorter than jobs.initial_backoff"))
+	}
+
+	if c.GraphQL.MaxDepth <= 0 || c.GraphQL.MaxComplexity <= 0 {
+		errs = append(errs, errors.New("graphql.max_depth and graphql.max_complexity must be positive"))
+	}
+	if c.GraphQL.Playground && c.GinMode == "release" {
+		errs = append(errs, errors.New("graphql.playground can't be enabled in release mode"))
+	}
+
+	if c.GRPC.Enabled {
+		if port, err := strconv.Atoi(c.GRPC.Port); err != nil || port < 1 || port > 65535 {
+			errs = append(errs, fmt.Errorf("grpc.port must be a number between 1 and 65535, got %q", c.GRPC.Port))
+		} else if c.GRPC.Port == c.Server.Port {
+			errs = append(errs, errors.New("grpc.port must differ from server.port"))
+		}
+	}
+	if c.GRPC.Reflection && c.GinMode == "release" {
+		errs = append(errs, errors.New("grpc.reflection can't be enabled in release mode"))
+	}
+
+	if c.Validation.Responses && c.GinMode == "release" {
+		errs = append(errs, errors.New("validation.responses can't be enabled in release mode"))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateMongoURI(uri string) error {
+	if uri == "" {
+		return errors.New("mongo.uri is required")
+	}
+	parsed, err := url.Parse(uri)
+	if err != nil {
+		return fmt.Errorf("mongo.uri is invalid: %w", err)
+	}
+	if parsed.Scheme != "mongodb" && parsed.Scheme != "mongodb+srv" {
+		return fmt.Errorf("mongo.uri must use the mongodb or mongodb+srv scheme, got %q", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return errors.New("mongo.uri must include a host")
+	}
+	return nil
+}
+
+type configField struct {
+	key   string
+	env   string
+	value reflect.Value
+}
+
+func collectFields(v reflect.Value, prefix string) []configField {
+	var fields []configField
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		structField := t.Field(i)
+		key := structField.Tag.Get("key")
+		if key == "" {
+			continue
+		}
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+
+		if structField.Type.Kind() == reflect.Struct {
+			fields = append(fields, collectFields(v.Field(i), key)...)
+			continue
+		}
+		env := structField.Tag.Get("env")
+		if env == "" {
+			env = strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+		}
+		fields = append(fields, configField{
+			key:   key,
+			env:   env,
+			value: v.Field(i),
+		})
+	}
+	return fields
+}
+
+func (f configField) set(raw string) error {
+	raw = strings.TrimSpace(raw)
+	switch f.value.Interface().(type) {
+	case string:
+		f.value.SetString(raw)
+	case time.Duration:
+		duration, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(int64(duration))
+	case int:
+		number, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		f.value.SetInt(int64(number))
+	case bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		f.value.SetBool(b)
+	case []string:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		f.value.Set(reflect.ValueOf(items))
+	case []int:
+		var items []int
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item == "" {
+				continue
+			}
+			number, err := strconv.Atoi(item)
+			if err != nil {
+				return err
+			}
+			items = append(items, number)
+		}
+		f.value.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported config type %s", f.value.Type())
+	}
+	return nil
+}
+
+func applyValues(fields []configField, values map[string]string, source string) error {
+	known := make(map[string]configField, len(fields))
+	for _, f := range fields {
+		known[f.key] = f
+	}
+
+	for key, value := range values {
+		f, ok := known[key]
+		if !ok {
+			return fmt.Errorf("unknown %s key %q", source, key)
+		}
+		if err := f.set(value); err != nil {
+			return fmt.Errorf("invalid %s key %q: %w", source, key, err)
+		}
+	}
+	return nil
+}
+
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+
+	var raw map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing config file: %w", err)
+	}
+
+	values := make(map[string]string)
+	flatten(raw, "", values)
+	return values, nil
+}
+
+// flatten turns nested sections into dotted keys matching the `key` tags
+func flatten(raw map[string]interface{}, prefix string, values map[string]string) {
+	for key, value := range raw {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			flatten(v, key, values)
+		case []interface{}:
+			items := make([]string, len(v))
+			for i, item := range v {
+				items[i] = fmt.Sprint(item)
+			}
+			values[key] = strings.Join(items, ",")
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+}
@@ -3,24 +3,13 @@ package configs
 import (
 	"context"
 	"log"
-	"os"
 
-	"github.com/joho/godotenv"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-func LoadEnv() error {
-	return godotenv.Load()
-}
-
-func ConnectDB(ctx context.Context) (*mongo.Client, error) {
-	uri := os.Getenv("MONGO_URI")
-	if uri == "" {
-		log.Fatal("MONGO_URI is not set in the environment")
-	}
-
-	clientOptions := options.Client().ApplyURI(uri)
+func ConnectDB(ctx context.Context, config MongoConfig) (*mongo.Client, error) {
+	clientOptions := options.Client().ApplyURI(config.URI)
 	client, err := mongo.Connect(ctx, clientOptions)
 	if err != nil {
 		return nil, err
@@ -35,11 +24,3 @@ func ConnectDB(ctx context.Context) (*mongo.Client, error) {
 	log.Println("Connected to MongoDB")
 	return client, nil
 }
-
-func GetDatabaseName() string {
-	dbName := os.Getenv("MONGO_DB_NAME")
-	if dbName == "" {
-		log.Fatal("MONGO_DB_NAME is not set in the environment")
-	}
-	return dbName
-}
@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+type CartController struct {
+	cartService *services.CartService
+}
+
+func NewCartController(cartService *services.CartService) *CartController {
+	return &CartController{
+		cartService: cartService,
+	}
+}
+
+func (cc *CartController) GetCart(c *gin.Context) {
+	cart, err := cc.cartService.GetCart(currentUserID(c))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Cart retrieved successfully", cart)
+}
+
+func (cc *CartController) AddItem(c *gin.Context) {
+	var request models.CartItemRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	cart, err := cc.cartService.AddItem(currentUserID(c), request)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Item added to cart successfully", cart)
+}
+
+func (cc *CartController) UpdateItem(c *gin.Context) {
+	var request models.CartItemUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	cart, err := cc.cartService.UpdateItem(currentUserID(c), c.Param("productId"), c.Query("sku"), request.Quantity)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Cart item updated successfully", cart)
+}
+
+func (cc *CartController) RemoveItem(c *gin.Context) {
+	cart, err := cc.cartService.RemoveItem(currentUserID(c), c.Param("productId"), c.Query("sku"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Item removed from cart successfully", cart)
+}
+
+func (cc *CartController) ClearCart(c *gin.Context) {
+	if err := cc.cartService.ClearCart(currentUserID(c)); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Cart cleared successfully", nil)
+}
@@ -0,0 +1,97 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/harsh-solanki21/golang-gin-crud-api/validations"
+)
+
+type CategoryController struct {
+	categoryService *services.CategoryService
+}
+
+func NewCategoryController(categoryService *services.CategoryService) *CategoryController {
+	return &CategoryController{
+		categoryService: categoryService,
+	}
+}
+
+func (cc *CategoryController) CreateCategory(c *gin.Context) {
+	var input models.CreateCategoryInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := validations.ValidateCreateCategoryInput(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation error", err)
+		return
+	}
+
+	category := input.ToCategory()
+	if err := cc.categoryService.CreateCategory(category); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, "Category created successfully", models.NewCategoryResponse(category))
+}
+
+func (cc *CategoryController) GetCategory(c *gin.Context) {
+	category, err := cc.categoryService.GetCategory(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Category retrieved successfully", models.NewCategoryResponse(category))
+}
+
+func (cc *CategoryController) ListCategories(c *gin.Context) {
+	categories, err := cc.categoryService.ListCategories()
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Categories retrieved successfully", models.NewCategoryResponses(categories))
+}
+
+func (cc *CategoryController) GetCategoryTree(c *gin.Context) {
+	tree, err := cc.categoryService.GetCategoryTree()
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Category tree retrieved successfully", tree)
+}
+
+func (cc *CategoryController) UpdateCategory(c *gin.Context) {
+	var request models.CategoryUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	category, err := cc.categoryService.UpdateCategory(c.Param("id"), &request)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Category updated successfully", models.NewCategoryResponse(category))
+}
+
+func (cc *CategoryController) DeleteCategory(c *gin.Context) {
+	if err := cc.categoryService.DeleteCategory(c.Param("id")); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Category deleted successfully", nil)
+}
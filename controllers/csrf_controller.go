@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/middlewares"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+type CSRFController struct {
+	csrfManager   *utils.CSRFManager
+	cookieOptions utils.CookieOptions
+}
+
+func NewCSRFController(csrfManager *utils.CSRFManager, cookieOptions utils.CookieOptions) *CSRFController {
+	return &CSRFController{
+		csrfManager:   csrfManager,
+		cookieOptions: cookieOptions,
+	}
+}
+
+// GetToken issues a new token as a cookie and in the body; clients send the
+// body value back in the X-CSRF-Token header on POST, PUT and DELETE requests.
+// Tokens are bound to the session, clients fetch a new one after logging in.
+func (cc *CSRFController) GetToken(c *gin.Context) {
+	token, err := cc.csrfManager.GenerateToken(middlewares.CSRFSession(c))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Error generating CSRF token", nil)
+		return
+	}
+
+	// Session cookie, the token is valid until the browser is closed
+	utils.SetCookie(c, cc.cookieOptions, middlewares.CSRFCookieName, token, 0)
+
+	utils.RespondWithSuccess(c, http.StatusOK, "CSRF token generated successfully", gin.H{
+		"csrf_token":  token,
+		"header_name": middlewares.CSRFHeaderName,
+	})
+}
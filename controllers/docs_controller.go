@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/openapi"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+// docsCSP lets the docs page load Swagger UI from unpkg, the API's own
+// policy allows nothing
+const docsCSP = "default-src 'none'; script-src 'unsafe-inline' https://unpkg.com; style-src 'unsafe-inline' https://unpkg.com; " +
+	"img-src 'self' data: https://unpkg.com; connect-src 'self'; frame-ancestors 'none'"
+
+// docsHTML runs Swagger UI against /openapi.json
+const docsHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>API Documentation</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script>
+    SwaggerUIBundle({ url: '/openapi.json', dom_id: '#swagger-ui', withCredentials: true });
+  </script>
+</body>
+</html>
+`
+
+type DocsController struct {
+	spec *openapi.Spec
+}
+
+// NewDocsController serves spec, which is generated on the first request once
+// every route has been registered
+func NewDocsController(spec *openapi.Spec) *DocsController {
+	return &DocsController{
+		spec: spec,
+	}
+}
+
+// Spec serves the OpenAPI document
+func (dc *DocsController) Spec(c *gin.Context) {
+	document := dc.spec.JSON()
+	if document == nil {
+		utils.RespondWithError(c, http.StatusInternalServerError, "Internal Server Error", nil)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", document)
+}
+
+// UI serves Swagger UI for the OpenAPI document
+func (dc *DocsController) UI(c *gin.Context) {
+	c.Header("Content-Security-Policy", docsCSP)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(docsHTML))
+}
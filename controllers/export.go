@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+// exportWriteTimeout bounds how long writing a part of an export may take.
+// The server's write timeout covers whole responses, which is too short for
+// large exports, so the deadline is pushed forward while rows are written.
+const exportWriteTimeout = time.Minute
+
+// parseExport reads the format and columns query parameters, it responds with an error if they're invalid
+func parseExport(c *gin.Context, available []string) (string, []string, bool) {
+	format := c.DefaultQuery("format", utils.ExportCSV)
+	if _, err := utils.ExportContentType(format); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Unsupported export format", err.Error())
+		return "", nil, false
+	}
+
+	columns, err := utils.SelectColumns(c.Query("columns"), available)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid columns", err.Error())
+		return "", nil, false
+	}
+	return format, columns, true
+}
+
+// streamExport sends the rows produced by export as a file download. The
+// response starts with the first row, so errors before it are still returned
+// as JSON; later errors can only cut the download short.
+func streamExport(c *gin.Context, name, format string, columns []string, export func(write func(values []interface{}) error) error) {
+	contentType, _ := utils.ExportContentType(format)
+	responseController := http.NewResponseController(c.Writer)
+
+	var writer utils.ExportWriter
+	var extendedAt time.Time
+	start := func() error {
+		c.Header("Content-Type", contentType)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+		c.Header("Cache-Control", "no-store")
+		c.Status(http.StatusOK)
+
+		var err error
+		writer, err = utils.NewExportWriter(c.Writer, format, columns)
+		return err
+	}
+	write := func(values []interface{}) error {
+		if time.Since(extendedAt) > exportWriteTimeout/4 {
+			extendedAt = time.Now()
+			// Not every writer supports deadlines, e.g. the recorders used in tests
+			_ = responseController.SetWriteDeadline(extendedAt.Add(exportWriteTimeout))
+		}
+		if writer == nil {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+		return writer.WriteRow(values)
+	}
+
+	err := export(write)
+	if err == nil && writer == nil {
+		// Nothing matched, the file only has its header
+		err = start()
+	}
+	if err == nil {
+		err = writer.Close()
+	}
+	if err != nil {
+		if writer == nil && !c.Writer.Written() {
+			utils.HandleError(c, err)
+			return
+		}
+		log.Printf("Error exporting %s: %v\n", name, err)
+	}
+}
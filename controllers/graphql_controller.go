@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/graph"
+	"github.com/harsh-solanki21/golang-gin-crud-api/middlewares"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+// playgroundCSP lets the playground load GraphiQL from unpkg, the API's own
+// policy allows nothing
+const playgroundCSP = "default-src 'none'; script-src 'unsafe-inline' https://unpkg.com; style-src 'unsafe-inline' https://unpkg.com; " +
+	"font-src https://unpkg.com data:; img-src 'self' data:; connect-src 'self'; frame-ancestors 'none'"
+
+// playgroundHTML runs GraphiQL against the page's own URL. Requests are made
+// with the session cookies, so it fetches a CSRF token before each one.
+const playgroundHTML = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>GraphQL Playground</title>
+  <style>body { margin: 0; height: 100vh; } #graphiql { height: 100vh; }</style>
+  <link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+  <script crossorigin src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+  <script crossorigin src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+</head>
+<body>
+  <div id="graphiql">Loading...</div>
+  <script>
+    async function fetcher(params) {
+      const headers = { 'Content-Type': 'application/json' };
+      const csrf = await fetch('/api/v1/csrf-token', { credentials: 'same-origin' })
+        .then((response) => (response.ok ? response.json() : null))
+        .catch(() => null);
+      if (csrf && csrf.data) {
+        headers[csrf.data.header_name] = csrf.data.csrf_token;
+      }
+      const response = await fetch(window.location.pathname, {
+        method: 'POST',
+        headers,
+        credentials: 'same-origin',
+        body: JSON.stringify(params),
+      });
+      return response.json();
+    }
+    ReactDOM.createRoot(document.getElementById('graphiql')).render(React.createElement(GraphiQL, { fetcher }));
+  </script>
+</body>
+</html>
+`
+
+type GraphQLController struct {
+	server *graph.Server
+}
+
+func NewGraphQLController(server *graph.Server) *GraphQLController {
+	return &GraphQLController{
+		server: server,
+	}
+}
+
+// Query runs a GraphQL query or mutation. The response is a GraphQL response
+// rather than the usual envelope, errors are reported in it with status 200.
+func (gc *GraphQLController) Query(c *gin.Context) {
+	claims, err := middlewares.GetClaimsFromContext(c)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	var request models.GraphQLRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gc.server.Execute(c.Request.Context(), claims, request))
+}
+
+// Playground serves GraphiQL, it's only routed when enabled outside release mode
+func (gc *GraphQLController) Playground(c *gin.Context) {
+	c.Header("Content-Security-Policy", playgroundCSP)
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(playgroundHTML))
+}
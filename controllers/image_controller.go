@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+// multipartOverhead leaves room for the multipart headers around the uploaded file
+const multipartOverhead = 64 << 10
+
+type ImageController struct {
+	imageService *services.ImageService
+}
+
+func NewImageController(imageService *services.ImageService) *ImageController {
+	return &ImageController{
+		imageService: imageService,
+	}
+}
+
+// UploadImage accepts a multipart form with the image in the "image" field
+func (ic *ImageController) UploadImage(c *gin.Context) {
+	maxBytes := ic.imageService.MaxUploadBytes()
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+multipartOverhead)
+
+	fileHeader, err := c.FormFile("image")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.RespondWithError(c, http.StatusRequestEntityTooLarge, "Image is too large", nil)
+			return
+		}
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", "an image file is required in the image field")
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	defer file.Close()
+
+	// Read one byte more than allowed so the service can tell the upload is too large
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	image, err := ic.imageService.UploadImage(c.Param("id"), data)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, "Image uploaded successfully", image)
+}
+
+func (ic *ImageController) ListImages(c *gin.Context) {
+	images, err := ic.imageService.ListImages(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Images retrieved successfully", images)
+}
+
+// GetImageFile streams the image, or the thumbnail selected with ?size=
+func (ic *ImageController) GetImageFile(c *gin.Context) {
+	size := 0
+	if value := c.Query("size"); value != "" {
+		var err error
+		if size, err = strconv.Atoi(value); err != nil || size <= 0 {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid size", value)
+			return
+		}
+	}
+
+	file, err := ic.imageService.OpenImage(c.Param("id"), c.Param("imageId"), size)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+	defer file.Close()
+
+	// Stored files never change, so clients may cache them for as long as they like
+	c.Header("ETag", file.ETag)
+	c.Header("Cache-Control", "private, max-age=31536000, immutable")
+	c.Header("Last-Modified", file.ModTime.UTC().Format(http.TimeFormat))
+	if c.GetHeader("If-None-Match") == file.ETag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.DataFromReader(http.StatusOK, file.Length, file.ContentType, file, nil)
+}
+
+func (ic *ImageController) SetPrimaryImage(c *gin.Context) {
+	image, err := ic.imageService.SetPrimaryImage(c.Param("id"), c.Param("imageId"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Primary image updated successfully", image)
+}
+
+func (ic *ImageController) ReorderImages(c *gin.Context) {
+	var request models.ImageOrderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	images, err := ic.imageService.ReorderImages(c.Param("id"), request.ImageIDs)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Images reordered successfully", images)
+}
+
+func (ic *ImageController) DeleteImage(c *gin.Context) {
+	if err := ic.imageService.DeleteImage(c.Param("id"), c.Param("imageId")); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Image deleted successfully", nil)
+}
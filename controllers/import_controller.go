@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/middlewares"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+type ImportController struct {
+	importService *services.ImportService
+}
+
+func NewImportController(importService *services.ImportService) *ImportController {
+	return &ImportController{
+		importService: importService,
+	}
+}
+
+// ImportProducts accepts a multipart form with the file in the "file" field
+// and optional "format", "mode" and "mapping" (a JSON object) fields. Large
+// imports are started as a background job.
+func (ic *ImportController) ImportProducts(c *gin.Context) {
+	claims, err := middlewares.GetClaimsFromContext(c)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, ic.importService.MaxUploadBytes()+multipartOverhead)
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.RespondWithError(c, http.StatusRequestEntityTooLarge, "Import file is too large", nil)
+			return
+		}
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", "an import file is required in the file field")
+		return
+	}
+
+	options := models.ImportOptions{
+		Format: c.PostForm("format"),
+		Mode:   c.PostForm("mode"),
+	}
+	if mapping := c.PostForm("mapping"); mapping != "" {
+		if err := json.Unmarshal([]byte(mapping), &options.Mapping); err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid column mapping", err.Error())
+			return
+		}
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	defer file.Close()
+
+	rows, err := ic.importService.CheckImport(file, fileHeader.Filename, &options)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	if ic.importService.RunsInBackground(rows) {
+		job, err := ic.importService.StartImportJob(file, rows, options, claims.UserID)
+		if err != nil {
+			utils.HandleError(c, err)
+			return
+		}
+		utils.RespondWithSuccess(c, http.StatusAccepted, "Import started", job)
+		return
+	}
+
+	report, err := ic.importService.RunImport(file, rows, options)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Import completed", report)
+}
+
+func (ic *ImportController) GetImportJob(c *gin.Context) {
+	job, err := ic.importService.GetImportJob(c.Param("jobId"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Import job retrieved successfully", job)
+}
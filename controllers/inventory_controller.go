@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/middlewares"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type InventoryController struct {
+	inventoryService *services.InventoryService
+}
+
+func NewInventoryController(inventoryService *services.InventoryService) *InventoryController {
+	return &InventoryController{
+		inventoryService: inventoryService,
+	}
+}
+
+func (ic *InventoryController) AdjustStock(c *gin.Context) {
+	var request models.StockAdjustmentRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	product, err := ic.inventoryService.AdjustStock(c.Param("id"), request, currentUserID(c))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Stock adjusted successfully", models.NewProductResponse(product))
+}
+
+func (ic *InventoryController) ReserveStock(c *gin.Context) {
+	var request models.StockReservationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	product, err := ic.inventoryService.ReserveStock(c.Param("id"), request.SKU, request.Quantity, request.Reason, currentUserID(c))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Stock reserved successfully", models.NewProductResponse(product))
+}
+
+func (ic *InventoryController) ReleaseStock(c *gin.Context) {
+	var request models.StockReservationRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	product, err := ic.inventoryService.ReleaseStock(c.Param("id"), request.SKU, request.Quantity, request.Reason, currentUserID(c))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Stock released successfully", models.NewProductResponse(product))
+}
+
+func (ic *InventoryController) ListLowStock(c *gin.Context) {
+	pagination := utils.GeneratePaginationFromRequest(c)
+	paginatedData, err := ic.inventoryService.ListLowStock(pagination)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+	products, _ := paginatedData.Data.([]*models.Product)
+	paginatedData.Data = models.NewProductResponses(products)
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Low stock products retrieved successfully", paginatedData)
+}
+
+func (ic *InventoryController) ListMovements(c *gin.Context) {
+	pagination := utils.GeneratePaginationFromRequest(c)
+	paginatedData, err := ic.inventoryService.ListMovements(c.Param("id"), pagination)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Stock movements retrieved successfully", paginatedData)
+}
+
+// currentUserID returns the id of the authenticated user, or an empty string
+func currentUserID(c *gin.Context) string {
+	claims, err := middlewares.GetClaimsFromContext(c)
+	if err != nil {
+		return ""
+	}
+	return claims.UserID
+}
+
+// currentOwnerID returns the id of the authenticated user as the owner of the products they create
+func currentOwnerID(c *gin.Context) *primitive.ObjectID {
+	ownerID, err := primitive.ObjectIDFromHex(currentUserID(c))
+	if err != nil {
+		return nil
+	}
+	return &ownerID
+}
@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+type JobController struct {
+	jobService *services.JobService
+}
+
+func NewJobController(jobService *services.JobService) *JobController {
+	return &JobController{
+		jobService: jobService,
+	}
+}
+
+// ListJobs returns the background jobs, newest first. The status and type
+// parameters filter them.
+func (jc *JobController) ListJobs(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && !slices.Contains(models.JobStatuses, status) {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid status", "status must be pending, running, succeeded, dead or cancelled")
+		return
+	}
+
+	pagination := utils.GeneratePaginationFromRequest(c)
+	paginatedData, err := jc.jobService.ListJobs(status, c.Query("type"), pagination)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Jobs retrieved successfully", paginatedData)
+}
+
+func (jc *JobController) GetJob(c *gin.Context) {
+	job, err := jc.jobService.GetJob(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Job retrieved successfully", job)
+}
+
+// RetryJob queues a dead or cancelled job again, it runs in the background
+func (jc *JobController) RetryJob(c *gin.Context) {
+	job, err := jc.jobService.RetryJob(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusAccepted, "Job queued for retry", job)
+}
+
+func (jc *JobController) CancelJob(c *gin.Context) {
+	job, err := jc.jobService.CancelJob(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Job cancelled successfully", job)
+}
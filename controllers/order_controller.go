@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/middlewares"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+type OrderController struct {
+	orderService *services.OrderService
+}
+
+func NewOrderController(orderService *services.OrderService) *OrderController {
+	return &OrderController{
+		orderService: orderService,
+	}
+}
+
+func (oc *OrderController) CreateOrder(c *gin.Context) {
+	order, err := oc.orderService.Checkout(currentUserID(c))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, "Order created successfully", order)
+}
+
+func (oc *OrderController) GetOrder(c *gin.Context) {
+	claims, err := middlewares.GetClaimsFromContext(c)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	order, err := oc.orderService.GetOrder(c.Param("id"), claims)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Order retrieved successfully", order)
+}
+
+func (oc *OrderController) ListOrders(c *gin.Context) {
+	claims, err := middlewares.GetClaimsFromContext(c)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	pagination := utils.GeneratePaginationFromRequest(c)
+	paginatedData, err := oc.orderService.ListOrders(claims, pagination)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Orders retrieved successfully", paginatedData)
+}
+
+func (oc *OrderController) CancelOrder(c *gin.Context) {
+	claims, err := middlewares.GetClaimsFromContext(c)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	order, err := oc.orderService.CancelOrder(c.Param("id"), claims)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Order cancelled successfully", order)
+}
+
+func (oc *OrderController) UpdateOrderStatus(c *gin.Context) {
+	claims, err := middlewares.GetClaimsFromContext(c)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	var request models.OrderStatusRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	order, err := oc.orderService.UpdateStatus(c.Param("id"), request.Status, claims)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Order status updated successfully", order)
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+type ProductBatchController struct {
+	productBatchService *services.ProductBatchService
+}
+
+func NewProductBatchController(productBatchService *services.ProductBatchService) *ProductBatchController {
+	return &ProductBatchController{
+		productBatchService: productBatchService,
+	}
+}
+
+// RunBatch applies up to 100 create, update and delete operations. Best
+// effort batches always respond with 200 and a status per operation, failed
+// atomic batches respond with the status of the operation that failed.
+func (pbc *ProductBatchController) RunBatch(c *gin.Context) {
+	var request models.ProductBatchRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	// Created products belong to the user sending the batch
+	response, err := pbc.productBatchService.RunBatch(request, currentOwnerID(c))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Batch completed", response)
+}
@@ -21,23 +21,27 @@ func NewProductController(productService *services.ProductService) *ProductContr
 }
 
 func (pc *ProductController) CreateProduct(c *gin.Context) {
-	var product models.Product
-	if err := c.ShouldBindJSON(&product); err != nil {
+	var input models.CreateProductInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	if err := validations.ValidateProductCreate(&product); err != nil {
+	if err := validations.ValidateCreateProductInput(&input); err != nil {
 		utils.RespondWithError(c, http.StatusBadRequest, "Validation error", err)
 		return
 	}
 
-	if err := pc.productService.CreateProduct(&product); err != nil {
+	// The product belongs to the user creating it
+	product := input.ToProduct()
+	product.OwnerID = currentOwnerID(c)
+
+	if err := pc.productService.CreateProduct(product); err != nil {
 		utils.HandleError(c, err)
 		return
 	}
 
-	utils.RespondWithSuccess(c, http.StatusCreated, "Product created successfully", product.ToJSON())
+	utils.RespondWithSuccess(c, http.StatusCreated, "Product created successfully", models.NewProductResponse(product))
 }
 
 func (pc *ProductController) GetProduct(c *gin.Context) {
@@ -48,29 +52,29 @@ func (pc *ProductController) GetProduct(c *gin.Context) {
 		return
 	}
 
-	utils.RespondWithSuccess(c, http.StatusOK, "Product retrieved successfully", product)
+	utils.RespondWithSuccess(c, http.StatusOK, "Product retrieved successfully", models.NewProductResponse(product))
 }
 
 func (pc *ProductController) UpdateProduct(c *gin.Context) {
 	id := c.Param("id")
-	var product models.Product
-	if err := c.ShouldBindJSON(&product); err != nil {
+	var input models.UpdateProductInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	if err := validations.ValidateProductUpdate(&product); err != nil {
+	if err := validations.ValidateUpdateProductInput(&input); err != nil {
 		utils.RespondWithError(c, http.StatusBadRequest, "Validation error", err)
 		return
 	}
 
-	updatedProduct, err := pc.productService.UpdateProduct(id, &product)
+	updatedProduct, err := pc.productService.UpdateProduct(id, &input)
 	if err != nil {
 		utils.HandleError(c, err)
 		return
 	}
 
-	utils.RespondWithSuccess(c, http.StatusOK, "Product updated successfully", updatedProduct)
+	utils.RespondWithSuccess(c, http.StatusOK, "Product updated successfully", models.NewProductResponse(updatedProduct))
 }
 
 func (pc *ProductController) DeleteProduct(c *gin.Context) {
@@ -84,12 +88,45 @@ func (pc *ProductController) DeleteProduct(c *gin.Context) {
 }
 
 func (pc *ProductController) ListProducts(c *gin.Context) {
+	filter, err := parseProductFilter(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+
 	pagination := utils.GeneratePaginationFromRequest(c)
-	paginatedData, err := pc.productService.ListProducts(pagination)
+	paginatedData, err := pc.productService.ListProducts(pagination, filter)
 	if err != nil {
 		utils.HandleError(c, err)
 		return
 	}
+	products, _ := paginatedData.Data.([]*models.Product)
+	paginatedData.Data = models.NewProductResponses(products)
 
 	utils.RespondWithSuccess(c, http.StatusOK, "Products retrieved successfully", paginatedData)
 }
+
+// ExportProducts streams the products matching the list filters as a csv,
+// ndjson or xlsx file with the columns given in the columns parameter
+func (pc *ProductController) ExportProducts(c *gin.Context) {
+	filter, err := parseProductFilter(c)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid filter", err.Error())
+		return
+	}
+	format, columns, ok := parseExport(c, models.ProductExportColumns)
+	if !ok {
+		return
+	}
+
+	streamExport(c, "products", format, columns, func(write func([]interface{}) error) error {
+		return pc.productService.ExportProducts(filter, c.DefaultQuery("sort", "created_at"), func(product *models.Product) error {
+			return write(product.ExportValues(columns))
+		})
+	})
+}
+
+// parseProductFilter reads the category, sku, currency, min_price and max_price query parameters
+func parseProductFilter(c *gin.Context) (models.ProductFilter, error) {
+	return models.ParseProductFilter(c.Query("category"), c.Query("sku"), c.Query("currency"), c.Query("min_price"), c.Query("max_price"))
+}
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+// streamWriteTimeout bounds writing one event. The server's write timeout
+// covers whole responses and would end streams, so the deadline is pushed
+// forward before every write.
+const streamWriteTimeout = 30 * time.Second
+
+type ProductStreamController struct {
+	productStreamService *services.ProductStreamService
+}
+
+func NewProductStreamController(productStreamService *services.ProductStreamService) *ProductStreamController {
+	return &ProductStreamController{
+		productStreamService: productStreamService,
+	}
+}
+
+// StreamEvents sends product changes as server-sent events until the client
+// disconnects. Clients reconnecting with Last-Event-ID first get the changes
+// they missed, or a reset event if those are no longer buffered.
+func (psc *ProductStreamController) StreamEvents(c *gin.Context) {
+	subscription, missed, resumed := psc.productStreamService.Subscribe(c.GetHeader("Last-Event-ID"))
+	defer psc.productStreamService.Unsubscribe(subscription)
+
+	responseController := http.NewResponseController(c.Writer)
+	// The server's read timeout would otherwise cancel the request while it streams
+	_ = responseController.SetReadDeadline(time.Time{})
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	send := func(write func() error) bool {
+		// Not every writer supports deadlines, e.g. the recorders used in tests
+		_ = responseController.SetWriteDeadline(time.Now().Add(streamWriteTimeout))
+		if err := write(); err != nil {
+			return false
+		}
+		c.Writer.Flush()
+		return true
+	}
+	sendChange := func(change models.ProductChange) bool {
+		data, err := json.Marshal(change)
+		if err != nil {
+			log.Println("Error encoding product change:", err)
+			return false
+		}
+		return send(func() error {
+			return utils.WriteServerSentEvent(c.Writer, change.ID, change.Type, data)
+		})
+	}
+
+	if !resumed {
+		if !send(func() error { return utils.WriteServerSentEvent(c.Writer, "", "reset", []byte("{}")) }) {
+			return
+		}
+	} else if !send(func() error { return utils.WriteServerSentComment(c.Writer, "connected") }) {
+		return
+	}
+	for _, change := range missed {
+		if !sendChange(change) {
+			return
+		}
+	}
+
+	heartbeat := time.NewTicker(psc.productStreamService.Heartbeat())
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case change, ok := <-subscription.Changes:
+			// Closed when the client fell behind or the server is shutting down
+			if !ok || !sendChange(change) {
+				return
+			}
+		case <-heartbeat.C:
+			if !send(func() error { return utils.WriteServerSentComment(c.Writer, "heartbeat") }) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/middlewares"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+type ReviewController struct {
+	reviewService *services.ReviewService
+}
+
+func NewReviewController(reviewService *services.ReviewService) *ReviewController {
+	return &ReviewController{
+		reviewService: reviewService,
+	}
+}
+
+func (rc *ReviewController) CreateReview(c *gin.Context) {
+	claims, err := middlewares.GetClaimsFromContext(c)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	var request models.ReviewRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	review, err := rc.reviewService.CreateReview(c.Param("id"), request, claims)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, "Review created successfully", review)
+}
+
+func (rc *ReviewController) ListReviews(c *gin.Context) {
+	claims, err := middlewares.GetClaimsFromContext(c)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	pagination := utils.GeneratePaginationFromRequest(c)
+	paginatedData, err := rc.reviewService.ListReviews(c.Param("id"), c.Query("status"), claims, pagination)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Reviews retrieved successfully", paginatedData)
+}
+
+func (rc *ReviewController) ListReportedReviews(c *gin.Context) {
+	claims, err := middlewares.GetClaimsFromContext(c)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	pagination := utils.GeneratePaginationFromRequest(c)
+	paginatedData, err := rc.reviewService.ListReportedReviews(claims, pagination)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Reported reviews retrieved successfully", paginatedData)
+}
+
+func (rc *ReviewController) UpdateReview(c *gin.Context) {
+	claims, err := middlewares.GetClaimsFromContext(c)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	var request models.ReviewUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	review, err := rc.reviewService.UpdateReview(c.Param("id"), c.Param("reviewId"), request, claims)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Review updated successfully", review)
+}
+
+func (rc *ReviewController) DeleteReview(c *gin.Context) {
+	claims, err := middlewares.GetClaimsFromContext(c)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	if err := rc.reviewService.DeleteReview(c.Param("id"), c.Param("reviewId"), claims); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Review deleted successfully", nil)
+}
+
+func (rc *ReviewController) ModerateReview(c *gin.Context) {
+	var request models.ReviewStatusRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	review, err := rc.reviewService.ModerateReview(c.Param("id"), c.Param("reviewId"), request.Status)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Review status updated successfully", review)
+}
+
+func (rc *ReviewController) ReportReview(c *gin.Context) {
+	claims, err := middlewares.GetClaimsFromContext(c)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	var request models.ReviewReportRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := rc.reviewService.ReportReview(c.Param("id"), c.Param("reviewId"), request.Reason, claims); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, "Review reported successfully", nil)
+}
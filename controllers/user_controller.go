@@ -21,23 +21,24 @@ func NewUserController(userService *services.UserService) *UserController {
 }
 
 func (uc *UserController) CreateUser(c *gin.Context) {
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var input models.CreateUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	if err := validations.ValidateUserCreate(&user); err != nil {
+	if err := validations.ValidateCreateUserInput(&input); err != nil {
 		utils.RespondWithError(c, http.StatusBadRequest, "Validation error", err)
 		return
 	}
 
-	if err := uc.userService.CreateUser(&user); err != nil {
+	user := input.ToUser()
+	if err := uc.userService.CreateUser(user); err != nil {
 		utils.HandleError(c, err)
 		return
 	}
 
-	utils.RespondWithSuccess(c, http.StatusCreated, "User created successfully", user.ToJSON())
+	utils.RespondWithSuccess(c, http.StatusCreated, "User created successfully", models.NewUserResponse(user))
 }
 
 func (uc *UserController) GetUser(c *gin.Context) {
@@ -48,29 +49,29 @@ func (uc *UserController) GetUser(c *gin.Context) {
 		return
 	}
 
-	utils.RespondWithSuccess(c, http.StatusOK, "User retrieved successfully", user)
+	utils.RespondWithSuccess(c, http.StatusOK, "User retrieved successfully", models.NewUserResponse(user))
 }
 
 func (uc *UserController) UpdateUser(c *gin.Context) {
 	id := c.Param("id")
-	var user models.User
-	if err := c.ShouldBindJSON(&user); err != nil {
+	var input models.UpdateUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
 		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
 		return
 	}
 
-	if err := validations.ValidateUserUpdate(&user); err != nil {
+	if err := validations.ValidateUpdateUserInput(&input); err != nil {
 		utils.RespondWithError(c, http.StatusBadRequest, "Validation error", err)
 		return
 	}
 
-	updatedUser, err := uc.userService.UpdateUser(id, &user)
+	updatedUser, err := uc.userService.UpdateUser(id, input.ToUser())
 	if err != nil {
 		utils.HandleError(c, err)
 		return
 	}
 
-	utils.RespondWithSuccess(c, http.StatusOK, "User updated successfully", updatedUser)
+	utils.RespondWithSuccess(c, http.StatusOK, "User updated successfully", models.NewUserResponse(updatedUser))
 }
 
 func (uc *UserController) DeleteUser(c *gin.Context) {
@@ -90,6 +91,23 @@ func (uc *UserController) ListUsers(c *gin.Context) {
 		utils.HandleError(c, err)
 		return
 	}
+	users, _ := paginatedData.Data.([]*models.User)
+	paginatedData.Data = models.NewUserResponses(users)
 
 	utils.RespondWithSuccess(c, http.StatusOK, "Users retrieved successfully", paginatedData)
 }
+
+// ExportUsers streams all users as a csv, ndjson or xlsx file. There is no
+// password column, so password hashes can't be exported.
+func (uc *UserController) ExportUsers(c *gin.Context) {
+	format, columns, ok := parseExport(c, models.UserExportColumns)
+	if !ok {
+		return
+	}
+
+	streamExport(c, "users", format, columns, func(write func([]interface{}) error) error {
+		return uc.userService.ExportUsers(func(user *models.User) error {
+			return write(user.ExportValues(columns))
+		})
+	})
+}
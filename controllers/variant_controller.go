@@ -0,0 +1,92 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/harsh-solanki21/golang-gin-crud-api/validations"
+)
+
+type VariantController struct {
+	variantService *services.VariantService
+}
+
+func NewVariantController(variantService *services.VariantService) *VariantController {
+	return &VariantController{
+		variantService: variantService,
+	}
+}
+
+func (vc *VariantController) CreateVariant(c *gin.Context) {
+	var input models.CreateVariantInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := validations.ValidateCreateVariantInput(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation error", err)
+		return
+	}
+
+	variant := input.ToVariant()
+	if err := vc.variantService.CreateVariant(c.Param("id"), variant); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, "Variant created successfully", models.NewVariantResponse(variant))
+}
+
+func (vc *VariantController) ListVariants(c *gin.Context) {
+	variants, err := vc.variantService.ListVariants(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Variants retrieved successfully", models.NewVariantResponses(variants))
+}
+
+func (vc *VariantController) GetVariant(c *gin.Context) {
+	variant, err := vc.variantService.GetVariant(c.Param("id"), c.Param("variantId"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Variant retrieved successfully", models.NewVariantResponse(variant))
+}
+
+func (vc *VariantController) UpdateVariant(c *gin.Context) {
+	var input models.UpdateVariantInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+
+	if err := validations.ValidateUpdateVariantInput(&input); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation error", err)
+		return
+	}
+
+	updatedVariant, err := vc.variantService.UpdateVariant(c.Param("id"), c.Param("variantId"), input.ToVariant())
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Variant updated successfully", models.NewVariantResponse(updatedVariant))
+}
+
+func (vc *VariantController) DeleteVariant(c *gin.Context) {
+	if err := vc.variantService.DeleteVariant(c.Param("id"), c.Param("variantId")); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Variant deleted successfully", nil)
+}
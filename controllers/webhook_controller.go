@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+type WebhookController struct {
+	webhookService *services.WebhookService
+}
+
+func NewWebhookController(webhookService *services.WebhookService) *WebhookController {
+	return &WebhookController{
+		webhookService: webhookService,
+	}
+}
+
+// CreateWebhook responds with the webhook's secret, it isn't returned anywhere else
+func (wc *WebhookController) CreateWebhook(c *gin.Context) {
+	var request models.WebhookRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	webhook, err := wc.webhookService.CreateWebhook(request)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, "Webhook created successfully", webhook)
+}
+
+func (wc *WebhookController) ListWebhooks(c *gin.Context) {
+	pagination := utils.GeneratePaginationFromRequest(c)
+	paginatedData, err := wc.webhookService.ListWebhooks(pagination)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Webhooks retrieved successfully", paginatedData)
+}
+
+func (wc *WebhookController) GetWebhook(c *gin.Context) {
+	webhook, err := wc.webhookService.GetWebhook(c.Param("id"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Webhook retrieved successfully", webhook)
+}
+
+func (wc *WebhookController) UpdateWebhook(c *gin.Context) {
+	var request models.WebhookUpdateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	webhook, err := wc.webhookService.UpdateWebhook(c.Param("id"), request)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Webhook updated successfully", webhook)
+}
+
+func (wc *WebhookController) DeleteWebhook(c *gin.Context) {
+	if err := wc.webhookService.DeleteWebhook(c.Param("id")); err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Webhook deleted successfully", nil)
+}
+
+// ListDeliveries returns the webhook's delivery log, newest first. The status
+// parameter filters it to pending, succeeded or failed deliveries.
+func (wc *WebhookController) ListDeliveries(c *gin.Context) {
+	status := c.Query("status")
+	switch status {
+	case "", models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed:
+	default:
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid status", "status must be pending, succeeded or failed")
+		return
+	}
+
+	pagination := utils.GeneratePaginationFromRequest(c)
+	paginatedData, err := wc.webhookService.ListDeliveries(c.Param("id"), status, pagination)
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Webhook deliveries retrieved successfully", paginatedData)
+}
+
+func (wc *WebhookController) GetDelivery(c *gin.Context) {
+	delivery, err := wc.webhookService.GetDelivery(c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, "Webhook delivery retrieved successfully", delivery)
+}
+
+// Redeliver queues the delivery's event again, the new delivery is sent in the background
+func (wc *WebhookController) Redeliver(c *gin.Context) {
+	delivery, err := wc.webhookService.Redeliver(c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		utils.HandleError(c, err)
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusAccepted, "Webhook redelivery queued", delivery)
+}
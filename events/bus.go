@@ -0,0 +1,83 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+)
+
+// Handler reacts to an event. Events are dispatched at least once, so a
+// handler may see the same event again and should key its work on the event ID.
+type Handler func(ctx context.Context, event models.Event) error
+
+type subscriber struct {
+	name       string
+	eventTypes []string
+	handler    Handler
+}
+
+// Bus dispatches events to the subscribers of their type, in the order they subscribed
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []subscriber
+}
+
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe registers handler for the given event types, or for every event
+// if there are none. The outbox remembers which subscribers handled an event
+// by name, so names must be unique and stay the same across restarts.
+func (b *Bus) Subscribe(name string, handler Handler, eventTypes ...string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, existing := range b.subscribers {
+		if existing.name == name {
+			panic(fmt.Sprintf("events: subscriber %q is already registered", name))
+		}
+	}
+	b.subscribers = append(b.subscribers, subscriber{name: name, eventTypes: eventTypes, handler: handler})
+}
+
+// Dispatch calls the subscribers of the event that aren't in delivered. It
+// returns delivered along with the subscribers that handled the event, and
+// the errors of the ones that failed. A failing subscriber doesn't stop the
+// others from getting the event.
+func (b *Bus) Dispatch(ctx context.Context, event models.Event, delivered []string) ([]string, error) {
+	b.mu.RLock()
+	subscribers := slices.Clone(b.subscribers)
+	b.mu.RUnlock()
+
+	delivered = slices.Clone(delivered)
+	var errs []error
+	for _, subscriber := range subscribers {
+		if slices.Contains(delivered, subscriber.name) {
+			continue
+		}
+		if len(subscriber.eventTypes) > 0 && !slices.Contains(subscriber.eventTypes, event.Type) {
+			continue
+		}
+		if err := subscriber.handle(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", subscriber.name, err))
+			continue
+		}
+		delivered = append(delivered, subscriber.name)
+	}
+	return delivered, errors.Join(errs...)
+}
+
+// handle calls the handler, a panic is returned as an error so the event is retried
+func (s subscriber) handle(ctx context.Context, event models.Event) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return s.handler(ctx, event)
+}
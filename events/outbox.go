@@ -0,0 +1,160 @@
+package events
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+// dispatchLeaseMargin is added to the dispatch timeout while an event is
+// being dispatched, so it's only picked up again if its relay stopped
+const dispatchLeaseMargin = 30 * time.Second
+
+// Outbox saves domain events along with the changes that cause them and
+// relays them to the bus. Events stay in the outbox until every subscriber
+// has handled them, so they survive crashes and restarts and are dispatched
+// at least once. Every instance of the API runs a relay, each event is
+// leased by one of them at a time.
+type Outbox struct {
+	repository   *repositories.OutboxRepository
+	transactions *repositories.Transactions
+	bus          *Bus
+	config       configs.EventsConfig
+
+	// wake is signalled when events are saved, so they're dispatched without waiting for the next poll
+	wake chan struct{}
+	// ctx is cancelled on shutdown, the relay stops after its current event
+	ctx    context.Context
+	cancel context.CancelFunc
+	relay  sync.WaitGroup
+}
+
+func NewOutbox(repository *repositories.OutboxRepository, transactions *repositories.Transactions, bus *Bus, config configs.EventsConfig) *Outbox {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Outbox{
+		repository:   repository,
+		transactions: transactions,
+		bus:          bus,
+		config:       config,
+		wake:         make(chan struct{}, 1),
+		ctx:          ctx,
+		cancel:       cancel,
+	}
+}
+
+// Run calls fn in a transaction if the deployment supports them, so the
+// events fn records are saved if and only if its writes are. On a standalone
+// server there are no transactions and the events are saved right after the
+// writes, a crash in between loses them.
+func (ob *Outbox) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := ob.transactions.RunIfSupported(ctx, fn); err != nil {
+		return err
+	}
+	ob.Notify()
+	return nil
+}
+
+// Record saves an event of eventType with data as its payload. Called with
+// the context of Run or of a transaction, it's part of the same transaction.
+func (ob *Outbox) Record(ctx context.Context, eventType string, data interface{}) error {
+	event, err := models.NewEvent(eventType, data)
+	if err != nil {
+		return err
+	}
+	return ob.repository.Append(ctx, event)
+}
+
+// RecordEvents saves several events in one write
+func (ob *Outbox) RecordEvents(ctx context.Context, events []models.Event) error {
+	return ob.repository.Append(ctx, events...)
+}
+
+// Notify wakes the relay after events were saved outside of Run
+func (ob *Outbox) Notify() {
+	select {
+	case ob.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start starts the relay
+func (ob *Outbox) Start() {
+	ob.relay.Add(1)
+	go ob.run()
+}
+
+// Shutdown stops the relay and waits for the event being dispatched
+func (ob *Outbox) Shutdown(ctx context.Context) error {
+	ob.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		ob.relay.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (ob *Outbox) run() {
+	defer ob.relay.Done()
+
+	ticker := time.NewTicker(ob.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ob.ctx.Err() == nil && ob.dispatchNext() {
+		}
+
+		select {
+		case <-ob.ctx.Done():
+			return
+		case <-ob.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// dispatchNext dispatches the next due event, it reports whether there was one
+func (ob *Outbox) dispatchNext() bool {
+	entry, err := ob.repository.ClaimDue(context.Background(), ob.config.Timeout+dispatchLeaseMargin)
+	if err != nil {
+		log.Println("Error claiming outbox event:", err)
+		return false
+	}
+	if entry == nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ob.config.Timeout)
+	delivered, dispatchErr := ob.bus.Dispatch(ctx, entry.Event, entry.DeliveredTo)
+	cancel()
+
+	if dispatchErr == nil {
+		err = ob.repository.MarkDispatched(context.Background(), entry.Event.ID, delivered, ob.config.Retention)
+	} else {
+		var nextAttemptAt *time.Time
+		if attempts := entry.Attempts + 1; attempts < ob.config.MaxAttempts {
+			next := time.Now().Add(utils.Backoff(attempts, ob.config.InitialBackoff, ob.config.MaxBackoff))
+			nextAttemptAt = &next
+		} else {
+			log.Printf("Giving up on %s event %s: %v\n", entry.Event.Type, entry.Event.ID.Hex(), dispatchErr)
+		}
+		err = ob.repository.RecordFailure(context.Background(), entry.Event.ID, delivered, dispatchErr, nextAttemptAt)
+	}
+	if err != nil {
+		log.Printf("Error recording dispatch of event %s: %v\n", entry.Event.ID.Hex(), err)
+	}
+	return true
+}
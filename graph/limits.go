@@ -0,0 +1,144 @@
+package graph
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/graphql-go/graphql/language/ast"
+)
+
+// defaultListCost is what the fields selected below a page cost per field
+// when the query doesn't set a limit, it matches the default page size
+const defaultListCost = 10
+
+// pagedFields are the fields that take a limit and return that many items
+var pagedFields = map[string]bool{"products": true, "users": true}
+
+// cost is the depth and complexity of a selection
+type cost struct {
+	depth      int
+	complexity int
+}
+
+// measurer measures the operation of a validated document
+type measurer struct {
+	fragments map[string]*ast.FragmentDefinition
+	variables map[string]interface{}
+	// visiting holds the fragments being measured, a cycle would recurse forever
+	visiting map[string]bool
+}
+
+// measure returns the depth and complexity of the operation to run. Every
+// field costs 1, the fields selected below a page cost limit times as much,
+// since they're resolved once per item. Introspection fields are free.
+func measure(doc *ast.Document, operationName string, variables map[string]interface{}) cost {
+	m := measurer{
+		fragments: make(map[string]*ast.FragmentDefinition),
+		variables: make(map[string]interface{}),
+		visiting:  make(map[string]bool),
+	}
+
+	var operation *ast.OperationDefinition
+	for _, definition := range doc.Definitions {
+		switch definition := definition.(type) {
+		case *ast.FragmentDefinition:
+			m.fragments[definition.Name.Value] = definition
+		case *ast.OperationDefinition:
+			name := ""
+			if definition.Name != nil {
+				name = definition.Name.Value
+			}
+			if operation == nil && (operationName == "" || operationName == name) {
+				operation = definition
+			}
+		}
+	}
+	if operation == nil {
+		return cost{}
+	}
+
+	// Variables left out of the request take their default value
+	for _, definition := range operation.VariableDefinitions {
+		if value, ok := definition.DefaultValue.(*ast.IntValue); ok {
+			m.variables[definition.Variable.Name.Value] = value
+		}
+	}
+	for name, value := range variables {
+		m.variables[name] = value
+	}
+	return m.selectionSet(operation.SelectionSet)
+}
+
+func (m *measurer) selectionSet(selectionSet *ast.SelectionSet) cost {
+	var total cost
+	if selectionSet == nil {
+		return total
+	}
+
+	for _, selection := range selectionSet.Selections {
+		var c cost
+		switch selection := selection.(type) {
+		case *ast.Field:
+			c = m.field(selection)
+		case *ast.InlineFragment:
+			c = m.selectionSet(selection.SelectionSet)
+		case *ast.FragmentSpread:
+			name := selection.Name.Value
+			fragment, ok := m.fragments[name]
+			if !ok || m.visiting[name] {
+				continue
+			}
+			m.visiting[name] = true
+			c = m.selectionSet(fragment.SelectionSet)
+			delete(m.visiting, name)
+		}
+		total.depth = max(total.depth, c.depth)
+		total.complexity += c.complexity
+	}
+	return total
+}
+
+func (m *measurer) field(field *ast.Field) cost {
+	if strings.HasPrefix(field.Name.Value, "__") {
+		return cost{}
+	}
+
+	children := m.selectionSet(field.SelectionSet)
+	if pagedFields[field.Name.Value] {
+		children.complexity *= m.limit(field)
+	}
+	return cost{depth: children.depth + 1, complexity: children.complexity + 1}
+}
+
+// limit returns the number of items a page field returns
+func (m *measurer) limit(field *ast.Field) int {
+	for _, argument := range field.Arguments {
+		if argument.Name.Value != "limit" {
+			continue
+		}
+
+		var value interface{} = argument.Value
+		if variable, ok := value.(*ast.Variable); ok {
+			value = m.variables[variable.Name.Value]
+		}
+		switch value := value.(type) {
+		case *ast.IntValue:
+			return positiveOr(strconv.Atoi(value.Value))
+		case float64:
+			// Variables decoded from JSON are floats
+			return positiveOr(int(value), nil)
+		case int:
+			return positiveOr(value, nil)
+		}
+	}
+	return defaultListCost
+}
+
+// positiveOr returns limit if it's valid, the pagination falls back to the
+// default page size otherwise
+func positiveOr(limit int, err error) int {
+	if err != nil || limit <= 0 {
+		return defaultListCost
+	}
+	return limit
+}
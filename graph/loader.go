@@ -0,0 +1,77 @@
+package graph
+
+import (
+	"sync"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// BatchFunc looks up the values of ids with one query. Ids without a value
+// are left out of the map.
+type BatchFunc func(ids []primitive.ObjectID) (map[primitive.ObjectID]interface{}, error)
+
+// Loader batches the lookups made while resolving one request. Load only
+// queues the id, the lookup happens when the first returned thunk is called.
+// The executor calls the thunks of a level of the response after resolving
+// all of its fields, so e.g. the owners of a page of products are looked up
+// with one query instead of one per product.
+type Loader struct {
+	fetch BatchFunc
+
+	mu      sync.Mutex
+	pending []primitive.ObjectID
+	queued  map[primitive.ObjectID]bool
+	values  map[primitive.ObjectID]interface{}
+	errs    map[primitive.ObjectID]error
+}
+
+func NewLoader(fetch BatchFunc) *Loader {
+	return &Loader{
+		fetch:  fetch,
+		queued: make(map[primitive.ObjectID]bool),
+		values: make(map[primitive.ObjectID]interface{}),
+		errs:   make(map[primitive.ObjectID]error),
+	}
+}
+
+// Load queues id and returns a thunk resolving to its value, or to nil if it has none
+func (l *Loader) Load(id primitive.ObjectID) func() (interface{}, error) {
+	l.mu.Lock()
+	if !l.queued[id] {
+		l.queued[id] = true
+		l.pending = append(l.pending, id)
+	}
+	l.mu.Unlock()
+
+	return func() (interface{}, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if len(l.pending) > 0 {
+			l.flush()
+		}
+		if err := l.errs[id]; err != nil {
+			return nil, err
+		}
+		// An explicit nil, a nil pointer in an interface isn't null to the executor
+		if value, ok := l.values[id]; ok {
+			return value, nil
+		}
+		return nil, nil
+	}
+}
+
+// flush looks up the pending ids, the caller holds the lock
+func (l *Loader) flush() {
+	ids := l.pending
+	l.pending = nil
+
+	values, err := l.fetch(ids)
+	for _, id := range ids {
+		if err != nil {
+			l.errs[id] = err
+		} else if value, ok := values[id]; ok {
+			l.values[id] = value
+		}
+	}
+}
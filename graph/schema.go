@@ -0,0 +1,407 @@
+package graph
+
+import (
+	"reflect"
+
+	"github.com/graphql-go/graphql"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/harsh-solanki21/golang-gin-crud-api/validations"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Fields without a resolver are read from the struct field of the same name,
+// ignoring case, e.g. stockQuantity from Product.StockQuantity.
+
+var moneyType = graphql.NewObject(graphql.ObjectConfig{
+	Name:        "Money",
+	Description: "An amount as a decimal string in the currency, e.g. 19.99 USD",
+	Fields: graphql.Fields{
+		"amount": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.String),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(models.Money).String(), nil
+			},
+		},
+		"currency": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+var moneyInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name: "MoneyInput",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"amount":   &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+		"currency": &graphql.InputObjectFieldConfig{Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+var categoryType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Category",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.ID),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*models.Category).ID.Hex(), nil
+			},
+		},
+		"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"slug": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+	},
+})
+
+// userType never has the password hash
+var userType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "User",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.ID),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*models.User).ID.Hex(), nil
+			},
+		},
+		"name":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"email":     &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"age":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"role":      &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"createdAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		"updatedAt": &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+	},
+})
+
+var userInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name:        "UserInput",
+	Description: "The fields to change, fields that are left out are kept",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name":  &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"email": &graphql.InputObjectFieldConfig{Type: graphql.String},
+	},
+})
+
+var productType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Product",
+	Fields: graphql.Fields{
+		"id": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.ID),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*models.Product).ID.Hex(), nil
+			},
+		},
+		"name":        &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"description": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+		"price":       &graphql.Field{Type: graphql.NewNonNull(moneyType)},
+		"categoryId": &graphql.Field{
+			Type: graphql.NewNonNull(graphql.ID),
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return p.Source.(*models.Product).CategoryID.Hex(), nil
+			},
+		},
+		"category": &graphql.Field{
+			Type: categoryType,
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				return loadersFrom(p.Context).categories.Load(p.Source.(*models.Product).CategoryID), nil
+			},
+		},
+		"owner": &graphql.Field{
+			Type:        userType,
+			Description: "The user who created the product, null for products created before owners were recorded",
+			Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+				product := p.Source.(*models.Product)
+				if product.OwnerID == nil {
+					return nil, nil
+				}
+				return loadersFrom(p.Context).users.Load(*product.OwnerID), nil
+			},
+		},
+		"stockQuantity":     &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"reserved":          &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"lowStockThreshold": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"inStock":           &graphql.Field{Type: graphql.NewNonNull(graphql.Boolean)},
+		"variantCount":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"averageRating":     &graphql.Field{Type: graphql.NewNonNull(graphql.Float)},
+		"reviewCount":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"createdAt":         &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+		"updatedAt":         &graphql.Field{Type: graphql.NewNonNull(graphql.DateTime)},
+	},
+})
+
+var productInputType = graphql.NewInputObject(graphql.InputObjectConfig{
+	Name:        "ProductInput",
+	Description: "New products need a name, description, price and category. Updates only change the fields that are set.",
+	Fields: graphql.InputObjectConfigFieldMap{
+		"name":              &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"description":       &graphql.InputObjectFieldConfig{Type: graphql.String},
+		"price":             &graphql.InputObjectFieldConfig{Type: moneyInputType},
+		"categoryId":        &graphql.InputObjectFieldConfig{Type: graphql.ID},
+		"stockQuantity":     &graphql.InputObjectFieldConfig{Type: graphql.Int},
+		"lowStockThreshold": &graphql.InputObjectFieldConfig{Type: graphql.Int},
+	},
+})
+
+var paginationType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Pagination",
+	Fields: graphql.Fields{
+		"limit":      &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"page":       &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"totalRows":  &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+		"totalPages": &graphql.Field{Type: graphql.NewNonNull(graphql.Int)},
+	},
+})
+
+// pageType is a page of itemType, it resolves from a utils.PaginatedResponse
+func pageType(name string, itemType *graphql.Object) *graphql.Object {
+	return graphql.NewObject(graphql.ObjectConfig{
+		Name: name,
+		Fields: graphql.Fields{
+			"data": &graphql.Field{
+				Type: graphql.NewNonNull(graphql.NewList(graphql.NewNonNull(itemType))),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					// Repositories may return a nil slice for an empty page, which would be null
+					data := p.Source.(utils.PaginatedResponse).Data
+					if value := reflect.ValueOf(data); value.Kind() == reflect.Slice && value.IsNil() {
+						return []interface{}{}, nil
+					}
+					return data, nil
+				},
+			},
+			"pagination": &graphql.Field{Type: graphql.NewNonNull(paginationType)},
+		},
+	})
+}
+
+// paginationArgs are the arguments of the fields in pagedFields, they work
+// like the page, limit and sort query parameters of the REST listings
+var paginationArgs = graphql.FieldConfigArgument{
+	"page":  &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+	"limit": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: defaultListCost},
+	"sort":  &graphql.ArgumentConfig{Type: graphql.String, Description: `A field and direction, e.g. "price desc"`},
+}
+
+func paginationFrom(args map[string]interface{}) utils.Pagination {
+	pagination := utils.Pagination{}
+	pagination.Page, _ = args["page"].(int)
+	pagination.Limit, _ = args["limit"].(int)
+	pagination.Sort, _ = args["sort"].(string)
+	return pagination
+}
+
+func (s *Server) newSchema() (graphql.Schema, error) {
+	idArgs := graphql.FieldConfigArgument{
+		"id": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+	}
+
+	productArgs := graphql.FieldConfigArgument{
+		"category": &graphql.ArgumentConfig{Type: graphql.String, Description: "The id or slug of a category, its subcategories are included"},
+		"sku":      &graphql.ArgumentConfig{Type: graphql.String},
+		"currency": &graphql.ArgumentConfig{Type: graphql.String, Description: "Required with minPrice and maxPrice"},
+		"minPrice": &graphql.ArgumentConfig{Type: graphql.String},
+		"maxPrice": &graphql.ArgumentConfig{Type: graphql.String},
+	}
+	for name, arg := range paginationArgs {
+		productArgs[name] = arg
+	}
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"me": &graphql.Field{
+				Type:    graphql.NewNonNull(userType),
+				Resolve: resolver(s.me),
+			},
+			"user": &graphql.Field{
+				Type:    graphql.NewNonNull(userType),
+				Args:    idArgs,
+				Resolve: resolver(s.user),
+			},
+			"users": &graphql.Field{
+				Type:        graphql.NewNonNull(pageType("UserPage", userType)),
+				Description: "Only admins can list users",
+				Args:        paginationArgs,
+				Resolve:     resolver(s.users),
+			},
+			"product": &graphql.Field{
+				Type:    graphql.NewNonNull(productType),
+				Args:    idArgs,
+				Resolve: resolver(s.product),
+			},
+			"products": &graphql.Field{
+				Type:    graphql.NewNonNull(pageType("ProductPage", productType)),
+				Args:    productArgs,
+				Resolve: resolver(s.products),
+			},
+		},
+	})
+
+	mutation := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Mutation",
+		Fields: graphql.Fields{
+			"createProduct": &graphql.Field{
+				Type: graphql.NewNonNull(productType),
+				Args: graphql.FieldConfigArgument{
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(productInputType)},
+				},
+				Resolve: resolver(s.createProduct),
+			},
+			"updateProduct": &graphql.Field{
+				Type: graphql.NewNonNull(productType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(productInputType)},
+				},
+				Resolve: resolver(s.updateProduct),
+			},
+			"deleteProduct": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Boolean),
+				Args:    idArgs,
+				Resolve: resolver(s.deleteProduct),
+			},
+			"updateUser": &graphql.Field{
+				Type: graphql.NewNonNull(userType),
+				Args: graphql.FieldConfigArgument{
+					"id":    &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.ID)},
+					"input": &graphql.ArgumentConfig{Type: graphql.NewNonNull(userInputType)},
+				},
+				Resolve: resolver(s.updateUser),
+			},
+			"deleteUser": &graphql.Field{
+				Type:    graphql.NewNonNull(graphql.Boolean),
+				Args:    idArgs,
+				Resolve: resolver(s.deleteUser),
+			},
+		},
+	})
+
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query, Mutation: mutation})
+}
+
+func (s *Server) me(p graphql.ResolveParams) (interface{}, error) {
+	return s.userService.GetUser(claimsFrom(p.Context).UserID)
+}
+
+func (s *Server) user(p graphql.ResolveParams) (interface{}, error) {
+	return s.userService.GetUser(p.Args["id"].(string))
+}
+
+func (s *Server) users(p graphql.ResolveParams) (interface{}, error) {
+	if claimsFrom(p.Context).Role != "admin" {
+		return nil, utils.NewCustomError(403, "Access denied", nil)
+	}
+
+	return s.userService.ListUsers(paginationFrom(p.Args))
+}
+
+func (s *Server) product(p graphql.ResolveParams) (interface{}, error) {
+	return s.productService.GetProduct(p.Args["id"].(string))
+}
+
+func (s *Server) products(p graphql.ResolveParams) (interface{}, error) {
+	arg := func(name string) string {
+		value, _ := p.Args[name].(string)
+		return value
+	}
+	filter, err := models.ParseProductFilter(arg("category"), arg("sku"), arg("currency"), arg("minPrice"), arg("maxPrice"))
+	if err != nil {
+		return nil, utils.NewCustomError(400, "Invalid filter", err.Error())
+	}
+
+	return s.productService.ListProducts(paginationFrom(p.Args), filter)
+}
+
+func (s *Server) createProduct(p graphql.ResolveParams) (interface{}, error) {
+	input, err := productFromInput(p.Args["input"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	if err := validations.ValidateCreateProductInput(input); err != nil {
+		return nil, utils.NewCustomError(400, "Validation error", err)
+	}
+	product := input.ToProduct()
+
+	// The product belongs to the user creating it
+	ownerID, err := primitive.ObjectIDFromHex(claimsFrom(p.Context).UserID)
+	if err == nil {
+		product.OwnerID = &ownerID
+	}
+
+	if err := s.productService.CreateProduct(product); err != nil {
+		return nil, err
+	}
+	return product, nil
+}
+
+func (s *Server) updateProduct(p graphql.ResolveParams) (interface{}, error) {
+	product, err := productFromInput(p.Args["input"].(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	// Like REST updates, stock is changed through the inventory endpoints
+	input := &models.UpdateProductInput{
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		CategoryID:  product.CategoryID,
+	}
+	if _, ok := p.Args["input"].(map[string]interface{})["lowStockThreshold"].(int); ok {
+		input.LowStockThreshold = &product.LowStockThreshold
+	}
+	if err := validations.ValidateUpdateProductInput(input); err != nil {
+		return nil, utils.NewCustomError(400, "Validation error", err)
+	}
+
+	return s.productService.UpdateProduct(p.Args["id"].(string), input)
+}
+
+func (s *Server) deleteProduct(p graphql.ResolveParams) (interface{}, error) {
+	if err := s.productService.DeleteProduct(p.Args["id"].(string)); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+func (s *Server) updateUser(p graphql.ResolveParams) (interface{}, error) {
+	fields := p.Args["input"].(map[string]interface{})
+	input := &models.UpdateUserInput{}
+	input.Name, _ = fields["name"].(string)
+	input.Email, _ = fields["email"].(string)
+	if err := validations.ValidateUpdateUserInput(input); err != nil {
+		return nil, utils.NewCustomError(400, "Validation error", err)
+	}
+
+	return s.userService.UpdateUser(p.Args["id"].(string), input.ToUser())
+}
+
+func (s *Server) deleteUser(p graphql.ResolveParams) (interface{}, error) {
+	if err := s.userService.DeleteUser(p.Args["id"].(string)); err != nil {
+		return nil, err
+	}
+	return true, nil
+}
+
+// productFromInput builds the input the REST endpoints would bind from the same fields
+func productFromInput(input map[string]interface{}) (*models.CreateProductInput, error) {
+	product := &models.CreateProductInput{}
+	product.Name, _ = input["name"].(string)
+	product.Description, _ = input["description"].(string)
+	product.StockQuantity, _ = input["stockQuantity"].(int)
+	product.LowStockThreshold, _ = input["lowStockThreshold"].(int)
+
+	if price, ok := input["price"].(map[string]interface{}); ok {
+		amount, _ := price["amount"].(string)
+		currency, _ := price["currency"].(string)
+		money, err := models.ParseMoney(amount, currency)
+		if err != nil {
+			return nil, utils.NewCustomError(400, "Invalid price", err.Error())
+		}
+		product.Price = money
+	}
+
+	if categoryID, ok := input["categoryId"].(string); ok {
+		objectID, err := primitive.ObjectIDFromHex(categoryID)
+		if err != nil {
+			return nil, utils.NewCustomError(400, services.ErrInvalidCategoryIdMessage, err.Error())
+		}
+		product.CategoryID = objectID
+	}
+
+	return product, nil
+}
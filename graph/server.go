@@ -0,0 +1,178 @@
+package graph
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/gqlerrors"
+	"github.com/graphql-go/graphql/language/parser"
+	"github.com/graphql-go/graphql/language/source"
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type contextKey int
+
+const (
+	claimsKey contextKey = iota
+	loadersKey
+)
+
+// loaders batch the lookups of one request
+type loaders struct {
+	users      *Loader
+	categories *Loader
+}
+
+// Server runs GraphQL requests. Resolvers go through the same services as the
+// REST endpoints, so both apply the same validation and authorization.
+type Server struct {
+	schema          graphql.Schema
+	productService  *services.ProductService
+	userService     *services.UserService
+	categoryService *services.CategoryService
+	config          configs.GraphQLConfig
+}
+
+func NewServer(productService *services.ProductService, userService *services.UserService, categoryService *services.CategoryService, config configs.GraphQLConfig) (*Server, error) {
+	s := &Server{
+		productService:  productService,
+		userService:     userService,
+		categoryService: categoryService,
+		config:          config,
+	}
+
+	schema, err := s.newSchema()
+	if err != nil {
+		return nil, fmt.Errorf("building GraphQL schema: %w", err)
+	}
+	s.schema = schema
+	return s, nil
+}
+
+// Execute runs request as the user with claims. Queries that are too deep or
+// too complex are rejected before anything is resolved.
+func (s *Server) Execute(ctx context.Context, claims *utils.Claims, request models.GraphQLRequest) *graphql.Result {
+	doc, err := parser.Parse(parser.ParseParams{
+		Source: source.NewSource(&source.Source{Body: []byte(request.Query), Name: "GraphQL request"}),
+	})
+	if err != nil {
+		return &graphql.Result{Errors: gqlerrors.FormatErrors(err)}
+	}
+
+	validation := graphql.ValidateDocument(&s.schema, doc, nil)
+	if !validation.IsValid {
+		return &graphql.Result{Errors: validation.Errors}
+	}
+
+	cost := measure(doc, request.OperationName, request.Variables)
+	if cost.depth > s.config.MaxDepth {
+		return errorResult(utils.NewCustomError(http.StatusBadRequest, fmt.Sprintf("Query depth %d exceeds the limit of %d", cost.depth, s.config.MaxDepth), nil))
+	}
+	if cost.complexity > s.config.MaxComplexity {
+		return errorResult(utils.NewCustomError(http.StatusBadRequest, fmt.Sprintf("Query complexity %d exceeds the limit of %d", cost.complexity, s.config.MaxComplexity), nil))
+	}
+
+	ctx = context.WithValue(ctx, claimsKey, claims)
+	ctx = context.WithValue(ctx, loadersKey, s.newLoaders())
+	result := graphql.Execute(graphql.ExecuteParams{
+		Schema:        s.schema,
+		AST:           doc,
+		OperationName: request.OperationName,
+		Args:          request.Variables,
+		Context:       ctx,
+	})
+	for i, err := range result.Errors {
+		result.Errors[i] = formatError(err)
+	}
+	return result
+}
+
+func (s *Server) newLoaders() *loaders {
+	return &loaders{
+		users: NewLoader(func(ids []primitive.ObjectID) (map[primitive.ObjectID]interface{}, error) {
+			users, err := s.userService.GetUsersByIDs(ids)
+			if err != nil {
+				return nil, err
+			}
+			values := make(map[primitive.ObjectID]interface{}, len(users))
+			for _, user := range users {
+				values[user.ID] = user
+			}
+			return values, nil
+		}),
+		categories: NewLoader(func(ids []primitive.ObjectID) (map[primitive.ObjectID]interface{}, error) {
+			categories, err := s.categoryService.GetCategoriesByIDs(ids)
+			if err != nil {
+				return nil, err
+			}
+			values := make(map[primitive.ObjectID]interface{}, len(categories))
+			for _, category := range categories {
+				values[category.ID] = category
+			}
+			return values, nil
+		}),
+	}
+}
+
+func claimsFrom(ctx context.Context) *utils.Claims {
+	if claims, ok := ctx.Value(claimsKey).(*utils.Claims); ok && claims != nil {
+		return claims
+	}
+	return &utils.Claims{}
+}
+
+func loadersFrom(ctx context.Context) *loaders {
+	return ctx.Value(loadersKey).(*loaders)
+}
+
+// resolver wraps the resolver of a root field. Errors that aren't
+// CustomErrors are logged and hidden from the client, like HandleError does.
+func resolver(resolve graphql.FieldResolveFn) graphql.FieldResolveFn {
+	return func(p graphql.ResolveParams) (interface{}, error) {
+		result, err := resolve(p)
+		if err == nil {
+			return result, nil
+		}
+		if _, ok := err.(*utils.CustomError); !ok {
+			log.Printf("Error resolving %s: %v\n", p.Info.FieldName, err)
+			return nil, utils.NewCustomError(http.StatusInternalServerError, "Internal Server Error", nil)
+		}
+		return nil, err
+	}
+}
+
+// formatError gives errors from the services the message and status they'd
+// have in a REST response, the status is in the error's extensions
+func formatError(formatted gqlerrors.FormattedError) gqlerrors.FormattedError {
+	var err error = formatted
+	for err != nil {
+		switch e := err.(type) {
+		case *utils.CustomError:
+			formatted.Message = e.Message
+			formatted.Extensions = map[string]interface{}{"status": e.StatusCode}
+			// Details that are errors may expose internals
+			if _, isError := e.Details.(error); e.Details != nil && !isError {
+				formatted.Extensions["details"] = e.Details
+			}
+			return formatted
+		case gqlerrors.FormattedError:
+			err = e.OriginalError()
+		case *gqlerrors.Error:
+			err = e.OriginalError
+		default:
+			return formatted
+		}
+	}
+	return formatted
+}
+
+func errorResult(err *utils.CustomError) *graphql.Result {
+	return &graphql.Result{Errors: []gqlerrors.FormattedError{formatError(gqlerrors.FormatError(err))}}
+}
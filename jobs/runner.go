@@ -0,0 +1,384 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+// recordTimeout bounds the write that records the outcome of a job
+const recordTimeout = 10 * time.Second
+
+var ErrUnknownJobType = errors.New("unknown job type")
+
+// Handler runs a job. Jobs run at least once, a job whose worker stops before
+// recording the outcome runs again, so handlers should be safe to repeat. ctx
+// is cancelled when the job is cancelled or the server has to stop it.
+type Handler func(ctx context.Context, job *models.Job) error
+
+type permanentError struct {
+	err error
+}
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// Permanent marks a handler's error as one retrying won't fix, the job is dead right away
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err: err}
+}
+
+// IsPermanent reports whether err was marked with Permanent
+func IsPermanent(err error) bool {
+	var permanent permanentError
+	return errors.As(err, &permanent)
+}
+
+type schedule struct {
+	jobType string
+	cron    *utils.CronSchedule
+	payload string
+}
+
+// Runner runs the jobs in the jobs collection on a pool of workers. Every
+// instance of the API runs one, each job is leased by one worker at a time
+// and the lease is renewed while the job runs.
+type Runner struct {
+	repository *repositories.JobRepository
+	config     configs.JobsConfig
+
+	mu        sync.RWMutex
+	handlers  map[string]Handler
+	schedules []schedule
+
+	// wake is signalled when jobs are enqueued, so they run without waiting for the next poll
+	wake chan struct{}
+	// ctx is cancelled on shutdown, workers stop claiming jobs and finish the running ones
+	ctx    context.Context
+	cancel context.CancelFunc
+	// jobsCtx is cancelled when the shutdown times out, it stops the running jobs
+	jobsCtx    context.Context
+	cancelJobs context.CancelFunc
+	workers    sync.WaitGroup
+}
+
+func NewRunner(repository *repositories.JobRepository, config configs.JobsConfig) *Runner {
+	ctx, cancel := context.WithCancel(context.Background())
+	jobsCtx, cancelJobs := context.WithCancel(context.Background())
+	return &Runner{
+		repository: repository,
+		config:     config,
+		handlers:   make(map[string]Handler),
+		wake:       make(chan struct{}, 1),
+		ctx:        ctx,
+		cancel:     cancel,
+		jobsCtx:    jobsCtx,
+		cancelJobs: cancelJobs,
+	}
+}
+
+// Register sets the handler of jobType. Workers only claim the types they
+// have handlers for, so register every type before calling Start.
+func (r *Runner) Register(jobType string, handler Handler) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.handlers[jobType]; ok {
+		panic(fmt.Sprintf("jobs: handler for %q is already registered", jobType))
+	}
+	r.handlers[jobType] = handler
+}
+
+// Schedule enqueues a job of jobType with payload at every time matching the
+// cron expression spec, in UTC. Every instance runs the schedules but only
+// one of them enqueues each run. Runs missed while no instance was up are
+// skipped.
+func (r *Runner) Schedule(jobType, spec string, payload interface{}) error {
+	cron, err := utils.ParseCron(spec)
+	if err != nil {
+		return fmt.Errorf("schedule of %s: %w", jobType, err)
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.handlers[jobType]; !ok {
+		return fmt.Errorf("%w %q", ErrUnknownJobType, jobType)
+	}
+	r.schedules = append(r.schedules, schedule{jobType: jobType, cron: cron, payload: string(encoded)})
+	return nil
+}
+
+// Enqueue saves a job of jobType with payload encoded as JSON, it runs as soon as a worker is free
+func (r *Runner) Enqueue(ctx context.Context, jobType string, payload interface{}) (*models.Job, error) {
+	return r.EnqueueAt(ctx, jobType, payload, time.Now())
+}
+
+// EnqueueAt saves a job of jobType that runs at runAt
+func (r *Runner) EnqueueAt(ctx context.Context, jobType string, payload interface{}, runAt time.Time) (*models.Job, error) {
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	job, err := r.enqueue(ctx, jobType, string(encoded), runAt, "")
+	if err != nil {
+		return nil, err
+	}
+	r.Notify()
+	return job, nil
+}
+
+func (r *Runner) enqueue(ctx context.Context, jobType, payload string, runAt time.Time, uniqueKey string) (*models.Job, error) {
+	r.mu.RLock()
+	_, ok := r.handlers[jobType]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownJobType, jobType)
+	}
+
+	now := time.Now()
+	job := &models.Job{
+		Type:        jobType,
+		Payload:     payload,
+		Status:      models.JobPending,
+		MaxAttempts: r.config.MaxAttempts,
+		RunAt:       runAt,
+		UniqueKey:   uniqueKey,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := r.repository.CreateJob(ctx, job); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// Notify wakes a worker, e.g. after a job was retried
+func (r *Runner) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start starts the workers and the scheduler
+func (r *Runner) Start() {
+	r.workers.Add(r.config.Workers + 1)
+	for range r.config.Workers {
+		go r.work()
+	}
+	go r.schedule()
+}
+
+// Shutdown stops the workers from claiming jobs and waits for the running
+// ones to finish. If ctx expires first the running jobs are cancelled and
+// handed back, so they run again after the restart.
+func (r *Runner) Shutdown(ctx context.Context) error {
+	r.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		r.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		r.cancelJobs()
+		return ctx.Err()
+	}
+}
+
+func (r *Runner) work() {
+	defer r.workers.Done()
+
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for r.ctx.Err() == nil && r.runNext() {
+		}
+
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-r.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNext runs the next due job, it reports whether there was one
+func (r *Runner) runNext() bool {
+	r.mu.RLock()
+	types := make([]string, 0, len(r.handlers))
+	for jobType := range r.handlers {
+		types = append(types, jobType)
+	}
+	r.mu.RUnlock()
+
+	job, err := r.repository.ClaimDue(context.Background(), types, r.config.Lease)
+	if err != nil {
+		log.Println("Error claiming job:", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	// There may be more due jobs, pass the wake on to an idle worker
+	r.Notify()
+	r.run(job)
+	return true
+}
+
+func (r *Runner) run(job *models.Job) {
+	var jobErr error
+	if job.Attempts > job.MaxAttempts {
+		// The workers of its earlier attempts stopped without recording them
+		jobErr = Permanent(errors.New("lease expired on the last attempt"))
+	} else {
+		jobErr = r.call(job)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), recordTimeout)
+	defer cancel()
+
+	switch {
+	case jobErr == nil:
+		r.recorded(job, r.repository.CompleteJob(ctx, job.ID, job.Attempts, r.config.Retention))
+	case r.jobsCtx.Err() != nil:
+		// Stopped by the shutdown, the attempt doesn't count
+		r.recorded(job, r.repository.ReleaseJob(ctx, job.ID, job.Attempts))
+	case IsPermanent(jobErr) || job.Attempts >= job.MaxAttempts:
+		log.Printf("Job %s of type %s is dead after %d attempts: %v\n", job.ID.Hex(), job.Type, job.Attempts, jobErr)
+		r.recorded(job, r.repository.FailJob(ctx, job.ID, job.Attempts, jobErr, nil))
+	default:
+		nextRunAt := time.Now().Add(utils.Backoff(job.Attempts, r.config.InitialBackoff, r.config.MaxBackoff))
+		r.recorded(job, r.repository.FailJob(ctx, job.ID, job.Attempts, jobErr, &nextRunAt))
+	}
+}
+
+// call runs the job's handler while renewing its lease. The handler's context
+// is cancelled if the lease is lost, e.g. because the job was cancelled.
+func (r *Runner) call(job *models.Job) (err error) {
+	r.mu.RLock()
+	handler := r.handlers[job.Type]
+	r.mu.RUnlock()
+
+	ctx, cancel := context.WithCancel(r.jobsCtx)
+	heartbeatDone := make(chan struct{})
+	go func() {
+		defer close(heartbeatDone)
+		r.heartbeat(ctx, cancel, job)
+	}()
+	defer func() {
+		cancel()
+		<-heartbeatDone
+	}()
+
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = fmt.Errorf("panic: %v", recovered)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+// heartbeat renews the job's lease until ctx is done, and cancels ctx if the lease is lost
+func (r *Runner) heartbeat(ctx context.Context, cancel context.CancelFunc, job *models.Job) {
+	ticker := time.NewTicker(r.config.Lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := r.repository.ExtendLease(ctx, job.ID, job.Attempts, r.config.Lease)
+		if errors.Is(err, repositories.ErrJobLeaseLost) {
+			log.Printf("Stopping job %s, it was cancelled or taken over by another worker\n", job.ID.Hex())
+			cancel()
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Error renewing lease of job %s: %v\n", job.ID.Hex(), err)
+		}
+	}
+}
+
+func (r *Runner) recorded(job *models.Job, err error) {
+	if errors.Is(err, repositories.ErrJobLeaseLost) {
+		log.Printf("Outcome of job %s not recorded, it was cancelled or taken over by another worker\n", job.ID.Hex())
+	} else if err != nil {
+		log.Printf("Error recording outcome of job %s: %v\n", job.ID.Hex(), err)
+	}
+}
+
+// schedule enqueues the scheduled jobs as they become due
+func (r *Runner) schedule() {
+	defer r.workers.Done()
+
+	r.mu.RLock()
+	schedules := r.schedules
+	r.mu.RUnlock()
+
+	next := make([]time.Time, len(schedules))
+	for i, s := range schedules {
+		next[i] = s.cron.Next(time.Now().UTC())
+	}
+
+	for {
+		var earliest time.Time
+		for _, at := range next {
+			if !at.IsZero() && (earliest.IsZero() || at.Before(earliest)) {
+				earliest = at
+			}
+		}
+		if earliest.IsZero() {
+			<-r.ctx.Done()
+			return
+		}
+
+		timer := time.NewTimer(time.Until(earliest))
+		select {
+		case <-r.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		for i, s := range schedules {
+			if next[i].IsZero() || next[i].After(time.Now()) {
+				continue
+			}
+			// Keyed on the run, so only the first instance to get here enqueues it
+			uniqueKey := fmt.Sprintf("cron:%s:%d", s.jobType, next[i].Unix())
+			_, err := r.enqueue(r.ctx, s.jobType, s.payload, next[i], uniqueKey)
+			if err != nil && !errors.Is(err, repositories.ErrDuplicateJob) && r.ctx.Err() == nil {
+				log.Printf("Error enqueuing scheduled %s job: %v\n", s.jobType, err)
+			}
+			next[i] = s.cron.Next(next[i])
+		}
+		r.Notify()
+	}
+}
@@ -4,85 +4,302 @@ import (
 	"context"
 	"log"
 	"os"
-	"strings"
-	"time"
+	"os/signal"
+	"syscall"
 
 	"github.com/gin-gonic/gin"
 	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
 	"github.com/harsh-solanki21/golang-gin-crud-api/controllers"
+	"github.com/harsh-solanki21/golang-gin-crud-api/events"
+	"github.com/harsh-solanki21/golang-gin-crud-api/graph"
+	"github.com/harsh-solanki21/golang-gin-crud-api/jobs"
 	"github.com/harsh-solanki21/golang-gin-crud-api/middlewares"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
 	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
 	"github.com/harsh-solanki21/golang-gin-crud-api/routes"
+	"github.com/harsh-solanki21/golang-gin-crud-api/rpc"
+	"github.com/harsh-solanki21/golang-gin-crud-api/server"
 	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/storage"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
 )
 
 func main() {
-	// Load environment variables
-	if err := configs.LoadEnv(); err != nil {
-		log.Fatal("Error loading env file:", err)
+	// Load configuration from file, environment and flags
+	config, err := configs.Load()
+	if err != nil {
+		log.Fatal("Error loading config: ", err)
 	}
 
 	// Set Gin mode
-	if os.Getenv("GIN_MODE") == "release" {
-		gin.SetMode(gin.ReleaseMode)
-	}
+	gin.SetMode(config.GinMode)
 
 	// Create a new router
 	router := gin.Default()
 
 	// Add necessary middleware
 	router.Use(middlewares.ErrorMiddleware())
+	router.Use(middlewares.SecurityHeadersMiddleware(config.Security))
+	router.Use(middlewares.CORSMiddleware(config.CORS))
 
 	// Set trusted proxies
-	trustedProxies := os.Getenv("TRUSTED_PROXIES")
-	proxyList := strings.Split(trustedProxies, ",")
-
-	if err := router.SetTrustedProxies(proxyList); err != nil {
+	if err := router.SetTrustedProxies(config.TrustedProxies); err != nil {
 		log.Fatalf("Error setting trusted proxies: %v", err)
 	}
 
+	// Cancel the root context on SIGINT/SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
 	// Connect to MongoDB
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	connectCtx, cancel := context.WithTimeout(ctx, config.Mongo.ConnectTimeout)
 	defer cancel()
 
-	client, err := configs.ConnectDB(ctx)
+	client, err := configs.ConnectDB(connectCtx, config.Mongo)
 	if err != nil {
 		log.Fatal("Error connecting to MongoDB:", err)
 	}
-	defer func() {
-		if err := client.Disconnect(ctx); err != nil {
-			log.Fatal("Error disconnecting from MongoDB:", err)
-		}
-	}()
+
+	// Initialize JWT manager
+	jwtManager := utils.NewJWTManager(
+		config.Auth.AccessTokenSecret,
+		config.Auth.RefreshTokenSecret,
+		config.Auth.AccessTokenTTL,
+		config.Auth.RefreshTokenTTL,
+	)
+
+	// Cookie attributes for the auth cookies
+	cookieOptions := utils.CookieOptions{
+		Domain:   config.Cookie.Domain,
+		Secure:   config.Cookie.Secure,
+		SameSite: config.Cookie.SameSiteMode(),
+	}
+
+	// Initialize CSRF protection for cookie-authenticated requests
+	var csrfManager *utils.CSRFManager
+	if config.CSRF.Enabled {
+		csrfManager = utils.NewCSRFManager(config.CSRF.Secret)
+	}
 
 	// Initialize repositories
-	userRepo := repositories.NewUserRepository(client)
-	productRepo := repositories.NewProductRepository(client)
+	userRepo := repositories.NewUserRepository(client, config.Mongo)
+	productRepo := repositories.NewProductRepository(client, config.Mongo)
+	stockMovementRepo := repositories.NewStockMovementRepository(client, config.Mongo)
+	cartRepo := repositories.NewCartRepository(client, config.Mongo)
+	orderRepo := repositories.NewOrderRepository(client, config.Mongo)
+	categoryRepo := repositories.NewCategoryRepository(client, config.Mongo)
+	variantRepo := repositories.NewVariantRepository(client, config.Mongo)
+	imageRepo := repositories.NewImageRepository(client, config.Mongo)
+	reviewRepo := repositories.NewReviewRepository(client, config.Mongo)
+	importJobRepo := repositories.NewImportJobRepository(client, config.Mongo)
+	webhookRepo := repositories.NewWebhookRepository(client, config.Mongo)
+	webhookDeliveryRepo := repositories.NewWebhookDeliveryRepository(client, config.Mongo)
+	outboxRepo := repositories.NewOutboxRepository(client, config.Mongo)
+	jobRepo := repositories.NewJobRepository(client, config.Mongo)
+	transactions := repositories.NewTransactions(client, config.Mongo)
+
+	// Create indexes
+	indexers := []interface {
+		EnsureIndexes(ctx context.Context) error
+	}{productRepo, stockMovementRepo, cartRepo, orderRepo, categoryRepo, variantRepo, imageRepo, reviewRepo, importJobRepo, webhookRepo, webhookDeliveryRepo, outboxRepo, jobRepo}
+	for _, indexer := range indexers {
+		if err := indexer.EnsureIndexes(ctx); err != nil {
+			log.Fatal("Error creating indexes:", err)
+		}
+	}
+
+	// Initialize rate limiter
+	var rateLimitStore utils.RateLimitStore = utils.NewMemoryRateLimitStore()
+	if config.RateLimit.Store == "mongo" {
+		rateLimitRepo := repositories.NewRateLimitRepository(client, config.Mongo)
+		if err := rateLimitRepo.EnsureIndexes(ctx); err != nil {
+			log.Fatal("Error creating rate limit indexes:", err)
+		}
+		rateLimitStore = rateLimitRepo
+	}
+	rateLimiter := middlewares.NewRateLimiter(rateLimitStore, config.RateLimit)
+
+	var idempotencyStore utils.IdempotencyStore = utils.NewMemoryIdempotencyStore()
+	if config.Idempotency.Store == "mongo" {
+		idempotencyRepo := repositories.NewIdempotencyRepository(client, config.Mongo)
+		if err := idempotencyRepo.EnsureIndexes(ctx); err != nil {
+			log.Fatal("Error creating idempotency indexes:", err)
+		}
+		idempotencyStore = idempotencyRepo
+	}
+	idempotency := middlewares.NewIdempotency(idempotencyStore, config.Idempotency)
+
+	// Initialize the file store of images and import uploads
+	var blobStore storage.BlobStore
+	if config.Images.Store == "filesystem" {
+		blobStore, err = storage.NewFileSystemStore(config.Images.Dir)
+	} else {
+		blobStore, err = storage.NewGridFSStore(client, config.Mongo, "images")
+	}
+	if err != nil {
+		log.Fatal("Error initializing file store:", err)
+	}
+
+	// Convert prices stored as plain numbers before prices had a currency
+	migrated, err := productRepo.MigrateLegacyPrices(ctx, config.Catalog.LegacyCurrency)
+	if err != nil {
+		log.Fatal("Error migrating legacy product prices:", err)
+	}
+	if migrated > 0 {
+		log.Printf("Migrated %d legacy product prices to %s\n", migrated, config.Catalog.LegacyCurrency)
+	}
+
+	// Domain events are saved to the outbox with the changes that cause them
+	// and relayed to the subscribers on the event bus
+	eventBus := events.NewBus()
+	outbox := events.NewOutbox(outboxRepo, transactions, eventBus, config.Events)
 
 	// Initialize services
-	authService := services.NewAuthService(userRepo)
-	userService := services.NewUserService(userRepo)
-	productService := services.NewProductService(productRepo)
+	webhookService := services.NewWebhookService(webhookRepo, webhookDeliveryRepo, config.Webhooks)
+	eventBus.Subscribe("webhooks", webhookService.HandleEvent, models.WebhookEvents...)
+	authService := services.NewAuthService(userRepo, outbox, jwtManager, cookieOptions, config.Auth)
+	userService := services.NewUserService(userRepo, outbox, config.Auth)
+	categoryService := services.NewCategoryService(categoryRepo, productRepo, transactions)
+	imageService := services.NewImageService(imageRepo, productRepo, blobStore, config.Images)
+	productService := services.NewProductService(productRepo, variantRepo, reviewRepo, categoryService, imageService, outbox)
+	variantService := services.NewVariantService(variantRepo, productRepo)
+	inventoryService := services.NewInventoryService(productRepo, variantRepo, stockMovementRepo)
+	cartService := services.NewCartService(cartRepo, productRepo, variantRepo)
+	orderService := services.NewOrderService(orderRepo, cartRepo, productRepo, variantRepo, inventoryService)
+	reviewService := services.NewReviewService(reviewRepo, productRepo, transactions)
+	productBatchService := services.NewProductBatchService(productService, transactions)
+	productStreamService := services.NewProductStreamService(productRepo, config.ProductEvents)
+
+	// Background jobs run on a pool of workers shared with the other instances
+	jobRunner := jobs.NewRunner(jobRepo, config.Jobs)
+	jobRunner.Register(services.JobPruneDeliveries, webhookService.PruneDeliveries)
+	if err := jobRunner.Schedule(services.JobPruneDeliveries, "@daily", nil); err != nil {
+		log.Fatal("Error scheduling jobs:", err)
+	}
+	jobRunner.Register(services.JobRecomputeRatings, reviewService.RecomputeRatings)
+	if err := jobRunner.Schedule(services.JobRecomputeRatings, "@daily", nil); err != nil {
+		log.Fatal("Error scheduling jobs:", err)
+	}
+	importService := services.NewImportService(productRepo, variantRepo, importJobRepo, jobRepo, categoryService, outbox, jobRunner, blobStore, config.Imports)
+	jobRunner.Register(services.JobRunImport, importService.RunImportJob)
+	jobService := services.NewJobService(jobRepo, jobRunner, config.Jobs)
+
+	// Change streams need a replica set or sharded cluster like transactions
+	// do, without them the product event stream is fed by the event bus,
+	// which only reaches the instance that relays each event
+	changeStreams, err := transactions.Supported(ctx)
+	if err != nil {
+		log.Fatal("Error checking for change stream support:", err)
+	}
+	if changeStreams {
+		productStreamService.Watch()
+	} else {
+		log.Println("Change streams aren't supported, the product event stream only sees the events relayed by this instance; run a single instance or use a replica set")
+		eventBus.Subscribe("product_stream", productStreamService.HandleEvent, services.ProductStreamEvents...)
+	}
+
+	// Move products with a free-form category name into the categories collection
+	migrated, err = categoryService.MigrateLegacyCategories(ctx)
+	if err != nil {
+		log.Fatal("Error migrating legacy product categories:", err)
+	}
+	if migrated > 0 {
+		log.Printf("Migrated %d products to categories\n", migrated)
+	}
 
 	// Initialize controllers
 	authController := controllers.NewAuthController(authService)
 	userController := controllers.NewUserController(userService)
 	productController := controllers.NewProductController(productService)
 
+	var graphqlController *controllers.GraphQLController
+	if config.GraphQL.Enabled {
+		graphqlServer, err := graph.NewServer(productService, userService, categoryService, config.GraphQL)
+		if err != nil {
+			log.Fatal("Error setting up GraphQL:", err)
+		}
+		graphqlController = controllers.NewGraphQLController(graphqlServer)
+	}
+
 	// Set up routes
-	routes.SetupRoutes(router, authController, userController, productController)
+	routes.SetupRoutes(router, routes.Dependencies{
+		JWTManager:          jwtManager,
+		CookieOptions:       cookieOptions,
+		RateLimiter:         rateLimiter,
+		Idempotency:         idempotency,
+		Validation:          middlewares.NewValidation(config.Validation),
+		CSRFManager:         csrfManager,
+		AuthController:      authController,
+		UserController:      userController,
+		ProductController:   productController,
+		CSRFController:      controllers.NewCSRFController(csrfManager, cookieOptions),
+		InventoryController: controllers.NewInventoryController(inventoryService),
+		CartController:      controllers.NewCartController(cartService),
+		OrderController:     controllers.NewOrderController(orderService),
+		CategoryController:  controllers.NewCategoryController(categoryService),
+		VariantController:   controllers.NewVariantController(variantService),
+		ImageController:     controllers.NewImageController(imageService),
+		ReviewController:    controllers.NewReviewController(reviewService),
+		ImportController:    controllers.NewImportController(importService),
+		BatchController:     controllers.NewProductBatchController(productBatchService),
+		WebhookController:   controllers.NewWebhookController(webhookService),
+		StreamController:    controllers.NewProductStreamController(productStreamService),
+		JobController:       controllers.NewJobController(jobService),
+		GraphQLController:   graphqlController,
+		GraphQLPlayground:   config.GraphQL.Playground,
+	})
+
+	// Create the HTTP server
+	srv := server.New(router, server.Options{
+		Addr:              ":" + config.Server.Port,
+		ReadTimeout:       config.Server.ReadTimeout,
+		ReadHeaderTimeout: config.Server.ReadHeaderTimeout,
+		WriteTimeout:      config.Server.WriteTimeout,
+		IdleTimeout:       config.Server.IdleTimeout,
+		MaxHeaderBytes:    config.Server.MaxHeaderBytes,
+		ShutdownTimeout:   config.Server.ShutdownTimeout,
+		HookTimeout:       config.Server.ShutdownHookTimeout,
+	})
 
-	// Get port from environment variable
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "5000"
+	// Serve the gRPC API next to REST, in-flight calls finish before disconnecting
+	if config.GRPC.Enabled {
+		grpcServer := rpc.NewServer(productService, userService, jwtManager, config.GRPC)
+		if err := grpcServer.Start(); err != nil {
+			log.Fatal("Error starting gRPC server:", err)
+		}
+		srv.OnDrain(grpcServer.Drain)
+		srv.OnShutdown(grpcServer.Shutdown)
+		log.Printf("gRPC server is running on localhost:%s\n", config.GRPC.Port)
 	}
 
+	// End open product event streams as soon as shutdown starts, they'd never drain
+	srv.OnDrain(productStreamService.Close)
+	srv.OnShutdown(productStreamService.Shutdown)
+
+	// Run background jobs, the running ones finish or are handed back before disconnecting
+	jobRunner.Start()
+	srv.OnShutdown(jobRunner.Shutdown)
+
+	// Relay saved events to their subscribers, the event being dispatched finishes before disconnecting
+	outbox.Start()
+	srv.OnShutdown(outbox.Shutdown)
+
+	// Send queued webhook deliveries in the background, the ones being sent finish before disconnecting
+	webhookService.Start()
+	srv.OnShutdown(webhookService.Shutdown)
+
+	// Disconnect from MongoDB once in-flight requests have drained
+	srv.OnShutdown(func(ctx context.Context) error {
+		return client.Disconnect(ctx)
+	})
+
 	// Add log before starting the server
-	log.Printf("Server is running on http://localhost:%s\n", port)
+	log.Printf("Server is running on http://localhost:%s\n", config.Server.Port)
 
-	// Run the server
-	if err := router.Run(":" + port); err != nil {
-		log.Fatal("Error starting server: ", err)
+	// Run the server until a shutdown signal arrives
+	if err := srv.Run(ctx); err != nil {
+		log.Fatal("Error running server: ", err)
 	}
+
+	log.Println("Server stopped")
 }
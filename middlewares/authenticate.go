@@ -3,20 +3,41 @@ package middlewares
 import (
 	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
 )
 
-func AuthMiddleware() gin.HandlerFunc {
+// Authentication methods stored in the context under "auth_method"
+const (
+	AuthMethodCookie = "cookie"
+	AuthMethodBearer = "bearer"
+)
+
+func AuthMiddleware(jwtManager *utils.JWTManager, cookieOptions utils.CookieOptions) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Non-browser clients can send the access token in the Authorization header
+		if bearerToken, ok := getBearerToken(c); ok {
+			claims, err := jwtManager.ValidateAccessToken(bearerToken)
+			if err != nil {
+				handleTokenError(c, err)
+				return
+			}
+
+			c.Set("claims", claims)
+			c.Set("auth_method", AuthMethodBearer)
+			c.Next()
+			return
+		}
+
 		accessToken, err := c.Cookie("access_token")
 		if err != nil {
 			handleTokenError(c, err)
 			return
 		}
 
-		claims, err := utils.ValidateAccessToken(accessToken)
+		claims, err := jwtManager.ValidateAccessToken(accessToken)
 		if err != nil {
 			// Access token is invalid or expired, try to refresh
 			refreshToken, err := c.Cookie("refresh_token")
@@ -25,16 +46,16 @@ func AuthMiddleware() gin.HandlerFunc {
 				return
 			}
 
-			newAccessToken, err := utils.RefreshAccessToken(refreshToken)
+			newAccessToken, err := jwtManager.RefreshAccessToken(refreshToken)
 			if err != nil {
 				handleTokenError(c, err)
 				return
 			}
 
 			// Set the new access token as a cookie
-			c.SetCookie("access_token", newAccessToken, 900, "/", "", false, true)
+			utils.SetCookie(c, cookieOptions, "access_token", newAccessToken, int(jwtManager.AccessTokenTTL().Seconds()))
 
-			claims, err = utils.ValidateAccessToken(newAccessToken)
+			claims, err = jwtManager.ValidateAccessToken(newAccessToken)
 			if err != nil {
 				handleTokenError(c, err)
 				return
@@ -43,12 +64,21 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		// Set the entire claims object in the context
 		c.Set("claims", claims)
+		c.Set("auth_method", AuthMethodCookie)
 		log.Println("User ID:", claims.UserID, "Role:", claims.Role)
 
 		c.Next()
 	}
 }
 
+func getBearerToken(c *gin.Context) (string, bool) {
+	scheme, token, found := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
 func handleTokenError(c *gin.Context, err error) {
 	utils.RespondWithError(c, http.StatusUnauthorized, "Authentication failed", err)
 	c.Abort()
@@ -9,7 +9,7 @@ import (
 
 func AuthorizeMiddleware(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		claims, err := getClaimsFromContext(c)
+		claims, err := GetClaimsFromContext(c)
 		if err != nil {
 			utils.RespondWithError(c, http.StatusUnauthorized, err.Error(), nil)
 			c.Abort()
@@ -26,7 +26,7 @@ func AuthorizeMiddleware(roles ...string) gin.HandlerFunc {
 	}
 }
 
-func getClaimsFromContext(c *gin.Context) (*utils.Claims, error) {
+func GetClaimsFromContext(c *gin.Context) (*utils.Claims, error) {
 	claims, exists := c.Get("claims")
 	if !exists {
 		return nil, utils.NewCustomError(http.StatusUnauthorized, "Unauthorized", nil)
@@ -0,0 +1,76 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+const (
+	CSRFCookieName = "csrf_token"
+	CSRFHeaderName = "X-CSRF-Token"
+)
+
+// CSRFSession is the value CSRF tokens are bound to, the refresh token cookie
+// that every cookie session has. It's empty for clients without a session.
+func CSRFSession(c *gin.Context) string {
+	session, _ := c.Cookie("refresh_token")
+	return session
+}
+
+// CSRFMiddleware requires a valid CSRF token on unsafe methods for requests
+// authenticated by cookie. It must run after AuthMiddleware; requests that
+// authenticated any other way (e.g. a bearer token) are exempt.
+func CSRFMiddleware(csrfManager *utils.CSRFManager) gin.HandlerFunc {
+	return csrfCheck(csrfManager, func(c *gin.Context) bool {
+		return c.GetString("auth_method") == AuthMethodCookie
+	})
+}
+
+// CSRFSessionMiddleware requires a valid CSRF token on unsafe methods for
+// requests with a session cookie, for routes such as /refresh and /logout that
+// use the refresh token cookie without AuthMiddleware
+func CSRFSessionMiddleware(csrfManager *utils.CSRFManager) gin.HandlerFunc {
+	return csrfCheck(csrfManager, func(c *gin.Context) bool {
+		return CSRFSession(c) != ""
+	})
+}
+
+// csrfCheck verifies the CSRF token of the unsafe requests that protected reports as cookie-authenticated
+func csrfCheck(csrfManager *utils.CSRFManager, protected func(c *gin.Context) bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isSafeMethod(c.Request.Method) || !protected(c) {
+			c.Next()
+			return
+		}
+
+		cookieToken, _ := c.Cookie(CSRFCookieName)
+		headerToken := c.GetHeader(CSRFHeaderName)
+
+		if err := csrfManager.VerifyToken(cookieToken, headerToken, CSRFSession(c)); err != nil {
+			code := "csrf_token_invalid"
+			switch {
+			case errors.Is(err, utils.ErrCSRFTokenMissing):
+				code = "csrf_token_missing"
+			case errors.Is(err, utils.ErrCSRFTokenMismatch):
+				code = "csrf_token_mismatch"
+			}
+
+			utils.RespondWithError(c, http.StatusForbidden, "CSRF validation failed", gin.H{"code": code})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,261 @@
+package middlewares
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+const (
+	IdempotencyKeyHeader      = "Idempotency-Key"
+	IdempotentReplayedHeader  = "Idempotent-Replayed"
+	maxIdempotencyKeyLength   = 255
+	idempotencyPollInterval   = 100 * time.Millisecond
+	maxIdempotentResponseSize = 1 << 20 // 1 MB
+)
+
+type Idempotency struct {
+	store  utils.IdempotencyStore
+	config configs.IdempotencyConfig
+}
+
+func NewIdempotency(store utils.IdempotencyStore, config configs.IdempotencyConfig) *Idempotency {
+	return &Idempotency{
+		store:  store,
+		config: config,
+	}
+}
+
+// Handle makes unsafe requests with an Idempotency-Key header run once. The
+// response is stored and replayed to retries with the same key and payload,
+// retries made while the original request is in flight wait for it. Keys are
+// scoped to the authenticated user, so the middleware must run after
+// AuthMiddleware on protected routes.
+func (im *Idempotency) Handle() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if !im.config.Enabled || key == "" || isSafeMethod(c.Request.Method) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid Idempotency-Key", gin.H{"code": "idempotency_key_invalid"})
+			c.Abort()
+			return
+		}
+
+		scope := "anonymous"
+		if claims, err := GetClaimsFromContext(c); err == nil {
+			scope = "user:" + claims.UserID
+		}
+		storeKey := scope + ":" + key
+
+		token, err := newIdempotencyToken()
+		if err != nil {
+			utils.HandleError(c, err)
+			c.Abort()
+			return
+		}
+
+		deadline := time.Now().Add(im.config.WaitTimeout)
+		for {
+			record, locked, err := im.store.Lock(c.Request.Context(), storeKey, token, im.config.LockTimeout, im.config.TTL)
+			if err != nil {
+				// Fail closed, running the request without the key could duplicate it
+				log.Println("Idempotency store error:", err)
+				utils.RespondWithError(c, http.StatusServiceUnavailable, "Service unavailable", nil)
+				c.Abort()
+				return
+			}
+			if locked {
+				im.execute(c, storeKey, token)
+				return
+			}
+			if record.Completed {
+				im.replay(c, record)
+				return
+			}
+
+			// The original request is still in flight
+			if time.Now().After(deadline) {
+				c.Header("Retry-After", strconv.Itoa(ceilSeconds(im.config.WaitTimeout.Seconds())))
+				utils.RespondWithError(c, http.StatusConflict, "A request with this Idempotency-Key is still in progress", gin.H{"code": "idempotency_key_in_use"})
+				c.Abort()
+				return
+			}
+			select {
+			case <-c.Request.Context().Done():
+				c.Abort()
+				return
+			case <-time.After(idempotencyPollInterval):
+			}
+		}
+	}
+}
+
+// execute runs the request while holding its key and stores the response.
+// Responses a retry could change, rate limits and server errors, aren't
+// stored and the key is released instead. Responses too large to store are
+// recorded as oversized, so retries don't run the request again.
+func (im *Idempotency) execute(c *gin.Context, storeKey, token string) {
+	completed := false
+	defer func() {
+		// Also releases the key if a handler panics
+		if !completed {
+			if err := im.store.Release(context.Background(), storeKey, token); err != nil {
+				log.Println("Error releasing idempotency key:", err)
+			}
+		}
+	}()
+
+	// The body is fingerprinted as the handler reads it, so it's never buffered
+	fingerprint := newFingerprint(c.Request)
+	body := &fingerprintedBody{reader: io.TeeReader(c.Request.Body, fingerprint), closer: c.Request.Body}
+	c.Request.Body = body
+
+	headers := c.Writer.Header().Clone()
+	recorder := &responseRecorder{ResponseWriter: c.Writer}
+	c.Writer = recorder
+
+	c.Next()
+	c.Writer = recorder.ResponseWriter
+
+	// Whatever the handler didn't read still counts for the fingerprint
+	if _, err := io.Copy(io.Discard, body.reader); err != nil {
+		return
+	}
+
+	status := recorder.Status()
+	if status == http.StatusTooManyRequests || status >= http.StatusInternalServerError {
+		return
+	}
+
+	record := utils.IdempotencyRecord{
+		Fingerprint: hex.EncodeToString(fingerprint.Sum(nil)),
+		StatusCode:  status,
+	}
+	if recorder.overflow {
+		record.Oversized = true
+	} else {
+		record.Header = addedHeaders(headers, recorder.Header())
+		record.Body = recorder.body.Bytes()
+	}
+	if err := im.store.Complete(context.Background(), storeKey, token, record); err != nil {
+		log.Println("Error storing idempotent response:", err)
+		return
+	}
+	completed = true
+}
+
+// replay sends the stored response if the request matches the one that created it
+func (im *Idempotency) replay(c *gin.Context, record *utils.IdempotencyRecord) {
+	fingerprint := newFingerprint(c.Request)
+	if _, err := io.Copy(fingerprint, c.Request.Body); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err.Error())
+		c.Abort()
+		return
+	}
+	if hex.EncodeToString(fingerprint.Sum(nil)) != record.Fingerprint {
+		utils.RespondWithError(c, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request", gin.H{"code": "idempotency_key_reused"})
+		c.Abort()
+		return
+	}
+	if record.Oversized {
+		utils.RespondWithError(c, http.StatusConflict, "The response to this Idempotency-Key was too large to store", gin.H{"code": "idempotency_response_too_large", "status": record.StatusCode})
+		c.Abort()
+		return
+	}
+
+	for name, values := range record.Header {
+		c.Writer.Header()[name] = values
+	}
+	c.Header(IdempotentReplayedHeader, "true")
+	c.Status(record.StatusCode)
+	if _, err := c.Writer.Write(record.Body); err != nil {
+		log.Println("Error replaying idempotent response:", err)
+	}
+	c.Abort()
+}
+
+// newFingerprint starts a hash of the request's method and URI, the body is written to it afterwards
+func newFingerprint(request *http.Request) hash.Hash {
+	fingerprint := sha256.New()
+	io.WriteString(fingerprint, request.Method+" "+request.URL.RequestURI()+"\n")
+	return fingerprint
+}
+
+func newIdempotencyToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// addedHeaders returns the response headers set after the middleware started,
+// headers from earlier middlewares, e.g. rate limits, belong to each request
+func addedHeaders(before, after http.Header) http.Header {
+	added := http.Header{}
+	for name, values := range after {
+		if !slices.Equal(before[name], values) {
+			added[name] = values
+		}
+	}
+	return added
+}
+
+type fingerprintedBody struct {
+	reader io.Reader
+	closer io.Closer
+}
+
+func (b *fingerprintedBody) Read(p []byte) (int, error) {
+	return b.reader.Read(p)
+}
+
+func (b *fingerprintedBody) Close() error {
+	return b.closer.Close()
+}
+
+// responseRecorder keeps a copy of the response body while it's written
+type responseRecorder struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.record(data)
+	return r.ResponseWriter.Write(data)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	r.record([]byte(s))
+	return r.ResponseWriter.WriteString(s)
+}
+
+// record stops recording once the body is too large to store
+func (r *responseRecorder) record(data []byte) {
+	if r.overflow {
+		return
+	}
+	if r.body.Len()+len(data) > maxIdempotentResponseSize {
+		r.overflow = true
+		r.body.Reset()
+		return
+	}
+	r.body.Write(data)
+}
@@ -0,0 +1,109 @@
+package middlewares
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/openapi"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+// maxValidatedBodySize is the largest JSON body that's buffered for validation
+const maxValidatedBodySize = 1 << 20 // 1 MB
+
+type Validation struct {
+	config configs.ValidationConfig
+	// OnResponseError is called with the problems of a response that doesn't
+	// match the document, they're logged by default. Tests set it to fail.
+	OnResponseError func(c *gin.Context, errors []openapi.ValidationError)
+}
+
+func NewValidation(config configs.ValidationConfig) *Validation {
+	return &Validation{
+		config: config,
+		OnResponseError: func(c *gin.Context, errors []openapi.ValidationError) {
+			log.Printf("Response of %s %s doesn't match the API documentation: %+v\n", c.Request.Method, c.FullPath(), errors)
+		},
+	}
+}
+
+// Handle validates requests against spec, requests with parameters or JSON
+// bodies that don't match are rejected with the problem of each field. With
+// response validation on, JSON responses are checked after the handler ran.
+// Routes that aren't documented pass through.
+func (vm *Validation) Handle(spec *openapi.Spec) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if vm == nil || (!vm.config.Requests && !vm.config.Responses) {
+			c.Next()
+			return
+		}
+
+		if vm.config.Requests && !vm.validateRequest(c, spec) {
+			c.Abort()
+			return
+		}
+		if !vm.config.Responses {
+			c.Next()
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		c.Writer = recorder.ResponseWriter
+
+		if recorder.overflow || !isJSON(recorder.Header().Get("Content-Type")) {
+			return
+		}
+		errors := spec.ValidateResponse(c.Request.Method, c.FullPath(), recorder.Status(), recorder.body.Bytes())
+		if len(errors) > 0 && vm.OnResponseError != nil {
+			vm.OnResponseError(c, errors)
+		}
+	}
+}
+
+// validateRequest responds with the validation errors of the request, it
+// returns whether the request is valid
+func (vm *Validation) validateRequest(c *gin.Context, spec *openapi.Spec) bool {
+	request := openapi.Request{
+		Method:     c.Request.Method,
+		Path:       c.FullPath(),
+		PathParams: map[string]string{},
+		Query:      c.Request.URL.Query(),
+		Header:     c.Request.Header,
+	}
+	for _, param := range c.Params {
+		request.PathParams[param.Key] = param.Value
+	}
+
+	// Only JSON bodies are validated, uploads are checked by their handlers
+	if c.Request.Body != nil && (c.ContentType() == "" || isJSON(c.ContentType())) {
+		body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxValidatedBodySize+1))
+		if err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err.Error())
+			return false
+		}
+		if len(body) > maxValidatedBodySize {
+			utils.RespondWithError(c, http.StatusRequestEntityTooLarge, "Request body too large", nil)
+			return false
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+		request.Body = body
+	}
+
+	if errors := spec.ValidateRequest(request); len(errors) > 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "Validation error", errors)
+		return false
+	}
+	return true
+}
+
+func isJSON(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	return err == nil && mediaType == "application/json"
+}
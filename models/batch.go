@@ -0,0 +1,82 @@
+package models
+
+import "encoding/json"
+
+// Batch modes. Atomic batches are applied in a transaction and either all
+// operations succeed or none is applied, best effort batches apply every
+// operation on its own.
+const (
+	BatchAtomic     = "atomic"
+	BatchBestEffort = "best_effort"
+)
+
+// Batch operations
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// ProductBatchRequest holds up to 100 operations, the mode defaults to best effort
+type ProductBatchRequest struct {
+	Mode       string                  `json:"mode" binding:"omitempty,oneof=atomic best_effort"`
+	Operations []ProductBatchOperation `json:"operations" binding:"required,min=1,max=100,dive"`
+}
+
+// ProductBatchOperation creates a product, or updates or deletes the product
+// with ID. The product is decoded as the input of the single-item endpoint
+// of the operation: Create for creates and Update for updates.
+type ProductBatchOperation struct {
+	Op     string              `json:"op" binding:"required,oneof=create update delete"`
+	ID     string              `json:"id" binding:"required_unless=Op create"`
+	Create *CreateProductInput `json:"-" binding:"required_if=Op create"`
+	Update *UpdateProductInput `json:"-" binding:"required_if=Op update"`
+}
+
+func (o *ProductBatchOperation) UnmarshalJSON(data []byte) error {
+	var operation struct {
+		Op      string          `json:"op"`
+		ID      string          `json:"id"`
+		Product json.RawMessage `json:"product"`
+	}
+	if err := json.Unmarshal(data, &operation); err != nil {
+		return err
+	}
+
+	*o = ProductBatchOperation{Op: operation.Op, ID: operation.ID}
+	if len(operation.Product) == 0 || string(operation.Product) == "null" {
+		return nil
+	}
+	switch operation.Op {
+	case BatchCreate:
+		o.Create = &CreateProductInput{}
+		return json.Unmarshal(operation.Product, o.Create)
+	case BatchUpdate:
+		o.Update = &UpdateProductInput{}
+		return json.Unmarshal(operation.Product, o.Update)
+	}
+	return nil
+}
+
+type ProductBatchError struct {
+	Message string      `json:"message"`
+	Details interface{} `json:"details,omitempty"`
+}
+
+// ProductBatchResult is the outcome of one operation, Status is the HTTP
+// status the single-item endpoint would have responded with
+type ProductBatchResult struct {
+	Index   int                `json:"index"`
+	Op      string             `json:"op"`
+	ID      string             `json:"id,omitempty"`
+	Status  int                `json:"status"`
+	Product *ProductResponse   `json:"product,omitempty"`
+	Error   *ProductBatchError `json:"error,omitempty"`
+}
+
+type ProductBatchResponse struct {
+	Mode      string               `json:"mode"`
+	Succeeded int                  `json:"succeeded"`
+	Failed    int                  `json:"failed"`
+	Results   []ProductBatchResult `json:"results"`
+}
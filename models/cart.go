@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CartItem struct {
+	ProductID primitive.ObjectID `bson:"product_id" json:"product_id"`
+	// SKU is set for products with variants
+	SKU      string `bson:"sku" json:"sku,omitempty"`
+	Quantity int    `bson:"quantity" json:"quantity"`
+}
+
+// Cart is stored per user and only holds product references; prices are
+// looked up when the cart is viewed
+type Cart struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Items     []CartItem         `bson:"items" json:"items"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// CartItemRequest adds a product or one of its variants. A SKU on its own is
+// enough to find the product.
+type CartItemRequest struct {
+	ProductID string `json:"product_id" binding:"required_without=SKU"`
+	SKU       string `json:"sku" binding:"max=64"`
+	Quantity  int    `json:"quantity" binding:"required,gt=0,lte=1000"`
+}
+
+type CartItemUpdateRequest struct {
+	Quantity int `json:"quantity" binding:"required,gt=0,lte=1000"`
+}
+
+// CartLine is a cart item priced with the current product data
+type CartLine struct {
+	ProductID primitive.ObjectID `json:"product_id"`
+	SKU       string             `json:"sku,omitempty"`
+	Name      string             `json:"name"`
+	Options   map[string]string  `json:"options,omitempty"`
+	UnitPrice Money              `json:"unit_price"`
+	Quantity  int                `json:"quantity"`
+	LineTotal Money              `json:"line_total"`
+	// Available is false when the product was deleted or has too little stock
+	Available bool `json:"available"`
+}
+
+type CartView struct {
+	Items         []CartLine `json:"items"`
+	TotalQuantity int        `json:"total_quantity"`
+	Total         Money      `json:"total"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Category struct {
+	ID       primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	Name     string              `bson:"name" json:"name" validate:"required,min=2,max=100"`
+	Slug     string              `bson:"slug" json:"slug" validate:"omitempty,slug,max=100"`
+	ParentID *primitive.ObjectID `bson:"parent_id,omitempty" json:"parent_id,omitempty"`
+	// Ancestors lists the ids from the root down to the parent, so a subtree can be found with one query
+	Ancestors []primitive.ObjectID `bson:"ancestors" json:"ancestors"`
+	Position  int                  `bson:"position" json:"position" validate:"gte=0"`
+	CreatedAt time.Time            `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time            `bson:"updated_at" json:"updated_at"`
+}
+
+func (c *Category) MarshalBSON() ([]byte, error) {
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	c.UpdatedAt = time.Now()
+	if c.Ancestors == nil {
+		c.Ancestors = []primitive.ObjectID{}
+	}
+
+	type my Category
+	return bson.Marshal((*my)(c))
+}
+
+// CreateCategoryInput is the body of a new category, the slug is derived
+// from the name when it's not set
+type CreateCategoryInput struct {
+	Name     string              `json:"name" validate:"required,min=2,max=100"`
+	Slug     string              `json:"slug" validate:"omitempty,slug,max=100"`
+	ParentID *primitive.ObjectID `json:"parent_id,omitempty"`
+	Position int                 `json:"position" validate:"gte=0"`
+}
+
+func (in *CreateCategoryInput) ToCategory() *Category {
+	return &Category{
+		Name:     in.Name,
+		Slug:     in.Slug,
+		ParentID: in.ParentID,
+		Position: in.Position,
+	}
+}
+
+// CategoryResponse is the public representation of a category
+type CategoryResponse struct {
+	ID        primitive.ObjectID   `json:"id"`
+	Name      string               `json:"name"`
+	Slug      string               `json:"slug"`
+	ParentID  *primitive.ObjectID  `json:"parent_id,omitempty"`
+	Ancestors []primitive.ObjectID `json:"ancestors"`
+	Position  int                  `json:"position"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+func NewCategoryResponse(category *Category) *CategoryResponse {
+	ancestors := category.Ancestors
+	if ancestors == nil {
+		ancestors = []primitive.ObjectID{}
+	}
+	return &CategoryResponse{
+		ID:        category.ID,
+		Name:      category.Name,
+		Slug:      category.Slug,
+		ParentID:  category.ParentID,
+		Ancestors: ancestors,
+		Position:  category.Position,
+		CreatedAt: category.CreatedAt,
+		UpdatedAt: category.UpdatedAt,
+	}
+}
+
+func NewCategoryResponses(categories []*Category) []*CategoryResponse {
+	responses := make([]*CategoryResponse, len(categories))
+	for i, category := range categories {
+		responses[i] = NewCategoryResponse(category)
+	}
+	return responses
+}
+
+// CategoryUpdateRequest only changes the fields that are set. An empty
+// parent_id moves the category to the top level.
+type CategoryUpdateRequest struct {
+	Name     *string `json:"name" validate:"omitempty,min=2,max=100"`
+	Slug     *string `json:"slug" validate:"omitempty,slug,max=100"`
+	ParentID *string `json:"parent_id"`
+	Position *int    `json:"position" validate:"omitempty,gte=0"`
+}
+
+// CategoryNode is a category in the category tree
+type CategoryNode struct {
+	ID       primitive.ObjectID `json:"id"`
+	Name     string             `json:"name"`
+	Slug     string             `json:"slug"`
+	Position int                `json:"position"`
+	// ProductCount counts the products directly in the category, TotalProductCount includes subcategories
+	ProductCount      int64           `json:"product_count"`
+	TotalProductCount int64           `json:"total_product_count"`
+	Children          []*CategoryNode `json:"children"`
+}
+
+// BuildCategoryTree arranges categories under their parents, keeping the
+// order they are given in. counts holds the number of products directly in
+// each category. Categories whose parent is missing become top-level nodes.
+func BuildCategoryTree(categories []*Category, counts map[primitive.ObjectID]int64) []*CategoryNode {
+	nodes := make(map[primitive.ObjectID]*CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &CategoryNode{
+			ID:           category.ID,
+			Name:         category.Name,
+			Slug:         category.Slug,
+			Position:     category.Position,
+			ProductCount: counts[category.ID],
+			Children:     []*CategoryNode{},
+		}
+	}
+
+	roots := []*CategoryNode{}
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID != nil {
+			if parent, ok := nodes[*category.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+
+	for _, root := range roots {
+		sumProductCounts(root)
+	}
+	return roots
+}
+
+func sumProductCounts(node *CategoryNode) int64 {
+	node.TotalProductCount = node.ProductCount
+	for _, child := range node.Children {
+		node.TotalProductCount += sumProductCounts(child)
+	}
+	return node.TotalProductCount
+}
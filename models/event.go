@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Domain events
+const (
+	EventProductCreated      = "product.created"
+	EventProductUpdated      = "product.updated"
+	EventProductPriceChanged = "product.price_changed"
+	EventProductDeleted      = "product.deleted"
+	EventUserRegistered      = "user.registered"
+	EventUserUpdated         = "user.updated"
+	EventUserDeleted         = "user.deleted"
+)
+
+// Outbox event statuses. Pending events are dispatched until every subscriber
+// has handled them or they run out of attempts.
+const (
+	OutboxPending    = "pending"
+	OutboxDispatched = "dispatched"
+	OutboxFailed     = "failed"
+)
+
+// Event is a change that was saved, Data is its JSON payload
+type Event struct {
+	ID         primitive.ObjectID
+	Type       string
+	OccurredAt time.Time
+	Data       json.RawMessage
+}
+
+// NewEvent creates an event of eventType with data encoded as its payload
+func NewEvent(eventType string, data interface{}) (Event, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{
+		ID:         primitive.NewObjectID(),
+		Type:       eventType,
+		OccurredAt: time.Now(),
+		Data:       payload,
+	}, nil
+}
+
+// OutboxEntry is an event waiting in the outbox. DeliveredTo are the
+// subscribers that already handled it, they're skipped when it's retried.
+type OutboxEntry struct {
+	Event       Event
+	Attempts    int
+	DeliveredTo []string
+}
+
+// PriceChange is the payload of product.price_changed
+type PriceChange struct {
+	ID       primitive.ObjectID `json:"id"`
+	OldPrice Money              `json:"old_price"`
+	NewPrice Money              `json:"new_price"`
+}
+
+// ProductChange is a product.created, product.updated or product.deleted
+// event of the product event stream, Product is nil for deletions. ID is the
+// stream's event ID and Token resumes the change stream after the change.
+type ProductChange struct {
+	ID        string             `json:"-"`
+	Type      string             `json:"type"`
+	ProductID primitive.ObjectID `json:"id"`
+	Product   *Product           `json:"product,omitempty"`
+	Token     bson.Raw           `json:"-"`
+}
@@ -0,0 +1,79 @@
+package models
+
+import (
+	"encoding/json"
+)
+
+// ProductExportColumns are the columns of a product export, in their default order
+var ProductExportColumns = []string{
+	"id", "name", "description", "price", "currency", "category_id", "stock_quantity", "reserved",
+	"low_stock_threshold", "in_stock", "variant_count", "average_rating", "review_count", "created_at", "updated_at",
+}
+
+// UserExportColumns are the columns of a user export. The password hash is never exported.
+var UserExportColumns = []string{"id", "name", "email", "age", "role", "created_at", "updated_at"}
+
+// ExportValues returns the product's values for the given export columns.
+// Prices are exact decimals in the product's currency.
+func (p *Product) ExportValues(columns []string) []interface{} {
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		switch column {
+		case "id":
+			values[i] = p.ID.Hex()
+		case "name":
+			values[i] = p.Name
+		case "description":
+			values[i] = p.Description
+		case "price":
+			values[i] = json.Number(p.Price.String())
+		case "currency":
+			values[i] = p.Price.Currency
+		case "category_id":
+			values[i] = p.CategoryID.Hex()
+		case "stock_quantity":
+			values[i] = p.StockQuantity
+		case "reserved":
+			values[i] = p.Reserved
+		case "low_stock_threshold":
+			values[i] = p.LowStockThreshold
+		case "in_stock":
+			values[i] = p.InStock
+		case "variant_count":
+			values[i] = p.VariantCount
+		case "average_rating":
+			values[i] = p.AverageRating
+		case "review_count":
+			values[i] = p.ReviewCount
+		case "created_at":
+			values[i] = p.CreatedAt
+		case "updated_at":
+			values[i] = p.UpdatedAt
+		}
+	}
+	return values
+}
+
+// ExportValues returns the user's values for the given export columns
+func (u *User) ExportValues(columns []string) []interface{} {
+	values := make([]interface{}, len(columns))
+	for i, column := range columns {
+		switch column {
+		case "id":
+			values[i] = u.ID.Hex()
+		case "name":
+			values[i] = u.Name
+		case "email":
+			values[i] = u.Email
+		case "age":
+			values[i] = u.Age
+		case "role":
+			values[i] = u.Role
+		case "created_at":
+			values[i] = u.CreatedAt
+		case "updated_at":
+			values[i] = u.UpdatedAt
+		}
+	}
+	return values
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProductImage describes an uploaded product image, the files themselves are kept in a blob store
+type ProductImage struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ProductID   primitive.ObjectID `bson:"product_id" json:"product_id"`
+	Key         string             `bson:"key" json:"-"`
+	ContentType string             `bson:"content_type" json:"content_type"`
+	Size        int64              `bson:"size" json:"size"`
+	Width       int                `bson:"width" json:"width"`
+	Height      int                `bson:"height" json:"height"`
+	Position    int                `bson:"position" json:"position"`
+	Primary     bool               `bson:"primary" json:"primary"`
+	Thumbnails  []ImageThumbnail   `bson:"thumbnails" json:"thumbnails"`
+	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
+}
+
+// ImageThumbnail is a scaled-down copy of an image, Size is the longest edge it was scaled to
+type ImageThumbnail struct {
+	Size        int    `bson:"size" json:"size"`
+	Key         string `bson:"key" json:"-"`
+	ContentType string `bson:"content_type" json:"content_type"`
+	Bytes       int64  `bson:"bytes" json:"bytes"`
+	Width       int    `bson:"width" json:"width"`
+	Height      int    `bson:"height" json:"height"`
+}
+
+// File returns the blob key, content type and length of the thumbnail with
+// the given size, or of the original image if size is 0
+func (i *ProductImage) File(size int) (key string, contentType string, bytes int64, ok bool) {
+	if size == 0 {
+		return i.Key, i.ContentType, i.Size, true
+	}
+	for _, thumbnail := range i.Thumbnails {
+		if thumbnail.Size == size {
+			return thumbnail.Key, thumbnail.ContentType, thumbnail.Bytes, true
+		}
+	}
+	return "", "", 0, false
+}
+
+// ImageOrderRequest lists all image ids of a product in their new order
+type ImageOrderRequest struct {
+	ImageIDs []string `json:"image_ids" binding:"required,min=1"`
+}
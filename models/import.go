@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Import modes, a dry run validates every row without writing anything
+const (
+	ImportDryRun = "dry_run"
+	ImportCommit = "commit"
+)
+
+// Import job statuses
+const (
+	ImportJobRunning   = "running"
+	ImportJobCompleted = "completed"
+	ImportJobFailed    = "failed"
+)
+
+// ImportFields are the product fields an import file can set. Rows are matched
+// to existing products by sku, or by name when they have no sku or an unknown one.
+var ImportFields = []string{"sku", "name", "description", "price", "currency", "category", "stock_quantity", "low_stock_threshold"}
+
+type ImportOptions struct {
+	Format string `json:"format"`
+	Mode   string `json:"mode"`
+	// Mapping maps the file's column names to ImportFields, other columns keep their names
+	Mapping map[string]string `json:"mapping,omitempty"`
+}
+
+// ImportRowError lists the problems with one row, Row is its line in the file
+type ImportRowError struct {
+	Row    int                 `bson:"row" json:"row"`
+	Errors []map[string]string `bson:"errors" json:"errors"`
+}
+
+// ImportMatchError is a row that doesn't match a single product
+type ImportMatchError struct {
+	Field   string
+	Message string
+}
+
+func (e *ImportMatchError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// MatchImportProduct finds the product an import row updates, the one with a
+// variant with the row's sku or else the only one with the row's name. Rows
+// with an unknown sku fall through to their name, and create a product when no
+// product has it. Imports don't create variants, so the sku of a new product
+// still has to be added as a variant.
+func MatchImportProduct(sku, name string, productsBySKU map[string]*Product, productsByName map[string][]*Product) (*Product, error) {
+	if sku != "" {
+		if product := productsBySKU[sku]; product != nil {
+			return product, nil
+		}
+		if name == "" {
+			return nil, &ImportMatchError{Field: "sku", Message: "no variant has this sku, rows for new products need a name"}
+		}
+	}
+
+	switch matches := productsByName[name]; len(matches) {
+	case 0:
+		return nil, nil
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, &ImportMatchError{Field: "name", Message: fmt.Sprintf("%d products have this name, match them by sku instead", len(matches))}
+	}
+}
+
+type ImportReport struct {
+	Mode      string           `bson:"mode" json:"mode"`
+	TotalRows int              `bson:"total_rows" json:"total_rows"`
+	Created   int              `bson:"created" json:"created"`
+	Updated   int              `bson:"updated" json:"updated"`
+	Failed    int              `bson:"failed" json:"failed"`
+	Errors    []ImportRowError `bson:"errors" json:"errors"`
+}
+
+// ImportJob tracks an import running in the background. It's run by the
+// background job JobID, Report holds the rows done so far until it finishes.
+type ImportJob struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	JobID      primitive.ObjectID `bson:"job_id,omitempty" json:"job_id,omitempty"`
+	Status     string             `bson:"status" json:"status"`
+	Options    ImportOptions      `bson:"options" json:"options"`
+	TotalRows  int                `bson:"total_rows" json:"total_rows"`
+	Processed  int                `bson:"processed" json:"processed"`
+	Report     *ImportReport      `bson:"report,omitempty" json:"report,omitempty"`
+	Error      string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedBy  primitive.ObjectID `bson:"created_by" json:"created_by"`
+	CreatedAt  time.Time          `bson:"created_at" json:"created_at"`
+	FinishedAt *time.Time         `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Stock movement types
+const (
+	StockReceive  = "receive"
+	StockAdjust   = "adjust"
+	StockWriteOff = "write_off"
+	StockReserve  = "reserve"
+	StockRelease  = "release"
+	StockSale     = "sale"
+)
+
+// StockMovement records every change to a product's stock
+type StockMovement struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ProductID primitive.ObjectID `bson:"product_id" json:"product_id"`
+	SKU       string             `bson:"sku,omitempty" json:"sku,omitempty"`
+	Type      string             `bson:"type" json:"type"`
+	// Quantity is signed, negative values remove stock or release a reservation
+	Quantity  int       `bson:"quantity" json:"quantity"`
+	Reason    string    `bson:"reason" json:"reason"`
+	UserID    string    `bson:"user_id" json:"user_id"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+}
+
+// StockAdjustmentRequest changes the stock on hand. Receive and write-off take
+// a positive quantity, adjust takes a signed correction. Products with
+// variants need the SKU of the variant to change.
+type StockAdjustmentRequest struct {
+	SKU      string `json:"sku" binding:"max=64"`
+	Type     string `json:"type" binding:"required,oneof=receive adjust write_off"`
+	Quantity int    `json:"quantity" binding:"required"`
+	Reason   string `json:"reason" binding:"required,max=200"`
+}
+
+type StockReservationRequest struct {
+	SKU      string `json:"sku" binding:"max=64"`
+	Quantity int    `json:"quantity" binding:"required,gt=0"`
+	Reason   string `json:"reason" binding:"max=200"`
+}
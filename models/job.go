@@ -0,0 +1,58 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Job statuses. Pending jobs run once they're due, failed attempts are
+// retried until the job runs out of attempts and is dead. Dead and cancelled
+// jobs stay until they're retried.
+const (
+	JobPending   = "pending"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobDead      = "dead"
+	JobCancelled = "cancelled"
+)
+
+// JobStatuses are the statuses jobs can be listed by
+var JobStatuses = []string{JobPending, JobRunning, JobSucceeded, JobDead, JobCancelled}
+
+// Job is a unit of background work. While it runs it's leased until
+// LeasedUntil, a job whose lease runs out is picked up again by another
+// worker. Attempts counts the runs started, it's also the lease's token.
+type Job struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Type        string             `bson:"type" json:"type"`
+	Payload     string             `bson:"payload" json:"-"`
+	Status      string             `bson:"status" json:"status"`
+	Attempts    int                `bson:"attempts" json:"attempts"`
+	MaxAttempts int                `bson:"max_attempts" json:"max_attempts"`
+	RunAt       time.Time          `bson:"run_at" json:"run_at"`
+	LeasedUntil *time.Time         `bson:"leased_until,omitempty" json:"leased_until,omitempty"`
+	LastError   string             `bson:"last_error,omitempty" json:"last_error,omitempty"`
+	// UniqueKey keeps a job from being enqueued twice, e.g. by every instance running a schedule
+	UniqueKey  string     `bson:"unique_key,omitempty" json:"unique_key,omitempty"`
+	StartedAt  *time.Time `bson:"started_at,omitempty" json:"started_at,omitempty"`
+	FinishedAt *time.Time `bson:"finished_at,omitempty" json:"finished_at,omitempty"`
+	CreatedAt  time.Time  `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time  `bson:"updated_at" json:"updated_at"`
+	ExpiresAt  *time.Time `bson:"expires_at,omitempty" json:"-"`
+}
+
+// DecodePayload decodes the job's JSON payload into v
+func (j *Job) DecodePayload(v interface{}) error {
+	return json.Unmarshal([]byte(j.Payload), v)
+}
+
+// MarshalJSON includes the payload as JSON rather than as a string
+func (j Job) MarshalJSON() ([]byte, error) {
+	type job Job
+	return json.Marshal(struct {
+		job
+		Payload json.RawMessage `json:"payload"`
+	}{job(j), json.RawMessage(j.Payload)})
+}
@@ -0,0 +1,170 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// currencyExponents maps the supported ISO 4217 codes to their number of decimal places
+var currencyExponents = map[string]int{
+	"AUD": 2, "BHD": 3, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2, "CZK": 2,
+	"DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2, "ILS": 2, "INR": 2,
+	"ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3, "MXN": 2, "MYR": 2, "NOK": 2,
+	"NZD": 2, "OMR": 3, "PHP": 2, "PLN": 2, "SEK": 2, "SGD": 2, "THB": 2, "TND": 3,
+	"TRY": 2, "TWD": 2, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+var (
+	ErrUnsupportedCurrency = errors.New("unsupported currency")
+	ErrCurrencyMismatch    = errors.New("currency mismatch")
+)
+
+// CurrencyExponent returns the number of decimal places of an ISO 4217 currency
+func CurrencyExponent(currency string) (int, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w %q", ErrUnsupportedCurrency, currency)
+	}
+	return exponent, nil
+}
+
+// Money is an exact amount in the minor unit of its currency, e.g. cents.
+// It is stored as {amount, currency} in MongoDB and serialized with the
+// amount as a decimal string in JSON, e.g. {"amount": "19.99", "currency": "USD"}.
+type Money struct {
+	Amount   int64  `bson:"amount"`
+	Currency string `bson:"currency"`
+}
+
+// ParseMoney parses a decimal amount, rejecting more decimal places than the currency has
+func ParseMoney(amount, currency string) (Money, error) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	exponent, err := CurrencyExponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+
+	amount = strings.TrimSpace(amount)
+	negative := strings.HasPrefix(amount, "-")
+	amount = strings.TrimPrefix(amount, "-")
+
+	whole, fraction, _ := strings.Cut(amount, ".")
+	if whole == "" || !isDigits(whole) || !isDigits(fraction) {
+		return Money{}, fmt.Errorf("invalid amount %q", amount)
+	}
+	if len(fraction) > exponent {
+		return Money{}, fmt.Errorf("%s amounts allow at most %d decimal places", currency, exponent)
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+
+	minor, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("invalid amount %q: %w", amount, err)
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return Money{Amount: minor, Currency: currency}, nil
+}
+
+// MinorUnitsFromFloat converts a legacy float amount, rounding to the nearest minor unit
+func MinorUnitsFromFloat(amount float64, currency string) (int64, error) {
+	exponent, err := CurrencyExponent(currency)
+	if err != nil {
+		return 0, err
+	}
+	return int64(math.Round(amount * math.Pow10(exponent))), nil
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String formats the amount as a decimal string, e.g. "19.99"
+func (m Money) String() string {
+	exponent, err := CurrencyExponent(m.Currency)
+	if err != nil {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+
+	sign := ""
+	amount := m.Amount
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	digits := strconv.FormatInt(amount, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0 && m.Currency == ""
+}
+
+func (m Money) Multiply(quantity int) Money {
+	return Money{Amount: m.Amount * int64(quantity), Currency: m.Currency}
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if m.IsZero() {
+		return other, nil
+	}
+	if other.Currency != m.Currency {
+		return Money{}, ErrCurrencyMismatch
+	}
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}, nil
+}
+
+type moneyJSON struct {
+	Amount   json.RawMessage `json:"amount"`
+	Currency string          `json:"currency"`
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]string{
+		"amount":   m.String(),
+		"currency": m.Currency,
+	})
+}
+
+// UnmarshalJSON accepts the amount as a decimal string or a plain JSON number;
+// numbers are parsed from their literal text so no float rounding happens
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var raw moneyJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw.Amount) == 0 || raw.Currency == "" {
+		return errors.New("money requires an amount and a currency")
+	}
+
+	amount := string(raw.Amount)
+	if strings.HasPrefix(amount, `"`) {
+		if err := json.Unmarshal(raw.Amount, &amount); err != nil {
+			return err
+		}
+	}
+
+	parsed, err := ParseMoney(amount, raw.Currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
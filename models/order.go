@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Order statuses
+const (
+	OrderPending   = "pending"
+	OrderPaid      = "paid"
+	OrderShipped   = "shipped"
+	OrderCancelled = "cancelled"
+)
+
+// orderTransitions lists the statuses each status can move to
+var orderTransitions = map[string][]string{
+	OrderPending:   {OrderPaid, OrderCancelled},
+	OrderPaid:      {OrderShipped, OrderCancelled},
+	OrderShipped:   {},
+	OrderCancelled: {},
+}
+
+// CanTransitionOrder reports whether an order may move from one status to another
+func CanTransitionOrder(from, to string) bool {
+	for _, status := range orderTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// OrderLine is a snapshot of the product at checkout time
+type OrderLine struct {
+	ProductID primitive.ObjectID `bson:"product_id" json:"product_id"`
+	SKU       string             `bson:"sku,omitempty" json:"sku,omitempty"`
+	Name      string             `bson:"name" json:"name"`
+	Options   map[string]string  `bson:"options,omitempty" json:"options,omitempty"`
+	UnitPrice Money              `bson:"unit_price" json:"unit_price"`
+	Quantity  int                `bson:"quantity" json:"quantity"`
+	LineTotal Money              `bson:"line_total" json:"line_total"`
+}
+
+type OrderStatusChange struct {
+	Status    string    `bson:"status" json:"status"`
+	UserID    string    `bson:"user_id" json:"user_id"`
+	ChangedAt time.Time `bson:"changed_at" json:"changed_at"`
+}
+
+type Order struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID        primitive.ObjectID  `bson:"user_id" json:"user_id"`
+	Lines         []OrderLine         `bson:"lines" json:"lines"`
+	Total         Money               `bson:"total" json:"total"`
+	Status        string              `bson:"status" json:"status"`
+	StatusHistory []OrderStatusChange `bson:"status_history" json:"status_history"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+type OrderStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=paid shipped cancelled"`
+}
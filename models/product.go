@@ -1,6 +1,8 @@
 package models
 
 import (
+	"fmt"
+	"strings"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -11,11 +13,71 @@ type Product struct {
 	ID          primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
 	Name        string             `bson:"name" json:"name" validate:"required,min=2,max=100"`
 	Description string             `bson:"description" json:"description" validate:"required,max=500"`
-	Price       float64            `bson:"price" json:"price" validate:"required,gte=0"`
-	Category    string             `bson:"category" json:"category" validate:"required"`
-	InStock     bool               `bson:"in_stock" json:"in_stock"`
-	CreatedAt   time.Time          `bson:"created_at" json:"created_at"`
-	UpdatedAt   time.Time          `bson:"updated_at" json:"updated_at"`
+	Price       Money              `bson:"price" json:"price" validate:"required,gte=0"`
+	CategoryID  primitive.ObjectID `bson:"category_id" json:"category_id" validate:"required"`
+	// OwnerID is the user who created the product, it's missing on products created before owners were recorded
+	OwnerID           *primitive.ObjectID `bson:"owner_id,omitempty" json:"owner_id,omitempty"`
+	StockQuantity     int                 `bson:"stock_quantity" json:"stock_quantity" validate:"gte=0"`
+	Reserved          int                 `bson:"reserved" json:"reserved" validate:"gte=0"`
+	LowStockThreshold int                 `bson:"low_stock_threshold" json:"low_stock_threshold" validate:"gte=0"`
+	Options           []ProductOption     `bson:"options,omitempty" json:"options,omitempty" validate:"omitempty,dive"`
+	// VariantCount is maintained by the variant endpoints, products with variants keep their stock per variant
+	VariantCount int `bson:"variant_count" json:"variant_count"`
+	// Variants and Images are only filled in when a single product is retrieved
+	Variants []*Variant      `bson:"-" json:"variants,omitempty"`
+	Images   []*ProductImage `bson:"-" json:"images,omitempty"`
+	// The rating covers published reviews and is maintained by the review endpoints
+	AverageRating float64 `bson:"average_rating" json:"average_rating"`
+	ReviewCount   int     `bson:"review_count" json:"review_count"`
+	RatingSum     int     `bson:"rating_sum" json:"-"`
+	// InStock is derived from the stock quantities and never stored
+	InStock   bool      `bson:"-" json:"in_stock"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+// ProductFilter narrows product listings, prices are in minor units of Currency.
+// Category is the id or slug of a category as given by the client, the
+// service resolves it into CategoryIDs covering its whole subtree.
+type ProductFilter struct {
+	Category    string
+	CategoryIDs []primitive.ObjectID
+	Currency    string
+	MinPrice    *int64
+	MaxPrice    *int64
+	// SKU finds the product that has a variant with this SKU
+	SKU        string
+	ProductIDs []primitive.ObjectID
+}
+
+// ParseProductFilter builds a filter from the category, sku, currency,
+// min_price and max_price parameters. Prices are decimal strings in the currency.
+func ParseProductFilter(category, sku, currency, minPrice, maxPrice string) (ProductFilter, error) {
+	filter := ProductFilter{
+		Category: category,
+		SKU:      sku,
+		Currency: strings.ToUpper(currency),
+	}
+
+	for param, value := range map[string]string{"min_price": minPrice, "max_price": maxPrice} {
+		if value == "" {
+			continue
+		}
+		if filter.Currency == "" {
+			return ProductFilter{}, fmt.Errorf("%s requires a currency", param)
+		}
+		price, err := ParseMoney(value, filter.Currency)
+		if err != nil {
+			return ProductFilter{}, fmt.Errorf("invalid %s: %w", param, err)
+		}
+		if param == "min_price" {
+			filter.MinPrice = &price.Amount
+		} else {
+			filter.MaxPrice = &price.Amount
+		}
+	}
+
+	return filter, nil
 }
 
 func (p *Product) MarshalBSON() ([]byte, error) {
@@ -23,18 +85,115 @@ func (p *Product) MarshalBSON() ([]byte, error) {
 		p.CreatedAt = time.Now()
 	}
 	p.UpdatedAt = time.Now()
+	p.InStock = p.Available() > 0
 
 	type my Product
 	return bson.Marshal((*my)(p))
 }
 
-// Response Data to send
-func (p *Product) ToJSON() map[string]interface{} {
-	return map[string]interface{}{
-		"name":        p.Name,
-		"description": p.Description,
-		"price":       p.Price,
-		"category":    p.Category,
-		"in_stock":    p.InStock,
+func (p *Product) UnmarshalBSON(data []byte) error {
+	type my Product
+	if err := bson.Unmarshal(data, (*my)(p)); err != nil {
+		return err
+	}
+	p.InStock = p.Available() > 0
+	return nil
+}
+
+// Available returns the quantity that can still be reserved
+func (p *Product) Available() int {
+	return p.StockQuantity - p.Reserved
+}
+
+// CreateProductInput is the body of a new product. Reservations, variants,
+// images and ratings are managed by their own endpoints.
+type CreateProductInput struct {
+	Name              string             `json:"name" validate:"required,min=2,max=100"`
+	Description       string             `json:"description" validate:"required,max=500"`
+	Price             Money              `json:"price" validate:"required,gte=0"`
+	CategoryID        primitive.ObjectID `json:"category_id" validate:"required"`
+	StockQuantity     int                `json:"stock_quantity" validate:"gte=0"`
+	LowStockThreshold int                `json:"low_stock_threshold" validate:"gte=0"`
+	Options           []ProductOption    `json:"options,omitempty" validate:"omitempty,dive"`
+}
+
+func (in *CreateProductInput) ToProduct() *Product {
+	return &Product{
+		Name:              in.Name,
+		Description:       in.Description,
+		Price:             in.Price,
+		CategoryID:        in.CategoryID,
+		StockQuantity:     in.StockQuantity,
+		LowStockThreshold: in.LowStockThreshold,
+		Options:           in.Options,
+	}
+}
+
+// UpdateProductInput only changes the fields that are set, stock is changed
+// through the inventory endpoints
+type UpdateProductInput struct {
+	Name        string             `json:"name" validate:"omitempty,min=2,max=100"`
+	Description string             `json:"description" validate:"omitempty,max=500"`
+	Price       Money              `json:"price" validate:"omitempty,gte=0"`
+	CategoryID  primitive.ObjectID `json:"category_id"`
+	// LowStockThreshold is a pointer so it can be set back to 0
+	LowStockThreshold *int            `json:"low_stock_threshold,omitempty" validate:"omitempty,gte=0"`
+	Options           []ProductOption `json:"options,omitempty" validate:"omitempty,dive"`
+}
+
+// ProductResponse is the public representation of a product
+type ProductResponse struct {
+	ID                primitive.ObjectID  `json:"id"`
+	Name              string              `json:"name"`
+	Description       string              `json:"description"`
+	Price             Money               `json:"price"`
+	CategoryID        primitive.ObjectID  `json:"category_id"`
+	OwnerID           *primitive.ObjectID `json:"owner_id,omitempty"`
+	StockQuantity     int                 `json:"stock_quantity"`
+	Reserved          int                 `json:"reserved"`
+	LowStockThreshold int                 `json:"low_stock_threshold"`
+	Options           []ProductOption     `json:"options,omitempty"`
+	VariantCount      int                 `json:"variant_count"`
+	// Variants and Images are only included when a single product is retrieved
+	Variants      []*VariantResponse `json:"variants,omitempty"`
+	Images        []*ProductImage    `json:"images,omitempty"`
+	AverageRating float64            `json:"average_rating"`
+	ReviewCount   int                `json:"review_count"`
+	InStock       bool               `json:"in_stock"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+func NewProductResponse(product *Product) *ProductResponse {
+	response := &ProductResponse{
+		ID:                product.ID,
+		Name:              product.Name,
+		Description:       product.Description,
+		Price:             product.Price,
+		CategoryID:        product.CategoryID,
+		OwnerID:           product.OwnerID,
+		StockQuantity:     product.StockQuantity,
+		Reserved:          product.Reserved,
+		LowStockThreshold: product.LowStockThreshold,
+		Options:           product.Options,
+		VariantCount:      product.VariantCount,
+		Images:            product.Images,
+		AverageRating:     product.AverageRating,
+		ReviewCount:       product.ReviewCount,
+		InStock:           product.InStock,
+		CreatedAt:         product.CreatedAt,
+		UpdatedAt:         product.UpdatedAt,
+	}
+	if product.Variants != nil {
+		response.Variants = NewVariantResponses(product.Variants)
+	}
+	return response
+}
+
+func NewProductResponses(products []*Product) []*ProductResponse {
+	responses := make([]*ProductResponse, len(products))
+	for i, product := range products {
+		responses[i] = NewProductResponse(product)
 	}
+	return responses
 }
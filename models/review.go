@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Review statuses, only published reviews are listed and count towards the product rating
+const (
+	ReviewPublished = "published"
+	ReviewHidden    = "hidden"
+)
+
+// ReviewReport flags a review as abusive, every user can report a review once
+type ReviewReport struct {
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Reason    string             `bson:"reason" json:"reason"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+}
+
+type Review struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ProductID primitive.ObjectID `bson:"product_id" json:"product_id"`
+	UserID    primitive.ObjectID `bson:"user_id" json:"user_id"`
+	Rating    int                `bson:"rating" json:"rating"`
+	Title     string             `bson:"title" json:"title"`
+	Body      string             `bson:"body" json:"body"`
+	Status    string             `bson:"status" json:"status"`
+	// Reports are only shown to admins, ReportCount lets them find reported reviews quickly
+	ReportCount int            `bson:"report_count" json:"report_count"`
+	Reports     []ReviewReport `bson:"reports,omitempty" json:"reports,omitempty"`
+	CreatedAt   time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt   time.Time      `bson:"updated_at" json:"updated_at"`
+}
+
+// RatingTotal is the rating sum and count of a product's published reviews
+type RatingTotal struct {
+	ProductID primitive.ObjectID `bson:"_id"`
+	Sum       int                `bson:"sum"`
+	Count     int                `bson:"count"`
+}
+
+// ReviewFilter narrows review listings, a nil ProductID lists reviews of all products
+type ReviewFilter struct {
+	ProductID *primitive.ObjectID
+	// Status is empty for reviews of any status
+	Status   string
+	Reported bool
+}
+
+type ReviewRequest struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Title  string `json:"title" binding:"required,max=120"`
+	Body   string `json:"body" binding:"max=5000"`
+}
+
+// ReviewUpdateRequest only changes the fields that are set
+type ReviewUpdateRequest struct {
+	Rating *int    `json:"rating" binding:"omitempty,min=1,max=5"`
+	Title  *string `json:"title" binding:"omitempty,min=1,max=120"`
+	Body   *string `json:"body" binding:"omitempty,max=5000"`
+}
+
+type ReviewStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=published hidden"`
+}
+
+type ReviewReportRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
+
+func (r *Review) MarshalBSON() ([]byte, error) {
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	r.UpdatedAt = time.Now()
+
+	type my Review
+	return bson.Marshal((*my)(r))
+}
+
+// RatingChange returns how a review change moves a product's rating sum and
+// review count. A nil review stands for one that doesn't exist (yet).
+func RatingChange(before, after *Review) (sum int, count int) {
+	for sign, review := range map[int]*Review{-1: before, 1: after} {
+		if review != nil && review.Status == ReviewPublished {
+			sum += sign * review.Rating
+			count += sign
+		}
+	}
+	return sum, count
+}
@@ -32,11 +32,65 @@ func (u *User) MarshalBSON() ([]byte, error) {
 	return bson.Marshal((*my)(u))
 }
 
-// Response Data to send
-func (u *User) ToJSON() map[string]interface{} {
-	return map[string]interface{}{
-		"name":  u.Name,
-		"email": u.Email,
-		"role":  u.Role,
+// CreateUserInput is the body of a registration. New users always get the
+// user role, it can't be chosen by the client.
+type CreateUserInput struct {
+	Name     string `json:"name" validate:"required,min=2,max=50"`
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=6"`
+	Age      int    `json:"age" validate:"gte=0,lte=120"`
+}
+
+func (in *CreateUserInput) ToUser() *User {
+	return &User{
+		Name:     in.Name,
+		Email:    in.Email,
+		Password: in.Password,
+		Age:      in.Age,
+		Role:     "user",
+	}
+}
+
+// UpdateUserInput only changes the fields that are set
+type UpdateUserInput struct {
+	Name  string `json:"name" validate:"omitempty,min=2,max=50"`
+	Email string `json:"email" validate:"omitempty,email"`
+}
+
+func (in *UpdateUserInput) ToUser() *User {
+	return &User{
+		Name:  in.Name,
+		Email: in.Email,
+	}
+}
+
+// UserResponse is the public representation of a user, it never includes the password hash
+type UserResponse struct {
+	ID        primitive.ObjectID `json:"id"`
+	Name      string             `json:"name"`
+	Email     string             `json:"email"`
+	Age       int                `json:"age"`
+	Role      string             `json:"role"`
+	CreatedAt time.Time          `json:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at"`
+}
+
+func NewUserResponse(user *User) *UserResponse {
+	return &UserResponse{
+		ID:        user.ID,
+		Name:      user.Name,
+		Email:     user.Email,
+		Age:       user.Age,
+		Role:      user.Role,
+		CreatedAt: user.CreatedAt,
+		UpdatedAt: user.UpdatedAt,
+	}
+}
+
+func NewUserResponses(users []*User) []*UserResponse {
+	responses := make([]*UserResponse, len(users))
+	for i, user := range users {
+		responses[i] = NewUserResponse(user)
 	}
+	return responses
 }
@@ -0,0 +1,181 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ProductOption is an axis a product varies along, e.g. size with the values S, M and L
+type ProductOption struct {
+	Name   string   `bson:"name" json:"name" validate:"required,max=50"`
+	Values []string `bson:"values" json:"values" validate:"required,min=1,dive,required,max=50"`
+}
+
+// Variant is a sellable version of a product with one value per option axis.
+// Products with variants track their stock per variant.
+type Variant struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	ProductID primitive.ObjectID `bson:"product_id" json:"product_id"`
+	SKU       string             `bson:"sku" json:"sku" validate:"required,max=64"`
+	Options   map[string]string  `bson:"options" json:"options"`
+	// OptionKey is the canonical form of Options, unique per product
+	OptionKey string `bson:"option_key" json:"-"`
+	// Price overrides the product price when set
+	Price         *Money `bson:"price,omitempty" json:"price,omitempty" validate:"omitempty,gte=0"`
+	StockQuantity int    `bson:"stock_quantity" json:"stock_quantity" validate:"gte=0"`
+	Reserved      int    `bson:"reserved" json:"reserved" validate:"gte=0"`
+	Barcode       string `bson:"barcode,omitempty" json:"barcode,omitempty" validate:"omitempty,max=64"`
+	// InStock is derived from the stock quantities and never stored
+	InStock   bool      `bson:"-" json:"in_stock"`
+	CreatedAt time.Time `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
+}
+
+func (v *Variant) MarshalBSON() ([]byte, error) {
+	if v.CreatedAt.IsZero() {
+		v.CreatedAt = time.Now()
+	}
+	v.UpdatedAt = time.Now()
+	v.OptionKey = VariantOptionKey(v.Options)
+	v.InStock = v.Available() > 0
+
+	type my Variant
+	return bson.Marshal((*my)(v))
+}
+
+func (v *Variant) UnmarshalBSON(data []byte) error {
+	type my Variant
+	if err := bson.Unmarshal(data, (*my)(v)); err != nil {
+		return err
+	}
+	v.InStock = v.Available() > 0
+	return nil
+}
+
+// Available returns the quantity that can still be reserved
+func (v *Variant) Available() int {
+	return v.StockQuantity - v.Reserved
+}
+
+// UnitPrice returns the variant's price, falling back to the product price
+func (v *Variant) UnitPrice(product *Product) Money {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return product.Price
+}
+
+// CreateVariantInput is the body of a new variant, it sets one value for
+// each of the product's options
+type CreateVariantInput struct {
+	SKU           string            `json:"sku" validate:"required,max=64"`
+	Options       map[string]string `json:"options"`
+	Price         *Money            `json:"price,omitempty" validate:"omitempty,gte=0"`
+	StockQuantity int               `json:"stock_quantity" validate:"gte=0"`
+	Barcode       string            `json:"barcode,omitempty" validate:"omitempty,max=64"`
+}
+
+func (in *CreateVariantInput) ToVariant() *Variant {
+	return &Variant{
+		SKU:           in.SKU,
+		Options:       in.Options,
+		Price:         in.Price,
+		StockQuantity: in.StockQuantity,
+		Barcode:       in.Barcode,
+	}
+}
+
+// UpdateVariantInput only changes the fields that are set, stock is changed
+// through the inventory endpoints
+type UpdateVariantInput struct {
+	SKU     string            `json:"sku" validate:"omitempty,max=64"`
+	Options map[string]string `json:"options,omitempty"`
+	Price   *Money            `json:"price,omitempty" validate:"omitempty,gte=0"`
+	Barcode string            `json:"barcode,omitempty" validate:"omitempty,max=64"`
+}
+
+func (in *UpdateVariantInput) ToVariant() *Variant {
+	return &Variant{
+		SKU:     in.SKU,
+		Options: in.Options,
+		Price:   in.Price,
+		Barcode: in.Barcode,
+	}
+}
+
+// VariantResponse is the public representation of a variant
+type VariantResponse struct {
+	ID            primitive.ObjectID `json:"id"`
+	ProductID     primitive.ObjectID `json:"product_id"`
+	SKU           string             `json:"sku"`
+	Options       map[string]string  `json:"options"`
+	Price         *Money             `json:"price,omitempty"`
+	StockQuantity int                `json:"stock_quantity"`
+	Reserved      int                `json:"reserved"`
+	Barcode       string             `json:"barcode,omitempty"`
+	InStock       bool               `json:"in_stock"`
+	CreatedAt     time.Time          `json:"created_at"`
+	UpdatedAt     time.Time          `json:"updated_at"`
+}
+
+func NewVariantResponse(variant *Variant) *VariantResponse {
+	return &VariantResponse{
+		ID:            variant.ID,
+		ProductID:     variant.ProductID,
+		SKU:           variant.SKU,
+		Options:       variant.Options,
+		Price:         variant.Price,
+		StockQuantity: variant.StockQuantity,
+		Reserved:      variant.Reserved,
+		Barcode:       variant.Barcode,
+		InStock:       variant.InStock,
+		CreatedAt:     variant.CreatedAt,
+		UpdatedAt:     variant.UpdatedAt,
+	}
+}
+
+func NewVariantResponses(variants []*Variant) []*VariantResponse {
+	responses := make([]*VariantResponse, len(variants))
+	for i, variant := range variants {
+		responses[i] = NewVariantResponse(variant)
+	}
+	return responses
+}
+
+// VariantOptionKey returns options in a canonical form, e.g. "color=red;size=m"
+func VariantOptionKey(options map[string]string) string {
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = strings.ToLower(name) + "=" + strings.ToLower(options[name])
+	}
+	return strings.Join(parts, ";")
+}
+
+// CheckVariantOptions checks that options set exactly one allowed value for every axis of the product
+func CheckVariantOptions(axes []ProductOption, options map[string]string) error {
+	if len(options) != len(axes) {
+		return fmt.Errorf("a variant must set exactly one value for each of the %d product options", len(axes))
+	}
+	for _, axis := range axes {
+		value, ok := options[axis.Name]
+		if !ok {
+			return fmt.Errorf("missing value for option %q", axis.Name)
+		}
+		if !slices.Contains(axis.Values, value) {
+			return fmt.Errorf("%q is not a value of option %q", value, axis.Name)
+		}
+	}
+	return nil
+}
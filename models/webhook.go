@@ -0,0 +1,96 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// WebhookEvents are the events webhooks can subscribe to
+var WebhookEvents = []string{
+	EventProductCreated, EventProductUpdated, EventProductPriceChanged, EventProductDeleted,
+	EventUserRegistered, EventUserUpdated, EventUserDeleted,
+}
+
+// Webhook delivery statuses. Pending deliveries are retried until they
+// succeed or run out of attempts.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+// Webhook is a subscription to events. The secret signs every delivery and is
+// only returned when the webhook is created.
+type Webhook struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	URL       string             `bson:"url" json:"url"`
+	Secret    string             `bson:"secret" json:"-"`
+	Events    []string           `bson:"events" json:"events"`
+	Active    bool               `bson:"active" json:"active"`
+	CreatedAt time.Time          `bson:"created_at" json:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at" json:"updated_at"`
+}
+
+// WebhookRequest creates a webhook, a secret is generated if none is given
+type WebhookRequest struct {
+	URL    string   `json:"url" binding:"required,url,max=2048"`
+	Secret string   `json:"secret" binding:"omitempty,min=16,max=256"`
+	Events []string `json:"events" binding:"required,min=1,dive,oneof=product.created product.updated product.price_changed product.deleted user.registered user.updated user.deleted"`
+	Active *bool    `json:"active"`
+}
+
+type WebhookUpdateRequest struct {
+	URL    *string  `json:"url" binding:"omitempty,url,max=2048"`
+	Secret *string  `json:"secret" binding:"omitempty,min=16,max=256"`
+	Events []string `json:"events" binding:"omitempty,min=1,dive,oneof=product.created product.updated product.price_changed product.deleted user.registered user.updated user.deleted"`
+	Active *bool    `json:"active"`
+}
+
+// CreatedWebhook is the response to creating a webhook, the only one that includes the secret
+type CreatedWebhook struct {
+	*Webhook
+	Secret string `json:"secret"`
+}
+
+// WebhookEvent is the JSON body of a delivery
+type WebhookEvent struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"created_at"`
+	Data      interface{} `json:"data"`
+}
+
+// DeliveryAttempt is one request made for a delivery
+type DeliveryAttempt struct {
+	AttemptedAt time.Time `bson:"attempted_at" json:"attempted_at"`
+	StatusCode  int       `bson:"status_code,omitempty" json:"status_code,omitempty"`
+	Error       string    `bson:"error,omitempty" json:"error,omitempty"`
+	DurationMS  int64     `bson:"duration_ms" json:"duration_ms"`
+}
+
+// WebhookDelivery sends one event to one webhook, Attempts is its delivery log
+type WebhookDelivery struct {
+	ID            primitive.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	WebhookID     primitive.ObjectID  `bson:"webhook_id" json:"webhook_id"`
+	EventID       string              `bson:"event_id" json:"event_id"`
+	Event         string              `bson:"event" json:"event"`
+	Payload       string              `bson:"payload" json:"-"`
+	Status        string              `bson:"status" json:"status"`
+	AttemptCount  int                 `bson:"attempt_count" json:"attempt_count"`
+	Attempts      []DeliveryAttempt   `bson:"attempts" json:"attempts"`
+	NextAttemptAt *time.Time          `bson:"next_attempt_at,omitempty" json:"next_attempt_at,omitempty"`
+	RedeliveryOf  *primitive.ObjectID `bson:"redelivery_of,omitempty" json:"redelivery_of,omitempty"`
+	CreatedAt     time.Time           `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time           `bson:"updated_at" json:"updated_at"`
+}
+
+// MarshalJSON includes the payload as JSON rather than as a string
+func (d WebhookDelivery) MarshalJSON() ([]byte, error) {
+	type delivery WebhookDelivery
+	return json.Marshal(struct {
+		delivery
+		Payload json.RawMessage `json:"payload"`
+	}{delivery(d), json.RawMessage(d.Payload)})
+}
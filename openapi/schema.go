@@ -0,0 +1,436 @@
+package openapi
+
+import (
+	"encoding/json"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Schema is a JSON Schema as used by OpenAPI 3.1
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 SchemaType         `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+	WriteOnly            bool               `json:"writeOnly,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+}
+
+// SchemaType is one type, or several for schemas that allow null
+type SchemaType []string
+
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = SchemaType{single}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// Has reports whether the schema allows values of type name
+func (t SchemaType) Has(name string) bool {
+	for _, value := range t {
+		if value == name {
+			return true
+		}
+	}
+	return false
+}
+
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func String() *Schema {
+	return &Schema{Type: SchemaType{"string"}}
+}
+
+func Integer() *Schema {
+	return &Schema{Type: SchemaType{"integer"}}
+}
+
+func Object(properties map[string]*Schema, required ...string) *Schema {
+	return &Schema{Type: SchemaType{"object"}, Properties: properties, Required: required}
+}
+
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: SchemaType{"array"}, Items: items}
+}
+
+// objectIDPattern matches the hex form of a MongoDB ObjectID
+const objectIDPattern = "^[0-9a-fA-F]{24}$"
+
+// ObjectID is the schema of ids
+func ObjectID() *Schema {
+	return &Schema{Type: SchemaType{"string"}, Pattern: objectIDPattern}
+}
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+	rawJSONType  = reflect.TypeOf(json.RawMessage{})
+)
+
+// Schemas turns Go types into schemas the way encoding/json serializes them.
+// Named structs become components that are referenced by name. Constraints
+// come from the validate and binding tags, e.g. required, min, max and oneof.
+type Schemas struct {
+	components map[string]*Schema
+	names      map[reflect.Type]string
+	defined    map[reflect.Type]*Schema
+	extended   map[reflect.Type]map[string]*Schema
+	access     map[reflect.Type]map[string]string
+}
+
+func NewSchemas() *Schemas {
+	return &Schemas{
+		components: map[string]*Schema{},
+		names:      map[reflect.Type]string{},
+		defined:    map[reflect.Type]*Schema{},
+		extended:   map[reflect.Type]map[string]*Schema{},
+		access:     map[reflect.Type]map[string]string{},
+	}
+}
+
+// Define sets the schema of the named type of value, for types with their own JSON encoding
+func (s *Schemas) Define(value interface{}, schema *Schema) {
+	s.defined[reflect.TypeOf(value)] = schema
+}
+
+// Extend adds a property to the schema of the struct type of value, for
+// properties added by its MarshalJSON
+func (s *Schemas) Extend(value interface{}, name string, schema *Schema) {
+	t := reflect.TypeOf(value)
+	if s.extended[t] == nil {
+		s.extended[t] = map[string]*Schema{}
+	}
+	s.extended[t][name] = schema
+}
+
+// ReadOnly marks properties of the struct type of value that are only ever
+// returned, requests that set them are rejected by validation
+func (s *Schemas) ReadOnly(value interface{}, names ...string) {
+	s.setAccess(value, "read", names)
+}
+
+// WriteOnly marks properties of the struct type of value that are never returned, e.g. passwords
+func (s *Schemas) WriteOnly(value interface{}, names ...string) {
+	s.setAccess(value, "write", names)
+}
+
+func (s *Schemas) setAccess(value interface{}, access string, names []string) {
+	t := reflect.TypeOf(value)
+	if s.access[t] == nil {
+		s.access[t] = map[string]string{}
+	}
+	for _, name := range names {
+		s.access[t][name] = access
+	}
+}
+
+// Components returns the schemas of the named types that were referenced
+func (s *Schemas) Components() map[string]*Schema {
+	return s.components
+}
+
+// Component returns a registered component by name
+func (s *Schemas) Component(name string) *Schema {
+	return s.components[name]
+}
+
+// For returns the schema of the type of value. Schemas are returned as they are.
+func (s *Schemas) For(value interface{}) *Schema {
+	if value == nil {
+		return &Schema{}
+	}
+	if schema, ok := value.(*Schema); ok {
+		return schema
+	}
+	return s.schema(reflect.TypeOf(value))
+}
+
+// Partial returns the schema of the struct type of value with no required
+// properties, for updates that only change the fields they set
+func (s *Schemas) Partial(value interface{}) *Schema {
+	t := reflect.TypeOf(value)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	s.schema(t)
+	name := s.names[t] + "Update"
+	if _, ok := s.components[name]; !ok {
+		partial := *s.components[s.names[t]]
+		partial.Required = nil
+		s.components[name] = &partial
+	}
+	return Ref(name)
+}
+
+func (s *Schemas) schema(t reflect.Type) *Schema {
+	if _, ok := s.defined[t]; ok {
+		return Ref(s.component(t))
+	}
+
+	switch t {
+	case timeType:
+		return &Schema{Type: SchemaType{"string"}, Format: "date-time"}
+	case objectIDType:
+		return ObjectID()
+	case rawJSONType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return s.schema(t.Elem())
+	case reflect.Bool:
+		return &Schema{Type: SchemaType{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return Integer()
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: SchemaType{"integer"}, Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: SchemaType{"number"}}
+	case reflect.String:
+		return String()
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: SchemaType{"string"}, Format: "byte"}
+		}
+		return ArrayOf(s.schema(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: SchemaType{"object"}, AdditionalProperties: s.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		return Ref(s.component(t))
+	}
+	// Interfaces can hold any value
+	return &Schema{}
+}
+
+// component registers the schema of a named struct type and returns its name
+func (s *Schemas) component(t reflect.Type) string {
+	if name, ok := s.names[t]; ok {
+		return name
+	}
+
+	name := t.Name()
+	if _, taken := s.components[name]; taken {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	s.names[t] = name
+	if schema, ok := s.defined[t]; ok {
+		s.components[name] = schema
+		return name
+	}
+	// Registered before it's built, so recursive types refer to themselves
+	s.components[name] = &Schema{}
+	*s.components[name] = *s.structSchema(t)
+	return name
+}
+
+func (s *Schemas) structSchema(t reflect.Type) *Schema {
+	schema := Object(map[string]*Schema{})
+	s.addFields(schema, t)
+	for name, property := range s.extended[t] {
+		schema.Properties[name] = property
+	}
+	for name, access := range s.access[t] {
+		if property, ok := schema.Properties[name]; ok {
+			marked := *property
+			marked.ReadOnly = access == "read"
+			marked.WriteOnly = access == "write"
+			schema.Properties[name] = &marked
+		}
+	}
+	return schema
+}
+
+func (s *Schemas) addFields(schema *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && options == "" {
+			continue
+		}
+
+		// Embedded structs without a name have their fields promoted
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				s.addFields(schema, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := s.schema(field.Type)
+		omitempty := strings.Contains(options, "omitempty")
+		// Nil pointers, slices and maps are serialized as null
+		if !omitempty && isNilable(field.Type) && property.Ref == "" && len(property.Type) == 1 {
+			nullable := *property
+			nullable.Type = SchemaType{property.Type[0], "null"}
+			property = &nullable
+		} else if !omitempty && field.Type.Kind() == reflect.Pointer && property.Ref != "" {
+			property = &Schema{AnyOf: []*Schema{property, {Type: SchemaType{"null"}}}}
+		}
+
+		required := false
+		for _, tag := range []string{"validate", "binding"} {
+			if value := field.Tag.Get(tag); value != "" {
+				var isRequired bool
+				property, isRequired = applyConstraints(property, field.Type, value)
+				required = required || isRequired
+			}
+		}
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+		schema.Properties[name] = property
+	}
+}
+
+func isNilable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		return !(t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8)
+	}
+	return false
+}
+
+// applyConstraints adds the constraints of a validator tag to a copy of
+// schema. Constraints after dive apply to the items of a slice.
+func applyConstraints(schema *Schema, t reflect.Type, tag string) (*Schema, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	rules := strings.Split(tag, ",")
+
+	// Structs have their own schemas, only whether they're required applies to them
+	if t.Kind() == reflect.Struct && t != timeType {
+		for _, rule := range rules {
+			if rule == "dive" {
+				break
+			}
+			if rule == "required" {
+				return schema, true
+			}
+		}
+		return schema, false
+	}
+
+	result := *schema
+	required := false
+	for i, rule := range rules {
+		name, param, _ := strings.Cut(rule, "=")
+		switch name {
+		case "required":
+			required = true
+		case "dive":
+			if result.Items != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+				result.Items, _ = applyConstraints(result.Items, t.Elem(), strings.Join(rules[i+1:], ","))
+			}
+			return &result, required
+		case "email":
+			result.Format = "email"
+		case "url":
+			result.Format = "uri"
+		case "slug":
+			result.Pattern = "^[a-z0-9]+(-[a-z0-9]+)*$"
+		case "oneof":
+			result.Enum = nil
+			for _, value := range strings.Fields(param) {
+				result.Enum = append(result.Enum, enumValue(t, value))
+			}
+		case "min", "max", "gte", "lte", "gt", "lt":
+			bound, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			applyBound(&result, t, name, bound)
+		}
+	}
+	return &result, required
+}
+
+func applyBound(schema *Schema, t reflect.Type, rule string, bound float64) {
+	count := int(bound)
+	switch t.Kind() {
+	case reflect.String:
+		switch rule {
+		case "min", "gte":
+			schema.MinLength = &count
+		case "max", "lte":
+			schema.MaxLength = &count
+		}
+	case reflect.Slice, reflect.Array, reflect.Map:
+		switch rule {
+		case "min", "gte":
+			schema.MinItems = &count
+		case "max", "lte":
+			schema.MaxItems = &count
+		}
+	default:
+		switch rule {
+		case "min", "gte":
+			schema.Minimum = &bound
+		case "max", "lte":
+			schema.Maximum = &bound
+		case "gt":
+			schema.ExclusiveMinimum = &bound
+		case "lt":
+			schema.ExclusiveMaximum = &bound
+		}
+	}
+}
+
+func enumValue(t reflect.Type, value string) interface{} {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if number, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return number
+		}
+	}
+	return value
+}
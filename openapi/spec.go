@@ -0,0 +1,320 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the OpenAPI version of the generated documents
+const Version = "3.1.0"
+
+type Document struct {
+	OpenAPI    string                           `json:"openapi"`
+	Info       Info                             `json:"info"`
+	Paths      map[string]map[string]*Operation `json:"paths"`
+	Components Components                       `json:"components"`
+	Security   []map[string][]string            `json:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	Parameters      map[string]*Parameter      `json:"parameters,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	In           string `json:"in,omitempty"`
+	Name         string `json:"name,omitempty"`
+	Description  string `json:"description,omitempty"`
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Ref         string  `json:"$ref,omitempty"`
+	Name        string  `json:"name,omitempty"`
+	In          string  `json:"in,omitempty"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Required bool                  `json:"required,omitempty"`
+	Content  map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Access is who may call a route
+type Access int
+
+const (
+	Public Access = iota
+	Authenticated
+	Admin
+)
+
+// Route documents one route of the API
+type Route struct {
+	Method  string
+	Path    string
+	Summary string
+	Tag     string
+	Access  Access
+	// Idempotent routes accept an Idempotency-Key header
+	Idempotent bool
+	// Parameters are the query and header parameters, path parameters are taken from Path
+	Parameters []*Parameter
+	// Body is a value of the JSON request body's type. Partial bodies only
+	// set the fields they change, so none are required.
+	Body    interface{}
+	Partial bool
+	// Form is the schema of a multipart/form-data request body
+	Form *Schema
+	// Status is the status of successful responses, 200 if it's not set
+	Status int
+	// Data is a value of the type of the response's data, Paginated responses
+	// hold a page of them. Raw responses are Data itself rather than an APIResponse.
+	Data      interface{}
+	Paginated bool
+	Raw       bool
+	// Content is the media type of responses that aren't JSON
+	Content string
+}
+
+// Key identifies the route by method and path, e.g. "GET /api/v1/products/:id"
+func (r Route) Key() string {
+	return r.Method + " " + r.Path
+}
+
+// Generator builds a Document from the routes of a router and their documentation
+type Generator struct {
+	Info    Info
+	Schemas *Schemas
+	// Envelope is the type of JSON responses, Page the type of the data of paginated ones
+	Envelope interface{}
+	Page     interface{}
+}
+
+// Generate documents every route in routes. Routes are documented by the
+// route in documented with the same method and path, routes without one are
+// included with their path parameters only and reported in the error.
+// Documented routes that aren't registered, e.g. of disabled features, are left out.
+func (g *Generator) Generate(routes gin.RoutesInfo, documented []Route) (*Document, error) {
+	byKey := make(map[string]Route, len(documented))
+	for _, route := range documented {
+		byKey[route.Key()] = route
+	}
+
+	document := &Document{
+		OpenAPI: Version,
+		Info:    g.Info,
+		Paths:   map[string]map[string]*Operation{},
+		Components: Components{
+			Parameters: map[string]*Parameter{
+				"IdempotencyKey": {
+					Name:        "Idempotency-Key",
+					In:          "header",
+					Description: "Retries with the same key replay the first response",
+					Schema:      &Schema{Type: SchemaType{"string"}, MaxLength: intPtr(255)},
+				},
+			},
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"cookieAuth": {Type: "apiKey", In: "cookie", Name: "access_token", Description: "Set by login, unsafe requests also need a CSRF token"},
+			},
+		},
+		Security: []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}},
+	}
+
+	var undocumented []string
+	for _, info := range routes {
+		route, ok := byKey[info.Method+" "+info.Path]
+		if !ok {
+			undocumented = append(undocumented, info.Method+" "+info.Path)
+			route = Route{Method: info.Method, Path: info.Path, Access: Authenticated}
+		}
+
+		path := OpenAPIPath(info.Path)
+		if document.Paths[path] == nil {
+			document.Paths[path] = map[string]*Operation{}
+		}
+		document.Paths[path][strings.ToLower(info.Method)] = g.operation(route)
+	}
+
+	document.Components.Schemas = g.Schemas.Components()
+
+	if len(undocumented) > 0 {
+		sort.Strings(undocumented)
+		return document, fmt.Errorf("routes without documentation: %s", strings.Join(undocumented, ", "))
+	}
+	return document, nil
+}
+
+func (g *Generator) operation(route Route) *Operation {
+	operation := &Operation{
+		OperationID: operationID(route),
+		Summary:     route.Summary,
+		Responses:   map[string]*Response{},
+	}
+	if route.Tag != "" {
+		operation.Tags = []string{route.Tag}
+	}
+	if route.Access == Public {
+		// An empty requirement overrides the document's security
+		operation.Security = []map[string][]string{{}}
+	}
+
+	for _, name := range PathParameters(route.Path) {
+		schema := String()
+		if name == "id" || strings.HasSuffix(name, "Id") {
+			schema = ObjectID()
+		}
+		operation.Parameters = append(operation.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: schema})
+	}
+	operation.Parameters = append(operation.Parameters, route.Parameters...)
+	if route.Idempotent {
+		operation.Parameters = append(operation.Parameters, &Parameter{Ref: "#/components/parameters/IdempotencyKey"})
+	}
+
+	switch {
+	case route.Body != nil:
+		schema := g.Schemas.For(route.Body)
+		if route.Partial {
+			schema = g.Schemas.Partial(route.Body)
+		}
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"application/json": {Schema: schema}},
+		}
+	case route.Form != nil:
+		operation.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]*MediaType{"multipart/form-data": {Schema: route.Form}},
+		}
+	}
+
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	operation.Responses[strconv.Itoa(status)] = g.success(route, status)
+
+	errorResponse := func(status int) {
+		operation.Responses[strconv.Itoa(status)] = &Response{
+			Description: http.StatusText(status),
+			Content:     map[string]*MediaType{"application/json": {Schema: g.Schemas.For(g.Envelope)}},
+		}
+	}
+	if route.Body != nil || route.Form != nil || len(operation.Parameters) > 0 {
+		errorResponse(http.StatusBadRequest)
+	}
+	if route.Access != Public {
+		errorResponse(http.StatusUnauthorized)
+		errorResponse(http.StatusForbidden)
+	}
+	if len(PathParameters(route.Path)) > 0 {
+		errorResponse(http.StatusNotFound)
+	}
+	errorResponse(http.StatusTooManyRequests)
+	errorResponse(http.StatusInternalServerError)
+
+	return operation
+}
+
+func (g *Generator) success(route Route, status int) *Response {
+	response := &Response{Description: http.StatusText(status)}
+	if route.Content != "" {
+		response.Content = map[string]*MediaType{route.Content: {}}
+		return response
+	}
+
+	data := g.Schemas.For(route.Data)
+	if route.Paginated {
+		data = &Schema{AllOf: []*Schema{
+			g.Schemas.For(g.Page),
+			Object(map[string]*Schema{"data": ArrayOf(data)}),
+		}}
+	}
+
+	schema := data
+	if !route.Raw {
+		envelope := Object(map[string]*Schema{})
+		if route.Data != nil {
+			envelope.Properties["data"] = data
+			envelope.Required = []string{"data"}
+		}
+		schema = &Schema{AllOf: []*Schema{g.Schemas.For(g.Envelope), envelope}}
+	}
+	response.Content = map[string]*MediaType{"application/json": {Schema: schema}}
+	return response
+}
+
+// OpenAPIPath turns the parameters of a gin path into OpenAPI ones, e.g. /products/:id into /products/{id}
+func OpenAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// PathParameters returns the names of the parameters in a gin path
+func PathParameters(path string) []string {
+	var names []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			names = append(names, segment[1:])
+		}
+	}
+	return names
+}
+
+// operationID names an operation after its method and path, e.g. get_products_id
+func operationID(route Route) string {
+	parts := []string{strings.ToLower(route.Method)}
+	for _, segment := range strings.Split(strings.TrimPrefix(route.Path, "/api/v1"), "/") {
+		segment = strings.Trim(segment, ":*")
+		if segment != "" {
+			parts = append(parts, strings.ReplaceAll(segment, "-", "_"))
+		}
+	}
+	return strings.Join(parts, "_")
+}
+
+func intPtr(value int) *int {
+	return &value
+}
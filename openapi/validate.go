@@ -0,0 +1,458 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationError is a problem with one field of a request or response. In
+// is where the field is: body, query, path or header.
+type ValidationError struct {
+	Field   string `json:"field"`
+	In      string `json:"in"`
+	Message string `json:"message"`
+}
+
+// Spec is a document that's generated on first use, so it can describe
+// routes registered after the Spec was created
+type Spec struct {
+	generate func() (*Document, error)
+	once     sync.Once
+	document *Document
+	json     []byte
+
+	patternsMu sync.Mutex
+	patterns   map[string]*regexp.Regexp
+}
+
+func NewSpec(generate func() (*Document, error)) *Spec {
+	return &Spec{
+		generate: generate,
+		patterns: map[string]*regexp.Regexp{},
+	}
+}
+
+func (s *Spec) load() {
+	s.once.Do(func() {
+		document, err := s.generate()
+		if err != nil {
+			log.Println("API documentation is incomplete:", err)
+		}
+		s.document = document
+		if s.json, err = json.Marshal(document); err != nil {
+			log.Println("Error encoding API documentation:", err)
+			s.json = nil
+		}
+	})
+}
+
+// Document returns the generated document
+func (s *Spec) Document() *Document {
+	s.load()
+	return s.document
+}
+
+// JSON returns the generated document encoded as JSON, or nil if it couldn't be generated
+func (s *Spec) JSON() []byte {
+	s.load()
+	return s.json
+}
+
+// Operation returns the operation of a route by its method and gin path, e.g. /products/:id
+func (s *Spec) Operation(method, path string) *Operation {
+	document := s.Document()
+	if document == nil {
+		return nil
+	}
+	return document.Paths[OpenAPIPath(path)][strings.ToLower(method)]
+}
+
+// Request is what's validated of a request
+type Request struct {
+	Method     string
+	Path       string
+	PathParams map[string]string
+	Query      url.Values
+	Header     http.Header
+	Body       []byte
+}
+
+// ValidateRequest checks the parameters and JSON body of a request to a
+// documented route. Bodies can't have fields the schema doesn't list or
+// fields that are read-only. Routes that aren't documented aren't checked.
+func (s *Spec) ValidateRequest(request Request) []ValidationError {
+	operation := s.Operation(request.Method, request.Path)
+	if operation == nil {
+		return nil
+	}
+	v := &validator{spec: s, request: true}
+
+	for _, parameter := range operation.Parameters {
+		parameter = s.parameter(parameter)
+		if parameter == nil {
+			continue
+		}
+		var value string
+		var present bool
+		switch parameter.In {
+		case "path":
+			value, present = request.PathParams[parameter.Name]
+		case "query":
+			present = request.Query.Has(parameter.Name)
+			value = request.Query.Get(parameter.Name)
+		case "header":
+			value = request.Header.Get(parameter.Name)
+			present = value != ""
+		}
+		v.in = parameter.In
+		if !present {
+			if parameter.Required {
+				v.fail(parameter.Name, "%s is required", parameter.Name)
+			}
+			continue
+		}
+		if parameter.Schema != nil {
+			v.validate(parameter.Schema, parse(s.resolve(parameter.Schema), value), parameter.Name, true)
+		}
+	}
+
+	if operation.RequestBody != nil {
+		if media := operation.RequestBody.Content["application/json"]; media != nil && media.Schema != nil {
+			v.in = "body"
+			v.validateJSON(media.Schema, request.Body, operation.RequestBody.Required)
+		}
+	}
+	return v.errors
+}
+
+// ValidateResponse checks a JSON response of a documented route. Responses
+// can't have fields the schema doesn't list or fields that are write-only.
+// Statuses that aren't documented aren't checked.
+func (s *Spec) ValidateResponse(method, path string, status int, body []byte) []ValidationError {
+	operation := s.Operation(method, path)
+	if operation == nil {
+		return nil
+	}
+	response := operation.Responses[strconv.Itoa(status)]
+	if response == nil {
+		return nil
+	}
+	media := response.Content["application/json"]
+	if media == nil || media.Schema == nil {
+		return nil
+	}
+
+	v := &validator{spec: s, in: "body"}
+	v.validateJSON(media.Schema, body, true)
+	return v.errors
+}
+
+func (s *Spec) parameter(parameter *Parameter) *Parameter {
+	if parameter.Ref == "" {
+		return parameter
+	}
+	return s.document.Components.Parameters[strings.TrimPrefix(parameter.Ref, "#/components/parameters/")]
+}
+
+// resolve follows the reference of a schema to its component
+func (s *Spec) resolve(schema *Schema) *Schema {
+	for schema != nil && schema.Ref != "" {
+		schema = s.document.Components.Schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")]
+	}
+	return schema
+}
+
+func (s *Spec) pattern(pattern string) *regexp.Regexp {
+	s.patternsMu.Lock()
+	defer s.patternsMu.Unlock()
+	re, ok := s.patterns[pattern]
+	if !ok {
+		re, _ = regexp.Compile(pattern)
+		s.patterns[pattern] = re
+	}
+	return re
+}
+
+// parse converts a parameter to the type of its schema, values that don't
+// convert are left as strings for validation to reject
+func parse(schema *Schema, value string) interface{} {
+	if schema == nil {
+		return value
+	}
+	switch {
+	case schema.Type.Has("integer"), schema.Type.Has("number"):
+		if _, err := strconv.ParseFloat(value, 64); err == nil {
+			return json.Number(value)
+		}
+	case schema.Type.Has("boolean"):
+		if parsed, err := strconv.ParseBool(value); err == nil {
+			return parsed
+		}
+	}
+	return value
+}
+
+type validator struct {
+	spec *Spec
+	// request validation rejects read-only fields, response validation write-only ones
+	request bool
+	in      string
+	errors  []ValidationError
+}
+
+func (v *validator) fail(field, format string, args ...interface{}) {
+	v.errors = append(v.errors, ValidationError{Field: field, In: v.in, Message: fmt.Sprintf(format, args...)})
+}
+
+func (v *validator) validateJSON(schema *Schema, body []byte, required bool) {
+	if len(bytes.TrimSpace(body)) == 0 {
+		if required {
+			v.fail("", "request body is required")
+		}
+		return
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		v.fail("", "body is not valid JSON: %v", err)
+		return
+	}
+	v.validate(schema, value, "", true)
+}
+
+// validate checks value against schema. Objects can only have the properties
+// of their schema, including those of every schema in an allOf, so branches
+// of an allOf don't check for unknown properties themselves.
+func (v *validator) validate(schema *Schema, value interface{}, field string, strict bool) {
+	schema = v.spec.resolve(schema)
+	if schema == nil {
+		return
+	}
+	name := field
+	if name == "" {
+		name = "body"
+	}
+
+	for _, branch := range schema.AllOf {
+		v.validate(branch, value, field, false)
+	}
+
+	if len(schema.AnyOf) > 0 {
+		matched := false
+		for _, branch := range schema.AnyOf {
+			branchValidator := &validator{spec: v.spec, request: v.request, in: v.in}
+			branchValidator.validate(branch, value, field, strict)
+			if len(branchValidator.errors) == 0 {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			v.fail(field, "%s is invalid", name)
+		}
+		return
+	}
+
+	if len(schema.Type) > 0 {
+		actual := jsonType(value)
+		if !schema.Type.Has(actual) && !(actual == "integer" && schema.Type.Has("number")) {
+			v.fail(field, "%s must be %s", name, article(schema.Type[0]))
+			return
+		}
+	}
+	if value == nil {
+		return
+	}
+
+	if len(schema.Enum) > 0 {
+		allowed := make([]string, len(schema.Enum))
+		found := false
+		for i, option := range schema.Enum {
+			allowed[i] = fmt.Sprint(option)
+			found = found || allowed[i] == fmt.Sprint(value)
+		}
+		if !found {
+			v.fail(field, "%s must be one of %s", name, strings.Join(allowed, ", "))
+			return
+		}
+	}
+
+	switch value := value.(type) {
+	case string:
+		v.validateString(schema, value, field, name)
+	case json.Number:
+		v.validateNumber(schema, value, field, name)
+	case []interface{}:
+		if schema.MinItems != nil && len(value) < *schema.MinItems {
+			v.fail(field, "%s must have at least %d items", name, *schema.MinItems)
+		}
+		if schema.MaxItems != nil && len(value) > *schema.MaxItems {
+			v.fail(field, "%s must not have more than %d items", name, *schema.MaxItems)
+		}
+		if schema.Items != nil {
+			for i, item := range value {
+				v.validate(schema.Items, item, fmt.Sprintf("%s[%d]", field, i), true)
+			}
+		}
+	case map[string]interface{}:
+		v.validateObject(schema, value, field, strict)
+	}
+}
+
+func (v *validator) validateString(schema *Schema, value, field, name string) {
+	length := utf8.RuneCountInString(value)
+	if schema.MinLength != nil && length < *schema.MinLength {
+		v.fail(field, "%s must be at least %d characters long", name, *schema.MinLength)
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		v.fail(field, "%s must not be longer than %d characters", name, *schema.MaxLength)
+	}
+	if schema.Pattern != "" {
+		if re := v.spec.pattern(schema.Pattern); re != nil && !re.MatchString(value) {
+			v.fail(field, "%s is invalid", name)
+		}
+	}
+
+	var err error
+	switch schema.Format {
+	case "email":
+		_, err = mail.ParseAddress(value)
+	case "uri":
+		var parsed *url.URL
+		if parsed, err = url.ParseRequestURI(value); err == nil && parsed.Scheme == "" {
+			err = fmt.Errorf("missing scheme")
+		}
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, value)
+	}
+	if err != nil {
+		v.fail(field, "%s must be a valid %s", name, schema.Format)
+	}
+}
+
+func (v *validator) validateNumber(schema *Schema, value json.Number, field, name string) {
+	number, err := value.Float64()
+	if err != nil {
+		v.fail(field, "%s must be a number", name)
+		return
+	}
+	if schema.Minimum != nil && number < *schema.Minimum {
+		v.fail(field, "%s must be at least %v", name, *schema.Minimum)
+	}
+	if schema.Maximum != nil && number > *schema.Maximum {
+		v.fail(field, "%s must not be more than %v", name, *schema.Maximum)
+	}
+	if schema.ExclusiveMinimum != nil && number <= *schema.ExclusiveMinimum {
+		v.fail(field, "%s must be more than %v", name, *schema.ExclusiveMinimum)
+	}
+	if schema.ExclusiveMaximum != nil && number >= *schema.ExclusiveMaximum {
+		v.fail(field, "%s must be less than %v", name, *schema.ExclusiveMaximum)
+	}
+}
+
+func (v *validator) validateObject(schema *Schema, value map[string]interface{}, field string, strict bool) {
+	path := func(property string) string {
+		if field == "" {
+			return property
+		}
+		return field + "." + property
+	}
+
+	for _, property := range schema.Required {
+		if _, ok := value[property]; ok {
+			continue
+		}
+		// Read-only properties aren't sent and write-only ones aren't returned
+		if propertySchema := schema.Properties[property]; propertySchema != nil && v.skipped(propertySchema) {
+			continue
+		}
+		v.fail(path(property), "%s is required", path(property))
+	}
+
+	properties := v.properties(schema)
+	for property, item := range value {
+		propertySchema, known := properties[property]
+		switch {
+		case known && v.skipped(propertySchema):
+			if v.request {
+				v.fail(path(property), "%s is read-only", path(property))
+			} else {
+				v.fail(path(property), "%s is write-only", path(property))
+			}
+		case known:
+			if _, own := schema.Properties[property]; own {
+				v.validate(propertySchema, item, path(property), true)
+			}
+		case schema.AdditionalProperties != nil:
+			v.validate(schema.AdditionalProperties, item, path(property), true)
+		case strict && len(properties) > 0:
+			v.fail(path(property), "%s is not allowed", path(property))
+		}
+	}
+}
+
+// skipped reports whether a property can't appear in the direction being validated
+func (v *validator) skipped(schema *Schema) bool {
+	if v.request {
+		return schema.ReadOnly
+	}
+	return schema.WriteOnly
+}
+
+// properties collects the properties of schema and the schemas of its allOf
+func (v *validator) properties(schema *Schema) map[string]*Schema {
+	properties := map[string]*Schema{}
+	for name, property := range schema.Properties {
+		properties[name] = property
+	}
+	for _, branch := range schema.AllOf {
+		for name, property := range v.properties(v.spec.resolve(branch)) {
+			if _, ok := properties[name]; !ok {
+				properties[name] = property
+			}
+		}
+	}
+	return properties
+}
+
+func jsonType(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+func article(typeName string) string {
+	switch typeName {
+	case "integer", "object", "array":
+		return "an " + typeName
+	}
+	return "a " + typeName
+}
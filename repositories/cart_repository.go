@@ -0,0 +1,134 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrCartItemNotFound = errors.New("cart item not found")
+
+type CartRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCartRepository(client *mongo.Client, config configs.MongoConfig) *CartRepository {
+	collection := client.Database(config.Database).Collection("carts")
+	return &CartRepository{
+		collection: collection,
+	}
+}
+
+func (cr *CartRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := cr.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"user_id": 1},
+		Options: options.Index().SetUnique(true),
+	})
+	return err
+}
+
+// GetCart returns the user's cart, or an empty cart if there is none yet
+func (cr *CartRepository) GetCart(ctx context.Context, userID primitive.ObjectID) (*models.Cart, error) {
+	var cart models.Cart
+	err := cr.collection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&cart)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return &models.Cart{UserID: userID, Items: []models.CartItem{}}, nil
+		}
+		return nil, err
+	}
+	return &cart, nil
+}
+
+// cartItemMatch matches the cart item for a product and SKU. Items of
+// products without variants have an empty SKU, or none if stored before
+// variants existed.
+func cartItemMatch(productID primitive.ObjectID, sku string) bson.M {
+	match := bson.M{"product_id": productID, "sku": sku}
+	if sku == "" {
+		match["sku"] = bson.M{"$in": bson.A{"", nil}}
+	}
+	return match
+}
+
+// AddItem increases the quantity of a product or variant in the cart, adding it if needed
+func (cr *CartRepository) AddItem(ctx context.Context, userID, productID primitive.ObjectID, sku string, quantity int) error {
+	now := time.Now()
+	match := cartItemMatch(productID, sku)
+
+	result, err := cr.collection.UpdateOne(ctx,
+		bson.M{"user_id": userID, "items": bson.M{"$elemMatch": match}},
+		bson.M{
+			"$inc": bson.M{"items.$.quantity": quantity},
+			"$set": bson.M{"updated_at": now},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount > 0 {
+		return nil
+	}
+
+	_, err = cr.collection.UpdateOne(ctx,
+		bson.M{"user_id": userID, "items": bson.M{"$not": bson.M{"$elemMatch": match}}},
+		bson.M{
+			"$push":        bson.M{"items": models.CartItem{ProductID: productID, SKU: sku, Quantity: quantity}},
+			"$set":         bson.M{"updated_at": now},
+			"$setOnInsert": bson.M{"created_at": now},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent request added the same product first
+		return cr.AddItem(ctx, userID, productID, sku, quantity)
+	}
+	return err
+}
+
+func (cr *CartRepository) SetItemQuantity(ctx context.Context, userID, productID primitive.ObjectID, sku string, quantity int) error {
+	result, err := cr.collection.UpdateOne(ctx,
+		bson.M{"user_id": userID, "items": bson.M{"$elemMatch": cartItemMatch(productID, sku)}},
+		bson.M{"$set": bson.M{"items.$.quantity": quantity, "updated_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCartItemNotFound
+	}
+	return nil
+}
+
+func (cr *CartRepository) RemoveItem(ctx context.Context, userID, productID primitive.ObjectID, sku string) error {
+	match := cartItemMatch(productID, sku)
+	result, err := cr.collection.UpdateOne(ctx,
+		bson.M{"user_id": userID, "items": bson.M{"$elemMatch": match}},
+		bson.M{
+			"$pull": bson.M{"items": match},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCartItemNotFound
+	}
+	return nil
+}
+
+func (cr *CartRepository) ClearCart(ctx context.Context, userID primitive.ObjectID) error {
+	_, err := cr.collection.UpdateOne(ctx,
+		bson.M{"user_id": userID},
+		bson.M{"$set": bson.M{"items": []models.CartItem{}, "updated_at": time.Now()}},
+	)
+	return err
+}
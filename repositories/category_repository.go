@@ -0,0 +1,222 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrCategoryNotFound  = errors.New("category not found")
+	ErrCategorySlugTaken = errors.New("category slug already exists")
+)
+
+type CategoryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewCategoryRepository(client *mongo.Client, config configs.MongoConfig) *CategoryRepository {
+	collection := client.Database(config.Database).Collection("categories")
+	return &CategoryRepository{
+		collection: collection,
+	}
+}
+
+func (cr *CategoryRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := cr.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.M{"slug": 1},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.M{"ancestors": 1}},
+	})
+	return err
+}
+
+func (cr *CategoryRepository) CreateCategory(ctx context.Context, category *models.Category) error {
+	result, err := cr.collection.InsertOne(ctx, category)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrCategorySlugTaken
+		}
+		return err
+	}
+	category.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (cr *CategoryRepository) GetCategory(ctx context.Context, id primitive.ObjectID) (*models.Category, error) {
+	return cr.findCategory(ctx, bson.M{"_id": id})
+}
+
+func (cr *CategoryRepository) GetCategoryBySlug(ctx context.Context, slug string) (*models.Category, error) {
+	return cr.findCategory(ctx, bson.M{"slug": slug})
+}
+
+// GetCategoriesByIDs returns the categories with the given ids in no
+// particular order, ids without a category are left out
+func (cr *CategoryRepository) GetCategoriesByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.Category, error) {
+	cursor, err := cr.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	categories := []*models.Category{}
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (cr *CategoryRepository) findCategory(ctx context.Context, filter bson.M) (*models.Category, error) {
+	var category models.Category
+	err := cr.collection.FindOne(ctx, filter).Decode(&category)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrCategoryNotFound
+		}
+		return nil, err
+	}
+	return &category, nil
+}
+
+// ListCategories returns all categories ordered by position, then name
+func (cr *CategoryRepository) ListCategories(ctx context.Context) ([]*models.Category, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "position", Value: 1}, {Key: "name", Value: 1}})
+	cursor, err := cr.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	categories := []*models.Category{}
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+// SubtreeIDs returns the id of the category and of all its descendants
+func (cr *CategoryRepository) SubtreeIDs(ctx context.Context, id primitive.ObjectID) ([]primitive.ObjectID, error) {
+	filter := bson.M{"$or": bson.A{bson.M{"_id": id}, bson.M{"ancestors": id}}}
+	cursor, err := cr.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var ids []primitive.ObjectID
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return nil, err
+		}
+		ids = append(ids, doc.ID)
+	}
+	return ids, cursor.Err()
+}
+
+func (cr *CategoryRepository) CountChildren(ctx context.Context, id primitive.ObjectID) (int64, error) {
+	return cr.collection.CountDocuments(ctx, bson.M{"parent_id": id})
+}
+
+func (cr *CategoryRepository) UpdateCategory(ctx context.Context, id primitive.ObjectID, update bson.M) error {
+	update["updated_at"] = time.Now()
+	result, err := cr.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrCategorySlugTaken
+		}
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// MoveCategory puts the category below parent, or at the top level if parent
+// is nil, and rewrites the ancestors of everything below it
+func (cr *CategoryRepository) MoveCategory(ctx context.Context, id primitive.ObjectID, parent *models.Category) error {
+	ancestors := []primitive.ObjectID{}
+	update := bson.M{}
+	if parent != nil {
+		ancestors = append(slices.Clone(parent.Ancestors), parent.ID)
+		update["$set"] = bson.M{"parent_id": parent.ID, "ancestors": ancestors, "updated_at": time.Now()}
+	} else {
+		update["$set"] = bson.M{"ancestors": ancestors, "updated_at": time.Now()}
+		update["$unset"] = bson.M{"parent_id": ""}
+	}
+
+	result, err := cr.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrCategoryNotFound
+	}
+
+	// Descendants keep the part of their path from this category down
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"ancestors": bson.M{"$concatArrays": bson.A{
+				ancestors,
+				bson.M{"$slice": bson.A{
+					"$ancestors",
+					bson.M{"$indexOfArray": bson.A{"$ancestors", id}},
+					bson.M{"$size": "$ancestors"},
+				}},
+			}},
+		}}},
+	}
+	_, err = cr.collection.UpdateMany(ctx, bson.M{"ancestors": id}, pipeline)
+	return err
+}
+
+func (cr *CategoryRepository) DeleteCategory(ctx context.Context, id primitive.ObjectID) error {
+	result, err := cr.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+// FindOrCreateBySlug returns the category with the given slug, creating a
+// top-level category with that name if there is none
+func (cr *CategoryRepository) FindOrCreateBySlug(ctx context.Context, slug string, name string) (*models.Category, error) {
+	now := time.Now()
+	update := bson.M{"$setOnInsert": bson.M{
+		"name":       name,
+		"slug":       slug,
+		"ancestors":  bson.A{},
+		"position":   0,
+		"created_at": now,
+		"updated_at": now,
+	}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var category models.Category
+	err := cr.collection.FindOneAndUpdate(ctx, bson.M{"slug": slug}, update, opts).Decode(&category)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent upsert created it first
+		return cr.GetCategoryBySlug(ctx, slug)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
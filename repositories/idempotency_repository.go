@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// IdempotencyRepository is an idempotency store shared by every instance of the API
+type IdempotencyRepository struct {
+	collection *mongo.Collection
+}
+
+type idempotencyDocument struct {
+	Key         string      `bson:"_id"`
+	Token       string      `bson:"token"`
+	Completed   bool        `bson:"completed"`
+	Fingerprint string      `bson:"fingerprint,omitempty"`
+	StatusCode  int         `bson:"status_code,omitempty"`
+	Header      http.Header `bson:"header,omitempty"`
+	Body        []byte      `bson:"body,omitempty"`
+	Oversized   bool        `bson:"oversized,omitempty"`
+	LockedUntil time.Time   `bson:"locked_until"`
+	ExpiresAt   time.Time   `bson:"expires_at"`
+}
+
+func NewIdempotencyRepository(client *mongo.Client, config configs.MongoConfig) *IdempotencyRepository {
+	collection := client.Database(config.Database).Collection("idempotency_keys")
+	return &IdempotencyRepository{
+		collection: collection,
+	}
+}
+
+func (ir *IdempotencyRepository) EnsureIndexes(ctx context.Context) error {
+	// Drop records once their responses shouldn't be replayed anymore
+	_, err := ir.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Lock inserts an in-flight record for key. The unique _id makes sure only
+// one of several concurrent requests with the same key gets it.
+func (ir *IdempotencyRepository) Lock(ctx context.Context, key, token string, lockTimeout, ttl time.Duration) (*utils.IdempotencyRecord, bool, error) {
+	now := time.Now()
+	document := idempotencyDocument{
+		Key:         key,
+		Token:       token,
+		LockedUntil: now.Add(lockTimeout),
+		ExpiresAt:   now.Add(ttl),
+	}
+	_, err := ir.collection.InsertOne(ctx, document)
+	if err == nil {
+		return nil, true, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, false, err
+	}
+
+	// Take over records the TTL monitor hasn't removed yet and locks left by
+	// requests that never finished
+	filter := bson.M{
+		"_id": key,
+		"$or": bson.A{
+			bson.M{"expires_at": bson.M{"$lte": now}},
+			bson.M{"completed": false, "locked_until": bson.M{"$lte": now}},
+		},
+	}
+	result, err := ir.collection.ReplaceOne(ctx, filter, document)
+	if err != nil {
+		return nil, false, err
+	}
+	if result.ModifiedCount == 1 {
+		return nil, true, nil
+	}
+
+	var stored idempotencyDocument
+	err = ir.collection.FindOne(ctx, bson.M{"_id": key}).Decode(&stored)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		// Released in the meantime, the caller tries again
+		return &utils.IdempotencyRecord{}, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	return &utils.IdempotencyRecord{
+		Completed:   stored.Completed,
+		Fingerprint: stored.Fingerprint,
+		StatusCode:  stored.StatusCode,
+		Header:      stored.Header,
+		Body:        stored.Body,
+		Oversized:   stored.Oversized,
+	}, false, nil
+}
+
+func (ir *IdempotencyRepository) Complete(ctx context.Context, key, token string, record utils.IdempotencyRecord) error {
+	update := bson.M{"$set": bson.M{
+		"completed":   true,
+		"fingerprint": record.Fingerprint,
+		"status_code": record.StatusCode,
+		"header":      record.Header,
+		"body":        record.Body,
+		"oversized":   record.Oversized,
+	}}
+	result, err := ir.collection.UpdateOne(ctx, bson.M{"_id": key, "token": token, "completed": false}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return utils.ErrIdempotencyLockLost
+	}
+	return nil
+}
+
+func (ir *IdempotencyRepository) Release(ctx context.Context, key, token string) error {
+	_, err := ir.collection.DeleteOne(ctx, bson.M{"_id": key, "token": token, "completed": false})
+	return err
+}
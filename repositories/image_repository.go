@@ -0,0 +1,129 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrImageNotFound = errors.New("image not found")
+
+type ImageRepository struct {
+	collection *mongo.Collection
+}
+
+func NewImageRepository(client *mongo.Client, config configs.MongoConfig) *ImageRepository {
+	collection := client.Database(config.Database).Collection("product_images")
+	return &ImageRepository{
+		collection: collection,
+	}
+}
+
+func (ir *ImageRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := ir.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "position", Value: 1}},
+	})
+	return err
+}
+
+func (ir *ImageRepository) CreateImage(ctx context.Context, image *models.ProductImage) error {
+	_, err := ir.collection.InsertOne(ctx, image)
+	return err
+}
+
+func (ir *ImageRepository) GetImage(ctx context.Context, productID, id primitive.ObjectID) (*models.ProductImage, error) {
+	var image models.ProductImage
+	err := ir.collection.FindOne(ctx, bson.M{"_id": id, "product_id": productID}).Decode(&image)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrImageNotFound
+		}
+		return nil, err
+	}
+	return &image, nil
+}
+
+// ListImages returns the images of a product in display order
+func (ir *ImageRepository) ListImages(ctx context.Context, productID primitive.ObjectID) ([]*models.ProductImage, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "position", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := ir.collection.Find(ctx, bson.M{"product_id": productID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	images := []*models.ProductImage{}
+	if err := cursor.All(ctx, &images); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// NextPosition returns the position after the product's last image
+func (ir *ImageRepository) NextPosition(ctx context.Context, productID primitive.ObjectID) (int, error) {
+	opts := options.FindOne().SetSort(bson.M{"position": -1}).SetProjection(bson.M{"position": 1})
+	var last models.ProductImage
+	err := ir.collection.FindOne(ctx, bson.M{"product_id": productID}, opts).Decode(&last)
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return last.Position + 1, nil
+}
+
+// SetPrimary makes the image the product's primary image
+func (ir *ImageRepository) SetPrimary(ctx context.Context, productID, id primitive.ObjectID) error {
+	result, err := ir.collection.UpdateOne(ctx,
+		bson.M{"_id": id, "product_id": productID},
+		bson.M{"$set": bson.M{"primary": true}},
+	)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrImageNotFound
+	}
+
+	_, err = ir.collection.UpdateMany(ctx,
+		bson.M{"product_id": productID, "_id": bson.M{"$ne": id}, "primary": true},
+		bson.M{"$set": bson.M{"primary": false}},
+	)
+	return err
+}
+
+// HasPrimary reports whether the product has a primary image
+func (ir *ImageRepository) HasPrimary(ctx context.Context, productID primitive.ObjectID) (bool, error) {
+	count, err := ir.collection.CountDocuments(ctx, bson.M{"product_id": productID, "primary": true})
+	return count > 0, err
+}
+
+// SetPositions stores the order of the given images, ids[0] comes first
+func (ir *ImageRepository) SetPositions(ctx context.Context, productID primitive.ObjectID, ids []primitive.ObjectID) error {
+	writes := make([]mongo.WriteModel, len(ids))
+	for i, id := range ids {
+		writes[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": id, "product_id": productID}).
+			SetUpdate(bson.M{"$set": bson.M{"position": i}})
+	}
+	_, err := ir.collection.BulkWrite(ctx, writes)
+	return err
+}
+
+func (ir *ImageRepository) DeleteImage(ctx context.Context, productID, id primitive.ObjectID) error {
+	result, err := ir.collection.DeleteOne(ctx, bson.M{"_id": id, "product_id": productID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrImageNotFound
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+var ErrImportJobNotFound = errors.New("import job not found")
+
+type ImportJobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewImportJobRepository(client *mongo.Client, config configs.MongoConfig) *ImportJobRepository {
+	collection := client.Database(config.Database).Collection("import_jobs")
+	return &ImportJobRepository{
+		collection: collection,
+	}
+}
+
+func (ijr *ImportJobRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := ijr.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.M{"status": 1},
+	})
+	return err
+}
+
+func (ijr *ImportJobRepository) CreateJob(ctx context.Context, job *models.ImportJob) error {
+	result, err := ijr.collection.InsertOne(ctx, job)
+	if err != nil {
+		return err
+	}
+	job.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (ijr *ImportJobRepository) GetJob(ctx context.Context, id primitive.ObjectID) (*models.ImportJob, error) {
+	var job models.ImportJob
+	err := ijr.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrImportJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// SetJobID links the import to the background job that runs it
+func (ijr *ImportJobRepository) SetJobID(ctx context.Context, id, jobID primitive.ObjectID) error {
+	_, err := ijr.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"job_id": jobID}})
+	return err
+}
+
+// UpdateProgress stores the rows processed so far and their report, an
+// interrupted import continues from there
+func (ijr *ImportJobRepository) UpdateProgress(ctx context.Context, id primitive.ObjectID, processed int, report *models.ImportReport) error {
+	_, err := ijr.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": bson.M{"processed": processed, "report": report}})
+	return err
+}
+
+// FinishJob stores the outcome of a job, errMessage is only set for failed jobs
+func (ijr *ImportJobRepository) FinishJob(ctx context.Context, id primitive.ObjectID, status string, report *models.ImportReport, errMessage string) error {
+	update := bson.M{"status": status, "finished_at": time.Now()}
+	if report != nil {
+		update["report"] = report
+	}
+	if errMessage != "" {
+		update["error"] = errMessage
+	}
+	_, err := ijr.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$set": update})
+	return err
+}
@@ -0,0 +1,243 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrJobNotFound  = errors.New("job not found")
+	ErrDuplicateJob = errors.New("a job with this unique key already exists")
+	// ErrJobLeaseLost is returned for a job that was cancelled or picked up by another worker
+	ErrJobLeaseLost      = errors.New("job lease lost")
+	ErrJobNotRetryable   = errors.New("only dead and cancelled jobs can be retried")
+	ErrJobNotCancellable = errors.New("only pending and running jobs can be cancelled")
+)
+
+type JobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewJobRepository(client *mongo.Client, config configs.MongoConfig) *JobRepository {
+	collection := client.Database(config.Database).Collection("jobs")
+	return &JobRepository{
+		collection: collection,
+	}
+}
+
+func (jr *JobRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := jr.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "run_at", Value: 1}}},
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "leased_until", Value: 1}}},
+		{Keys: bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		{
+			Keys: bson.M{"unique_key": 1},
+			Options: options.Index().
+				SetUnique(true).
+				SetPartialFilterExpression(bson.M{"unique_key": bson.M{"$exists": true}}),
+		},
+		// Only succeeded and cancelled jobs get an expiry, dead ones are kept
+		{Keys: bson.M{"expires_at": 1}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+func (jr *JobRepository) CreateJob(ctx context.Context, job *models.Job) error {
+	result, err := jr.collection.InsertOne(ctx, job)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateJob
+		}
+		return err
+	}
+	job.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (jr *JobRepository) GetJob(ctx context.Context, id primitive.ObjectID) (*models.Job, error) {
+	var job models.Job
+	err := jr.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrJobNotFound
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ListJobs returns the jobs with the status and type, if given, newest first
+func (jr *JobRepository) ListJobs(ctx context.Context, status, jobType string, limit int, offset int) ([]*models.Job, int64, error) {
+	query := bson.M{}
+	if status != "" {
+		query["status"] = status
+	}
+	if jobType != "" {
+		query["type"] = jobType
+	}
+
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := jr.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	jobs := []*models.Job{}
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, 0, err
+	}
+
+	totalCount, err := jr.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return jobs, totalCount, nil
+}
+
+// ClaimDue takes the job of one of types that is due the longest, or returns
+// nil if none is due. Running jobs whose lease ran out are due again, their
+// worker stopped without recording the outcome. The claimed job is running
+// and leased until lease has passed.
+func (jr *JobRepository) ClaimDue(ctx context.Context, types []string, lease time.Duration) (*models.Job, error) {
+	now := time.Now()
+	filter := bson.M{
+		"type": bson.M{"$in": types},
+		"$or": bson.A{
+			bson.M{"status": models.JobPending, "run_at": bson.M{"$lte": now}},
+			bson.M{"status": models.JobRunning, "leased_until": bson.M{"$lte": now}},
+		},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"status":       models.JobRunning,
+			"leased_until": now.Add(lease),
+			"started_at":   now,
+			"updated_at":   now,
+		},
+		"$inc": bson.M{"attempts": 1},
+	}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "run_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job models.Job
+	err := jr.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &job, nil
+}
+
+// ExtendLease keeps the job leased until lease has passed. attempt is the
+// job's attempt count when it was claimed.
+func (jr *JobRepository) ExtendLease(ctx context.Context, id primitive.ObjectID, attempt int, lease time.Duration) error {
+	return jr.updateLeased(ctx, id, attempt, bson.M{
+		"$set": bson.M{"leased_until": time.Now().Add(lease)},
+	})
+}
+
+// CompleteJob records that the job succeeded, it's deleted after retention
+func (jr *JobRepository) CompleteJob(ctx context.Context, id primitive.ObjectID, attempt int, retention time.Duration) error {
+	now := time.Now()
+	return jr.updateLeased(ctx, id, attempt, bson.M{
+		"$set": bson.M{
+			"status":      models.JobSucceeded,
+			"finished_at": now,
+			"updated_at":  now,
+			"expires_at":  now.Add(retention),
+		},
+		"$unset": bson.M{"leased_until": "", "last_error": ""},
+	})
+}
+
+// FailJob records a failed attempt. The job is retried at nextRunAt, or is
+// dead if it's nil.
+func (jr *JobRepository) FailJob(ctx context.Context, id primitive.ObjectID, attempt int, jobErr error, nextRunAt *time.Time) error {
+	now := time.Now()
+	set := bson.M{"last_error": jobErr.Error(), "updated_at": now}
+	if nextRunAt != nil {
+		set["status"] = models.JobPending
+		set["run_at"] = *nextRunAt
+	} else {
+		set["status"] = models.JobDead
+		set["finished_at"] = now
+	}
+	return jr.updateLeased(ctx, id, attempt, bson.M{"$set": set, "$unset": bson.M{"leased_until": ""}})
+}
+
+// ReleaseJob hands a job back without counting the attempt, it's run again right away
+func (jr *JobRepository) ReleaseJob(ctx context.Context, id primitive.ObjectID, attempt int) error {
+	now := time.Now()
+	return jr.updateLeased(ctx, id, attempt, bson.M{
+		"$set":   bson.M{"status": models.JobPending, "run_at": now, "updated_at": now},
+		"$inc":   bson.M{"attempts": -1},
+		"$unset": bson.M{"leased_until": ""},
+	})
+}
+
+// updateLeased updates the job if it's still running the given attempt
+func (jr *JobRepository) updateLeased(ctx context.Context, id primitive.ObjectID, attempt int, update bson.M) error {
+	result, err := jr.collection.UpdateOne(ctx, bson.M{"_id": id, "status": models.JobRunning, "attempts": attempt}, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
+
+// RetryJob makes a dead or cancelled job pending again with its attempts reset
+func (jr *JobRepository) RetryJob(ctx context.Context, id primitive.ObjectID) (*models.Job, error) {
+	now := time.Now()
+	return jr.transition(ctx, id, []string{models.JobDead, models.JobCancelled}, ErrJobNotRetryable, bson.M{
+		"$set":   bson.M{"status": models.JobPending, "attempts": 0, "run_at": now, "updated_at": now},
+		"$unset": bson.M{"last_error": "", "started_at": "", "finished_at": "", "expires_at": ""},
+	})
+}
+
+// CancelJob cancels a pending or running job, it's deleted after retention.
+// A running job loses its lease, so its worker stops it.
+func (jr *JobRepository) CancelJob(ctx context.Context, id primitive.ObjectID, retention time.Duration) (*models.Job, error) {
+	now := time.Now()
+	return jr.transition(ctx, id, []string{models.JobPending, models.JobRunning}, ErrJobNotCancellable, bson.M{
+		"$set":   bson.M{"status": models.JobCancelled, "finished_at": now, "updated_at": now, "expires_at": now.Add(retention)},
+		"$unset": bson.M{"leased_until": ""},
+	})
+}
+
+// transition applies update to the job if it has one of statuses, or returns
+// invalid if it exists with another status
+func (jr *JobRepository) transition(ctx context.Context, id primitive.ObjectID, statuses []string, invalid error, update bson.M) (*models.Job, error) {
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var job models.Job
+	err := jr.collection.FindOneAndUpdate(ctx, bson.M{"_id": id, "status": bson.M{"$in": statuses}}, update, opts).Decode(&job)
+	if err == nil {
+		return &job, nil
+	}
+	if err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+	if _, err := jr.GetJob(ctx, id); err != nil {
+		return nil, err
+	}
+	return nil, invalid
+}
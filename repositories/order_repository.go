@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrOrderNotFound       = errors.New("order not found")
+	ErrOrderStatusConflict = errors.New("order status changed concurrently")
+)
+
+type OrderRepository struct {
+	collection *mongo.Collection
+}
+
+func NewOrderRepository(client *mongo.Client, config configs.MongoConfig) *OrderRepository {
+	collection := client.Database(config.Database).Collection("orders")
+	return &OrderRepository{
+		collection: collection,
+	}
+}
+
+func (or *OrderRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := or.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	return err
+}
+
+func (or *OrderRepository) CreateOrder(ctx context.Context, order *models.Order) error {
+	_, err := or.collection.InsertOne(ctx, order)
+	return err
+}
+
+func (or *OrderRepository) GetOrder(ctx context.Context, id primitive.ObjectID) (*models.Order, error) {
+	var order models.Order
+	err := or.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrOrderNotFound
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// UpdateStatus moves an order to a new status only if it still has the expected one
+func (or *OrderRepository) UpdateStatus(ctx context.Context, id primitive.ObjectID, from string, change models.OrderStatusChange) (*models.Order, error) {
+	filter := bson.M{"_id": id, "status": from}
+	update := bson.M{
+		"$set":  bson.M{"status": change.Status, "updated_at": time.Now()},
+		"$push": bson.M{"status_history": change},
+	}
+
+	var order models.Order
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := or.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&order)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrOrderStatusConflict
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// orderSortFields are the fields orders can be sorted by
+var orderSortFields = map[string]string{
+	"total":      "total.amount",
+	"status":     "status",
+	"created_at": "created_at",
+	"updated_at": "updated_at",
+}
+
+// ListOrders lists all orders, or only the user's orders when userID is set
+func (or *OrderRepository) ListOrders(ctx context.Context, userID *primitive.ObjectID, limit int, offset int, sort string) ([]*models.Order, int64, error) {
+	sortDoc, err := parseSort(sort, orderSortFields)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	filter := bson.M{}
+	if userID != nil {
+		filter["user_id"] = *userID
+	}
+
+	options := options.Find().
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset)).
+		SetSort(sortDoc)
+
+	cursor, err := or.collection.Find(ctx, filter, options)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	orders := []*models.Order{}
+	if err := cursor.All(ctx, &orders); err != nil {
+		return nil, 0, err
+	}
+
+	// Get total count
+	totalCount, err := or.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return orders, totalCount, nil
+}
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OutboxRepository stores domain events until the relay has dispatched them
+type OutboxRepository struct {
+	collection *mongo.Collection
+}
+
+type outboxDocument struct {
+	ID            primitive.ObjectID `bson:"_id"`
+	Type          string             `bson:"type"`
+	OccurredAt    time.Time          `bson:"occurred_at"`
+	Data          string             `bson:"data"`
+	Status        string             `bson:"status"`
+	Attempts      int                `bson:"attempts"`
+	DeliveredTo   []string           `bson:"delivered_to"`
+	LastError     string             `bson:"last_error,omitempty"`
+	NextAttemptAt *time.Time         `bson:"next_attempt_at,omitempty"`
+	ExpiresAt     *time.Time         `bson:"expires_at,omitempty"`
+}
+
+func NewOutboxRepository(client *mongo.Client, config configs.MongoConfig) *OutboxRepository {
+	collection := client.Database(config.Database).Collection("outbox")
+	return &OutboxRepository{
+		collection: collection,
+	}
+}
+
+func (ob *OutboxRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := ob.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		// Only dispatched events get an expiry, pending and failed ones are kept
+		{Keys: bson.M{"expires_at": 1}, Options: options.Index().SetExpireAfterSeconds(0)},
+	})
+	return err
+}
+
+// Append stores events as pending. Called with the context of a transaction,
+// they're only stored if the transaction commits.
+func (ob *OutboxRepository) Append(ctx context.Context, events ...models.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, len(events))
+	for i, event := range events {
+		nextAttemptAt := event.OccurredAt
+		documents[i] = outboxDocument{
+			ID:            event.ID,
+			Type:          event.Type,
+			OccurredAt:    event.OccurredAt,
+			Data:          string(event.Data),
+			Status:        models.OutboxPending,
+			DeliveredTo:   []string{},
+			NextAttemptAt: &nextAttemptAt,
+		}
+	}
+	_, err := ob.collection.InsertMany(ctx, documents)
+	return err
+}
+
+// ClaimDue takes the oldest pending event that is due, or returns nil if none
+// is due. It's leased like webhook deliveries are, so other instances skip it
+// and it's picked up again if the relay stops before recording the outcome.
+func (ob *OutboxRepository) ClaimDue(ctx context.Context, lease time.Duration) (*models.OutboxEntry, error) {
+	now := time.Now()
+	filter := bson.M{"status": models.OutboxPending, "next_attempt_at": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "next_attempt_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var document outboxDocument
+	err := ob.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&document)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &models.OutboxEntry{
+		Event: models.Event{
+			ID:         document.ID,
+			Type:       document.Type,
+			OccurredAt: document.OccurredAt,
+			Data:       json.RawMessage(document.Data),
+		},
+		Attempts:    document.Attempts,
+		DeliveredTo: document.DeliveredTo,
+	}, nil
+}
+
+// MarkDispatched records that every subscriber handled the event, it's deleted after retention
+func (ob *OutboxRepository) MarkDispatched(ctx context.Context, id primitive.ObjectID, deliveredTo []string, retention time.Duration) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":       models.OutboxDispatched,
+			"delivered_to": deliveredTo,
+			"expires_at":   time.Now().Add(retention),
+		},
+		"$inc":   bson.M{"attempts": 1},
+		"$unset": bson.M{"next_attempt_at": "", "last_error": ""},
+	}
+	_, err := ob.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// RecordFailure records a dispatch that some subscribers failed. The event
+// is retried at nextAttemptAt, or marked failed if it's nil.
+func (ob *OutboxRepository) RecordFailure(ctx context.Context, id primitive.ObjectID, deliveredTo []string, dispatchErr error, nextAttemptAt *time.Time) error {
+	set := bson.M{
+		"status":       models.OutboxPending,
+		"delivered_to": deliveredTo,
+		"last_error":   dispatchErr.Error(),
+	}
+	update := bson.M{"$set": set, "$inc": bson.M{"attempts": 1}}
+	if nextAttemptAt != nil {
+		set["next_attempt_at"] = *nextAttemptAt
+	} else {
+		set["status"] = models.OutboxFailed
+		update["$unset"] = bson.M{"next_attempt_at": ""}
+	}
+
+	_, err := ob.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
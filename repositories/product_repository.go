@@ -3,6 +3,9 @@ package repositories
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"time"
 
 	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
 	"github.com/harsh-solanki21/golang-gin-crud-api/models"
@@ -12,25 +15,44 @@ import (
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
-var ErrProductNotFound = errors.New("product not found")
+var (
+	ErrProductNotFound   = errors.New("product not found")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrStockTrackedPerVariant is returned for product-level stock changes on a product with variants
+	ErrStockTrackedPerVariant = errors.New("stock is tracked per variant")
+	// ErrChangeHistoryLost is returned when a change stream can't resume
+	// because the oplog no longer has the change to resume after
+	ErrChangeHistoryLost = errors.New("change stream history lost")
+)
 
 type ProductRepository struct {
 	collection *mongo.Collection
 }
 
-func NewProductRepository(client *mongo.Client) *ProductRepository {
-	dbName := configs.GetDatabaseName()
-	collection := client.Database(dbName).Collection("products")
+func NewProductRepository(client *mongo.Client, config configs.MongoConfig) *ProductRepository {
+	collection := client.Database(config.Database).Collection("products")
 	return &ProductRepository{
 		collection: collection,
 	}
 }
 
-func (pr *ProductRepository) CreateProduct(ctx context.Context, product *models.Product) error {
-	_, err := pr.collection.InsertOne(ctx, product)
+func (pr *ProductRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := pr.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.M{"category_id": 1}},
+		{Keys: bson.M{"average_rating": -1}},
+	})
 	return err
 }
 
+func (pr *ProductRepository) CreateProduct(ctx context.Context, product *models.Product) error {
+	result, err := pr.collection.InsertOne(ctx, product)
+	if err != nil {
+		return err
+	}
+	product.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
 func (pr *ProductRepository) GetProduct(ctx context.Context, id primitive.ObjectID) (*models.Product, error) {
 	var product models.Product
 	err := pr.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&product)
@@ -60,6 +82,78 @@ func (pr *ProductRepository) UpdateProduct(ctx context.Context, id primitive.Obj
 	return pr.GetProduct(ctx, id)
 }
 
+// WatchProducts calls handle with every change to the products collection
+// until ctx is cancelled or the change stream fails. With resumeAfter the
+// stream continues after that change. Change streams need a replica set or a
+// sharded cluster.
+func (pr *ProductRepository) WatchProducts(ctx context.Context, resumeAfter bson.Raw, handle func(models.ProductChange)) error {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{
+		"operationType": bson.M{"$in": bson.A{"insert", "update", "replace", "delete"}},
+	}}}}
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if resumeAfter != nil {
+		opts.SetResumeAfter(resumeAfter)
+	}
+
+	stream, err := pr.collection.Watch(ctx, pipeline, opts)
+	if err != nil {
+		return changeStreamError(err)
+	}
+	defer stream.Close(context.Background())
+
+	for stream.Next(ctx) {
+		var event struct {
+			Token         bson.Raw `bson:"_id"`
+			OperationType string   `bson:"operationType"`
+			DocumentKey   struct {
+				ID primitive.ObjectID `bson:"_id"`
+			} `bson:"documentKey"`
+			FullDocument *models.Product `bson:"fullDocument"`
+		}
+		if err := stream.Decode(&event); err != nil {
+			return err
+		}
+
+		change := models.ProductChange{
+			ID:        resumeTokenID(event.Token),
+			ProductID: event.DocumentKey.ID,
+			Product:   event.FullDocument,
+			Token:     event.Token,
+		}
+		switch event.OperationType {
+		case "insert":
+			change.Type = models.EventProductCreated
+		case "delete":
+			change.Type = models.EventProductDeleted
+		default:
+			// Products deleted before their update was looked up get their own delete event
+			if change.Product == nil {
+				continue
+			}
+			change.Type = models.EventProductUpdated
+		}
+		handle(change)
+	}
+	return changeStreamError(stream.Err())
+}
+
+func changeStreamError(err error) error {
+	var serverErr mongo.ServerError
+	// ChangeStreamHistoryLost, and ChangeStreamFatalError on older servers
+	if errors.As(err, &serverErr) && (serverErr.HasErrorCode(286) || serverErr.HasErrorCode(280)) {
+		return fmt.Errorf("%w: %v", ErrChangeHistoryLost, err)
+	}
+	return err
+}
+
+// resumeTokenID turns a resume token into an event ID, tokens are the same on every instance
+func resumeTokenID(token bson.Raw) string {
+	if data, ok := token.Lookup("_data").StringValueOK(); ok {
+		return data
+	}
+	return token.String()
+}
+
 func (pr *ProductRepository) DeleteProduct(ctx context.Context, id primitive.ObjectID) error {
 	result, err := pr.collection.DeleteOne(ctx, bson.M{"_id": id})
 	if err != nil {
@@ -71,13 +165,92 @@ func (pr *ProductRepository) DeleteProduct(ctx context.Context, id primitive.Obj
 	return nil
 }
 
-func (pr *ProductRepository) ListProducts(ctx context.Context, limit int, offset int, sort string) ([]*models.Product, int64, error) {
+// productSortFields are the fields products can be sorted by
+var productSortFields = map[string]string{
+	"name":           "name",
+	"price":          "price.amount",
+	"stock_quantity": "stock_quantity",
+	"rating":         "average_rating",
+	"review_count":   "review_count",
+	"created_at":     "created_at",
+	"updated_at":     "updated_at",
+}
+
+func (pr *ProductRepository) ListProducts(ctx context.Context, filter models.ProductFilter, limit int, offset int, sort string) ([]*models.Product, int64, error) {
+	return pr.findProducts(ctx, productQuery(filter), limit, offset, sort)
+}
+
+// StreamProducts calls fn for each product matching the filter, decoding them
+// from the cursor one at a time. It stops at the first error fn returns.
+func (pr *ProductRepository) StreamProducts(ctx context.Context, filter models.ProductFilter, sort string, fn func(*models.Product) error) error {
+	sortDoc, err := parseSort(sort, productSortFields)
+	if err != nil {
+		return err
+	}
+
+	cursor, err := pr.collection.Find(ctx, productQuery(filter), options.Find().SetSort(sortDoc))
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var product models.Product
+		if err := cursor.Decode(&product); err != nil {
+			return err
+		}
+		if err := fn(&product); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+func productQuery(filter models.ProductFilter) bson.M {
+	query := bson.M{}
+	if filter.ProductIDs != nil {
+		query["_id"] = bson.M{"$in": filter.ProductIDs}
+	}
+	if filter.CategoryIDs != nil {
+		query["category_id"] = bson.M{"$in": filter.CategoryIDs}
+	}
+	if filter.Currency != "" {
+		query["price.currency"] = filter.Currency
+	}
+	priceRange := bson.M{}
+	if filter.MinPrice != nil {
+		priceRange["$gte"] = *filter.MinPrice
+	}
+	if filter.MaxPrice != nil {
+		priceRange["$lte"] = *filter.MaxPrice
+	}
+	if len(priceRange) > 0 {
+		query["price.amount"] = priceRange
+	}
+	return query
+}
+
+// ListLowStock lists products whose available quantity is at or below their low-stock threshold
+func (pr *ProductRepository) ListLowStock(ctx context.Context, limit int, offset int, sort string) ([]*models.Product, int64, error) {
+	filter := bson.M{
+		"$expr":         bson.M{"$lte": bson.A{availableQuantity, "$low_stock_threshold"}},
+		"variant_count": bson.M{"$not": bson.M{"$gt": 0}},
+	}
+	return pr.findProducts(ctx, filter, limit, offset, sort)
+}
+
+func (pr *ProductRepository) findProducts(ctx context.Context, filter bson.M, limit int, offset int, sort string) ([]*models.Product, int64, error) {
+	sortDoc, err := parseSort(sort, productSortFields)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	options := options.Find().
 		SetLimit(int64(limit)).
 		SetSkip(int64(offset)).
-		SetSort(bson.M{sort: 1})
+		SetSort(sortDoc)
 
-	cursor, err := pr.collection.Find(ctx, bson.M{}, options)
+	cursor, err := pr.collection.Find(ctx, filter, options)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -97,10 +270,307 @@ func (pr *ProductRepository) ListProducts(ctx context.Context, limit int, offset
 	}
 
 	// Get total count
-	totalCount, err := pr.collection.CountDocuments(ctx, bson.M{})
+	totalCount, err := pr.collection.CountDocuments(ctx, filter)
 	if err != nil {
 		return nil, 0, err
 	}
 
 	return products, totalCount, nil
 }
+
+func (pr *ProductRepository) GetProductsByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*models.Product, error) {
+	cursor, err := pr.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	products := make(map[primitive.ObjectID]*models.Product, len(ids))
+	for cursor.Next(ctx) {
+		var product models.Product
+		if err := cursor.Decode(&product); err != nil {
+			return nil, err
+		}
+		products[product.ID] = &product
+	}
+
+	return products, cursor.Err()
+}
+
+// GetProductsByNames returns the products with the given names, names can be shared by several products
+func (pr *ProductRepository) GetProductsByNames(ctx context.Context, names []string) (map[string][]*models.Product, error) {
+	cursor, err := pr.collection.Find(ctx, bson.M{"name": bson.M{"$in": names}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	products := make(map[string][]*models.Product, len(names))
+	for cursor.Next(ctx) {
+		var product models.Product
+		if err := cursor.Decode(&product); err != nil {
+			return nil, err
+		}
+		products[product.Name] = append(products[product.Name], &product)
+	}
+
+	return products, cursor.Err()
+}
+
+// ProductWrite either inserts Insert, or applies Update to the product with ID
+type ProductWrite struct {
+	Insert *models.Product
+	ID     primitive.ObjectID
+	Update bson.M
+}
+
+// BulkWriteProducts sends the writes in one unordered bulk write and returns
+// the error of each write, nil for the ones that succeeded
+func (pr *ProductRepository) BulkWriteProducts(ctx context.Context, writes []ProductWrite) ([]error, error) {
+	writeModels := make([]mongo.WriteModel, len(writes))
+	for i, write := range writes {
+		if write.Insert != nil {
+			if write.Insert.ID.IsZero() {
+				write.Insert.ID = primitive.NewObjectID()
+			}
+			writeModels[i] = mongo.NewInsertOneModel().SetDocument(write.Insert)
+			continue
+		}
+		update := bson.M{}
+		for key, value := range write.Update {
+			update[key] = value
+		}
+		update["updated_at"] = time.Now()
+		writeModels[i] = mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": write.ID}).
+			SetUpdate(bson.M{"$set": update})
+	}
+
+	errs := make([]error, len(writes))
+	result, err := pr.collection.BulkWrite(ctx, writeModels, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		var bulkErr mongo.BulkWriteException
+		if !errors.As(err, &bulkErr) || bulkErr.WriteConcernError != nil {
+			return nil, err
+		}
+		for _, writeErr := range bulkErr.WriteErrors {
+			errs[writeErr.Index] = writeErr
+		}
+		return errs, nil
+	}
+
+	// Updates of products deleted since they were looked up match nothing
+	if result.MatchedCount+result.InsertedCount < int64(len(writes)) {
+		for i, write := range writes {
+			if write.Insert == nil {
+				if _, getErr := pr.GetProduct(ctx, write.ID); errors.Is(getErr, ErrProductNotFound) {
+					errs[i] = ErrProductNotFound
+				}
+			}
+		}
+	}
+	return errs, nil
+}
+
+// CountByCategory returns the number of products directly in each category
+func (pr *ProductRepository) CountByCategory(ctx context.Context) (map[primitive.ObjectID]int64, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"category_id": bson.M{"$exists": true}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$category_id", "count": bson.M{"$sum": 1}}}},
+	}
+	cursor, err := pr.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	counts := make(map[primitive.ObjectID]int64)
+	for cursor.Next(ctx) {
+		var row struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Count int64              `bson:"count"`
+		}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		counts[row.ID] = row.Count
+	}
+	return counts, cursor.Err()
+}
+
+func (pr *ProductRepository) CountInCategory(ctx context.Context, categoryID primitive.ObjectID) (int64, error) {
+	return pr.collection.CountDocuments(ctx, bson.M{"category_id": categoryID})
+}
+
+// LegacyCategories returns the free-form category names of products that
+// were created before categories had their own collection
+func (pr *ProductRepository) LegacyCategories(ctx context.Context) ([]string, error) {
+	filter := bson.M{"category": bson.M{"$type": "string"}, "category_id": bson.M{"$exists": false}}
+	values, err := pr.collection.Distinct(ctx, "category", filter)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(values))
+	for _, value := range values {
+		names = append(names, value.(string))
+	}
+	return names, nil
+}
+
+// AssignLegacyCategory moves the products with the given legacy category name into a category
+func (pr *ProductRepository) AssignLegacyCategory(ctx context.Context, name string, categoryID primitive.ObjectID) (int64, error) {
+	result, err := pr.collection.UpdateMany(ctx,
+		bson.M{"category": name, "category_id": bson.M{"$exists": false}},
+		bson.M{
+			"$set":   bson.M{"category_id": categoryID},
+			"$unset": bson.M{"category": ""},
+		},
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// MigrateLegacyPrices converts prices stored as plain numbers into money in the
+// given currency. The conversion goes through Decimal128 so e.g. 1.005 isn't
+// rounded down by binary float error. It returns the number of migrated products.
+func (pr *ProductRepository) MigrateLegacyPrices(ctx context.Context, currency string) (int64, error) {
+	exponent, err := models.CurrencyExponent(currency)
+	if err != nil {
+		return 0, err
+	}
+
+	filter := bson.M{"price": bson.M{"$type": bson.A{"double", "int", "long", "decimal"}}}
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"price": bson.M{
+				"amount": bson.M{"$toLong": bson.M{"$round": bson.A{
+					bson.M{"$multiply": bson.A{bson.M{"$toDecimal": "$price"}, math.Pow10(exponent)}},
+					0,
+				}}},
+				"currency": currency,
+			},
+		}}},
+	}
+
+	result, err := pr.collection.UpdateMany(ctx, filter, pipeline)
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// AdjustStock adds delta to the stock on hand. Removing stock fails with
+// ErrInsufficientStock if it would drop below the reserved quantity.
+func (pr *ProductRepository) AdjustStock(ctx context.Context, id primitive.ObjectID, delta int) (*models.Product, error) {
+	return pr.updateStock(ctx, id, adjustStockChange(delta))
+}
+
+// ReserveStock sets aside quantity units if they are available
+func (pr *ProductRepository) ReserveStock(ctx context.Context, id primitive.ObjectID, quantity int) (*models.Product, error) {
+	return pr.updateStock(ctx, id, reserveStockChange(quantity))
+}
+
+// ReleaseStock returns previously reserved units to the available stock
+func (pr *ProductRepository) ReleaseStock(ctx context.Context, id primitive.ObjectID, quantity int) (*models.Product, error) {
+	return pr.updateStock(ctx, id, releaseStockChange(quantity))
+}
+
+// CommitStock ships reserved units, removing them from both the stock on hand and the reservations
+func (pr *ProductRepository) CommitStock(ctx context.Context, id primitive.ObjectID, quantity int) (*models.Product, error) {
+	return pr.updateStock(ctx, id, commitStockChange(quantity))
+}
+
+// IncrementVariantCount keeps the product's variant count in step with its variants
+func (pr *ProductRepository) IncrementVariantCount(ctx context.Context, id primitive.ObjectID, delta int) error {
+	_, err := pr.collection.UpdateOne(ctx, bson.M{"_id": id}, bson.M{"$inc": bson.M{"variant_count": delta}})
+	return err
+}
+
+// ApplyRatingChange adds to the product's rating sum and review count and
+// recomputes the average in the same update, so concurrent changes can't
+// leave the average out of step with the count
+func (pr *ProductRepository) ApplyRatingChange(ctx context.Context, id primitive.ObjectID, sum int, count int) error {
+	if sum == 0 && count == 0 {
+		return nil
+	}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{
+			"rating_sum":   bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$rating_sum", 0}}, sum}},
+			"review_count": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$review_count", 0}}, count}},
+		}}},
+		averageRatingStage,
+	}
+
+	result, err := pr.collection.UpdateOne(ctx, bson.M{"_id": id}, pipeline)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		return ErrProductNotFound
+	}
+	return nil
+}
+
+// averageRatingStage recomputes the average rating from the rating sum and review count
+var averageRatingStage = bson.D{{Key: "$set", Value: bson.M{
+	"average_rating": bson.M{"$cond": bson.A{
+		bson.M{"$gt": bson.A{"$review_count", 0}},
+		bson.M{"$round": bson.A{bson.M{"$divide": bson.A{"$rating_sum", "$review_count"}}, 2}},
+		0,
+	}},
+}}}
+
+// SetRatings overwrites the ratings of the products with the totals of their
+// reviews, products without totals have no rating. It returns the number of
+// products whose rating was out of step.
+func (pr *ProductRepository) SetRatings(ctx context.Context, totals []models.RatingTotal) (int64, error) {
+	writeModels := make([]mongo.WriteModel, 0, len(totals)+1)
+	productIDs := make([]primitive.ObjectID, 0, len(totals))
+	for _, total := range totals {
+		productIDs = append(productIDs, total.ProductID)
+		writeModels = append(writeModels, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": total.ProductID, "$or": bson.A{
+				bson.M{"rating_sum": bson.M{"$ne": total.Sum}},
+				bson.M{"review_count": bson.M{"$ne": total.Count}},
+			}}).
+			SetUpdate(mongo.Pipeline{
+				{{Key: "$set", Value: bson.M{"rating_sum": total.Sum, "review_count": total.Count}}},
+				averageRatingStage,
+			}))
+	}
+	writeModels = append(writeModels, mongo.NewUpdateManyModel().
+		SetFilter(bson.M{"_id": bson.M{"$nin": productIDs}, "review_count": bson.M{"$gt": 0}}).
+		SetUpdate(bson.M{"$set": bson.M{"rating_sum": 0, "review_count": 0, "average_rating": 0}}))
+
+	result, err := pr.collection.BulkWrite(ctx, writeModels, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		return 0, err
+	}
+	return result.ModifiedCount, nil
+}
+
+// updateStock changes the product-level stock. Products with variants keep
+// their stock per variant, so the update is refused for them.
+func (pr *ProductRepository) updateStock(ctx context.Context, id primitive.ObjectID, change stockChange) (*models.Product, error) {
+	change.condition["variant_count"] = bson.M{"$not": bson.M{"$gt": 0}}
+
+	var product models.Product
+	err := applyStockChange(ctx, pr.collection, id, change, &product)
+	if err == nil {
+		return &product, nil
+	}
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrProductNotFound
+	}
+	if err == ErrInsufficientStock {
+		// Tell a product with variants apart from a failed stock condition
+		if existing, getErr := pr.GetProduct(ctx, id); getErr == nil && existing.VariantCount > 0 {
+			return nil, ErrStockTrackedPerVariant
+		}
+	}
+	return nil, err
+}
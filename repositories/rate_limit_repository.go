@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// RateLimitRepository is a rate limit store shared by every instance of the API
+type RateLimitRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRateLimitRepository(client *mongo.Client, config configs.MongoConfig) *RateLimitRepository {
+	collection := client.Database(config.Database).Collection("rate_limits")
+	return &RateLimitRepository{
+		collection: collection,
+	}
+}
+
+func (rr *RateLimitRepository) EnsureIndexes(ctx context.Context) error {
+	// Drop buckets once they would be full again
+	_, err := rr.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.M{"expires_at": 1},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	return err
+}
+
+// Take refills and takes a token in a single update so concurrent requests
+// from several instances can't overdraw the bucket
+func (rr *RateLimitRepository) Take(ctx context.Context, key string, policy utils.RateLimitPolicy) (utils.RateLimitResult, error) {
+	now := time.Now()
+	capacity := policy.Capacity()
+	fullRefill := time.Duration(capacity / policy.RefillRate() * float64(time.Second))
+
+	elapsedSeconds := bson.M{"$divide": bson.A{
+		bson.M{"$subtract": bson.A{now, bson.M{"$ifNull": bson.A{"$updated_at", now}}}},
+		1000,
+	}}
+	refilled := bson.M{"$min": bson.A{
+		capacity,
+		bson.M{"$add": bson.A{
+			bson.M{"$ifNull": bson.A{"$tokens", capacity}},
+			bson.M{"$multiply": bson.A{elapsedSeconds, policy.RefillRate()}},
+		}},
+	}}
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"tokens": refilled, "updated_at": now}}},
+		{{Key: "$set", Value: bson.M{"allowed": bson.M{"$gte": bson.A{"$tokens", 1}}}}},
+		{{Key: "$set", Value: bson.M{
+			"tokens": bson.M{"$cond": bson.A{"$allowed", bson.M{"$subtract": bson.A{"$tokens", 1}}, "$tokens"}},
+			// Expire the bucket once it would have refilled completely
+			"expires_at": now.Add(fullRefill),
+		}}},
+	}
+
+	var bucket struct {
+		Tokens  float64 `bson:"tokens"`
+		Allowed bool    `bson:"allowed"`
+	}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+	err := rr.collection.FindOneAndUpdate(ctx, bson.M{"_id": key}, pipeline, opts).Decode(&bucket)
+	if err != nil {
+		return utils.RateLimitResult{}, err
+	}
+
+	return utils.NewRateLimitResult(policy, bucket.Tokens, bucket.Allowed), nil
+}
@@ -0,0 +1,208 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrReviewNotFound  = errors.New("review not found")
+	ErrReviewExists    = errors.New("user already reviewed this product")
+	ErrAlreadyReported = errors.New("user already reported this review")
+)
+
+type ReviewRepository struct {
+	collection *mongo.Collection
+}
+
+func NewReviewRepository(client *mongo.Client, config configs.MongoConfig) *ReviewRepository {
+	collection := client.Database(config.Database).Collection("reviews")
+	return &ReviewRepository{
+		collection: collection,
+	}
+}
+
+func (rr *ReviewRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := rr.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "user_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.M{"report_count": -1}},
+	})
+	return err
+}
+
+func (rr *ReviewRepository) CreateReview(ctx context.Context, review *models.Review) error {
+	result, err := rr.collection.InsertOne(ctx, review)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrReviewExists
+		}
+		return err
+	}
+	review.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (rr *ReviewRepository) GetReview(ctx context.Context, productID, id primitive.ObjectID) (*models.Review, error) {
+	var review models.Review
+	err := rr.collection.FindOne(ctx, bson.M{"_id": id, "product_id": productID}).Decode(&review)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+	return &review, nil
+}
+
+// UpdateReview applies update and returns the review as it was just before
+// the write, so callers know exactly what the update changed
+func (rr *ReviewRepository) UpdateReview(ctx context.Context, productID, id primitive.ObjectID, update bson.M) (*models.Review, error) {
+	return rr.modifyReview(ctx, productID, id, bson.M{"$set": update})
+}
+
+// ApproveReview publishes a review and dismisses its reports
+func (rr *ReviewRepository) ApproveReview(ctx context.Context, productID, id primitive.ObjectID) (*models.Review, error) {
+	return rr.modifyReview(ctx, productID, id, bson.M{
+		"$set":   bson.M{"status": models.ReviewPublished, "report_count": 0},
+		"$unset": bson.M{"reports": ""},
+	})
+}
+
+func (rr *ReviewRepository) modifyReview(ctx context.Context, productID, id primitive.ObjectID, update bson.M) (*models.Review, error) {
+	update["$currentDate"] = bson.M{"updated_at": true}
+
+	var before models.Review
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+	err := rr.collection.FindOneAndUpdate(ctx, bson.M{"_id": id, "product_id": productID}, update, opts).Decode(&before)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+	return &before, nil
+}
+
+// DeleteReview removes a review and returns it
+func (rr *ReviewRepository) DeleteReview(ctx context.Context, productID, id primitive.ObjectID) (*models.Review, error) {
+	var review models.Review
+	err := rr.collection.FindOneAndDelete(ctx, bson.M{"_id": id, "product_id": productID}).Decode(&review)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrReviewNotFound
+		}
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (rr *ReviewRepository) DeleteProductReviews(ctx context.Context, productID primitive.ObjectID) error {
+	_, err := rr.collection.DeleteMany(ctx, bson.M{"product_id": productID})
+	return err
+}
+
+// AddReport records a report unless the user already reported the review
+func (rr *ReviewRepository) AddReport(ctx context.Context, productID, id primitive.ObjectID, report models.ReviewReport) error {
+	filter := bson.M{"_id": id, "product_id": productID, "reports.user_id": bson.M{"$ne": report.UserID}}
+	update := bson.M{
+		"$push": bson.M{"reports": report},
+		"$inc":  bson.M{"report_count": 1},
+	}
+
+	result, err := rr.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if result.MatchedCount == 0 {
+		// Tell a missing review apart from a repeated report
+		if _, err := rr.GetReview(ctx, productID, id); err != nil {
+			return err
+		}
+		return ErrAlreadyReported
+	}
+	return nil
+}
+
+// reviewSortFields are the fields reviews can be sorted by
+var reviewSortFields = map[string]string{
+	"rating":       "rating",
+	"report_count": "report_count",
+	"created_at":   "created_at",
+	"updated_at":   "updated_at",
+}
+
+// RatingTotals sums up the published reviews of every product that has some
+func (rr *ReviewRepository) RatingTotals(ctx context.Context) ([]models.RatingTotal, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": models.ReviewPublished}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$product_id",
+			"sum":   bson.M{"$sum": "$rating"},
+			"count": bson.M{"$sum": 1},
+		}}},
+	}
+	cursor, err := rr.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	var totals []models.RatingTotal
+	if err := cursor.All(ctx, &totals); err != nil {
+		return nil, err
+	}
+	return totals, nil
+}
+
+func (rr *ReviewRepository) ListReviews(ctx context.Context, filter models.ReviewFilter, limit int, offset int, sort string) ([]*models.Review, int64, error) {
+	sortDoc, err := parseSort(sort, reviewSortFields)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	query := bson.M{}
+	if filter.ProductID != nil {
+		query["product_id"] = *filter.ProductID
+	}
+	if filter.Status != "" {
+		query["status"] = filter.Status
+	}
+	if filter.Reported {
+		query["report_count"] = bson.M{"$gt": 0}
+	}
+
+	options := options.Find().
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset)).
+		SetSort(sortDoc)
+
+	cursor, err := rr.collection.Find(ctx, query, options)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	reviews := []*models.Review{}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, 0, err
+	}
+
+	// Get total count
+	totalCount, err := rr.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return reviews, totalCount, nil
+}
@@ -0,0 +1,37 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var ErrInvalidSort = errors.New("invalid sort")
+
+// parseSort turns "field", "field asc", "field desc" or "-field" into a sort
+// document. fields maps the public field names to their document paths.
+func parseSort(sort string, fields map[string]string) (bson.D, error) {
+	name, direction, _ := strings.Cut(strings.TrimSpace(sort), " ")
+	order := 1
+	if strings.HasPrefix(name, "-") {
+		name = strings.TrimPrefix(name, "-")
+		order = -1
+	}
+	switch strings.ToLower(strings.TrimSpace(direction)) {
+	case "", "asc":
+	case "desc":
+		order = -1
+	default:
+		return nil, fmt.Errorf("%w direction %q", ErrInvalidSort, direction)
+	}
+
+	path, ok := fields[name]
+	if !ok {
+		return nil, fmt.Errorf("%w field %q", ErrInvalidSort, name)
+	}
+
+	// Break ties by id so pages are stable
+	return bson.D{{Key: path, Value: order}, {Key: "_id", Value: order}}, nil
+}
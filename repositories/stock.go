@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// availableQuantity is the stock that is not reserved yet
+var availableQuantity = bson.M{"$subtract": bson.A{"$stock_quantity", "$reserved"}}
+
+// stockChange is a conditional update of stock_quantity and reserved, shared
+// by products and variants
+type stockChange struct {
+	condition bson.M
+	update    bson.M
+}
+
+func adjustStockChange(delta int) stockChange {
+	condition := bson.M{}
+	if delta < 0 {
+		condition["$expr"] = bson.M{"$gte": bson.A{availableQuantity, -delta}}
+	}
+	return stockChange{
+		condition: condition,
+		update:    bson.M{"$inc": bson.M{"stock_quantity": delta}},
+	}
+}
+
+func reserveStockChange(quantity int) stockChange {
+	return stockChange{
+		condition: bson.M{"$expr": bson.M{"$gte": bson.A{availableQuantity, quantity}}},
+		update:    bson.M{"$inc": bson.M{"reserved": quantity}},
+	}
+}
+
+func releaseStockChange(quantity int) stockChange {
+	return stockChange{
+		condition: bson.M{"reserved": bson.M{"$gte": quantity}},
+		update:    bson.M{"$inc": bson.M{"reserved": -quantity}},
+	}
+}
+
+func commitStockChange(quantity int) stockChange {
+	return stockChange{
+		condition: bson.M{"reserved": bson.M{"$gte": quantity}},
+		update:    bson.M{"$inc": bson.M{"reserved": -quantity, "stock_quantity": -quantity}},
+	}
+}
+
+// applyStockChange applies the change to the document with the given id and
+// decodes the updated document into result. The condition and the increment
+// are evaluated atomically so concurrent requests can't oversell. It returns
+// mongo.ErrNoDocuments if there is no such document and ErrInsufficientStock
+// if the condition failed.
+func applyStockChange(ctx context.Context, collection *mongo.Collection, id primitive.ObjectID, change stockChange, result interface{}) error {
+	filter := bson.M{"_id": id}
+	for key, value := range change.condition {
+		filter[key] = value
+	}
+	change.update["$set"] = bson.M{"updated_at": time.Now()}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := collection.FindOneAndUpdate(ctx, filter, change.update, opts).Decode(result)
+	if err != mongo.ErrNoDocuments {
+		return err
+	}
+
+	count, err := collection.CountDocuments(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return mongo.ErrNoDocuments
+	}
+	return ErrInsufficientStock
+}
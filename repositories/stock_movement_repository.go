@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type StockMovementRepository struct {
+	collection *mongo.Collection
+}
+
+func NewStockMovementRepository(client *mongo.Client, config configs.MongoConfig) *StockMovementRepository {
+	collection := client.Database(config.Database).Collection("stock_movements")
+	return &StockMovementRepository{
+		collection: collection,
+	}
+}
+
+func (sr *StockMovementRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := sr.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "product_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	return err
+}
+
+func (sr *StockMovementRepository) CreateMovement(ctx context.Context, movement *models.StockMovement) error {
+	result, err := sr.collection.InsertOne(ctx, movement)
+	if err != nil {
+		return err
+	}
+	movement.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (sr *StockMovementRepository) ListMovements(ctx context.Context, productID primitive.ObjectID, limit int, offset int) ([]*models.StockMovement, int64, error) {
+	filter := bson.M{"product_id": productID}
+	options := options.Find().
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset)).
+		SetSort(bson.M{"created_at": -1})
+
+	cursor, err := sr.collection.Find(ctx, filter, options)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	var movements []*models.StockMovement
+	if err := cursor.All(ctx, &movements); err != nil {
+		return nil, 0, err
+	}
+
+	// Get total count
+	totalCount, err := sr.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return movements, totalCount, nil
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"sync"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// Transactions runs writes in MongoDB transactions. Transactions need a
+// replica set or a sharded cluster, a standalone server doesn't support them.
+// With mongo.transactions off they're never used.
+type Transactions struct {
+	client   *mongo.Client
+	disabled bool
+
+	mu        sync.Mutex
+	checked   bool
+	supported bool
+}
+
+func NewTransactions(client *mongo.Client, config configs.MongoConfig) *Transactions {
+	return &Transactions{
+		client:   client,
+		disabled: config.Transactions == "off",
+	}
+}
+
+// Supported reports whether the deployment supports transactions, the answer is cached after the first check
+func (t *Transactions) Supported(ctx context.Context) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.disabled {
+		return false, nil
+	}
+	if t.checked {
+		return t.supported, nil
+	}
+
+	var hello struct {
+		SetName string `bson:"setName"`
+		Msg     string `bson:"msg"`
+	}
+	if err := t.client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		return false, err
+	}
+
+	// Replica set members report their set name, mongos routers report isdbgrid
+	t.supported = hello.SetName != "" || hello.Msg == "isdbgrid"
+	t.checked = true
+	return t.supported, nil
+}
+
+// Run calls fn in a transaction that is committed if fn returns nil and
+// aborted otherwise. fn must do all its reads and writes with the context it
+// gets, and may be called again if the transaction hits a transient error.
+func (t *Transactions) Run(ctx context.Context, fn func(ctx context.Context) error) error {
+	session, err := t.client.StartSession()
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	_, err = session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return nil, fn(sessionCtx)
+	})
+	return err
+}
+
+// RunIfSupported calls fn in a transaction when the deployment supports
+// them, and directly otherwise. Callers must accept that without a
+// transaction the writes of a failed fn aren't rolled back.
+func (t *Transactions) RunIfSupported(ctx context.Context, fn func(ctx context.Context) error) error {
+	supported, err := t.Supported(ctx)
+	if err != nil {
+		return err
+	}
+	if !supported {
+		return fn(ctx)
+	}
+	return t.Run(ctx, fn)
+}
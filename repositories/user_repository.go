@@ -18,17 +18,20 @@ type UserRepository struct {
 	collection *mongo.Collection
 }
 
-func NewUserRepository(client *mongo.Client) *UserRepository {
-	dbName := configs.GetDatabaseName()
-	collection := client.Database(dbName).Collection("users")
+func NewUserRepository(client *mongo.Client, config configs.MongoConfig) *UserRepository {
+	collection := client.Database(config.Database).Collection("users")
 	return &UserRepository{
 		collection: collection,
 	}
 }
 
 func (ur *UserRepository) CreateUser(ctx context.Context, user *models.User) error {
-	_, err := ur.collection.InsertOne(ctx, user)
-	return err
+	result, err := ur.collection.InsertOne(ctx, user)
+	if err != nil {
+		return err
+	}
+	user.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
 }
 
 func (ur *UserRepository) GetUser(ctx context.Context, id primitive.ObjectID) (*models.User, error) {
@@ -43,6 +46,23 @@ func (ur *UserRepository) GetUser(ctx context.Context, id primitive.ObjectID) (*
 	return &user, err
 }
 
+// GetUsersByIDs returns the users with the given ids in no particular order,
+// ids without a user are left out. Password hashes are not loaded.
+func (ur *UserRepository) GetUsersByIDs(ctx context.Context, ids []primitive.ObjectID) ([]*models.User, error) {
+	opts := options.Find().SetProjection(bson.M{"password": 0})
+	cursor, err := ur.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	users := []*models.User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
 func (ur *UserRepository) UpdateUser(ctx context.Context, id primitive.ObjectID, update bson.M) (*models.User, error) {
 	filter := bson.M{"_id": id}
 	updateDoc := bson.M{
@@ -105,6 +125,31 @@ func (ur *UserRepository) ListUsers(ctx context.Context, limit int, offset int,
 	return users, totalCount, nil
 }
 
+// StreamUsers calls fn for each user in the order they signed up. The
+// password hash is left out of the query, so it never leaves the database.
+func (ur *UserRepository) StreamUsers(ctx context.Context, fn func(*models.User) error) error {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetProjection(bson.M{"password": 0})
+
+	cursor, err := ur.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var user models.User
+		if err := cursor.Decode(&user); err != nil {
+			return err
+		}
+		if err := fn(&user); err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
 func (ur *UserRepository) GetUserByEmail(email string) (*models.User, error) {
 	var user models.User
 	err := ur.collection.FindOne(context.Background(), bson.M{"email": email}).Decode(&user)
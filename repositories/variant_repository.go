@@ -0,0 +1,190 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	ErrVariantNotFound = errors.New("variant not found")
+	ErrSKUTaken        = errors.New("sku already exists")
+	ErrVariantExists   = errors.New("a variant with these options already exists")
+)
+
+const (
+	skuIndexName       = "sku_unique"
+	optionKeyIndexName = "product_option_key_unique"
+)
+
+type VariantRepository struct {
+	collection *mongo.Collection
+}
+
+func NewVariantRepository(client *mongo.Client, config configs.MongoConfig) *VariantRepository {
+	collection := client.Database(config.Database).Collection("variants")
+	return &VariantRepository{
+		collection: collection,
+	}
+}
+
+func (vr *VariantRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := vr.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.M{"sku": 1},
+			Options: options.Index().SetUnique(true).SetName(skuIndexName),
+		},
+		{
+			Keys:    bson.D{{Key: "product_id", Value: 1}, {Key: "option_key", Value: 1}},
+			Options: options.Index().SetUnique(true).SetName(optionKeyIndexName),
+		},
+	})
+	return err
+}
+
+func (vr *VariantRepository) CreateVariant(ctx context.Context, variant *models.Variant) error {
+	result, err := vr.collection.InsertOne(ctx, variant)
+	if err != nil {
+		return duplicateVariantError(err)
+	}
+	variant.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (vr *VariantRepository) GetVariant(ctx context.Context, productID, id primitive.ObjectID) (*models.Variant, error) {
+	return vr.findVariant(ctx, bson.M{"_id": id, "product_id": productID})
+}
+
+func (vr *VariantRepository) GetVariantBySKU(ctx context.Context, sku string) (*models.Variant, error) {
+	return vr.findVariant(ctx, bson.M{"sku": sku})
+}
+
+func (vr *VariantRepository) findVariant(ctx context.Context, filter bson.M) (*models.Variant, error) {
+	var variant models.Variant
+	err := vr.collection.FindOne(ctx, filter).Decode(&variant)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrVariantNotFound
+		}
+		return nil, err
+	}
+	return &variant, nil
+}
+
+func (vr *VariantRepository) ListVariants(ctx context.Context, productID primitive.ObjectID) ([]*models.Variant, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "option_key", Value: 1}})
+	cursor, err := vr.collection.Find(ctx, bson.M{"product_id": productID}, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	variants := []*models.Variant{}
+	if err := cursor.All(ctx, &variants); err != nil {
+		return nil, err
+	}
+	return variants, nil
+}
+
+func (vr *VariantRepository) GetVariantsBySKUs(ctx context.Context, skus []string) (map[string]*models.Variant, error) {
+	cursor, err := vr.collection.Find(ctx, bson.M{"sku": bson.M{"$in": skus}})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	variants := make(map[string]*models.Variant, len(skus))
+	for cursor.Next(ctx) {
+		var variant models.Variant
+		if err := cursor.Decode(&variant); err != nil {
+			return nil, err
+		}
+		variants[variant.SKU] = &variant
+	}
+
+	return variants, cursor.Err()
+}
+
+func (vr *VariantRepository) UpdateVariant(ctx context.Context, productID, id primitive.ObjectID, update bson.M) (*models.Variant, error) {
+	result, err := vr.collection.UpdateOne(ctx, bson.M{"_id": id, "product_id": productID}, bson.M{"$set": update})
+	if err != nil {
+		return nil, duplicateVariantError(err)
+	}
+	if result.MatchedCount == 0 {
+		return nil, ErrVariantNotFound
+	}
+
+	return vr.GetVariant(ctx, productID, id)
+}
+
+func (vr *VariantRepository) DeleteVariant(ctx context.Context, productID, id primitive.ObjectID) error {
+	result, err := vr.collection.DeleteOne(ctx, bson.M{"_id": id, "product_id": productID})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrVariantNotFound
+	}
+	return nil
+}
+
+// DeleteProductVariants removes all variants of a deleted product
+func (vr *VariantRepository) DeleteProductVariants(ctx context.Context, productID primitive.ObjectID) error {
+	_, err := vr.collection.DeleteMany(ctx, bson.M{"product_id": productID})
+	return err
+}
+
+// AdjustStock adds delta to the stock on hand of the variant with the given SKU
+func (vr *VariantRepository) AdjustStock(ctx context.Context, sku string, delta int) (*models.Variant, error) {
+	return vr.updateStock(ctx, sku, adjustStockChange(delta))
+}
+
+func (vr *VariantRepository) ReserveStock(ctx context.Context, sku string, quantity int) (*models.Variant, error) {
+	return vr.updateStock(ctx, sku, reserveStockChange(quantity))
+}
+
+func (vr *VariantRepository) ReleaseStock(ctx context.Context, sku string, quantity int) (*models.Variant, error) {
+	return vr.updateStock(ctx, sku, releaseStockChange(quantity))
+}
+
+func (vr *VariantRepository) CommitStock(ctx context.Context, sku string, quantity int) (*models.Variant, error) {
+	return vr.updateStock(ctx, sku, commitStockChange(quantity))
+}
+
+func (vr *VariantRepository) updateStock(ctx context.Context, sku string, change stockChange) (*models.Variant, error) {
+	variant, err := vr.GetVariantBySKU(ctx, sku)
+	if err != nil {
+		return nil, err
+	}
+
+	var updated models.Variant
+	err = applyStockChange(ctx, vr.collection, variant.ID, change, &updated)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// duplicateVariantError tells which unique index a write violated
+func duplicateVariantError(err error) error {
+	if !mongo.IsDuplicateKeyError(err) {
+		return err
+	}
+	if strings.Contains(err.Error(), skuIndexName) {
+		return ErrSKUTaken
+	}
+	if strings.Contains(err.Error(), optionKeyIndexName) {
+		return ErrVariantExists
+	}
+	return err
+}
@@ -0,0 +1,173 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrDeliveryNotFound = errors.New("webhook delivery not found")
+
+// maxDeliveryAttempts bounds the attempts kept in a delivery's log
+const maxDeliveryAttempts = 50
+
+type WebhookDeliveryRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWebhookDeliveryRepository(client *mongo.Client, config configs.MongoConfig) *WebhookDeliveryRepository {
+	collection := client.Database(config.Database).Collection("webhook_deliveries")
+	return &WebhookDeliveryRepository{
+		collection: collection,
+	}
+}
+
+func (wdr *WebhookDeliveryRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := wdr.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "status", Value: 1}, {Key: "next_attempt_at", Value: 1}}},
+		{Keys: bson.D{{Key: "webhook_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		{Keys: bson.M{"event_id": 1}},
+	})
+	return err
+}
+
+func (wdr *WebhookDeliveryRepository) CreateDeliveries(ctx context.Context, deliveries []*models.WebhookDelivery) error {
+	documents := make([]interface{}, len(deliveries))
+	for i, delivery := range deliveries {
+		documents[i] = delivery
+	}
+	result, err := wdr.collection.InsertMany(ctx, documents)
+	if err != nil {
+		return err
+	}
+	for i, id := range result.InsertedIDs {
+		deliveries[i].ID = id.(primitive.ObjectID)
+	}
+	return nil
+}
+
+// EventWebhookIDs returns the webhooks that have a delivery of the event, not counting redeliveries
+func (wdr *WebhookDeliveryRepository) EventWebhookIDs(ctx context.Context, eventID string) ([]primitive.ObjectID, error) {
+	values, err := wdr.collection.Distinct(ctx, "webhook_id", bson.M{"event_id": eventID, "redelivery_of": bson.M{"$exists": false}})
+	if err != nil {
+		return nil, err
+	}
+	webhookIDs := make([]primitive.ObjectID, 0, len(values))
+	for _, value := range values {
+		if id, ok := value.(primitive.ObjectID); ok {
+			webhookIDs = append(webhookIDs, id)
+		}
+	}
+	return webhookIDs, nil
+}
+
+func (wdr *WebhookDeliveryRepository) GetDelivery(ctx context.Context, webhookID, id primitive.ObjectID) (*models.WebhookDelivery, error) {
+	var delivery models.WebhookDelivery
+	err := wdr.collection.FindOne(ctx, bson.M{"_id": id, "webhook_id": webhookID}).Decode(&delivery)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrDeliveryNotFound
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ListDeliveries returns the webhook's deliveries, newest first
+func (wdr *WebhookDeliveryRepository) ListDeliveries(ctx context.Context, webhookID primitive.ObjectID, status string, limit int, offset int) ([]*models.WebhookDelivery, int64, error) {
+	query := bson.M{"webhook_id": webhookID}
+	if status != "" {
+		query["status"] = status
+	}
+
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := wdr.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	deliveries := []*models.WebhookDelivery{}
+	if err := cursor.All(ctx, &deliveries); err != nil {
+		return nil, 0, err
+	}
+
+	totalCount, err := wdr.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, totalCount, nil
+}
+
+// ClaimDue takes the pending delivery that is due the longest, or returns nil
+// if none is due. It's leased until lease has passed, so other workers and
+// instances skip it and it's picked up again if this one stops before
+// recording the attempt.
+func (wdr *WebhookDeliveryRepository) ClaimDue(ctx context.Context, lease time.Duration) (*models.WebhookDelivery, error) {
+	now := time.Now()
+	filter := bson.M{"status": models.DeliveryPending, "next_attempt_at": bson.M{"$lte": now}}
+	update := bson.M{"$set": bson.M{"next_attempt_at": now.Add(lease)}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.M{"next_attempt_at": 1}).
+		SetReturnDocument(options.After)
+
+	var delivery models.WebhookDelivery
+	err := wdr.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&delivery)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// RecordAttempt adds the attempt to the delivery's log. Pending deliveries
+// are retried at nextAttemptAt.
+func (wdr *WebhookDeliveryRepository) RecordAttempt(ctx context.Context, id primitive.ObjectID, attempt models.DeliveryAttempt, status string, nextAttemptAt *time.Time) error {
+	update := bson.M{
+		"$set": bson.M{"status": status, "updated_at": time.Now()},
+		"$inc": bson.M{"attempt_count": 1},
+		"$push": bson.M{"attempts": bson.M{
+			"$each":  bson.A{attempt},
+			"$slice": -maxDeliveryAttempts,
+		}},
+	}
+	if nextAttemptAt != nil {
+		update["$set"].(bson.M)["next_attempt_at"] = *nextAttemptAt
+	} else {
+		update["$unset"] = bson.M{"next_attempt_at": ""}
+	}
+
+	_, err := wdr.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	return err
+}
+
+// DeleteFinishedBefore deletes the succeeded and failed deliveries last updated before the given time
+func (wdr *WebhookDeliveryRepository) DeleteFinishedBefore(ctx context.Context, before time.Time) (int64, error) {
+	result, err := wdr.collection.DeleteMany(ctx, bson.M{
+		"status":     bson.M{"$in": bson.A{models.DeliverySucceeded, models.DeliveryFailed}},
+		"updated_at": bson.M{"$lt": before},
+	})
+	if err != nil {
+		return 0, err
+	}
+	return result.DeletedCount, nil
+}
+
+func (wdr *WebhookDeliveryRepository) DeleteWebhookDeliveries(ctx context.Context, webhookID primitive.ObjectID) error {
+	_, err := wdr.collection.DeleteMany(ctx, bson.M{"webhook_id": webhookID})
+	return err
+}
@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var ErrWebhookNotFound = errors.New("webhook not found")
+
+type WebhookRepository struct {
+	collection *mongo.Collection
+}
+
+func NewWebhookRepository(client *mongo.Client, config configs.MongoConfig) *WebhookRepository {
+	collection := client.Database(config.Database).Collection("webhooks")
+	return &WebhookRepository{
+		collection: collection,
+	}
+}
+
+func (wr *WebhookRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := wr.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "events", Value: 1}, {Key: "active", Value: 1}},
+	})
+	return err
+}
+
+func (wr *WebhookRepository) CreateWebhook(ctx context.Context, webhook *models.Webhook) error {
+	result, err := wr.collection.InsertOne(ctx, webhook)
+	if err != nil {
+		return err
+	}
+	webhook.ID = result.InsertedID.(primitive.ObjectID)
+	return nil
+}
+
+func (wr *WebhookRepository) GetWebhook(ctx context.Context, id primitive.ObjectID) (*models.Webhook, error) {
+	var webhook models.Webhook
+	err := wr.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&webhook)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (wr *WebhookRepository) ListWebhooks(ctx context.Context, limit int, offset int) ([]*models.Webhook, int64, error) {
+	opts := options.Find().
+		SetLimit(int64(limit)).
+		SetSkip(int64(offset)).
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}})
+
+	cursor, err := wr.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []*models.Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, 0, err
+	}
+
+	totalCount, err := wr.collection.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return webhooks, totalCount, nil
+}
+
+// ListSubscribers returns the active webhooks subscribed to event
+func (wr *WebhookRepository) ListSubscribers(ctx context.Context, event string) ([]*models.Webhook, error) {
+	cursor, err := wr.collection.Find(ctx, bson.M{"events": event, "active": true})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	webhooks := []*models.Webhook{}
+	if err := cursor.All(ctx, &webhooks); err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func (wr *WebhookRepository) UpdateWebhook(ctx context.Context, id primitive.ObjectID, update bson.M) (*models.Webhook, error) {
+	update["updated_at"] = time.Now()
+
+	var webhook models.Webhook
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := wr.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, bson.M{"$set": update}, opts).Decode(&webhook)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrWebhookNotFound
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func (wr *WebhookRepository) DeleteWebhook(ctx context.Context, id primitive.ObjectID) error {
+	result, err := wr.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if result.DeletedCount == 0 {
+		return ErrWebhookNotFound
+	}
+	return nil
+}
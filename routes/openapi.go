@@ -0,0 +1,215 @@
+package routes
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/openapi"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+)
+
+// OpenAPI generates the API's OpenAPI document from the registered routes.
+// Every route needs an entry in Documentation, the error lists those without one.
+func OpenAPI(routes gin.RoutesInfo) (*openapi.Document, error) {
+	schemas := openapi.NewSchemas()
+	schemas.Define(models.Money{}, openapi.Object(map[string]*openapi.Schema{
+		"amount":   {Type: openapi.SchemaType{"string"}, Pattern: `^-?\d+(\.\d+)?$`, Description: "Decimal amount, e.g. 19.99"},
+		"currency": {Type: openapi.SchemaType{"string"}, Pattern: "^[A-Z]{3}$", Description: "ISO 4217 currency code"},
+	}, "amount", "currency"))
+	schemas.Extend(models.ProductBatchOperation{}, "product", &openapi.Schema{
+		AnyOf:       []*openapi.Schema{schemas.For(models.CreateProductInput{}), schemas.For(models.UpdateProductInput{})},
+		Description: "The product to create, or the fields to update",
+	})
+	schemas.Extend(models.Job{}, "payload", &openapi.Schema{Description: "The job's JSON payload"})
+	schemas.Extend(models.WebhookDelivery{}, "payload", &openapi.Schema{Description: "The JSON body sent to the webhook"})
+
+	generator := &openapi.Generator{
+		Info: openapi.Info{
+			Title:       "Golang Gin CRUD API",
+			Version:     "1.0.0",
+			Description: "Generated from the registered routes and the request and response types.",
+		},
+		Schemas:  schemas,
+		Envelope: utils.APIResponse{},
+		Page:     utils.PaginatedResponse{},
+	}
+	return generator.Generate(routes, Documentation())
+}
+
+func query(name, description string, schema *openapi.Schema) *openapi.Parameter {
+	return &openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func enum(values ...string) *openapi.Schema {
+	schema := openapi.String()
+	for _, value := range values {
+		schema.Enum = append(schema.Enum, value)
+	}
+	return schema
+}
+
+func positive() *openapi.Schema {
+	minimum := 1.0
+	return &openapi.Schema{Type: openapi.SchemaType{"integer"}, Minimum: &minimum}
+}
+
+// paginated adds the page, limit and sort parameters of utils.GeneratePaginationFromRequest
+func paginated(parameters ...*openapi.Parameter) []*openapi.Parameter {
+	return append(slices.Clone(parameters),
+		query("page", "Page number, starting at 1", positive()),
+		query("limit", "Items per page, 10 by default", positive()),
+		query("sort", `Sort order, e.g. "created_at desc"`, openapi.String()),
+	)
+}
+
+var productFilterParameters = []*openapi.Parameter{
+	query("category", "Category id or slug, includes its subcategories", openapi.String()),
+	query("sku", "SKU of one of the product's variants", openapi.String()),
+	query("currency", "Currency of min_price and max_price", openapi.String()),
+	query("min_price", "Lowest price as a decimal amount", openapi.String()),
+	query("max_price", "Highest price as a decimal amount", openapi.String()),
+}
+
+func exportParameters(parameters ...*openapi.Parameter) []*openapi.Parameter {
+	return append(slices.Clone(parameters),
+		query("format", "File format, csv by default", enum(utils.ExportCSV, utils.ExportNDJSON, utils.ExportXLSX)),
+		query("columns", "Comma-separated columns to include, all by default", openapi.String()),
+	)
+}
+
+var skuParameter = query("sku", "SKU of the variant, for products with variants", openapi.String())
+
+// Documentation describes every route SetupRoutes can register
+func Documentation() []openapi.Route {
+	csrfToken := openapi.Object(map[string]*openapi.Schema{
+		"csrf_token":  openapi.String(),
+		"header_name": openapi.String(),
+	}, "csrf_token", "header_name")
+	graphqlResult := openapi.Object(map[string]*openapi.Schema{
+		"data":   {},
+		"errors": openapi.ArrayOf(&openapi.Schema{}),
+	})
+	imageForm := openapi.Object(map[string]*openapi.Schema{
+		"image": {Type: openapi.SchemaType{"string"}, Format: "binary"},
+	}, "image")
+	importForm := openapi.Object(map[string]*openapi.Schema{
+		"file":    {Type: openapi.SchemaType{"string"}, Format: "binary"},
+		"format":  enum(utils.ImportCSV, utils.ImportNDJSON),
+		"mode":    enum(models.ImportDryRun, models.ImportCommit),
+		"mapping": {Type: openapi.SchemaType{"string"}, Description: "JSON object mapping column names to product fields"},
+	}, "file")
+
+	return []openapi.Route{
+		// Documentation
+		{Method: http.MethodGet, Path: "/openapi.json", Summary: "Get this OpenAPI document", Tag: "Docs", Raw: true, Data: &openapi.Schema{Type: openapi.SchemaType{"object"}}},
+		{Method: http.MethodGet, Path: "/docs", Summary: "Browse the API documentation", Tag: "Docs", Content: "text/html"},
+
+		// Authentication
+		{Method: http.MethodPost, Path: "/api/v1/register", Summary: "Register a user", Tag: "Auth", Idempotent: true, Body: models.CreateUserInput{}, Status: http.StatusCreated, Data: models.UserResponse{}},
+		{Method: http.MethodPost, Path: "/api/v1/login", Summary: "Log in, setting the auth cookies", Tag: "Auth", Body: models.LoginRequest{}},
+		{Method: http.MethodPost, Path: "/api/v1/logout", Summary: "Log out, clearing the auth cookies", Tag: "Auth"},
+		{Method: http.MethodPost, Path: "/api/v1/refresh", Summary: "Issue a new access token from the refresh token cookie", Tag: "Auth"},
+		{Method: http.MethodGet, Path: "/api/v1/csrf-token", Summary: "Get a CSRF token for cookie-authenticated requests", Tag: "Auth", Data: csrfToken},
+
+		// Users
+		{Method: http.MethodGet, Path: "/api/v1/users/", Summary: "List users", Tag: "Users", Access: openapi.Admin, Parameters: paginated(), Data: models.UserResponse{}, Paginated: true},
+		{Method: http.MethodGet, Path: "/api/v1/users/export", Summary: "Export users", Tag: "Users", Access: openapi.Admin, Parameters: exportParameters(), Content: "application/octet-stream"},
+		{Method: http.MethodGet, Path: "/api/v1/users/:id", Summary: "Get a user", Tag: "Users", Access: openapi.Authenticated, Data: models.UserResponse{}},
+		{Method: http.MethodPut, Path: "/api/v1/users/:id", Summary: "Update a user", Tag: "Users", Access: openapi.Authenticated, Idempotent: true, Body: models.UpdateUserInput{}, Data: models.UserResponse{}},
+		{Method: http.MethodDelete, Path: "/api/v1/users/:id", Summary: "Delete a user", Tag: "Users", Access: openapi.Authenticated, Idempotent: true},
+
+		// Products
+		{Method: http.MethodPost, Path: "/api/v1/products/", Summary: "Create a product", Tag: "Products", Access: openapi.Authenticated, Idempotent: true, Body: models.CreateProductInput{}, Status: http.StatusCreated, Data: models.ProductResponse{}},
+		{Method: http.MethodGet, Path: "/api/v1/products/", Summary: "List products", Tag: "Products", Access: openapi.Authenticated, Parameters: paginated(productFilterParameters...), Data: models.ProductResponse{}, Paginated: true},
+		{Method: http.MethodGet, Path: "/api/v1/products/export", Summary: "Export products", Tag: "Products", Access: openapi.Authenticated, Parameters: exportParameters(productFilterParameters...), Content: "application/octet-stream"},
+		{Method: http.MethodGet, Path: "/api/v1/products/events", Summary: "Stream product changes as server-sent events", Tag: "Products", Access: openapi.Authenticated,
+			Parameters: []*openapi.Parameter{{Name: "Last-Event-ID", In: "header", Description: "Resume after this event", Schema: openapi.String()}}, Content: "text/event-stream"},
+		{Method: http.MethodGet, Path: "/api/v1/products/:id", Summary: "Get a product with its variants and images", Tag: "Products", Access: openapi.Authenticated, Data: models.ProductResponse{}},
+		{Method: http.MethodPut, Path: "/api/v1/products/:id", Summary: "Update a product", Tag: "Products", Access: openapi.Authenticated, Idempotent: true, Body: models.UpdateProductInput{}, Data: models.ProductResponse{}},
+		{Method: http.MethodDelete, Path: "/api/v1/products/:id", Summary: "Delete a product", Tag: "Products", Access: openapi.Authenticated, Idempotent: true},
+		{Method: http.MethodPost, Path: "/api/v1/products/import", Summary: "Import products from a CSV or NDJSON file", Tag: "Products", Access: openapi.Admin, Idempotent: true, Form: importForm, Data: models.ImportReport{}},
+		{Method: http.MethodGet, Path: "/api/v1/products/imports/:jobId", Summary: "Get a background import job", Tag: "Products", Access: openapi.Admin, Data: models.ImportJob{}},
+		{Method: http.MethodPost, Path: "/api/v1/products/batch", Summary: "Create, update and delete products in one request", Tag: "Products", Access: openapi.Admin, Idempotent: true, Body: models.ProductBatchRequest{}, Data: models.ProductBatchResponse{}},
+
+		// Variants
+		{Method: http.MethodGet, Path: "/api/v1/products/:id/variants", Summary: "List a product's variants", Tag: "Variants", Access: openapi.Authenticated, Data: []models.VariantResponse{}},
+		{Method: http.MethodGet, Path: "/api/v1/products/:id/variants/:variantId", Summary: "Get a variant", Tag: "Variants", Access: openapi.Authenticated, Data: models.VariantResponse{}},
+		{Method: http.MethodPost, Path: "/api/v1/products/:id/variants", Summary: "Create a variant", Tag: "Variants", Access: openapi.Admin, Idempotent: true, Body: models.CreateVariantInput{}, Status: http.StatusCreated, Data: models.VariantResponse{}},
+		{Method: http.MethodPut, Path: "/api/v1/products/:id/variants/:variantId", Summary: "Update a variant", Tag: "Variants", Access: openapi.Admin, Idempotent: true, Body: models.UpdateVariantInput{}, Data: models.VariantResponse{}},
+		{Method: http.MethodDelete, Path: "/api/v1/products/:id/variants/:variantId", Summary: "Delete a variant", Tag: "Variants", Access: openapi.Admin, Idempotent: true},
+
+		// Images
+		{Method: http.MethodGet, Path: "/api/v1/products/:id/images", Summary: "List a product's images", Tag: "Images", Access: openapi.Authenticated, Data: []models.ProductImage{}},
+		{Method: http.MethodGet, Path: "/api/v1/products/:id/images/:imageId/file", Summary: "Download an image or one of its thumbnails", Tag: "Images", Access: openapi.Authenticated,
+			Parameters: []*openapi.Parameter{query("size", "Longest edge of the thumbnail in pixels", positive())}, Content: "image/*"},
+		{Method: http.MethodPost, Path: "/api/v1/products/:id/images", Summary: "Upload an image", Tag: "Images", Access: openapi.Admin, Idempotent: true, Form: imageForm, Status: http.StatusCreated, Data: models.ProductImage{}},
+		{Method: http.MethodPut, Path: "/api/v1/products/:id/images/order", Summary: "Reorder a product's images", Tag: "Images", Access: openapi.Admin, Idempotent: true, Body: models.ImageOrderRequest{}, Data: []models.ProductImage{}},
+		{Method: http.MethodPut, Path: "/api/v1/products/:id/images/:imageId/primary", Summary: "Make an image the primary one", Tag: "Images", Access: openapi.Admin, Idempotent: true, Data: models.ProductImage{}},
+		{Method: http.MethodDelete, Path: "/api/v1/products/:id/images/:imageId", Summary: "Delete an image", Tag: "Images", Access: openapi.Admin, Idempotent: true},
+
+		// Reviews
+		{Method: http.MethodGet, Path: "/api/v1/products/:id/reviews", Summary: "List a product's reviews", Tag: "Reviews", Access: openapi.Authenticated,
+			Parameters: paginated(query("status", "Admins can list hidden reviews, or all of them", enum(models.ReviewPublished, models.ReviewHidden, "all"))), Data: models.Review{}, Paginated: true},
+		{Method: http.MethodPost, Path: "/api/v1/products/:id/reviews", Summary: "Review a product", Tag: "Reviews", Access: openapi.Authenticated, Idempotent: true, Body: models.ReviewRequest{}, Status: http.StatusCreated, Data: models.Review{}},
+		{Method: http.MethodPut, Path: "/api/v1/products/:id/reviews/:reviewId", Summary: "Edit your review", Tag: "Reviews", Access: openapi.Authenticated, Idempotent: true, Body: models.ReviewUpdateRequest{}, Data: models.Review{}},
+		{Method: http.MethodDelete, Path: "/api/v1/products/:id/reviews/:reviewId", Summary: "Delete a review", Tag: "Reviews", Access: openapi.Authenticated, Idempotent: true},
+		{Method: http.MethodPost, Path: "/api/v1/products/:id/reviews/:reviewId/reports", Summary: "Report a review as abusive", Tag: "Reviews", Access: openapi.Authenticated, Idempotent: true, Body: models.ReviewReportRequest{}, Status: http.StatusCreated},
+		{Method: http.MethodPut, Path: "/api/v1/products/:id/reviews/:reviewId/status", Summary: "Publish or hide a review", Tag: "Reviews", Access: openapi.Admin, Idempotent: true, Body: models.ReviewStatusRequest{}, Data: models.Review{}},
+		{Method: http.MethodGet, Path: "/api/v1/reviews/reported", Summary: "List reported reviews", Tag: "Reviews", Access: openapi.Admin, Parameters: paginated(), Data: models.Review{}, Paginated: true},
+
+		// Inventory
+		{Method: http.MethodGet, Path: "/api/v1/products/low-stock", Summary: "List products at or below their low stock threshold", Tag: "Inventory", Access: openapi.Admin, Parameters: paginated(), Data: models.ProductResponse{}, Paginated: true},
+		{Method: http.MethodGet, Path: "/api/v1/products/:id/stock/movements", Summary: "List a product's stock movements", Tag: "Inventory", Access: openapi.Admin, Parameters: paginated(), Data: models.StockMovement{}, Paginated: true},
+		{Method: http.MethodPost, Path: "/api/v1/products/:id/stock/adjustments", Summary: "Adjust a product's stock", Tag: "Inventory", Access: openapi.Admin, Idempotent: true, Body: models.StockAdjustmentRequest{}, Data: models.ProductResponse{}},
+		{Method: http.MethodPost, Path: "/api/v1/products/:id/stock/reserve", Summary: "Reserve stock", Tag: "Inventory", Access: openapi.Admin, Idempotent: true, Body: models.StockReservationRequest{}, Data: models.ProductResponse{}},
+		{Method: http.MethodPost, Path: "/api/v1/products/:id/stock/release", Summary: "Release reserved stock", Tag: "Inventory", Access: openapi.Admin, Idempotent: true, Body: models.StockReservationRequest{}, Data: models.ProductResponse{}},
+
+		// Categories
+		{Method: http.MethodGet, Path: "/api/v1/categories/", Summary: "List categories", Tag: "Categories", Access: openapi.Authenticated, Data: []models.CategoryResponse{}},
+		{Method: http.MethodGet, Path: "/api/v1/categories/tree", Summary: "Get the category tree with product counts", Tag: "Categories", Access: openapi.Authenticated, Data: []models.CategoryNode{}},
+		{Method: http.MethodGet, Path: "/api/v1/categories/:id", Summary: "Get a category", Tag: "Categories", Access: openapi.Authenticated, Data: models.CategoryResponse{}},
+		{Method: http.MethodPost, Path: "/api/v1/categories/", Summary: "Create a category", Tag: "Categories", Access: openapi.Admin, Idempotent: true, Body: models.CreateCategoryInput{}, Status: http.StatusCreated, Data: models.CategoryResponse{}},
+		{Method: http.MethodPut, Path: "/api/v1/categories/:id", Summary: "Update a category", Tag: "Categories", Access: openapi.Admin, Idempotent: true, Body: models.CategoryUpdateRequest{}, Data: models.CategoryResponse{}},
+		{Method: http.MethodDelete, Path: "/api/v1/categories/:id", Summary: "Delete a category", Tag: "Categories", Access: openapi.Admin, Idempotent: true},
+
+		// Cart
+		{Method: http.MethodGet, Path: "/api/v1/cart/", Summary: "Get your cart", Tag: "Cart", Access: openapi.Authenticated, Data: models.CartView{}},
+		{Method: http.MethodDelete, Path: "/api/v1/cart/", Summary: "Clear your cart", Tag: "Cart", Access: openapi.Authenticated, Idempotent: true},
+		{Method: http.MethodPost, Path: "/api/v1/cart/items", Summary: "Add an item to your cart", Tag: "Cart", Access: openapi.Authenticated, Idempotent: true, Body: models.CartItemRequest{}, Data: models.CartView{}},
+		{Method: http.MethodPut, Path: "/api/v1/cart/items/:productId", Summary: "Change the quantity of an item", Tag: "Cart", Access: openapi.Authenticated, Idempotent: true,
+			Parameters: []*openapi.Parameter{skuParameter}, Body: models.CartItemUpdateRequest{}, Data: models.CartView{}},
+		{Method: http.MethodDelete, Path: "/api/v1/cart/items/:productId", Summary: "Remove an item from your cart", Tag: "Cart", Access: openapi.Authenticated, Idempotent: true,
+			Parameters: []*openapi.Parameter{skuParameter}, Data: models.CartView{}},
+
+		// Orders
+		{Method: http.MethodPost, Path: "/api/v1/orders/", Summary: "Check out your cart", Tag: "Orders", Access: openapi.Authenticated, Idempotent: true, Status: http.StatusCreated, Data: models.Order{}},
+		{Method: http.MethodGet, Path: "/api/v1/orders/", Summary: "List your orders, or every order for admins", Tag: "Orders", Access: openapi.Authenticated, Parameters: paginated(), Data: models.Order{}, Paginated: true},
+		{Method: http.MethodGet, Path: "/api/v1/orders/:id", Summary: "Get an order", Tag: "Orders", Access: openapi.Authenticated, Data: models.Order{}},
+		{Method: http.MethodPost, Path: "/api/v1/orders/:id/cancel", Summary: "Cancel an order", Tag: "Orders", Access: openapi.Authenticated, Idempotent: true, Data: models.Order{}},
+		{Method: http.MethodPut, Path: "/api/v1/orders/:id/status", Summary: "Change an order's status", Tag: "Orders", Access: openapi.Admin, Idempotent: true, Body: models.OrderStatusRequest{}, Data: models.Order{}},
+
+		// Webhooks
+		{Method: http.MethodPost, Path: "/api/v1/webhooks/", Summary: "Create a webhook", Tag: "Webhooks", Access: openapi.Admin, Idempotent: true, Body: models.WebhookRequest{}, Status: http.StatusCreated, Data: models.CreatedWebhook{}},
+		{Method: http.MethodGet, Path: "/api/v1/webhooks/", Summary: "List webhooks", Tag: "Webhooks", Access: openapi.Admin, Parameters: paginated(), Data: models.Webhook{}, Paginated: true},
+		{Method: http.MethodGet, Path: "/api/v1/webhooks/:id", Summary: "Get a webhook", Tag: "Webhooks", Access: openapi.Admin, Data: models.Webhook{}},
+		{Method: http.MethodPut, Path: "/api/v1/webhooks/:id", Summary: "Update a webhook", Tag: "Webhooks", Access: openapi.Admin, Idempotent: true, Body: models.WebhookUpdateRequest{}, Data: models.Webhook{}},
+		{Method: http.MethodDelete, Path: "/api/v1/webhooks/:id", Summary: "Delete a webhook", Tag: "Webhooks", Access: openapi.Admin, Idempotent: true},
+		{Method: http.MethodGet, Path: "/api/v1/webhooks/:id/deliveries", Summary: "List a webhook's deliveries", Tag: "Webhooks", Access: openapi.Admin,
+			Parameters: paginated(query("status", "", enum(models.DeliveryPending, models.DeliverySucceeded, models.DeliveryFailed))), Data: models.WebhookDelivery{}, Paginated: true},
+		{Method: http.MethodGet, Path: "/api/v1/webhooks/:id/deliveries/:deliveryId", Summary: "Get a delivery with its attempts", Tag: "Webhooks", Access: openapi.Admin, Data: models.WebhookDelivery{}},
+		{Method: http.MethodPost, Path: "/api/v1/webhooks/:id/deliveries/:deliveryId/redeliver", Summary: "Send a delivery again", Tag: "Webhooks", Access: openapi.Admin, Idempotent: true, Status: http.StatusAccepted, Data: models.WebhookDelivery{}},
+
+		// Jobs
+		{Method: http.MethodGet, Path: "/api/v1/jobs/", Summary: "List background jobs", Tag: "Jobs", Access: openapi.Admin,
+			Parameters: paginated(query("status", "", enum(models.JobStatuses...)), query("type", "", openapi.String())), Data: models.Job{}, Paginated: true},
+		{Method: http.MethodGet, Path: "/api/v1/jobs/:id", Summary: "Get a job", Tag: "Jobs", Access: openapi.Admin, Data: models.Job{}},
+		{Method: http.MethodPost, Path: "/api/v1/jobs/:id/retry", Summary: "Retry a dead or cancelled job", Tag: "Jobs", Access: openapi.Admin, Idempotent: true, Status: http.StatusAccepted, Data: models.Job{}},
+		{Method: http.MethodPost, Path: "/api/v1/jobs/:id/cancel", Summary: "Cancel a pending job", Tag: "Jobs", Access: openapi.Admin, Idempotent: true, Data: models.Job{}},
+
+		// GraphQL
+		{Method: http.MethodGet, Path: "/api/v1/graphql", Summary: "GraphiQL playground", Tag: "GraphQL", Content: "text/html"},
+		{Method: http.MethodPost, Path: "/api/v1/graphql", Summary: "Run a GraphQL query or mutation", Tag: "GraphQL", Access: openapi.Authenticated, Idempotent: true, Body: models.GraphQLRequest{}, Raw: true, Data: graphqlResult},
+	}
+}
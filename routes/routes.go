@@ -4,25 +4,112 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/harsh-solanki21/golang-gin-crud-api/controllers"
 	"github.com/harsh-solanki21/golang-gin-crud-api/middlewares"
+	"github.com/harsh-solanki21/golang-gin-crud-api/openapi"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
 )
 
-func SetupRoutes(router *gin.Engine, authController *controllers.AuthController, userController *controllers.UserController, productController *controllers.ProductController) {
+type Dependencies struct {
+	JWTManager    *utils.JWTManager
+	CookieOptions utils.CookieOptions
+	RateLimiter   *middlewares.RateLimiter
+	Idempotency   *middlewares.Idempotency
+	// Validation checks requests, and responses in tests, against the API documentation
+	Validation *middlewares.Validation
+	// CSRFManager is nil when CSRF protection is disabled
+	CSRFManager *utils.CSRFManager
+
+	AuthController      *controllers.AuthController
+	UserController      *controllers.UserController
+	ProductController   *controllers.ProductController
+	CSRFController      *controllers.CSRFController
+	InventoryController *controllers.InventoryController
+	CartController      *controllers.CartController
+	OrderController     *controllers.OrderController
+	CategoryController  *controllers.CategoryController
+	VariantController   *controllers.VariantController
+	ImageController     *controllers.ImageController
+	ReviewController    *controllers.ReviewController
+	ImportController    *controllers.ImportController
+	BatchController     *controllers.ProductBatchController
+	WebhookController   *controllers.WebhookController
+	StreamController    *controllers.ProductStreamController
+	JobController       *controllers.JobController
+	// GraphQLController is nil when GraphQL is disabled
+	GraphQLController *controllers.GraphQLController
+	// GraphQLPlayground serves GraphiQL, the config only allows it outside release mode
+	GraphQLPlayground bool
+}
+
+func SetupRoutes(router *gin.Engine, deps Dependencies) {
+	authController := deps.AuthController
+	userController := deps.UserController
+	productController := deps.ProductController
+	inventoryController := deps.InventoryController
+	cartController := deps.CartController
+	orderController := deps.OrderController
+	categoryController := deps.CategoryController
+	variantController := deps.VariantController
+	imageController := deps.ImageController
+	reviewController := deps.ReviewController
+	importController := deps.ImportController
+	batchController := deps.BatchController
+	webhookController := deps.WebhookController
+	streamController := deps.StreamController
+	jobController := deps.JobController
+	graphqlController := deps.GraphQLController
+
+	// Retries of unsafe requests with an Idempotency-Key replay the first
+	// response. It runs after the rate limiters, so replays are limited too.
+	idempotent := deps.Idempotency.Handle()
+
+	// API documentation, generated from the route table once every route is registered
+	spec := openapi.NewSpec(func() (*openapi.Document, error) {
+		return OpenAPI(router.Routes())
+	})
+	docsController := controllers.NewDocsController(spec)
+
+	// Requests that don't match the documentation are rejected before they're
+	// handled or stored for idempotent replays
+	validate := deps.Validation.Handle(spec)
+	router.GET("/openapi.json", docsController.Spec)
+	router.GET("/docs", docsController.UI)
+
+	// Logging out and refreshing use the session cookie without AuthMiddleware,
+	// so they check the CSRF token of requests with a session themselves
+	sessionCSRF := func(c *gin.Context) { c.Next() }
+	if deps.CSRFManager != nil {
+		sessionCSRF = middlewares.CSRFSessionMiddleware(deps.CSRFManager)
+	}
+
 	// Public routes
 	public := router.Group("/api/v1")
+	public.Use(deps.RateLimiter.Auth(), validate)
 	{
-		public.POST("/register", userController.CreateUser)
+		public.POST("/register", idempotent, userController.CreateUser)
 		public.POST("/login", authController.Login)
-		public.POST("/logout", authController.Logout)
+		public.POST("/logout", sessionCSRF, authController.Logout)
+		public.POST("/refresh", sessionCSRF, authController.RefreshToken)
+		if deps.CSRFManager != nil {
+			public.GET("/csrf-token", deps.CSRFController.GetToken)
+		}
+		if graphqlController != nil && deps.GraphQLPlayground {
+			public.GET("/graphql", graphqlController.Playground)
+		}
 	}
 
 	// Protected routes
 	protected := router.Group("/api/v1")
-	protected.Use(middlewares.AuthMiddleware())
+	protected.Use(middlewares.AuthMiddleware(deps.JWTManager, deps.CookieOptions))
+	if deps.CSRFManager != nil {
+		protected.Use(middlewares.CSRFMiddleware(deps.CSRFManager))
+	}
 	{
 		// User routes group
 		users := protected.Group("/users")
+		users.Use(deps.RateLimiter.Users(), validate, idempotent)
 		{
 			users.GET("/", middlewares.AuthorizeMiddleware("admin"), userController.ListUsers)
+			users.GET("/export", middlewares.AuthorizeMiddleware("admin"), userController.ExportUsers)
 			users.GET("/:id", userController.GetUser)
 			users.PUT("/:id", userController.UpdateUser)
 			users.DELETE("/:id", userController.DeleteUser)
@@ -30,12 +117,122 @@ func SetupRoutes(router *gin.Engine, authController *controllers.AuthController,
 
 		// Product routes group
 		products := protected.Group("/products")
+		products.Use(deps.RateLimiter.Products(), validate, idempotent)
 		{
 			products.POST("/", productController.CreateProduct)
 			products.GET("/", productController.ListProducts)
+			products.GET("/export", productController.ExportProducts)
+			products.GET("/events", streamController.StreamEvents)
 			products.GET("/:id", productController.GetProduct)
 			products.PUT("/:id", productController.UpdateProduct)
 			products.DELETE("/:id", productController.DeleteProduct)
+
+			// Bulk import
+			products.POST("/import", middlewares.AuthorizeMiddleware("admin"), importController.ImportProducts)
+			products.GET("/imports/:jobId", middlewares.AuthorizeMiddleware("admin"), importController.GetImportJob)
+
+			// Batch operations
+			products.POST("/batch", middlewares.AuthorizeMiddleware("admin"), batchController.RunBatch)
+
+			// Variants
+			products.GET("/:id/variants", variantController.ListVariants)
+			products.GET("/:id/variants/:variantId", variantController.GetVariant)
+			products.POST("/:id/variants", middlewares.AuthorizeMiddleware("admin"), variantController.CreateVariant)
+			products.PUT("/:id/variants/:variantId", middlewares.AuthorizeMiddleware("admin"), variantController.UpdateVariant)
+			products.DELETE("/:id/variants/:variantId", middlewares.AuthorizeMiddleware("admin"), variantController.DeleteVariant)
+
+			// Images
+			products.GET("/:id/images", imageController.ListImages)
+			products.GET("/:id/images/:imageId/file", imageController.GetImageFile)
+			products.POST("/:id/images", middlewares.AuthorizeMiddleware("admin"), imageController.UploadImage)
+			products.PUT("/:id/images/order", middlewares.AuthorizeMiddleware("admin"), imageController.ReorderImages)
+			products.PUT("/:id/images/:imageId/primary", middlewares.AuthorizeMiddleware("admin"), imageController.SetPrimaryImage)
+			products.DELETE("/:id/images/:imageId", middlewares.AuthorizeMiddleware("admin"), imageController.DeleteImage)
+
+			// Reviews
+			products.GET("/:id/reviews", reviewController.ListReviews)
+			products.POST("/:id/reviews", reviewController.CreateReview)
+			products.PUT("/:id/reviews/:reviewId", reviewController.UpdateReview)
+			products.DELETE("/:id/reviews/:reviewId", reviewController.DeleteReview)
+			products.POST("/:id/reviews/:reviewId/reports", reviewController.ReportReview)
+			products.PUT("/:id/reviews/:reviewId/status", middlewares.AuthorizeMiddleware("admin"), reviewController.ModerateReview)
+
+			// Inventory management
+			products.GET("/low-stock", middlewares.AuthorizeMiddleware("admin"), inventoryController.ListLowStock)
+			products.GET("/:id/stock/movements", middlewares.AuthorizeMiddleware("admin"), inventoryController.ListMovements)
+			products.POST("/:id/stock/adjustments", middlewares.AuthorizeMiddleware("admin"), inventoryController.AdjustStock)
+			products.POST("/:id/stock/reserve", middlewares.AuthorizeMiddleware("admin"), inventoryController.ReserveStock)
+			products.POST("/:id/stock/release", middlewares.AuthorizeMiddleware("admin"), inventoryController.ReleaseStock)
+		}
+
+		// Category routes group
+		categories := protected.Group("/categories")
+		categories.Use(deps.RateLimiter.Products(), validate, idempotent)
+		{
+			categories.GET("/", categoryController.ListCategories)
+			categories.GET("/tree", categoryController.GetCategoryTree)
+			categories.GET("/:id", categoryController.GetCategory)
+			categories.POST("/", middlewares.AuthorizeMiddleware("admin"), categoryController.CreateCategory)
+			categories.PUT("/:id", middlewares.AuthorizeMiddleware("admin"), categoryController.UpdateCategory)
+			categories.DELETE("/:id", middlewares.AuthorizeMiddleware("admin"), categoryController.DeleteCategory)
+		}
+
+		// Review moderation queue
+		reviews := protected.Group("/reviews")
+		reviews.Use(deps.RateLimiter.Products(), validate, idempotent)
+		{
+			reviews.GET("/reported", middlewares.AuthorizeMiddleware("admin"), reviewController.ListReportedReviews)
+		}
+
+		// Cart routes group, the cart always belongs to the authenticated user
+		cart := protected.Group("/cart")
+		cart.Use(deps.RateLimiter.Users(), validate, idempotent)
+		{
+			cart.GET("/", cartController.GetCart)
+			cart.DELETE("/", cartController.ClearCart)
+			cart.POST("/items", cartController.AddItem)
+			cart.PUT("/items/:productId", cartController.UpdateItem)
+			cart.DELETE("/items/:productId", cartController.RemoveItem)
+		}
+
+		// Order routes group
+		orders := protected.Group("/orders")
+		orders.Use(deps.RateLimiter.Users(), validate, idempotent)
+		{
+			orders.POST("/", orderController.CreateOrder)
+			orders.GET("/", orderController.ListOrders)
+			orders.GET("/:id", orderController.GetOrder)
+			orders.POST("/:id/cancel", orderController.CancelOrder)
+			orders.PUT("/:id/status", middlewares.AuthorizeMiddleware("admin"), orderController.UpdateOrderStatus)
+		}
+
+		// Webhook routes group, admins only
+		webhooks := protected.Group("/webhooks")
+		webhooks.Use(deps.RateLimiter.Users(), validate, idempotent, middlewares.AuthorizeMiddleware("admin"))
+		{
+			webhooks.POST("/", webhookController.CreateWebhook)
+			webhooks.GET("/", webhookController.ListWebhooks)
+			webhooks.GET("/:id", webhookController.GetWebhook)
+			webhooks.PUT("/:id", webhookController.UpdateWebhook)
+			webhooks.DELETE("/:id", webhookController.DeleteWebhook)
+			webhooks.GET("/:id/deliveries", webhookController.ListDeliveries)
+			webhooks.GET("/:id/deliveries/:deliveryId", webhookController.GetDelivery)
+			webhooks.POST("/:id/deliveries/:deliveryId/redeliver", webhookController.Redeliver)
+		}
+
+		// Background job routes group, admins only
+		jobs := protected.Group("/jobs")
+		jobs.Use(deps.RateLimiter.Users(), validate, idempotent, middlewares.AuthorizeMiddleware("admin"))
+		{
+			jobs.GET("/", jobController.ListJobs)
+			jobs.GET("/:id", jobController.GetJob)
+			jobs.POST("/:id/retry", jobController.RetryJob)
+			jobs.POST("/:id/cancel", jobController.CancelJob)
+		}
+
+		// GraphQL over users and products, the resolvers authorize like the REST endpoints
+		if graphqlController != nil {
+			protected.POST("/graphql", deps.RateLimiter.Products(), validate, idempotent, graphqlController.Query)
 		}
 	}
 }
@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/rpc/pb"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func productToPB(product *models.Product) *pb.Product {
+	message := &pb.Product{
+		Id:                product.ID.Hex(),
+		Name:              product.Name,
+		Description:       product.Description,
+		Price:             &pb.Money{Amount: product.Price.String(), Currency: product.Price.Currency},
+		CategoryId:        product.CategoryID.Hex(),
+		StockQuantity:     int32(product.StockQuantity),
+		Reserved:          int32(product.Reserved),
+		LowStockThreshold: int32(product.LowStockThreshold),
+		InStock:           product.InStock,
+		VariantCount:      int32(product.VariantCount),
+		AverageRating:     product.AverageRating,
+		ReviewCount:       int32(product.ReviewCount),
+		CreatedAt:         timestamppb.New(product.CreatedAt),
+		UpdatedAt:         timestamppb.New(product.UpdatedAt),
+	}
+	if product.OwnerID != nil {
+		message.OwnerId = product.OwnerID.Hex()
+	}
+	return message
+}
+
+// userToPB leaves out the password hash
+func userToPB(user *models.User) *pb.User {
+	return &pb.User{
+		Id:        user.ID.Hex(),
+		Name:      user.Name,
+		Email:     user.Email,
+		Age:       int32(user.Age),
+		Role:      user.Role,
+		CreatedAt: timestamppb.New(user.CreatedAt),
+		UpdatedAt: timestamppb.New(user.UpdatedAt),
+	}
+}
+
+func paginationFrom(page *pb.PageRequest) utils.Pagination {
+	return utils.Pagination{
+		Page:  int(page.GetPage()),
+		Limit: int(page.GetLimit()),
+		Sort:  page.GetSort(),
+	}
+}
+
+func paginationToPB(pagination utils.PaginationData) *pb.Pagination {
+	return &pb.Pagination{
+		Limit:      int32(pagination.Limit),
+		Page:       int32(pagination.Page),
+		TotalRows:  pagination.TotalRows,
+		TotalPages: int32(pagination.TotalPages),
+	}
+}
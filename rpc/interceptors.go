@@ -0,0 +1,175 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type contextKey int
+
+const claimsKey contextKey = iota
+
+// publicServices can be called without a token, so load balancers and tools
+// can check the server's health and list its services
+var publicServices = []string{"/grpc.health.v1.Health/", "/grpc.reflection."}
+
+func unaryAuth(jwtManager *utils.JWTManager) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := authenticate(ctx, jwtManager, info.FullMethod)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAuth(jwtManager *utils.JWTManager) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := authenticate(stream.Context(), jwtManager, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &authenticatedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context {
+	return s.ctx
+}
+
+// authenticate validates the access token in the authorization metadata,
+// which is sent like the REST API's header: "Bearer <token>"
+func authenticate(ctx context.Context, jwtManager *utils.JWTManager, method string) (context.Context, error) {
+	for _, prefix := range publicServices {
+		if strings.HasPrefix(method, prefix) {
+			return ctx, nil
+		}
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return nil, utils.NewCustomError(http.StatusUnauthorized, "Authentication failed", nil)
+	}
+	scheme, token, found := strings.Cut(values[0], " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return nil, utils.NewCustomError(http.StatusUnauthorized, "Authentication failed", nil)
+	}
+
+	claims, err := jwtManager.ValidateAccessToken(strings.TrimSpace(token))
+	if err != nil {
+		return nil, utils.NewCustomError(http.StatusUnauthorized, "Authentication failed", nil)
+	}
+	return context.WithValue(ctx, claimsKey, claims), nil
+}
+
+func claimsFrom(ctx context.Context) *utils.Claims {
+	if claims, ok := ctx.Value(claimsKey).(*utils.Claims); ok && claims != nil {
+		return claims
+	}
+	return &utils.Claims{}
+}
+
+// unaryErrors turns the errors of the services into gRPC statuses and panics
+// into Internal errors, like the REST API does with HandleError and Recovery
+func unaryErrors(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = toStatus(info.FullMethod, fmt.Errorf("panic: %v", recovered))
+		}
+	}()
+
+	resp, err = handler(ctx, req)
+	if err != nil {
+		return nil, toStatus(info.FullMethod, err)
+	}
+	return resp, nil
+}
+
+func streamErrors(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = toStatus(info.FullMethod, fmt.Errorf("panic: %v", recovered))
+		}
+	}()
+
+	if err := handler(srv, stream); err != nil {
+		return toStatus(info.FullMethod, err)
+	}
+	return nil
+}
+
+// toStatus maps a CustomError's status to the matching gRPC code. Validation
+// errors carry their field violations as BadRequest details. Other errors
+// are logged and hidden from the client.
+func toStatus(method string, err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	customError, ok := err.(*utils.CustomError)
+	if !ok {
+		log.Printf("Error in %s: %v\n", method, err)
+		return status.Error(codes.Internal, "Internal Server Error")
+	}
+
+	st := status.New(StatusCode(customError.StatusCode), customError.Message)
+	if violations, ok := customError.Details.([]map[string]string); ok {
+		badRequest := &errdetails.BadRequest{}
+		for _, violation := range violations {
+			badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+				Field:       violation["field"],
+				Description: violation["message"],
+			})
+		}
+		if withDetails, err := st.WithDetails(badRequest); err == nil {
+			st = withDetails
+		}
+	}
+	return st.Err()
+}
+
+// StatusCode returns the gRPC code matching an HTTP status
+func StatusCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusPreconditionFailed:
+		return codes.FailedPrecondition
+	case http.StatusRequestEntityTooLarge, http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	if httpStatus >= 400 && httpStatus < 500 {
+		return codes.FailedPrecondition
+	}
+	return codes.Internal
+}
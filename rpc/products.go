@@ -0,0 +1,144 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/rpc/pb"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/harsh-solanki21/golang-gin-crud-api/validations"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type productServer struct {
+	pb.UnimplementedProductServiceServer
+	productService *services.ProductService
+}
+
+func (ps *productServer) CreateProduct(ctx context.Context, request *pb.CreateProductRequest) (*pb.Product, error) {
+	product := &models.Product{
+		Name:              request.Name,
+		Description:       request.Description,
+		StockQuantity:     int(request.StockQuantity),
+		LowStockThreshold: int(request.LowStockThreshold),
+	}
+	if err := setPrice(product, request.Price); err != nil {
+		return nil, err
+	}
+	if err := setCategory(product, request.CategoryId); err != nil {
+		return nil, err
+	}
+	if err := validations.ValidateProductCreate(product); err != nil {
+		return nil, utils.NewCustomError(http.StatusBadRequest, "Validation error", err)
+	}
+
+	// The product belongs to the user creating it
+	if ownerID, err := primitive.ObjectIDFromHex(claimsFrom(ctx).UserID); err == nil {
+		product.OwnerID = &ownerID
+	}
+
+	if err := ps.productService.CreateProduct(product); err != nil {
+		return nil, err
+	}
+	return productToPB(product), nil
+}
+
+func (ps *productServer) GetProduct(ctx context.Context, request *pb.GetProductRequest) (*pb.Product, error) {
+	product, err := ps.productService.GetProduct(request.Id)
+	if err != nil {
+		return nil, err
+	}
+	return productToPB(product), nil
+}
+
+func (ps *productServer) UpdateProduct(ctx context.Context, request *pb.UpdateProductRequest) (*pb.Product, error) {
+	product := &models.Product{
+		Name:        request.GetName(),
+		Description: request.GetDescription(),
+	}
+	if request.Price != nil {
+		if err := setPrice(product, request.Price); err != nil {
+			return nil, err
+		}
+	}
+	if request.CategoryId != nil {
+		if err := setCategory(product, *request.CategoryId); err != nil {
+			return nil, err
+		}
+	}
+	input := &models.UpdateProductInput{
+		Name:        product.Name,
+		Description: product.Description,
+		Price:       product.Price,
+		CategoryID:  product.CategoryID,
+	}
+	if request.LowStockThreshold != nil {
+		threshold := int(*request.LowStockThreshold)
+		input.LowStockThreshold = &threshold
+	}
+	if err := validations.ValidateUpdateProductInput(input); err != nil {
+		return nil, utils.NewCustomError(http.StatusBadRequest, "Validation error", err)
+	}
+
+	updatedProduct, err := ps.productService.UpdateProduct(request.Id, input)
+	if err != nil {
+		return nil, err
+	}
+	return productToPB(updatedProduct), nil
+}
+
+func (ps *productServer) DeleteProduct(ctx context.Context, request *pb.DeleteProductRequest) (*emptypb.Empty, error) {
+	if err := ps.productService.DeleteProduct(request.Id); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (ps *productServer) ListProducts(ctx context.Context, request *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	filter, err := models.ParseProductFilter(request.Category, request.Sku, request.Currency, request.MinPrice, request.MaxPrice)
+	if err != nil {
+		return nil, utils.NewCustomError(http.StatusBadRequest, "Invalid filter", err.Error())
+	}
+
+	page, err := ps.productService.ListProducts(paginationFrom(request.Page), filter)
+	if err != nil {
+		return nil, err
+	}
+
+	products, _ := page.Data.([]*models.Product)
+	response := &pb.ListProductsResponse{
+		Products:   make([]*pb.Product, 0, len(products)),
+		Pagination: paginationToPB(page.Pagination),
+	}
+	for _, product := range products {
+		response.Products = append(response.Products, productToPB(product))
+	}
+	return response, nil
+}
+
+func setPrice(product *models.Product, price *pb.Money) error {
+	if price == nil {
+		return nil
+	}
+	money, err := models.ParseMoney(price.Amount, price.Currency)
+	if err != nil {
+		return utils.NewCustomError(http.StatusBadRequest, "Invalid price", err.Error())
+	}
+	product.Price = money
+	return nil
+}
+
+func setCategory(product *models.Product, categoryID string) error {
+	if categoryID == "" {
+		return nil
+	}
+	objectID, err := primitive.ObjectIDFromHex(categoryID)
+	if err != nil {
+		return utils.NewCustomError(http.StatusBadRequest, services.ErrInvalidCategoryIdMessage, err.Error())
+	}
+	product.CategoryID = objectID
+	return nil
+}
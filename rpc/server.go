@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"context"
+	"net"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/rpc/pb"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+// Server serves the product and user services over gRPC. The services are
+// the ones behind the REST API, so both apply the same validation and
+// authorization.
+type Server struct {
+	grpcServer *grpc.Server
+	health     *health.Server
+	config     configs.GRPCConfig
+}
+
+func NewServer(productService *services.ProductService, userService *services.UserService, jwtManager *utils.JWTManager, config configs.GRPCConfig) *Server {
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(unaryErrors, unaryAuth(jwtManager)),
+		grpc.ChainStreamInterceptor(streamErrors, streamAuth(jwtManager)),
+	)
+	pb.RegisterProductServiceServer(grpcServer, &productServer{productService: productService})
+	pb.RegisterUserServiceServer(grpcServer, &userServer{userService: userService})
+
+	healthServer := health.NewServer()
+	healthpb.RegisterHealthServer(grpcServer, healthServer)
+	for service := range grpcServer.GetServiceInfo() {
+		healthServer.SetServingStatus(service, healthpb.HealthCheckResponse_SERVING)
+	}
+
+	if config.Reflection {
+		reflection.Register(grpcServer)
+	}
+
+	return &Server{
+		grpcServer: grpcServer,
+		health:     healthServer,
+		config:     config,
+	}
+}
+
+// Start listens on the configured port and serves in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", ":"+s.config.Port)
+	if err != nil {
+		return err
+	}
+	go s.Serve(listener)
+	return nil
+}
+
+// Serve serves on listener until the server is shut down
+func (s *Server) Serve(listener net.Listener) error {
+	return s.grpcServer.Serve(listener)
+}
+
+// Drain reports every service as not serving, so health-checking clients
+// move to another instance while in-flight calls finish
+func (s *Server) Drain() {
+	s.health.Shutdown()
+}
+
+// Shutdown stops accepting calls and waits for the running ones to finish.
+// If ctx expires first the remaining calls are cancelled.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.Drain()
+
+	done := make(chan struct{})
+	go func() {
+		s.grpcServer.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		s.grpcServer.Stop()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,70 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/rpc/pb"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/harsh-solanki21/golang-gin-crud-api/validations"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type userServer struct {
+	pb.UnimplementedUserServiceServer
+	userService *services.UserService
+}
+
+func (us *userServer) ListUsers(ctx context.Context, request *pb.ListUsersRequest) (*pb.ListUsersResponse, error) {
+	if claimsFrom(ctx).Role != "admin" {
+		return nil, utils.NewCustomError(http.StatusForbidden, "Access denied", nil)
+	}
+
+	page, err := us.userService.ListUsers(paginationFrom(request.Page))
+	if err != nil {
+		return nil, err
+	}
+
+	users, _ := page.Data.([]*models.User)
+	response := &pb.ListUsersResponse{
+		Users:      make([]*pb.User, 0, len(users)),
+		Pagination: paginationToPB(page.Pagination),
+	}
+	for _, user := range users {
+		response.Users = append(response.Users, userToPB(user))
+	}
+	return response, nil
+}
+
+func (us *userServer) GetUser(ctx context.Context, request *pb.GetUserRequest) (*pb.User, error) {
+	user, err := us.userService.GetUser(request.Id)
+	if err != nil {
+		return nil, err
+	}
+	return userToPB(user), nil
+}
+
+func (us *userServer) UpdateUser(ctx context.Context, request *pb.UpdateUserRequest) (*pb.User, error) {
+	input := &models.UpdateUserInput{
+		Name:  request.GetName(),
+		Email: request.GetEmail(),
+	}
+	if err := validations.ValidateUpdateUserInput(input); err != nil {
+		return nil, utils.NewCustomError(http.StatusBadRequest, "Validation error", err)
+	}
+
+	updatedUser, err := us.userService.UpdateUser(request.Id, input.ToUser())
+	if err != nil {
+		return nil, err
+	}
+	return userToPB(updatedUser), nil
+}
+
+func (us *userServer) DeleteUser(ctx context.Context, request *pb.DeleteUserRequest) (*emptypb.Empty, error) {
+	if err := us.userService.DeleteUser(request.Id); err != nil {
+		return nil, err
+	}
+	return &emptypb.Empty{}, nil
+}
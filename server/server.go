@@ -0,0 +1,140 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Options struct {
+	Addr              string
+	ReadTimeout       time.Duration
+	ReadHeaderTimeout time.Duration
+	WriteTimeout      time.Duration
+	IdleTimeout       time.Duration
+	MaxHeaderBytes    int
+	ShutdownTimeout   time.Duration
+	// HookTimeout is the time the shutdown hooks get after the requests drained
+	HookTimeout time.Duration
+}
+
+// ShutdownHook releases a resource once the HTTP server has stopped accepting requests.
+type ShutdownHook func(ctx context.Context) error
+
+type Server struct {
+	httpServer      *http.Server
+	shutdownTimeout time.Duration
+	hookTimeout     time.Duration
+
+	mu    sync.Mutex
+	hooks []ShutdownHook
+}
+
+func New(handler http.Handler, opts Options) *Server {
+	shutdownTimeout := opts.ShutdownTimeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = 15 * time.Second
+	}
+	hookTimeout := opts.HookTimeout
+	if hookTimeout <= 0 {
+		hookTimeout = 10 * time.Second
+	}
+
+	return &Server{
+		httpServer: &http.Server{
+			Addr:              opts.Addr,
+			Handler:           handler,
+			ReadTimeout:       opts.ReadTimeout,
+			ReadHeaderTimeout: opts.ReadHeaderTimeout,
+			WriteTimeout:      opts.WriteTimeout,
+			IdleTimeout:       opts.IdleTimeout,
+			MaxHeaderBytes:    opts.MaxHeaderBytes,
+		},
+		shutdownTimeout: shutdownTimeout,
+		hookTimeout:     hookTimeout,
+	}
+}
+
+// OnShutdown registers a hook to run after in-flight requests have drained.
+// Hooks run in registration order, so background workers should be registered
+// before the database client they depend on.
+func (s *Server) OnShutdown(hook ShutdownHook) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hooks = append(s.hooks, hook)
+}
+
+// OnDrain registers a function to call as soon as shutdown starts, before
+// in-flight requests are drained. Long-lived responses such as event streams
+// use it to end, so they don't hold up the shutdown.
+func (s *Server) OnDrain(fn func()) {
+	s.httpServer.RegisterOnShutdown(fn)
+}
+
+// Run listens on the configured address and blocks until ctx is cancelled,
+// then shuts the server down gracefully.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return err
+	}
+	return s.Serve(ctx, listener)
+}
+
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- s.httpServer.Serve(listener)
+	}()
+
+	select {
+	case err := <-serveErr:
+		// The server stopped on its own, still release the registered resources
+		shutdownErr := s.runHooks()
+		if errors.Is(err, http.ErrServerClosed) {
+			return shutdownErr
+		}
+		return errors.Join(err, shutdownErr)
+	case <-ctx.Done():
+		log.Println("Shutting down server...")
+	}
+
+	return s.Shutdown()
+}
+
+// Shutdown drains in-flight requests within the shutdown timeout and then
+// runs the registered hooks. The hooks get their own timeout, so requests
+// that use up the shutdown timeout don't leave them an expired context.
+func (s *Server) Shutdown() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	err := s.httpServer.Shutdown(ctx)
+	return errors.Join(err, s.runHooks())
+}
+
+func (s *Server) runHooks() error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.hookTimeout)
+	defer cancel()
+
+	var errs []error
+	for _, hook := range s.takeHooks() {
+		if err := hook(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// takeHooks hands out the hooks only once so a second shutdown is a no-op
+func (s *Server) takeHooks() []ShutdownHook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hooks := s.hooks
+	s.hooks = nil
+	return hooks
+}
@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/events"
 	"github.com/harsh-solanki21/golang-gin-crud-api/models"
 	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
 	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
@@ -15,11 +17,19 @@ import (
 
 type AuthService struct {
 	userRepository *repositories.UserRepository
+	outbox         *events.Outbox
+	jwtManager     *utils.JWTManager
+	cookieOptions  utils.CookieOptions
+	config         configs.AuthConfig
 }
 
-func NewAuthService(userRepository *repositories.UserRepository) *AuthService {
+func NewAuthService(userRepository *repositories.UserRepository, outbox *events.Outbox, jwtManager *utils.JWTManager, cookieOptions utils.CookieOptions, config configs.AuthConfig) *AuthService {
 	return &AuthService{
 		userRepository: userRepository,
+		outbox:         outbox,
+		jwtManager:     jwtManager,
+		cookieOptions:  cookieOptions,
+		config:         config,
 	}
 }
 
@@ -33,7 +43,7 @@ func (as *AuthService) Register(user *models.User) error {
 		return utils.NewCustomError(http.StatusConflict, "Email already in use", nil)
 	}
 
-	hashedPassword, err := utils.HashPassword(user.Password)
+	hashedPassword, err := utils.HashPassword(user.Password, as.config.BcryptCost)
 	if err != nil {
 		return utils.NewCustomError(http.StatusInternalServerError, "Error hashing password", err)
 	}
@@ -43,12 +53,12 @@ func (as *AuthService) Register(user *models.User) error {
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
 
-	err = as.userRepository.CreateUser(context.Background(), user)
-	if err != nil {
-		return utils.NewCustomError(http.StatusInternalServerError, "Error creating user", err)
-	}
-
-	return nil
+	return as.outbox.Run(context.Background(), func(ctx context.Context) error {
+		if err := as.userRepository.CreateUser(ctx, user); err != nil {
+			return utils.NewCustomError(http.StatusInternalServerError, "Error creating user", err)
+		}
+		return recordEvent(ctx, as.outbox, models.EventUserRegistered, userData(user))
+	})
 }
 
 func (as *AuthService) Login(c *gin.Context, email, password string) error {
@@ -61,63 +71,31 @@ func (as *AuthService) Login(c *gin.Context, email, password string) error {
 		return utils.NewCustomError(http.StatusUnauthorized, "Invalid email or password", nil)
 	}
 
-	accessToken, err := utils.GenerateAccessToken(user.ID.Hex(), user.Role)
+	accessToken, err := as.jwtManager.GenerateAccessToken(user.ID.Hex(), user.Role)
 	if err != nil {
 		return utils.NewCustomError(http.StatusInternalServerError, "Error generating access token", err)
 	}
 
-	refreshToken, err := utils.GenerateRefreshToken(user.ID.Hex())
+	refreshToken, err := as.jwtManager.GenerateRefreshToken(user.ID.Hex())
 	if err != nil {
 		return utils.NewCustomError(http.StatusInternalServerError, "Error generating refresh token", err)
 	}
 
 	// Set the access token as an HTTP-only cookie
-	c.SetCookie(
-		"access_token",
-		accessToken,
-		int(15*time.Minute.Seconds()), // 15 Minutes
-		"/",
-		"",
-		false,
-		true,
-	)
+	utils.SetCookie(c, as.cookieOptions, "access_token", accessToken, int(as.config.AccessTokenTTL.Seconds()))
 
 	// Set the refresh token as an HTTP-only cookie
-	c.SetCookie(
-		"refresh_token",
-		refreshToken,
-		int(7*24*time.Hour.Seconds()), // 7 Days
-		"/",
-		"",
-		false,
-		true,
-	)
+	utils.SetCookie(c, as.cookieOptions, "refresh_token", refreshToken, int(as.config.RefreshTokenTTL.Seconds()))
 
 	return nil
 }
 
 func (as *AuthService) Logout(c *gin.Context) {
 	// Clear the access token cookie
-	c.SetCookie(
-		"access_token",
-		"",
-		-1,
-		"/",
-		"",
-		false,
-		true,
-	)
+	utils.ClearCookie(c, as.cookieOptions, "access_token")
 
 	// Clear the refresh token cookie
-	c.SetCookie(
-		"refresh_token",
-		"",
-		-1,
-		"/",
-		"",
-		false,
-		true,
-	)
+	utils.ClearCookie(c, as.cookieOptions, "refresh_token")
 }
 
 func (as *AuthService) RefreshToken(c *gin.Context) error {
@@ -126,7 +104,7 @@ func (as *AuthService) RefreshToken(c *gin.Context) error {
 		return utils.NewCustomError(http.StatusUnauthorized, "Refresh token not found", err)
 	}
 
-	claims, err := utils.ValidateRefreshToken(refreshToken)
+	claims, err := as.jwtManager.ValidateRefreshToken(refreshToken)
 	if err != nil {
 		return utils.NewCustomError(http.StatusUnauthorized, "Invalid refresh token", err)
 	}
@@ -141,21 +119,13 @@ func (as *AuthService) RefreshToken(c *gin.Context) error {
 		return utils.NewCustomError(http.StatusUnauthorized, "User not found", err)
 	}
 
-	newAccessToken, err := utils.GenerateAccessToken(user.ID.Hex(), user.Role)
+	newAccessToken, err := as.jwtManager.GenerateAccessToken(user.ID.Hex(), user.Role)
 	if err != nil {
 		return utils.NewCustomError(http.StatusInternalServerError, "Error generating new access token", err)
 	}
 
 	// Set the new access token as an HTTP-only cookie
-	c.SetCookie(
-		"access_token",
-		newAccessToken,
-		int(15*time.Minute.Seconds()),
-		"/",
-		"",
-		false,
-		true,
-	)
+	utils.SetCookie(c, as.cookieOptions, "access_token", newAccessToken, int(as.config.AccessTokenTTL.Seconds()))
 
 	return nil
 }
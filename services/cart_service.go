@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const ErrCartItemNotFoundMessage = "Product is not in the cart"
+
+type CartService struct {
+	cartRepository    *repositories.CartRepository
+	productRepository *repositories.ProductRepository
+	variantRepository *repositories.VariantRepository
+}
+
+func NewCartService(cartRepository *repositories.CartRepository, productRepository *repositories.ProductRepository, variantRepository *repositories.VariantRepository) *CartService {
+	return &CartService{
+		cartRepository:    cartRepository,
+		productRepository: productRepository,
+		variantRepository: variantRepository,
+	}
+}
+
+func (cs *CartService) GetCart(userID string) (*models.CartView, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidUserId, err)
+	}
+
+	cart, err := cs.cartRepository.GetCart(context.Background(), userObjectID)
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error retrieving cart", err)
+	}
+
+	products, variants, err := loadCartItems(context.Background(), cs.productRepository, cs.variantRepository, cart.Items)
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error retrieving cart products", err)
+	}
+
+	return buildCartView(cart, products, variants), nil
+}
+
+// AddItem adds a product, or the variant with the given SKU, to the cart.
+// Products with variants can only be added by SKU.
+func (cs *CartService) AddItem(userID string, request models.CartItemRequest) (*models.CartView, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidUserId, err)
+	}
+
+	var variant *models.Variant
+	if request.SKU != "" {
+		variant, err = cs.variantRepository.GetVariantBySKU(context.Background(), request.SKU)
+		if err != nil {
+			if errors.Is(err, repositories.ErrVariantNotFound) {
+				return nil, utils.NewCustomError(404, ErrVariantNotFoundMessage, request.SKU)
+			}
+			return nil, utils.NewCustomError(500, "Error retrieving variant", err)
+		}
+		if request.ProductID == "" {
+			request.ProductID = variant.ProductID.Hex()
+		} else if request.ProductID != variant.ProductID.Hex() {
+			return nil, utils.NewCustomError(400, "SKU belongs to another product", nil)
+		}
+	}
+
+	productID, err := primitive.ObjectIDFromHex(request.ProductID)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidIdMessage, err)
+	}
+
+	product, err := cs.productRepository.GetProduct(context.Background(), productID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrProductNotFound) {
+			return nil, utils.NewCustomError(404, ErrProductNotFoundMessage, err)
+		}
+		return nil, utils.NewCustomError(500, "Error retrieving product", err)
+	}
+
+	price := product.Price
+	if variant != nil {
+		price = variant.UnitPrice(product)
+	} else if product.VariantCount > 0 {
+		return nil, utils.NewCustomError(400, "Product has variants, a SKU is required", nil)
+	}
+
+	// Totals are only exact within one currency
+	cart, err := cs.GetCart(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !cart.Total.IsZero() && cart.Total.Currency != price.Currency {
+		return nil, utils.NewCustomError(409, "Cart can only hold products in one currency", nil)
+	}
+
+	if err := cs.cartRepository.AddItem(context.Background(), userObjectID, productID, request.SKU, request.Quantity); err != nil {
+		return nil, utils.NewCustomError(500, "Error adding item to cart", err)
+	}
+
+	return cs.GetCart(userID)
+}
+
+// UpdateItem sets the quantity of a product in the cart, sku selects the variant
+func (cs *CartService) UpdateItem(userID, productID, sku string, quantity int) (*models.CartView, error) {
+	userObjectID, productObjectID, err := parseCartIDs(userID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cs.cartRepository.SetItemQuantity(context.Background(), userObjectID, productObjectID, sku, quantity); err != nil {
+		if errors.Is(err, repositories.ErrCartItemNotFound) {
+			return nil, utils.NewCustomError(404, ErrCartItemNotFoundMessage, err)
+		}
+		return nil, utils.NewCustomError(500, "Error updating cart item", err)
+	}
+
+	return cs.GetCart(userID)
+}
+
+func (cs *CartService) RemoveItem(userID, productID, sku string) (*models.CartView, error) {
+	userObjectID, productObjectID, err := parseCartIDs(userID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cs.cartRepository.RemoveItem(context.Background(), userObjectID, productObjectID, sku); err != nil {
+		if errors.Is(err, repositories.ErrCartItemNotFound) {
+			return nil, utils.NewCustomError(404, ErrCartItemNotFoundMessage, err)
+		}
+		return nil, utils.NewCustomError(500, "Error removing cart item", err)
+	}
+
+	return cs.GetCart(userID)
+}
+
+func (cs *CartService) ClearCart(userID string) error {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return utils.NewCustomError(400, ErrInvalidUserId, err)
+	}
+
+	if err := cs.cartRepository.ClearCart(context.Background(), userObjectID); err != nil {
+		return utils.NewCustomError(500, "Error clearing cart", err)
+	}
+	return nil
+}
+
+func parseCartIDs(userID, productID string) (primitive.ObjectID, primitive.ObjectID, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, utils.NewCustomError(400, ErrInvalidUserId, err)
+	}
+	productObjectID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, utils.NewCustomError(400, ErrInvalidIdMessage, err)
+	}
+	return userObjectID, productObjectID, nil
+}
+
+// loadCartItems looks up the products and variants cart items refer to
+func loadCartItems(ctx context.Context, productRepository *repositories.ProductRepository, variantRepository *repositories.VariantRepository, items []models.CartItem) (map[primitive.ObjectID]*models.Product, map[string]*models.Variant, error) {
+	productIDs := make([]primitive.ObjectID, 0, len(items))
+	skus := []string{}
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+		if item.SKU != "" {
+			skus = append(skus, item.SKU)
+		}
+	}
+
+	products, err := productRepository.GetProductsByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	variants := map[string]*models.Variant{}
+	if len(skus) > 0 {
+		variants, err = variantRepository.GetVariantsBySKUs(ctx, skus)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return products, variants, nil
+}
+
+// cartItemVariant returns the variant a cart item refers to. It returns
+// false if the item has a SKU that no longer exists on the product.
+func cartItemVariant(item models.CartItem, variants map[string]*models.Variant) (*models.Variant, bool) {
+	if item.SKU == "" {
+		return nil, true
+	}
+	variant, ok := variants[item.SKU]
+	if !ok || variant.ProductID != item.ProductID {
+		return nil, false
+	}
+	return variant, true
+}
+
+// buildCartView prices the cart with the current product and variant data.
+// Deleted products and variants and products in another currency are kept
+// in the view but left out of the totals.
+func buildCartView(cart *models.Cart, products map[primitive.ObjectID]*models.Product, variants map[string]*models.Variant) *models.CartView {
+	view := &models.CartView{
+		Items:     []models.CartLine{},
+		UpdatedAt: cart.UpdatedAt,
+	}
+
+	for _, item := range cart.Items {
+		line := models.CartLine{
+			ProductID: item.ProductID,
+			SKU:       item.SKU,
+			Quantity:  item.Quantity,
+		}
+
+		product, ok := products[item.ProductID]
+		variant, variantOK := cartItemVariant(item, variants)
+		if ok && variantOK {
+			line.Name = product.Name
+			line.UnitPrice = product.Price
+			available := product.Available()
+			if variant != nil {
+				line.Options = variant.Options
+				line.UnitPrice = variant.UnitPrice(product)
+				available = variant.Available()
+			}
+			line.LineTotal = line.UnitPrice.Multiply(item.Quantity)
+			line.Available = available >= item.Quantity
+
+			// A product whose currency changed since it was added can't be checked out
+			if total, err := view.Total.Add(line.LineTotal); err == nil {
+				view.TotalQuantity += item.Quantity
+				view.Total = total
+			} else {
+				line.Available = false
+			}
+		}
+
+		view.Items = append(view.Items, line)
+	}
+
+	return view
+}
@@ -0,0 +1,317 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/harsh-solanki21/golang-gin-crud-api/validations"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ErrCategoryNotFoundMessage  = "Category not found"
+	ErrInvalidCategoryIdMessage = "Invalid category ID"
+	ErrCategorySlugTakenMessage = "Category slug already exists"
+)
+
+type CategoryService struct {
+	categoryRepository *repositories.CategoryRepository
+	productRepository  *repositories.ProductRepository
+	transactions       *repositories.Transactions
+}
+
+func NewCategoryService(categoryRepository *repositories.CategoryRepository, productRepository *repositories.ProductRepository, transactions *repositories.Transactions) *CategoryService {
+	return &CategoryService{
+		categoryRepository: categoryRepository,
+		productRepository:  productRepository,
+		transactions:       transactions,
+	}
+}
+
+func (cs *CategoryService) CreateCategory(category *models.Category) error {
+	ctx := context.Background()
+
+	if category.Slug == "" {
+		category.Slug = utils.Slugify(category.Name)
+	}
+	if validationErrors := validations.ValidateCategory(category); validationErrors != nil {
+		return utils.NewCustomError(400, "Validation error", validationErrors)
+	}
+
+	category.Ancestors = []primitive.ObjectID{}
+	if category.ParentID != nil {
+		parent, err := cs.categoryRepository.GetCategory(ctx, *category.ParentID)
+		if err != nil {
+			return cs.parentError(err)
+		}
+		category.Ancestors = slices.Concat(parent.Ancestors, []primitive.ObjectID{parent.ID})
+	}
+
+	if err := cs.categoryRepository.CreateCategory(ctx, category); err != nil {
+		if errors.Is(err, repositories.ErrCategorySlugTaken) {
+			return utils.NewCustomError(409, ErrCategorySlugTakenMessage, err)
+		}
+		return utils.NewCustomError(500, "Error creating category", err)
+	}
+
+	return nil
+}
+
+func (cs *CategoryService) GetCategory(id string) (*models.Category, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidCategoryIdMessage, err)
+	}
+
+	category, err := cs.categoryRepository.GetCategory(context.Background(), objectID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrCategoryNotFound) {
+			return nil, utils.NewCustomError(404, ErrCategoryNotFoundMessage, err)
+		}
+		return nil, utils.NewCustomError(500, "Error retrieving category", err)
+	}
+
+	return category, nil
+}
+
+// GetCategoriesByIDs looks up several categories with one query, categories
+// that don't exist are left out
+func (cs *CategoryService) GetCategoriesByIDs(ids []primitive.ObjectID) ([]*models.Category, error) {
+	categories, err := cs.categoryRepository.GetCategoriesByIDs(context.Background(), ids)
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error retrieving categories", err)
+	}
+	return categories, nil
+}
+
+func (cs *CategoryService) ListCategories() ([]*models.Category, error) {
+	categories, err := cs.categoryRepository.ListCategories(context.Background())
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error listing categories", err)
+	}
+	return categories, nil
+}
+
+// GetCategoryTree returns the top-level categories with their subcategories and product counts
+func (cs *CategoryService) GetCategoryTree() ([]*models.CategoryNode, error) {
+	ctx := context.Background()
+
+	categories, err := cs.categoryRepository.ListCategories(ctx)
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error listing categories", err)
+	}
+
+	counts, err := cs.productRepository.CountByCategory(ctx)
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error counting products", err)
+	}
+
+	return models.BuildCategoryTree(categories, counts), nil
+}
+
+func (cs *CategoryService) UpdateCategory(id string, request *models.CategoryUpdateRequest) (*models.Category, error) {
+	ctx := context.Background()
+
+	category, err := cs.GetCategory(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if validationErrors := validations.ValidateCategoryUpdate(request); validationErrors != nil {
+		return nil, utils.NewCustomError(400, "Validation error", validationErrors)
+	}
+
+	var parent *models.Category
+	if request.ParentID != nil {
+		parent, err = cs.newParent(ctx, category, *request.ParentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	update := bson.M{}
+	if request.Name != nil {
+		update["name"] = *request.Name
+	}
+	if request.Slug != nil {
+		update["slug"] = *request.Slug
+	}
+	if request.Position != nil {
+		update["position"] = *request.Position
+	}
+
+	// The fields are updated before the move, so a taken slug fails before
+	// anything changed even without transactions
+	err = cs.transactions.RunIfSupported(ctx, func(ctx context.Context) error {
+		if len(update) > 0 {
+			if err := cs.categoryRepository.UpdateCategory(ctx, category.ID, update); err != nil {
+				return cs.updateError(err)
+			}
+		}
+		// A nil parent moves the category to the top level
+		if request.ParentID != nil {
+			if err := cs.categoryRepository.MoveCategory(ctx, category.ID, parent); err != nil {
+				return cs.updateError(err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return cs.GetCategory(id)
+}
+
+// DeleteCategory only deletes empty categories, so no product is left pointing at a missing category
+func (cs *CategoryService) DeleteCategory(id string) error {
+	ctx := context.Background()
+
+	category, err := cs.GetCategory(id)
+	if err != nil {
+		return err
+	}
+
+	children, err := cs.categoryRepository.CountChildren(ctx, category.ID)
+	if err != nil {
+		return utils.NewCustomError(500, "Error deleting category", err)
+	}
+	if children > 0 {
+		return utils.NewCustomError(409, "Category has subcategories", fmt.Sprintf("%d subcategories", children))
+	}
+
+	products, err := cs.productRepository.CountInCategory(ctx, category.ID)
+	if err != nil {
+		return utils.NewCustomError(500, "Error deleting category", err)
+	}
+	if products > 0 {
+		return utils.NewCustomError(409, "Category has products", fmt.Sprintf("%d products", products))
+	}
+
+	if err := cs.categoryRepository.DeleteCategory(ctx, category.ID); err != nil {
+		return cs.updateError(err)
+	}
+	return nil
+}
+
+// FindCategory returns the category with the given id or slug
+func (cs *CategoryService) FindCategory(idOrSlug string) (*models.Category, error) {
+	ctx := context.Background()
+
+	var category *models.Category
+	var err error
+	if objectID, parseErr := primitive.ObjectIDFromHex(idOrSlug); parseErr == nil {
+		category, err = cs.categoryRepository.GetCategory(ctx, objectID)
+	} else {
+		category, err = cs.categoryRepository.GetCategoryBySlug(ctx, idOrSlug)
+	}
+	if err != nil {
+		if errors.Is(err, repositories.ErrCategoryNotFound) {
+			return nil, utils.NewCustomError(404, ErrCategoryNotFoundMessage, idOrSlug)
+		}
+		return nil, utils.NewCustomError(500, "Error retrieving category", err)
+	}
+	return category, nil
+}
+
+// ResolveCategory returns the ids of the category with the given id or slug and of all its subcategories
+func (cs *CategoryService) ResolveCategory(idOrSlug string) ([]primitive.ObjectID, error) {
+	category, err := cs.FindCategory(idOrSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := cs.categoryRepository.SubtreeIDs(context.Background(), category.ID)
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error retrieving category", err)
+	}
+	return ids, nil
+}
+
+// RequireCategory checks that a product can be put into the category
+func (cs *CategoryService) RequireCategory(id primitive.ObjectID) error {
+	_, err := cs.categoryRepository.GetCategory(context.Background(), id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrCategoryNotFound) {
+			return utils.NewCustomError(400, "Category does not exist", id.Hex())
+		}
+		return utils.NewCustomError(500, "Error retrieving category", err)
+	}
+	return nil
+}
+
+// MigrateLegacyCategories moves products that still have a free-form category
+// name into categories. Names that only differ in case or punctuation, like
+// "Shoes" and "shoes", end up in the same category.
+func (cs *CategoryService) MigrateLegacyCategories(ctx context.Context) (int64, error) {
+	names, err := cs.productRepository.LegacyCategories(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	var migrated int64
+	for _, name := range names {
+		slug := utils.Slugify(name)
+		if slug == "" {
+			slug = "uncategorized"
+		}
+
+		category, err := cs.categoryRepository.FindOrCreateBySlug(ctx, slug, name)
+		if err != nil {
+			return migrated, err
+		}
+
+		count, err := cs.productRepository.AssignLegacyCategory(ctx, name, category.ID)
+		if err != nil {
+			return migrated, err
+		}
+		migrated += count
+	}
+
+	return migrated, nil
+}
+
+// newParent loads the new parent of a category, nil means the top level
+func (cs *CategoryService) newParent(ctx context.Context, category *models.Category, parentID string) (*models.Category, error) {
+	if parentID == "" {
+		return nil, nil
+	}
+
+	objectID, err := primitive.ObjectIDFromHex(parentID)
+	if err != nil {
+		return nil, utils.NewCustomError(400, "Invalid parent category ID", err)
+	}
+
+	parent, err := cs.categoryRepository.GetCategory(ctx, objectID)
+	if err != nil {
+		return nil, cs.parentError(err)
+	}
+	if parent.ID == category.ID || slices.Contains(parent.Ancestors, category.ID) {
+		return nil, utils.NewCustomError(400, "A category cannot be moved below itself", nil)
+	}
+
+	return parent, nil
+}
+
+func (cs *CategoryService) parentError(err error) error {
+	if errors.Is(err, repositories.ErrCategoryNotFound) {
+		return utils.NewCustomError(400, "Parent category does not exist", err)
+	}
+	return utils.NewCustomError(500, "Error retrieving parent category", err)
+}
+
+func (cs *CategoryService) updateError(err error) error {
+	switch {
+	case errors.Is(err, repositories.ErrCategoryNotFound):
+		return utils.NewCustomError(404, ErrCategoryNotFoundMessage, err)
+	case errors.Is(err, repositories.ErrCategorySlugTaken):
+		return utils.NewCustomError(409, ErrCategorySlugTakenMessage, err)
+	}
+	return utils.NewCustomError(500, "Error updating category", err)
+}
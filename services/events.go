@@ -0,0 +1,34 @@
+package services
+
+import (
+	"context"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/events"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// recordEvent saves an event with ctx, so inside outbox.Run it's part of the change's transaction
+func recordEvent(ctx context.Context, outbox *events.Outbox, eventType string, data interface{}) error {
+	if err := outbox.Record(ctx, eventType, data); err != nil {
+		return utils.NewCustomError(500, "Error recording "+eventType+" event", err)
+	}
+	return nil
+}
+
+// deletedData is the payload of the deleted events
+func deletedData(id primitive.ObjectID) map[string]interface{} {
+	return map[string]interface{}{"id": id.Hex()}
+}
+
+// userData is the payload of the user events, it never includes the password hash
+func userData(user *models.User) map[string]interface{} {
+	return map[string]interface{}{
+		"id":         user.ID.Hex(),
+		"name":       user.Name,
+		"email":      user.Email,
+		"role":       user.Role,
+		"created_at": user.CreatedAt,
+	}
+}
@@ -0,0 +1,340 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/storage"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ErrImageNotFoundMessage  = "Image not found"
+	ErrInvalidImageIdMessage = "Invalid image ID"
+)
+
+type ImageService struct {
+	imageRepository   *repositories.ImageRepository
+	productRepository *repositories.ProductRepository
+	blobStore         storage.BlobStore
+	config            configs.ImagesConfig
+}
+
+func NewImageService(imageRepository *repositories.ImageRepository, productRepository *repositories.ProductRepository, blobStore storage.BlobStore, config configs.ImagesConfig) *ImageService {
+	return &ImageService{
+		imageRepository:   imageRepository,
+		productRepository: productRepository,
+		blobStore:         blobStore,
+		config:            config,
+	}
+}
+
+// ImageFile is an open image or thumbnail file, the caller must close it
+type ImageFile struct {
+	io.ReadCloser
+	ContentType string
+	Length      int64
+	// ETag never changes because stored files are never modified
+	ETag    string
+	ModTime time.Time
+}
+
+func (is *ImageService) MaxUploadBytes() int64 {
+	return int64(is.config.MaxUploadBytes)
+}
+
+// UploadImage stores an image with its thumbnails. The type is sniffed from
+// the data; the first image of a product becomes its primary image.
+func (is *ImageService) UploadImage(productID string, data []byte) (*models.ProductImage, error) {
+	ctx := context.Background()
+
+	product, err := findProduct(is.productRepository, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > is.MaxUploadBytes() {
+		return nil, utils.NewCustomError(413, "Image is too large", fmt.Sprintf("at most %d bytes", is.config.MaxUploadBytes))
+	}
+	contentType, err := utils.SniffImageType(data)
+	if err != nil {
+		return nil, utils.NewCustomError(415, "Unsupported image type", "JPEG, PNG, GIF and WebP images are supported")
+	}
+	img, err := utils.DecodeImage(data, contentType, is.config.MaxDimension, is.config.MaxPixels)
+	if errors.Is(err, utils.ErrImageTooLarge) {
+		return nil, utils.NewCustomError(413, "Image dimensions are too large",
+			fmt.Sprintf("at most %d pixels wide or high and %d pixels in total", is.config.MaxDimension, is.config.MaxPixels))
+	}
+	if err != nil {
+		return nil, utils.NewCustomError(400, "Invalid image", err.Error())
+	}
+
+	image := &models.ProductImage{
+		ID:          primitive.NewObjectID(),
+		ProductID:   product.ID,
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Thumbnails:  []models.ImageThumbnail{},
+		CreatedAt:   time.Now(),
+	}
+	image.Key = is.blobKey(image, "original")
+
+	if err := is.blobStore.Put(ctx, image.Key, bytes.NewReader(data)); err != nil {
+		return nil, utils.NewCustomError(500, "Error storing image", err)
+	}
+
+	if err := is.createThumbnails(ctx, image, img); err != nil {
+		is.deleteFiles(image)
+		return nil, utils.NewCustomError(500, "Error creating thumbnails", err)
+	}
+
+	image.Position, err = is.imageRepository.NextPosition(ctx, product.ID)
+	if err == nil {
+		var hasPrimary bool
+		hasPrimary, err = is.imageRepository.HasPrimary(ctx, product.ID)
+		image.Primary = !hasPrimary
+	}
+	if err == nil {
+		err = is.imageRepository.CreateImage(ctx, image)
+	}
+	if err != nil {
+		is.deleteFiles(image)
+		return nil, utils.NewCustomError(500, "Error saving image", err)
+	}
+
+	return image, nil
+}
+
+func (is *ImageService) ListImages(productID string) ([]*models.ProductImage, error) {
+	product, err := findProduct(is.productRepository, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	images, err := is.imageRepository.ListImages(context.Background(), product.ID)
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error listing images", err)
+	}
+	return images, nil
+}
+
+func (is *ImageService) productImages(productID primitive.ObjectID) ([]*models.ProductImage, error) {
+	return is.imageRepository.ListImages(context.Background(), productID)
+}
+
+func (is *ImageService) GetImage(productID, id string) (*models.ProductImage, error) {
+	productObjectID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidIdMessage, err)
+	}
+	imageID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidImageIdMessage, err)
+	}
+
+	image, err := is.imageRepository.GetImage(context.Background(), productObjectID, imageID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrImageNotFound) {
+			return nil, utils.NewCustomError(404, ErrImageNotFoundMessage, err)
+		}
+		return nil, utils.NewCustomError(500, "Error retrieving image", err)
+	}
+	return image, nil
+}
+
+// OpenImage opens the original image, or its thumbnail with the given size
+func (is *ImageService) OpenImage(productID, id string, size int) (*ImageFile, error) {
+	image, err := is.GetImage(productID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	key, contentType, length, ok := image.File(size)
+	if !ok {
+		return nil, utils.NewCustomError(404, "Thumbnail size not found", nil)
+	}
+
+	file, err := is.blobStore.Open(context.Background(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrBlobNotFound) {
+			return nil, utils.NewCustomError(404, ErrImageNotFoundMessage, err)
+		}
+		return nil, utils.NewCustomError(500, "Error opening image", err)
+	}
+
+	return &ImageFile{
+		ReadCloser:  file,
+		ContentType: contentType,
+		Length:      length,
+		ETag:        fmt.Sprintf(`"%s-%d"`, image.ID.Hex(), size),
+		ModTime:     image.CreatedAt,
+	}, nil
+}
+
+func (is *ImageService) SetPrimaryImage(productID, id string) (*models.ProductImage, error) {
+	image, err := is.GetImage(productID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := is.imageRepository.SetPrimary(context.Background(), image.ProductID, image.ID); err != nil {
+		return nil, utils.NewCustomError(500, "Error updating image", err)
+	}
+
+	image.Primary = true
+	return image, nil
+}
+
+// ReorderImages puts the product's images in the given order, every image must be listed once
+func (is *ImageService) ReorderImages(productID string, imageIDs []string) ([]*models.ProductImage, error) {
+	ctx := context.Background()
+
+	images, err := is.ListImages(productID)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(imageIDs))
+	for _, imageID := range imageIDs {
+		id, err := primitive.ObjectIDFromHex(imageID)
+		if err != nil {
+			return nil, utils.NewCustomError(400, ErrInvalidImageIdMessage, imageID)
+		}
+		ids = append(ids, id)
+	}
+
+	existing := make([]primitive.ObjectID, len(images))
+	for i, image := range images {
+		existing[i] = image.ID
+	}
+	sorted := slices.Clone(ids)
+	slices.SortFunc(sorted, compareObjectIDs)
+	slices.SortFunc(existing, compareObjectIDs)
+	if !slices.Equal(sorted, existing) {
+		return nil, utils.NewCustomError(400, "image_ids must list every image of the product exactly once", nil)
+	}
+
+	if err := is.imageRepository.SetPositions(ctx, images[0].ProductID, ids); err != nil {
+		return nil, utils.NewCustomError(500, "Error reordering images", err)
+	}
+
+	return is.ListImages(productID)
+}
+
+// DeleteImage removes an image and its files. If it was the primary image,
+// the next image in order becomes primary.
+func (is *ImageService) DeleteImage(productID, id string) error {
+	ctx := context.Background()
+
+	image, err := is.GetImage(productID, id)
+	if err != nil {
+		return err
+	}
+
+	if err := is.imageRepository.DeleteImage(ctx, image.ProductID, image.ID); err != nil {
+		if errors.Is(err, repositories.ErrImageNotFound) {
+			return utils.NewCustomError(404, ErrImageNotFoundMessage, err)
+		}
+		return utils.NewCustomError(500, "Error deleting image", err)
+	}
+	is.deleteFiles(image)
+
+	if image.Primary {
+		remaining, err := is.imageRepository.ListImages(ctx, image.ProductID)
+		if err == nil && len(remaining) > 0 {
+			err = is.imageRepository.SetPrimary(ctx, image.ProductID, remaining[0].ID)
+		}
+		if err != nil {
+			log.Printf("Error choosing a new primary image for product %s: %v", image.ProductID.Hex(), err)
+		}
+	}
+
+	return nil
+}
+
+// DeleteProductImages removes all images of a deleted product
+func (is *ImageService) DeleteProductImages(productID primitive.ObjectID) error {
+	ctx := context.Background()
+
+	images, err := is.imageRepository.ListImages(ctx, productID)
+	if err != nil {
+		return err
+	}
+	for _, image := range images {
+		if err := is.imageRepository.DeleteImage(ctx, productID, image.ID); err != nil && !errors.Is(err, repositories.ErrImageNotFound) {
+			return err
+		}
+		is.deleteFiles(image)
+	}
+	return nil
+}
+
+// createThumbnails stores a thumbnail for every configured size smaller than the image
+func (is *ImageService) createThumbnails(ctx context.Context, productImage *models.ProductImage, img image.Image) error {
+	sizes := slices.Clone(is.config.ThumbnailSizes)
+	slices.Sort(sizes)
+	sizes = slices.Compact(sizes)
+
+	for _, size := range sizes {
+		if size >= max(productImage.Width, productImage.Height) {
+			break
+		}
+
+		thumbnail := utils.Thumbnail(img, size)
+		data, contentType, err := utils.EncodeThumbnail(thumbnail, productImage.ContentType)
+		if err != nil {
+			return err
+		}
+
+		key := is.blobKey(productImage, strconv.Itoa(size))
+		if err := is.blobStore.Put(ctx, key, bytes.NewReader(data)); err != nil {
+			return err
+		}
+		productImage.Thumbnails = append(productImage.Thumbnails, models.ImageThumbnail{
+			Size:        size,
+			Key:         key,
+			ContentType: contentType,
+			Bytes:       int64(len(data)),
+			Width:       thumbnail.Bounds().Dx(),
+			Height:      thumbnail.Bounds().Dy(),
+		})
+	}
+	return nil
+}
+
+func (is *ImageService) blobKey(productImage *models.ProductImage, name string) string {
+	return fmt.Sprintf("products/%s/images/%s/%s", productImage.ProductID.Hex(), productImage.ID.Hex(), name)
+}
+
+// deleteFiles removes the stored files of an image. The image record is
+// already gone at this point, so leftover files are only logged.
+func (is *ImageService) deleteFiles(productImage *models.ProductImage) {
+	keys := []string{productImage.Key}
+	for _, thumbnail := range productImage.Thumbnails {
+		keys = append(keys, thumbnail.Key)
+	}
+
+	for _, key := range keys {
+		if err := is.blobStore.Delete(context.Background(), key); err != nil {
+			log.Printf("Error deleting image file %s: %v", key, err)
+		}
+	}
+}
+
+func compareObjectIDs(a, b primitive.ObjectID) int {
+	return bytes.Compare(a[:], b[:])
+}
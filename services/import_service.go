@@ -0,0 +1,653 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/events"
+	"github.com/harsh-solanki21/golang-gin-crud-api/jobs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/storage"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/harsh-solanki21/golang-gin-crud-api/validations"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ErrImportJobNotFoundMessage = "Import job not found"
+	ErrInvalidImportJobId       = "Invalid import job ID"
+)
+
+// JobRunImport is the background job type of imports too large to run during the request
+const JobRunImport = "imports.run"
+
+var errCategoryMissing = errors.New("category does not exist")
+
+type ImportService struct {
+	productRepository   *repositories.ProductRepository
+	variantRepository   *repositories.VariantRepository
+	importJobRepository *repositories.ImportJobRepository
+	jobRepository       *repositories.JobRepository
+	categoryService     *CategoryService
+	outbox              *events.Outbox
+	jobRunner           *jobs.Runner
+	// files keeps the uploads of background imports until they're done
+	files  storage.BlobStore
+	config configs.ImportsConfig
+}
+
+func NewImportService(productRepository *repositories.ProductRepository, variantRepository *repositories.VariantRepository, importJobRepository *repositories.ImportJobRepository, jobRepository *repositories.JobRepository, categoryService *CategoryService, outbox *events.Outbox, jobRunner *jobs.Runner, files storage.BlobStore, config configs.ImportsConfig) *ImportService {
+	return &ImportService{
+		productRepository:   productRepository,
+		variantRepository:   variantRepository,
+		importJobRepository: importJobRepository,
+		jobRepository:       jobRepository,
+		categoryService:     categoryService,
+		outbox:              outbox,
+		jobRunner:           jobRunner,
+		files:               files,
+		config:              config,
+	}
+}
+
+// importJobPayload is the payload of a JobRunImport job
+type importJobPayload struct {
+	ImportJobID string `json:"import_job_id"`
+}
+
+func (is *ImportService) MaxUploadBytes() int64 {
+	return int64(is.config.MaxUploadBytes)
+}
+
+// CheckImport checks the import options and the file, and returns its number
+// of rows. The rows are only counted, file is rewound for the import.
+func (is *ImportService) CheckImport(file io.ReadSeeker, filename string, options *models.ImportOptions) (int, error) {
+	format, err := utils.ImportFormat(options.Format, filename)
+	if err != nil {
+		return 0, utils.NewCustomError(400, "Unsupported import format", err.Error())
+	}
+	options.Format = format
+
+	if options.Mode == "" {
+		options.Mode = models.ImportDryRun
+	}
+	if options.Mode != models.ImportDryRun && options.Mode != models.ImportCommit {
+		return 0, utils.NewCustomError(400, "Invalid import mode", "mode must be dry_run or commit")
+	}
+	for column, field := range options.Mapping {
+		if !slices.Contains(models.ImportFields, field) {
+			return 0, utils.NewCustomError(400, "Invalid column mapping", fmt.Sprintf("column %q is mapped to unknown field %q", column, field))
+		}
+	}
+
+	rows, err := countImportRows(file, format)
+	if err != nil {
+		return 0, utils.NewCustomError(400, "Invalid import file", err.Error())
+	}
+	if rows == 0 {
+		return 0, utils.NewCustomError(400, "The import file has no rows", nil)
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, utils.NewCustomError(500, "Error reading import file", err)
+	}
+	return rows, nil
+}
+
+func countImportRows(file io.Reader, format string) (int, error) {
+	reader, err := utils.NewImportReader(file, format)
+	if err != nil {
+		return 0, err
+	}
+	rows := 0
+	for {
+		if _, err := reader.Read(); err == io.EOF {
+			return rows, nil
+		} else if err != nil {
+			return 0, err
+		}
+		rows++
+	}
+}
+
+// RunsInBackground reports whether an import of this many rows is run as a background job
+func (is *ImportService) RunsInBackground(rows int) bool {
+	return rows > is.config.BackgroundRows
+}
+
+// RunImport imports the rows of a file checked by CheckImport and returns the report once it's done
+func (is *ImportService) RunImport(file io.Reader, rows int, options models.ImportOptions) (*models.ImportReport, error) {
+	run := newImportRun(options, rows)
+	if err := is.importRecords(context.Background(), file, run, 0, nil); err != nil {
+		return nil, utils.NewCustomError(500, "Error importing products", err)
+	}
+	return run.report, nil
+}
+
+// StartImportJob stores the file and imports it in a background job, the
+// returned import job tracks its progress
+func (is *ImportService) StartImportJob(file io.Reader, rows int, options models.ImportOptions, userID string) (*models.ImportJob, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidUserId, err)
+	}
+
+	ctx := context.Background()
+	importJob := &models.ImportJob{
+		Status:    models.ImportJobRunning,
+		Options:   options,
+		TotalRows: rows,
+		CreatedBy: userObjectID,
+		CreatedAt: time.Now(),
+	}
+	if err := is.importJobRepository.CreateJob(ctx, importJob); err != nil {
+		return nil, utils.NewCustomError(500, "Error creating import job", err)
+	}
+
+	fail := func(message string, err error) (*models.ImportJob, error) {
+		is.finishImportJob(ctx, importJob.ID, models.ImportJobFailed, nil, message)
+		return nil, utils.NewCustomError(500, message, err)
+	}
+	if err := is.files.Put(ctx, importFileKey(importJob.ID), file); err != nil {
+		return fail("Error storing import file", err)
+	}
+	job, err := is.jobRunner.Enqueue(ctx, JobRunImport, importJobPayload{ImportJobID: importJob.ID.Hex()})
+	if err != nil {
+		return fail("Error starting import job", err)
+	}
+	if err := is.importJobRepository.SetJobID(ctx, importJob.ID, job.ID); err != nil {
+		log.Printf("Error linking import job %s to job %s: %v\n", importJob.ID.Hex(), job.ID.Hex(), err)
+	} else {
+		importJob.JobID = job.ID
+	}
+
+	return importJob, nil
+}
+
+func (is *ImportService) GetImportJob(id string) (*models.ImportJob, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidImportJobId, err)
+	}
+
+	ctx := context.Background()
+	importJob, err := is.importJobRepository.GetJob(ctx, objectID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrImportJobNotFound) {
+			return nil, utils.NewCustomError(404, ErrImportJobNotFoundMessage, err)
+		}
+		return nil, utils.NewCustomError(500, "Error retrieving import job", err)
+	}
+
+	// A job that died or was cancelled before the import recorded its
+	// outcome, e.g. because every attempt's worker stopped, won't finish it
+	if importJob.Status == models.ImportJobRunning && !importJob.JobID.IsZero() {
+		job, err := is.jobRepository.GetJob(ctx, importJob.JobID)
+		if err != nil && !errors.Is(err, repositories.ErrJobNotFound) {
+			return nil, utils.NewCustomError(500, "Error retrieving import job", err)
+		}
+		if job != nil && (job.Status == models.JobDead || job.Status == models.JobCancelled) {
+			importJob.Status = models.ImportJobFailed
+			importJob.Error = "the import job is " + job.Status
+			if job.LastError != "" {
+				importJob.Error += ": " + job.LastError
+			}
+		}
+	}
+	return importJob, nil
+}
+
+// RunImportJob is the handler of JobRunImport jobs. Progress is stored after
+// every batch, a job that was interrupted continues after the last stored
+// batch. A batch written right before an interruption is written again, its
+// rows then update the products they created.
+func (is *ImportService) RunImportJob(ctx context.Context, job *models.Job) error {
+	var payload importJobPayload
+	if err := job.DecodePayload(&payload); err != nil {
+		return jobs.Permanent(err)
+	}
+	id, err := primitive.ObjectIDFromHex(payload.ImportJobID)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	importJob, err := is.importJobRepository.GetJob(ctx, id)
+	if errors.Is(err, repositories.ErrImportJobNotFound) {
+		return jobs.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	if importJob.Status != models.ImportJobRunning {
+		return nil
+	}
+
+	file, err := is.files.Open(ctx, importFileKey(id))
+	if errors.Is(err, storage.ErrBlobNotFound) {
+		is.finishImportJob(ctx, id, models.ImportJobFailed, importJob.Report, "the import file is missing")
+		return jobs.Permanent(err)
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	run := newImportRun(importJob.Options, importJob.TotalRows)
+	if importJob.Report != nil {
+		run.report = importJob.Report
+	}
+	progress := func(processed int) {
+		if err := is.importJobRepository.UpdateProgress(context.Background(), id, processed, run.report); err != nil {
+			log.Printf("Error updating progress of import job %s: %v\n", id.Hex(), err)
+		}
+	}
+
+	if err := is.importRecords(ctx, file, run, importJob.Processed, progress); err != nil {
+		// Stopped by a shutdown or a cancellation, or to be retried
+		if ctx.Err() != nil || (job.Attempts < job.MaxAttempts && !jobs.IsPermanent(err)) {
+			return err
+		}
+		is.finishImportJob(context.Background(), id, models.ImportJobFailed, run.report, err.Error())
+		return jobs.Permanent(err)
+	}
+	is.finishImportJob(context.Background(), id, models.ImportJobCompleted, run.report, "")
+	return nil
+}
+
+// finishImportJob records the outcome of an import and deletes its file
+func (is *ImportService) finishImportJob(ctx context.Context, id primitive.ObjectID, status string, report *models.ImportReport, message string) {
+	if err := is.importJobRepository.FinishJob(ctx, id, status, report, message); err != nil {
+		log.Printf("Error finishing import job %s: %v\n", id.Hex(), err)
+	}
+	if err := is.files.Delete(ctx, importFileKey(id)); err != nil {
+		log.Printf("Error deleting file of import job %s: %v\n", id.Hex(), err)
+	}
+}
+
+func importFileKey(id primitive.ObjectID) string {
+	return "imports/" + id.Hex()
+}
+
+func newImportRun(options models.ImportOptions, rows int) *importRun {
+	return &importRun{
+		options:    options,
+		report:     &models.ImportReport{Mode: options.Mode, TotalRows: rows, Errors: []models.ImportRowError{}},
+		seen:       make(map[string]int),
+		categories: make(map[string]primitive.ObjectID),
+	}
+}
+
+// importRecords reads the file and validates and writes its rows batch by
+// batch, skipping the first skip rows. Cancelling ctx stops it between
+// batches. After an error the report covers the batches that were done.
+func (is *ImportService) importRecords(ctx context.Context, file io.Reader, run *importRun, skip int, progress func(processed int)) error {
+	reader, err := utils.NewImportReader(file, run.options.Format)
+	if err != nil {
+		return jobs.Permanent(err)
+	}
+
+	processed := 0
+	batch := make([]utils.ImportRecord, 0, is.config.BatchSize)
+	for done := false; !done; {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		batch = batch[:0]
+		for len(batch) < is.config.BatchSize {
+			record, err := reader.Read()
+			if err == io.EOF {
+				done = true
+				break
+			}
+			if err != nil {
+				return jobs.Permanent(fmt.Errorf("error reading import file: %w", err))
+			}
+			if processed++; processed > skip {
+				batch = append(batch, record)
+			}
+		}
+		if len(batch) == 0 {
+			continue
+		}
+
+		if err := is.importBatch(run, batch); err != nil {
+			return err
+		}
+		if progress != nil {
+			progress(processed)
+		}
+	}
+	return nil
+}
+
+// importRun holds the state of one import across its batches
+type importRun struct {
+	options models.ImportOptions
+	report  *models.ImportReport
+	// seen maps the sku or name of every row to the line it's on, to catch rows for the same product
+	seen map[string]int
+	// categories caches the category ids by the id or slug used in the file,
+	// a nil id stands for a category that doesn't exist
+	categories map[string]primitive.ObjectID
+}
+
+// importRow is a row on its way to becoming a product write
+type importRow struct {
+	line     int
+	fields   map[string]string
+	existing *models.Product
+	// product is the product the row creates or updates it to
+	product *models.Product
+	errors  []map[string]string
+}
+
+func (r *importRow) fail(field, message string) {
+	entry := map[string]string{"message": message}
+	if field != "" {
+		entry["field"] = field
+	}
+	r.errors = append(r.errors, entry)
+}
+
+func (is *ImportService) importBatch(run *importRun, records []utils.ImportRecord) error {
+	rows := make([]*importRow, len(records))
+	var skus, names []string
+	for i, record := range records {
+		row := &importRow{line: record.Line}
+		rows[i] = row
+		if record.Err != nil {
+			row.fail("", record.Err.Error())
+			continue
+		}
+
+		row.fields = make(map[string]string, len(record.Fields))
+		for column, value := range record.Fields {
+			if field, ok := run.options.Mapping[column]; ok {
+				column = field
+			}
+			if slices.Contains(models.ImportFields, column) {
+				row.fields[column] = value
+			}
+		}
+
+		key := "name:" + row.fields["name"]
+		if name := row.fields["name"]; name != "" {
+			// Rows with an unknown sku are matched by name too
+			names = append(names, name)
+		}
+		if sku := row.fields["sku"]; sku != "" {
+			key = "sku:" + sku
+			skus = append(skus, sku)
+		} else if row.fields["name"] == "" {
+			row.fail("", "a name or sku is required")
+			continue
+		}
+		if line, ok := run.seen[key]; ok {
+			row.fail("", fmt.Sprintf("row %d is for the same product", line))
+			continue
+		}
+		run.seen[key] = row.line
+	}
+
+	if err := is.matchProducts(run, rows, skus, names); err != nil {
+		return err
+	}
+
+	var writes []repositories.ProductWrite
+	var written []*importRow
+	for _, row := range rows {
+		if len(row.errors) == 0 {
+			write, err := is.buildWrite(run, row)
+			if err != nil {
+				return err
+			}
+			if len(row.errors) == 0 {
+				writes = append(writes, write)
+				written = append(written, row)
+			}
+		}
+	}
+
+	if run.options.Mode == models.ImportCommit && len(writes) > 0 {
+		// The events are saved in the same transaction as the products. In a
+		// transaction a write error aborts the whole batch.
+		var writeErrs []error
+		err := is.outbox.Run(context.Background(), func(ctx context.Context) error {
+			var err error
+			writeErrs, err = is.productRepository.BulkWriteProducts(ctx, writes)
+			if err != nil {
+				return err
+			}
+			return is.recordEvents(ctx, written, writeErrs)
+		})
+		if err != nil {
+			return err
+		}
+		for i, writeErr := range writeErrs {
+			if writeErr != nil {
+				written[i].fail("", "error writing product: "+writeErr.Error())
+			}
+		}
+	}
+
+	for _, row := range rows {
+		switch {
+		case len(row.errors) > 0:
+			run.report.Failed++
+			run.report.Errors = append(run.report.Errors, models.ImportRowError{Row: row.line, Errors: row.errors})
+		case row.existing != nil:
+			run.report.Updated++
+		default:
+			run.report.Created++
+		}
+	}
+	return nil
+}
+
+// recordEvents saves the events of the rows that were written, writeErrs has
+// the error of every row that wasn't
+func (is *ImportService) recordEvents(ctx context.Context, written []*importRow, writeErrs []error) error {
+	var productEvents []models.Event
+	add := func(eventType string, data interface{}) error {
+		event, err := models.NewEvent(eventType, data)
+		if err != nil {
+			return err
+		}
+		productEvents = append(productEvents, event)
+		return nil
+	}
+
+	for i, row := range written {
+		if writeErrs[i] != nil {
+			continue
+		}
+		if row.existing == nil {
+			if err := add(models.EventProductCreated, row.product); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := add(models.EventProductUpdated, row.product); err != nil {
+			return err
+		}
+		if row.existing.Price != row.product.Price {
+			change := models.PriceChange{ID: row.product.ID, OldPrice: row.existing.Price, NewPrice: row.product.Price}
+			if err := add(models.EventProductPriceChanged, change); err != nil {
+				return err
+			}
+		}
+	}
+	if len(productEvents) == 0 {
+		return nil
+	}
+
+	if err := is.outbox.RecordEvents(ctx, productEvents); err != nil {
+		return fmt.Errorf("error recording product events: %w", err)
+	}
+	return nil
+}
+
+// matchProducts finds the existing product of every row, by the product
+// owning the variant with the row's sku or else by name
+func (is *ImportService) matchProducts(run *importRun, rows []*importRow, skus, names []string) error {
+	variants, err := is.variantRepository.GetVariantsBySKUs(context.Background(), skus)
+	if err != nil {
+		return err
+	}
+	productIDs := make([]primitive.ObjectID, 0, len(variants))
+	for _, variant := range variants {
+		productIDs = append(productIDs, variant.ProductID)
+	}
+	productsByID, err := is.productRepository.GetProductsByIDs(context.Background(), productIDs)
+	if err != nil {
+		return err
+	}
+	productsByName, err := is.productRepository.GetProductsByNames(context.Background(), names)
+	if err != nil {
+		return err
+	}
+
+	productsBySKU := make(map[string]*models.Product, len(variants))
+	for sku, variant := range variants {
+		productsBySKU[sku] = productsByID[variant.ProductID]
+	}
+
+	for _, row := range rows {
+		if len(row.errors) > 0 {
+			continue
+		}
+
+		sku, name := row.fields["sku"], row.fields["name"]
+		existing, err := models.MatchImportProduct(sku, name, productsBySKU, productsByName)
+		var matchErr *models.ImportMatchError
+		if errors.As(err, &matchErr) {
+			row.fail(matchErr.Field, matchErr.Message)
+			continue
+		}
+		row.existing = existing
+
+		// A row with an unknown sku was matched by name, it can't be for the
+		// same product as a row with that name
+		if sku != "" && productsBySKU[sku] == nil {
+			key := "name:" + name
+			if line, ok := run.seen[key]; ok {
+				row.fail("", fmt.Sprintf("row %d is for the same product", line))
+				continue
+			}
+			run.seen[key] = row.line
+		}
+	}
+	return nil
+}
+
+// buildWrite turns a row into an insert of a new product, or an update of the
+// fields it sets on the existing one. Either way the resulting product goes
+// through the product validators.
+func (is *ImportService) buildWrite(run *importRun, row *importRow) (repositories.ProductWrite, error) {
+	product := models.Product{}
+	if row.existing != nil {
+		product = *row.existing
+	}
+	update := bson.M{}
+	fields := row.fields
+
+	if name := fields["name"]; name != "" {
+		product.Name = name
+		update["name"] = name
+	}
+	if description := fields["description"]; description != "" {
+		product.Description = description
+		update["description"] = description
+	}
+
+	currency := strings.ToUpper(fields["currency"])
+	if amount := fields["price"]; amount != "" {
+		if currency == "" {
+			currency = product.Price.Currency
+		}
+		price, err := models.ParseMoney(amount, currency)
+		if err != nil {
+			row.fail("price", err.Error())
+		} else {
+			product.Price = price
+			update["price"] = price
+		}
+	} else if currency != "" && currency != product.Price.Currency {
+		row.fail("currency", "changing the currency requires a price")
+	}
+
+	if category := fields["category"]; category != "" {
+		categoryID, err := is.findCategory(run, category)
+		if errors.Is(err, errCategoryMissing) {
+			row.fail("category", fmt.Sprintf("category %q does not exist", category))
+		} else if err != nil {
+			return repositories.ProductWrite{}, err
+		} else {
+			product.CategoryID = categoryID
+			update["category_id"] = categoryID
+		}
+	}
+
+	for field, target := range map[string]*int{"stock_quantity": &product.StockQuantity, "low_stock_threshold": &product.LowStockThreshold} {
+		value := fields[field]
+		if value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			row.fail(field, "must be a whole number")
+			continue
+		}
+		// Stock of existing products only changes through the stock endpoints
+		if field == "stock_quantity" && row.existing != nil {
+			continue
+		}
+		*target = number
+		if field != "stock_quantity" {
+			update[field] = number
+		}
+	}
+
+	if len(row.errors) > 0 {
+		return repositories.ProductWrite{}, nil
+	}
+
+	row.product = &product
+	if row.existing == nil {
+		row.errors = append(row.errors, validations.ValidateProductCreate(&product)...)
+		return repositories.ProductWrite{Insert: &product}, nil
+	}
+	row.errors = append(row.errors, validations.ValidateProduct(&product)...)
+	return repositories.ProductWrite{ID: product.ID, Update: update}, nil
+}
+
+func (is *ImportService) findCategory(run *importRun, idOrSlug string) (primitive.ObjectID, error) {
+	if id, ok := run.categories[idOrSlug]; ok {
+		if id.IsZero() {
+			return id, errCategoryMissing
+		}
+		return id, nil
+	}
+
+	category, err := is.categoryService.FindCategory(idOrSlug)
+	if err != nil {
+		var customErr *utils.CustomError
+		if errors.As(err, &customErr) && customErr.StatusCode == 404 {
+			run.categories[idOrSlug] = primitive.NilObjectID
+			return primitive.NilObjectID, errCategoryMissing
+		}
+		return primitive.NilObjectID, err
+	}
+	run.categories[idOrSlug] = category.ID
+	return category.ID, nil
+}
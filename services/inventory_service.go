@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const ErrInsufficientStockMessage = "Insufficient stock"
+
+type InventoryService struct {
+	productRepository       *repositories.ProductRepository
+	variantRepository       *repositories.VariantRepository
+	stockMovementRepository *repositories.StockMovementRepository
+}
+
+func NewInventoryService(productRepository *repositories.ProductRepository, variantRepository *repositories.VariantRepository, stockMovementRepository *repositories.StockMovementRepository) *InventoryService {
+	return &InventoryService{
+		productRepository:       productRepository,
+		variantRepository:       variantRepository,
+		stockMovementRepository: stockMovementRepository,
+	}
+}
+
+func (is *InventoryService) AdjustStock(id string, request models.StockAdjustmentRequest, userID string) (*models.Product, error) {
+	delta := request.Quantity
+	switch request.Type {
+	case models.StockReceive, models.StockWriteOff:
+		if request.Quantity <= 0 {
+			return nil, utils.NewCustomError(400, "Quantity must be positive", nil)
+		}
+		if request.Type == models.StockWriteOff {
+			delta = -request.Quantity
+		}
+	case models.StockAdjust:
+		if request.Quantity == 0 {
+			return nil, utils.NewCustomError(400, "Quantity must not be zero", nil)
+		}
+	default:
+		return nil, utils.NewCustomError(400, "Invalid stock adjustment type", nil)
+	}
+
+	return is.changeStock(id, request.SKU, request.Type, delta, request.Reason, userID,
+		is.productRepository.AdjustStock, is.variantRepository.AdjustStock)
+}
+
+// ReserveStock sets aside stock of the product, or of its variant with the given SKU
+func (is *InventoryService) ReserveStock(id string, sku string, quantity int, reason string, userID string) (*models.Product, error) {
+	return is.changeStock(id, sku, models.StockReserve, quantity, reason, userID,
+		is.productRepository.ReserveStock, is.variantRepository.ReserveStock)
+}
+
+func (is *InventoryService) ReleaseStock(id string, sku string, quantity int, reason string, userID string) (*models.Product, error) {
+	return is.changeStock(id, sku, models.StockRelease, quantity, reason, userID,
+		is.productRepository.ReleaseStock, is.variantRepository.ReleaseStock)
+}
+
+// CommitStock removes reserved units from stock once they have been shipped
+func (is *InventoryService) CommitStock(id string, sku string, quantity int, reason string, userID string) (*models.Product, error) {
+	return is.changeStock(id, sku, models.StockSale, quantity, reason, userID,
+		is.productRepository.CommitStock, is.variantRepository.CommitStock)
+}
+
+func (is *InventoryService) ListLowStock(pagination utils.Pagination) (utils.PaginatedResponse, error) {
+	products, totalRows, err := is.productRepository.ListLowStock(
+		context.Background(),
+		pagination.GetLimit(),
+		pagination.GetOffset(),
+		pagination.GetSort(),
+	)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidSort) {
+			return utils.PaginatedResponse{}, utils.NewCustomError(400, "Invalid sort parameter", err.Error())
+		}
+		return utils.PaginatedResponse{}, utils.NewCustomError(500, "Error listing low stock products", err)
+	}
+
+	return pagination.GenerateResponse(products, totalRows), nil
+}
+
+func (is *InventoryService) ListMovements(id string, pagination utils.Pagination) (utils.PaginatedResponse, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.PaginatedResponse{}, utils.NewCustomError(400, ErrInvalidIdMessage, err)
+	}
+
+	movements, totalRows, err := is.stockMovementRepository.ListMovements(
+		context.Background(),
+		objectID,
+		pagination.GetLimit(),
+		pagination.GetOffset(),
+	)
+	if err != nil {
+		return utils.PaginatedResponse{}, utils.NewCustomError(500, "Error listing stock movements", err)
+	}
+
+	return pagination.GenerateResponse(movements, totalRows), nil
+}
+
+type (
+	stockUpdate        func(ctx context.Context, id primitive.ObjectID, quantity int) (*models.Product, error)
+	variantStockUpdate func(ctx context.Context, sku string, quantity int) (*models.Variant, error)
+)
+
+// changeStock applies a stock update to the product, or to its variant if a
+// SKU is given, and records it in the stock movement log
+func (is *InventoryService) changeStock(id, sku, movementType string, quantity int, reason, userID string, update stockUpdate, variantUpdate variantStockUpdate) (*models.Product, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidIdMessage, err)
+	}
+
+	var product *models.Product
+	if sku == "" {
+		product, err = update(context.Background(), objectID, quantity)
+	} else {
+		product, err = is.changeVariantStock(objectID, sku, quantity, variantUpdate)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, repositories.ErrProductNotFound):
+			return nil, utils.NewCustomError(404, ErrProductNotFoundMessage, err)
+		case errors.Is(err, repositories.ErrVariantNotFound):
+			return nil, utils.NewCustomError(404, ErrVariantNotFoundMessage, sku)
+		case errors.Is(err, repositories.ErrStockTrackedPerVariant):
+			return nil, utils.NewCustomError(400, "Product has variants, the SKU of the variant is required", nil)
+		case errors.Is(err, repositories.ErrInsufficientStock):
+			return nil, utils.NewCustomError(409, ErrInsufficientStockMessage, nil)
+		}
+		return nil, utils.NewCustomError(500, "Error updating stock", err)
+	}
+
+	signedQuantity := quantity
+	if movementType == models.StockRelease || movementType == models.StockSale {
+		signedQuantity = -quantity
+	}
+	movement := &models.StockMovement{
+		ProductID: objectID,
+		SKU:       sku,
+		Type:      movementType,
+		Quantity:  signedQuantity,
+		Reason:    reason,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}
+	// The stock is already updated, so a failed log entry must not fail the request
+	if err := is.stockMovementRepository.CreateMovement(context.Background(), movement); err != nil {
+		log.Println("Error recording stock movement:", err)
+	}
+
+	return product, nil
+}
+
+// changeVariantStock updates the stock of a variant of the product and
+// returns the product with all its variants
+func (is *InventoryService) changeVariantStock(productID primitive.ObjectID, sku string, quantity int, update variantStockUpdate) (*models.Product, error) {
+	ctx := context.Background()
+
+	variant, err := is.variantRepository.GetVariantBySKU(ctx, sku)
+	if err != nil {
+		return nil, err
+	}
+	if variant.ProductID != productID {
+		return nil, repositories.ErrVariantNotFound
+	}
+
+	if _, err := update(ctx, sku, quantity); err != nil {
+		return nil, err
+	}
+
+	product, err := is.productRepository.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	product.Variants, err = is.variantRepository.ListVariants(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	return product, nil
+}
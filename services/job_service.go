@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/jobs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ErrJobNotFoundMessage = "Job not found"
+	ErrInvalidJobId       = "Invalid job ID"
+)
+
+// JobService lets admins inspect background jobs and retry or cancel them
+type JobService struct {
+	jobRepository *repositories.JobRepository
+	runner        *jobs.Runner
+	config        configs.JobsConfig
+}
+
+func NewJobService(jobRepository *repositories.JobRepository, runner *jobs.Runner, config configs.JobsConfig) *JobService {
+	return &JobService{
+		jobRepository: jobRepository,
+		runner:        runner,
+		config:        config,
+	}
+}
+
+func (js *JobService) ListJobs(status, jobType string, pagination utils.Pagination) (utils.PaginatedResponse, error) {
+	results, totalRows, err := js.jobRepository.ListJobs(context.Background(), status, jobType, pagination.GetLimit(), pagination.GetOffset())
+	if err != nil {
+		return utils.PaginatedResponse{}, utils.NewCustomError(500, "Error listing jobs", err)
+	}
+	return pagination.GenerateResponse(results, totalRows), nil
+}
+
+func (js *JobService) GetJob(id string) (*models.Job, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidJobId, err)
+	}
+
+	job, err := js.jobRepository.GetJob(context.Background(), objectID)
+	if err != nil {
+		return nil, jobError(err, "Error retrieving job")
+	}
+	return job, nil
+}
+
+// RetryJob runs a dead or cancelled job again with a fresh set of attempts
+func (js *JobService) RetryJob(id string) (*models.Job, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidJobId, err)
+	}
+
+	job, err := js.jobRepository.RetryJob(context.Background(), objectID)
+	if err != nil {
+		return nil, jobError(err, "Error retrying job")
+	}
+	js.runner.Notify()
+	return job, nil
+}
+
+// CancelJob keeps a pending job from running, a running job is stopped when
+// its worker next renews the lease
+func (js *JobService) CancelJob(id string) (*models.Job, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidJobId, err)
+	}
+
+	job, err := js.jobRepository.CancelJob(context.Background(), objectID, js.config.Retention)
+	if err != nil {
+		return nil, jobError(err, "Error cancelling job")
+	}
+	return job, nil
+}
+
+func jobError(err error, message string) error {
+	switch {
+	case errors.Is(err, repositories.ErrJobNotFound):
+		return utils.NewCustomError(404, ErrJobNotFoundMessage, err)
+	case errors.Is(err, repositories.ErrJobNotRetryable):
+		return utils.NewCustomError(409, "Only dead and cancelled jobs can be retried", err)
+	case errors.Is(err, repositories.ErrJobNotCancellable):
+		return utils.NewCustomError(409, "Only pending and running jobs can be cancelled", err)
+	default:
+		return utils.NewCustomError(500, message, err)
+	}
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ErrOrderNotFoundMessage = "Order not found"
+	ErrInvalidOrderId       = "Invalid order ID"
+)
+
+type OrderService struct {
+	orderRepository   *repositories.OrderRepository
+	cartRepository    *repositories.CartRepository
+	productRepository *repositories.ProductRepository
+	variantRepository *repositories.VariantRepository
+	inventoryService  *InventoryService
+}
+
+func NewOrderService(orderRepository *repositories.OrderRepository, cartRepository *repositories.CartRepository, productRepository *repositories.ProductRepository, variantRepository *repositories.VariantRepository, inventoryService *InventoryService) *OrderService {
+	return &OrderService{
+		orderRepository:   orderRepository,
+		cartRepository:    cartRepository,
+		productRepository: productRepository,
+		variantRepository: variantRepository,
+		inventoryService:  inventoryService,
+	}
+}
+
+// Checkout turns the user's cart into a pending order. Product names and
+// prices are copied into the order and the stock for every line is reserved.
+func (ors *OrderService) Checkout(userID string) (*models.Order, error) {
+	userObjectID, err := primitive.ObjectIDFromHex(userID)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidUserId, err)
+	}
+
+	cart, err := ors.cartRepository.GetCart(context.Background(), userObjectID)
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error retrieving cart", err)
+	}
+	if len(cart.Items) == 0 {
+		return nil, utils.NewCustomError(400, "Cart is empty", nil)
+	}
+
+	products, variants, err := loadCartItems(context.Background(), ors.productRepository, ors.variantRepository, cart.Items)
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error retrieving cart products", err)
+	}
+
+	now := time.Now()
+	order := &models.Order{
+		ID:     primitive.NewObjectID(),
+		UserID: userObjectID,
+		Status: models.OrderPending,
+		StatusHistory: []models.OrderStatusChange{
+			{Status: models.OrderPending, UserID: userID, ChangedAt: now},
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, item := range cart.Items {
+		product, ok := products[item.ProductID]
+		variant, variantOK := cartItemVariant(item, variants)
+		if !ok || !variantOK {
+			return nil, utils.NewCustomError(409, "A product in the cart is no longer available", map[string]string{"product_id": item.ProductID.Hex(), "sku": item.SKU})
+		}
+
+		line := models.OrderLine{
+			ProductID: product.ID,
+			Name:      product.Name,
+			UnitPrice: product.Price,
+			Quantity:  item.Quantity,
+		}
+		if variant != nil {
+			line.SKU = variant.SKU
+			line.Options = variant.Options
+			line.UnitPrice = variant.UnitPrice(product)
+		}
+		line.LineTotal = line.UnitPrice.Multiply(item.Quantity)
+		order.Lines = append(order.Lines, line)
+
+		order.Total, err = order.Total.Add(line.LineTotal)
+		if err != nil {
+			return nil, utils.NewCustomError(409, "Products in the cart have different currencies", nil)
+		}
+	}
+
+	// Reserve stock line by line and undo the reservations if any line fails
+	reason := fmt.Sprintf("order %s checkout", order.ID.Hex())
+	for i, line := range order.Lines {
+		if _, err := ors.inventoryService.ReserveStock(line.ProductID.Hex(), line.SKU, line.Quantity, reason, userID); err != nil {
+			ors.releaseLines(order.Lines[:i], fmt.Sprintf("order %s checkout failed", order.ID.Hex()), userID)
+
+			var customErr *utils.CustomError
+			if errors.As(err, &customErr) && customErr.StatusCode == 409 {
+				return nil, utils.NewCustomError(409, ErrInsufficientStockMessage, map[string]string{"product_id": line.ProductID.Hex(), "sku": line.SKU})
+			}
+			return nil, err
+		}
+	}
+
+	if err := ors.orderRepository.CreateOrder(context.Background(), order); err != nil {
+		ors.releaseLines(order.Lines, fmt.Sprintf("order %s checkout failed", order.ID.Hex()), userID)
+		return nil, utils.NewCustomError(500, "Error creating order", err)
+	}
+
+	// The order exists at this point, a stale cart is only an inconvenience
+	if err := ors.cartRepository.ClearCart(context.Background(), userObjectID); err != nil {
+		log.Println("Error clearing cart after checkout:", err)
+	}
+
+	return order, nil
+}
+
+// GetOrder returns an order owned by the user, admins can see every order
+func (ors *OrderService) GetOrder(id string, claims *utils.Claims) (*models.Order, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidOrderId, err)
+	}
+
+	order, err := ors.orderRepository.GetOrder(context.Background(), objectID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrOrderNotFound) {
+			return nil, utils.NewCustomError(404, ErrOrderNotFoundMessage, err)
+		}
+		return nil, utils.NewCustomError(500, "Error retrieving order", err)
+	}
+
+	// Don't reveal that other users' orders exist
+	if claims.Role != "admin" && order.UserID.Hex() != claims.UserID {
+		return nil, utils.NewCustomError(404, ErrOrderNotFoundMessage, nil)
+	}
+
+	return order, nil
+}
+
+// ListOrders lists the user's own orders, or every order for admins
+func (ors *OrderService) ListOrders(claims *utils.Claims, pagination utils.Pagination) (utils.PaginatedResponse, error) {
+	var userID *primitive.ObjectID
+	if claims.Role != "admin" {
+		objectID, err := primitive.ObjectIDFromHex(claims.UserID)
+		if err != nil {
+			return utils.PaginatedResponse{}, utils.NewCustomError(400, ErrInvalidUserId, err)
+		}
+		userID = &objectID
+	}
+
+	orders, totalRows, err := ors.orderRepository.ListOrders(
+		context.Background(),
+		userID,
+		pagination.GetLimit(),
+		pagination.GetOffset(),
+		pagination.GetSort(),
+	)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidSort) {
+			return utils.PaginatedResponse{}, utils.NewCustomError(400, "Invalid sort parameter", err.Error())
+		}
+		return utils.PaginatedResponse{}, utils.NewCustomError(500, "Error listing orders", err)
+	}
+
+	return pagination.GenerateResponse(orders, totalRows), nil
+}
+
+// CancelOrder lets the owner cancel an order that hasn't shipped yet
+func (ors *OrderService) CancelOrder(id string, claims *utils.Claims) (*models.Order, error) {
+	return ors.UpdateStatus(id, models.OrderCancelled, claims)
+}
+
+// UpdateStatus moves an order along its lifecycle. Only admins can mark orders
+// as paid or shipped; owners can only cancel their own orders.
+func (ors *OrderService) UpdateStatus(id string, status string, claims *utils.Claims) (*models.Order, error) {
+	order, err := ors.GetOrder(id, claims)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.Role != "admin" && status != models.OrderCancelled {
+		return nil, utils.NewCustomError(403, "Access denied", nil)
+	}
+	if !models.CanTransitionOrder(order.Status, status) {
+		return nil, utils.NewCustomError(409, fmt.Sprintf("Order can't move from %s to %s", order.Status, status), nil)
+	}
+
+	change := models.OrderStatusChange{
+		Status:    status,
+		UserID:    claims.UserID,
+		ChangedAt: time.Now(),
+	}
+	updatedOrder, err := ors.orderRepository.UpdateStatus(context.Background(), order.ID, order.Status, change)
+	if err != nil {
+		if errors.Is(err, repositories.ErrOrderStatusConflict) {
+			return nil, utils.NewCustomError(409, "Order was updated concurrently, try again", err)
+		}
+		return nil, utils.NewCustomError(500, "Error updating order", err)
+	}
+
+	// Settle the reserved stock now that the status change is committed
+	switch status {
+	case models.OrderCancelled:
+		ors.releaseLines(order.Lines, fmt.Sprintf("order %s cancelled", order.ID.Hex()), claims.UserID)
+	case models.OrderShipped:
+		reason := fmt.Sprintf("order %s shipped", order.ID.Hex())
+		for _, line := range order.Lines {
+			if _, err := ors.inventoryService.CommitStock(line.ProductID.Hex(), line.SKU, line.Quantity, reason, claims.UserID); err != nil {
+				log.Printf("Error committing stock for order %s: %v", order.ID.Hex(), err)
+			}
+		}
+	}
+
+	return updatedOrder, nil
+}
+
+func (ors *OrderService) releaseLines(lines []models.OrderLine, reason, userID string) {
+	for _, line := range lines {
+		if _, err := ors.inventoryService.ReleaseStock(line.ProductID.Hex(), line.SKU, line.Quantity, reason, userID); err != nil {
+			log.Printf("Error releasing stock for product %s: %v", line.ProductID.Hex(), err)
+		}
+	}
+}
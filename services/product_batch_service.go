@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/harsh-solanki21/golang-gin-crud-api/validations"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// errBatchItemFailed aborts the transaction of an atomic batch when one of its operations fails
+var errBatchItemFailed = errors.New("batch operation failed")
+
+// ErrCodeTransactionsUnsupported is the error code of atomic batches sent to
+// a deployment without transactions
+const ErrCodeTransactionsUnsupported = "transactions_unsupported"
+
+// ProductBatchService applies batches of product operations. Every operation
+// goes through the same validation and ProductService logic as the
+// single-item endpoints.
+type ProductBatchService struct {
+	productService *ProductService
+	transactions   *repositories.Transactions
+}
+
+func NewProductBatchService(productService *ProductService, transactions *repositories.Transactions) *ProductBatchService {
+	return &ProductBatchService{
+		productService: productService,
+		transactions:   transactions,
+	}
+}
+
+// RunBatch applies the batch's operations in order, created products belong
+// to ownerID. A failed atomic batch returns an error with the per-operation
+// results as its details.
+func (pbs *ProductBatchService) RunBatch(request models.ProductBatchRequest, ownerID *primitive.ObjectID) (*models.ProductBatchResponse, error) {
+	mode := request.Mode
+	if mode == "" {
+		mode = models.BatchBestEffort
+	}
+
+	if mode == models.BatchAtomic {
+		return pbs.runAtomic(request.Operations, ownerID)
+	}
+	return pbs.runBestEffort(request.Operations, ownerID), nil
+}
+
+func (pbs *ProductBatchService) runBestEffort(operations []models.ProductBatchOperation, ownerID *primitive.ObjectID) *models.ProductBatchResponse {
+	results := make([]models.ProductBatchResult, len(operations))
+	for i, operation := range operations {
+		// Every operation is saved with its event like a single-item request
+		var deleted primitive.ObjectID
+		err := pbs.productService.outbox.Run(context.Background(), func(ctx context.Context) error {
+			results[i], deleted = pbs.apply(ctx, i, operation, ownerID)
+			if results[i].Error != nil {
+				return errBatchItemFailed
+			}
+			return nil
+		})
+		if err != nil && !errors.Is(err, errBatchItemFailed) {
+			results[i].Product = nil
+			setBatchError(&results[i], utils.NewCustomError(500, "Error committing operation", err))
+			continue
+		}
+		if !deleted.IsZero() {
+			pbs.deleteImages(&results[i], deleted)
+		}
+	}
+	return newBatchResponse(models.BatchBestEffort, results)
+}
+
+func (pbs *ProductBatchService) runAtomic(operations []models.ProductBatchOperation, ownerID *primitive.ObjectID) (*models.ProductBatchResponse, error) {
+	supported, err := pbs.transactions.Supported(context.Background())
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error checking transaction support", err)
+	}
+	if !supported {
+		return nil, utils.NewCustomError(501, "Atomic batches need MongoDB transactions", map[string]string{
+			"code":   ErrCodeTransactionsUnsupported,
+			"detail": "the database doesn't support transactions or mongo.transactions is off, use the best_effort mode",
+		})
+	}
+
+	// Validation doesn't need the database, so invalid batches fail before a transaction is started
+	results := make([]models.ProductBatchResult, len(operations))
+	for i, operation := range operations {
+		results[i] = newBatchResult(i, operation)
+	}
+	for i, operation := range operations {
+		if failed := validateBatchOperation(&results[i], operation); failed {
+			return nil, abortedBatch(results, i)
+		}
+	}
+
+	// deleted maps the indexes of delete operations to the deleted products
+	deleted := make(map[int]primitive.ObjectID)
+	failedIndex := -1
+	err = pbs.transactions.Run(context.Background(), func(ctx context.Context) error {
+		// The transaction may be retried, every attempt starts over
+		clear(deleted)
+		for i, operation := range operations {
+			var deletedID primitive.ObjectID
+			results[i], deletedID = pbs.apply(ctx, i, operation, ownerID)
+			if results[i].Error != nil {
+				failedIndex = i
+				return errBatchItemFailed
+			}
+			if !deletedID.IsZero() {
+				deleted[i] = deletedID
+			}
+		}
+		failedIndex = -1
+		return nil
+	})
+	if errors.Is(err, errBatchItemFailed) {
+		return nil, abortedBatch(results, failedIndex)
+	}
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error committing batch", err)
+	}
+
+	// The operations' events were recorded in the transaction, image files
+	// aren't part of it and are only removed once it's committed
+	pbs.productService.outbox.Notify()
+	for i, productID := range deleted {
+		pbs.deleteImages(&results[i], productID)
+	}
+	return newBatchResponse(models.BatchAtomic, results), nil
+}
+
+// apply runs one operation with ctx. It returns the ID of a deleted product,
+// whose images still have to be deleted.
+func (pbs *ProductBatchService) apply(ctx context.Context, index int, operation models.ProductBatchOperation, ownerID *primitive.ObjectID) (models.ProductBatchResult, primitive.ObjectID) {
+	result := newBatchResult(index, operation)
+	if failed := validateBatchOperation(&result, operation); failed {
+		return result, primitive.NilObjectID
+	}
+
+	switch operation.Op {
+	case models.BatchCreate:
+		product := operation.Create.ToProduct()
+		product.OwnerID = ownerID
+		if err := pbs.productService.createProduct(ctx, product); err != nil {
+			setBatchError(&result, err)
+			break
+		}
+		result.ID = product.ID.Hex()
+		result.Status = http.StatusCreated
+		result.Product = models.NewProductResponse(product)
+	case models.BatchUpdate:
+		updatedProduct, err := pbs.productService.updateProduct(ctx, operation.ID, operation.Update)
+		if err != nil {
+			setBatchError(&result, err)
+			break
+		}
+		result.Status = http.StatusOK
+		result.Product = models.NewProductResponse(updatedProduct)
+	case models.BatchDelete:
+		productID, err := pbs.productService.deleteProduct(ctx, operation.ID)
+		if err != nil {
+			setBatchError(&result, err)
+			break
+		}
+		result.Status = http.StatusOK
+		return result, productID
+	}
+	return result, primitive.NilObjectID
+}
+
+func (pbs *ProductBatchService) deleteImages(result *models.ProductBatchResult, productID primitive.ObjectID) {
+	if err := pbs.productService.deleteProductImages(productID); err != nil {
+		setBatchError(result, err)
+	}
+}
+
+func newBatchResult(index int, operation models.ProductBatchOperation) models.ProductBatchResult {
+	return models.ProductBatchResult{
+		Index: index,
+		Op:    operation.Op,
+		ID:    operation.ID,
+	}
+}
+
+// validateBatchOperation validates the operation like the single-item
+// controllers do, it reports whether the operation failed validation
+func validateBatchOperation(result *models.ProductBatchResult, operation models.ProductBatchOperation) bool {
+	var validationErrors []map[string]string
+	switch operation.Op {
+	case models.BatchCreate:
+		validationErrors = validations.ValidateCreateProductInput(operation.Create)
+	case models.BatchUpdate:
+		validationErrors = validations.ValidateUpdateProductInput(operation.Update)
+	}
+	if validationErrors != nil {
+		setBatchError(result, utils.NewCustomError(400, "Validation error", validationErrors))
+		return true
+	}
+	return false
+}
+
+// setBatchError records err the way the single-item endpoints would respond with it
+func setBatchError(result *models.ProductBatchResult, err error) {
+	var customErr *utils.CustomError
+	if errors.As(err, &customErr) {
+		result.Status = customErr.StatusCode
+		result.Error = &models.ProductBatchError{Message: customErr.Message, Details: customErr.Details}
+		return
+	}
+	result.Status = http.StatusInternalServerError
+	result.Error = &models.ProductBatchError{Message: "Internal Server Error"}
+}
+
+// abortedBatch is the error of an atomic batch whose operation failedIndex
+// failed. None of the operations is applied, the failed one keeps its error
+// and the others get 424 Failed Dependency.
+func abortedBatch(results []models.ProductBatchResult, failedIndex int) error {
+	for i := range results {
+		if i == failedIndex {
+			continue
+		}
+		results[i].Status = http.StatusFailedDependency
+		results[i].Product = nil
+		results[i].Error = &models.ProductBatchError{Message: fmt.Sprintf("Not applied, operation %d failed", failedIndex)}
+	}
+
+	response := newBatchResponse(models.BatchAtomic, results)
+	return utils.NewCustomError(results[failedIndex].Status, "Batch failed, no operation was applied", response)
+}
+
+func newBatchResponse(mode string, results []models.ProductBatchResult) *models.ProductBatchResponse {
+	response := &models.ProductBatchResponse{
+		Mode:    mode,
+		Results: results,
+	}
+	for _, result := range results {
+		if result.Error != nil {
+			response.Failed++
+		} else {
+			response.Succeeded++
+		}
+	}
+	return response
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/harsh-solanki21/golang-gin-crud-api/events"
 	"github.com/harsh-solanki21/golang-gin-crud-api/models"
 	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
 	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
@@ -20,20 +21,53 @@ const (
 
 type ProductService struct {
 	productRepository *repositories.ProductRepository
+	variantRepository *repositories.VariantRepository
+	reviewRepository  *repositories.ReviewRepository
+	categoryService   *CategoryService
+	imageService      *ImageService
+	outbox            *events.Outbox
 }
 
-func NewProductService(productRepository *repositories.ProductRepository) *ProductService {
+func NewProductService(productRepository *repositories.ProductRepository, variantRepository *repositories.VariantRepository, reviewRepository *repositories.ReviewRepository, categoryService *CategoryService, imageService *ImageService, outbox *events.Outbox) *ProductService {
 	return &ProductService{
 		productRepository: productRepository,
+		variantRepository: variantRepository,
+		reviewRepository:  reviewRepository,
+		categoryService:   categoryService,
+		imageService:      imageService,
+		outbox:            outbox,
 	}
 }
 
 func (ps *ProductService) CreateProduct(product *models.Product) error {
+	return ps.outbox.Run(context.Background(), func(ctx context.Context) error {
+		return ps.createProduct(ctx, product)
+	})
+}
+
+// createProduct creates the product and records its event with ctx, so
+// batches can create products in a transaction
+func (ps *ProductService) createProduct(ctx context.Context, product *models.Product) error {
 	if validationErrors := validations.ValidateProduct(product); validationErrors != nil {
 		return fmt.Errorf("validation error: %v", validationErrors)
 	}
 
-	return ps.productRepository.CreateProduct(context.Background(), product)
+	if err := ps.categoryService.RequireCategory(product.CategoryID); err != nil {
+		return err
+	}
+
+	// Reservations are only made through the inventory endpoints, variants
+	// through the variant endpoints and ratings through the review endpoints
+	product.Reserved = 0
+	product.VariantCount = 0
+	product.AverageRating = 0
+	product.ReviewCount = 0
+	product.RatingSum = 0
+
+	if err := ps.productRepository.CreateProduct(ctx, product); err != nil {
+		return err
+	}
+	return recordEvent(ctx, ps.outbox, models.EventProductCreated, product)
 }
 
 func (ps *ProductService) GetProduct(id string) (*models.Product, error) {
@@ -50,32 +84,75 @@ func (ps *ProductService) GetProduct(id string) (*models.Product, error) {
 		return nil, utils.NewCustomError(500, "Error retrieving product", err)
 	}
 
+	// Return the variant matrix and the images along with the product
+	product.Variants, err = ps.variantRepository.ListVariants(context.Background(), objectID)
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error retrieving variants", err)
+	}
+	product.Images, err = ps.imageService.productImages(objectID)
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error retrieving images", err)
+	}
+
 	return product, nil
 }
 
-func (ps *ProductService) UpdateProduct(id string, product *models.Product) (*models.Product, error) {
-	objectID, err := primitive.ObjectIDFromHex(id)
+func (ps *ProductService) UpdateProduct(id string, input *models.UpdateProductInput) (*models.Product, error) {
+	var updatedProduct *models.Product
+	err := ps.outbox.Run(context.Background(), func(ctx context.Context) error {
+		var err error
+		updatedProduct, err = ps.updateProduct(ctx, id, input)
+		return err
+	})
 	if err != nil {
-		return nil, utils.NewCustomError(400, ErrInvalidIdMessage, err)
+		return nil, err
 	}
+	return updatedProduct, nil
+}
 
-	validationErrors := validations.ValidateProductUpdate(product)
-	if validationErrors != nil {
-		return nil, fmt.Errorf("validation error: %v", validationErrors)
+func (ps *ProductService) updateProduct(ctx context.Context, id string, input *models.UpdateProductInput) (*models.Product, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidIdMessage, err)
 	}
 
 	update := bson.M{}
-	if product.Name != "" {
-		update["name"] = product.Name
+	if input.Name != "" {
+		update["name"] = input.Name
 	}
-	if product.Description != "" {
-		update["description"] = product.Description
+	if input.Description != "" {
+		update["description"] = input.Description
 	}
-	if product.Price != 0 {
-		update["price"] = product.Price
+	// The old price is needed for the price change event
+	var oldPrice *models.Money
+	if !input.Price.IsZero() {
+		current, err := ps.productRepository.GetProduct(ctx, objectID)
+		if err != nil {
+			if errors.Is(err, repositories.ErrProductNotFound) {
+				return nil, utils.NewCustomError(404, ErrProductNotFoundMessage, err)
+			}
+			return nil, utils.NewCustomError(500, "Error retrieving product", err)
+		}
+		oldPrice = &current.Price
+		update["price"] = input.Price
+	}
+	if !input.CategoryID.IsZero() {
+		if err := ps.categoryService.RequireCategory(input.CategoryID); err != nil {
+			return nil, err
+		}
+		update["category_id"] = input.CategoryID
+	}
+	if input.LowStockThreshold != nil {
+		update["low_stock_threshold"] = *input.LowStockThreshold
+	}
+	if input.Options != nil {
+		if err := ps.checkOptions(ctx, objectID, input.Options); err != nil {
+			return nil, err
+		}
+		update["options"] = input.Options
 	}
 
-	updatedProduct, err := ps.productRepository.UpdateProduct(context.Background(), objectID, update)
+	updatedProduct, err := ps.productRepository.UpdateProduct(ctx, objectID, update)
 	if err != nil {
 		if errors.Is(err, repositories.ErrProductNotFound) {
 			return nil, utils.NewCustomError(404, ErrProductNotFoundMessage, err)
@@ -83,36 +160,159 @@ func (ps *ProductService) UpdateProduct(id string, product *models.Product) (*mo
 		return nil, utils.NewCustomError(500, "Error updating product", err)
 	}
 
+	if err := recordEvent(ctx, ps.outbox, models.EventProductUpdated, updatedProduct); err != nil {
+		return nil, err
+	}
+	if oldPrice != nil && *oldPrice != updatedProduct.Price {
+		change := models.PriceChange{ID: objectID, OldPrice: *oldPrice, NewPrice: updatedProduct.Price}
+		if err := recordEvent(ctx, ps.outbox, models.EventProductPriceChanged, change); err != nil {
+			return nil, err
+		}
+	}
+
 	return updatedProduct, nil
 }
 
 func (ps *ProductService) DeleteProduct(id string) error {
+	var objectID primitive.ObjectID
+	err := ps.outbox.Run(context.Background(), func(ctx context.Context) error {
+		var err error
+		objectID, err = ps.deleteProduct(ctx, id)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return ps.deleteProductImages(objectID)
+}
+
+// deleteProduct deletes the product with its variants and reviews. The image
+// files can't be part of a transaction, so they're deleted separately.
+func (ps *ProductService) deleteProduct(ctx context.Context, id string) (primitive.ObjectID, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
-		return utils.NewCustomError(400, ErrInvalidIdMessage, err)
+		return objectID, utils.NewCustomError(400, ErrInvalidIdMessage, err)
 	}
 
-	err = ps.productRepository.DeleteProduct(context.Background(), objectID)
+	err = ps.productRepository.DeleteProduct(ctx, objectID)
 	if err != nil {
 		if errors.Is(err, repositories.ErrProductNotFound) {
-			return utils.NewCustomError(404, ErrProductNotFoundMessage, err)
+			return objectID, utils.NewCustomError(404, ErrProductNotFoundMessage, err)
 		}
-		return utils.NewCustomError(500, "Error deleting product", err)
+		return objectID, utils.NewCustomError(500, "Error deleting product", err)
+	}
+
+	if err := ps.variantRepository.DeleteProductVariants(ctx, objectID); err != nil {
+		return objectID, utils.NewCustomError(500, "Error deleting product variants", err)
 	}
+	if err := ps.reviewRepository.DeleteProductReviews(ctx, objectID); err != nil {
+		return objectID, utils.NewCustomError(500, "Error deleting product reviews", err)
+	}
+
+	return objectID, recordEvent(ctx, ps.outbox, models.EventProductDeleted, deletedData(objectID))
+}
 
+func (ps *ProductService) deleteProductImages(productID primitive.ObjectID) error {
+	if err := ps.imageService.DeleteProductImages(productID); err != nil {
+		return utils.NewCustomError(500, "Error deleting product images", err)
+	}
 	return nil
 }
 
-func (ps *ProductService) ListProducts(pagination utils.Pagination) (utils.PaginatedResponse, error) {
+func (ps *ProductService) ListProducts(pagination utils.Pagination, filter models.ProductFilter) (utils.PaginatedResponse, error) {
+	filter, err := ps.resolveFilter(filter)
+	if err != nil {
+		return utils.PaginatedResponse{}, err
+	}
+
 	products, totalRows, err := ps.productRepository.ListProducts(
 		context.Background(),
+		filter,
 		pagination.GetLimit(),
 		pagination.GetOffset(),
 		pagination.GetSort(),
 	)
 	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidSort) {
+			return utils.PaginatedResponse{}, utils.NewCustomError(400, "Invalid sort parameter", err.Error())
+		}
 		return utils.PaginatedResponse{}, utils.NewCustomError(500, "Error listing products", err)
 	}
 
 	return pagination.GenerateResponse(products, totalRows), nil
 }
+
+// ExportProducts calls write for every product matching the filter, without
+// loading them all into memory. Errors returned by write stop the export.
+func (ps *ProductService) ExportProducts(filter models.ProductFilter, sort string, write func(*models.Product) error) error {
+	filter, err := ps.resolveFilter(filter)
+	if err != nil {
+		return err
+	}
+
+	err = ps.productRepository.StreamProducts(context.Background(), filter, sort, write)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidSort) {
+			return utils.NewCustomError(400, "Invalid sort parameter", err.Error())
+		}
+		return utils.NewCustomError(500, "Error exporting products", err)
+	}
+	return nil
+}
+
+// resolveFilter turns the category and SKU given by the client into the ids the repository filters on
+func (ps *ProductService) resolveFilter(filter models.ProductFilter) (models.ProductFilter, error) {
+	// Listing a category includes its subcategories
+	if filter.Category != "" {
+		categoryIDs, err := ps.categoryService.ResolveCategory(filter.Category)
+		if err != nil {
+			return filter, err
+		}
+		filter.CategoryIDs = categoryIDs
+	}
+
+	// Searching by SKU finds the product the variant belongs to
+	if filter.SKU != "" {
+		filter.ProductIDs = []primitive.ObjectID{}
+		variant, err := ps.variantRepository.GetVariantBySKU(context.Background(), filter.SKU)
+		if err == nil {
+			filter.ProductIDs = append(filter.ProductIDs, variant.ProductID)
+		} else if !errors.Is(err, repositories.ErrVariantNotFound) {
+			return filter, utils.NewCustomError(500, "Error retrieving variant", err)
+		}
+	}
+
+	return filter, nil
+}
+
+// checkOptions makes sure changed option axes still fit the product's existing variants
+func (ps *ProductService) checkOptions(ctx context.Context, productID primitive.ObjectID, options []models.ProductOption) error {
+	variants, err := ps.variantRepository.ListVariants(ctx, productID)
+	if err != nil {
+		return utils.NewCustomError(500, "Error retrieving variants", err)
+	}
+
+	for _, variant := range variants {
+		if err := models.CheckVariantOptions(options, variant.Options); err != nil {
+			return utils.NewCustomError(409, "Options don't match the existing variants", map[string]string{"sku": variant.SKU, "error": err.Error()})
+		}
+	}
+	return nil
+}
+
+// findProduct loads a product by its hex id
+func findProduct(productRepository *repositories.ProductRepository, id string) (*models.Product, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidIdMessage, err)
+	}
+
+	product, err := productRepository.GetProduct(context.Background(), objectID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrProductNotFound) {
+			return nil, utils.NewCustomError(404, ErrProductNotFoundMessage, err)
+		}
+		return nil, utils.NewCustomError(500, "Error retrieving product", err)
+	}
+	return product, nil
+}
@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// productSubscriberBuffer is how many changes a stream may fall behind before
+// it's dropped, the client reconnects and resumes from the replay buffer
+const productSubscriberBuffer = 64
+
+// ProductStreamEvents are the events of the product event stream
+var ProductStreamEvents = []string{models.EventProductCreated, models.EventProductUpdated, models.EventProductDeleted}
+
+// ProductStreamService fans product changes out to the clients of the
+// product event stream. Changes come from a MongoDB change stream, which
+// every instance watches, or else from the event bus. The latest changes are
+// kept so clients can resume after reconnecting.
+type ProductStreamService struct {
+	productRepository *repositories.ProductRepository
+	config            configs.ProductEventsConfig
+
+	mu          sync.Mutex
+	buffer      []models.ProductChange
+	subscribers map[*ProductSubscription]struct{}
+	closed      bool
+
+	// ctx is cancelled on shutdown, it stops the change stream
+	ctx     context.Context
+	cancel  context.CancelFunc
+	watcher sync.WaitGroup
+}
+
+// ProductSubscription receives the changes published after it was created.
+// Changes is closed when the subscriber falls too far behind or the service
+// shuts down.
+type ProductSubscription struct {
+	Changes <-chan models.ProductChange
+	changes chan models.ProductChange
+}
+
+func NewProductStreamService(productRepository *repositories.ProductRepository, config configs.ProductEventsConfig) *ProductStreamService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ProductStreamService{
+		productRepository: productRepository,
+		config:            config,
+		subscribers:       make(map[*ProductSubscription]struct{}),
+		ctx:               ctx,
+		cancel:            cancel,
+	}
+}
+
+// Heartbeat is how often idle streams get a comment to keep proxies from closing them
+func (pss *ProductStreamService) Heartbeat() time.Duration {
+	return pss.config.Heartbeat
+}
+
+// Subscribe starts a subscription along with the buffered changes after
+// lastEventID. It reports false if lastEventID is no longer buffered, the
+// client may have missed changes and should reload the products.
+func (pss *ProductStreamService) Subscribe(lastEventID string) (*ProductSubscription, []models.ProductChange, bool) {
+	pss.mu.Lock()
+	defer pss.mu.Unlock()
+
+	changes := make(chan models.ProductChange, productSubscriberBuffer)
+	subscription := &ProductSubscription{Changes: changes, changes: changes}
+	if pss.closed {
+		close(changes)
+	} else {
+		pss.subscribers[subscription] = struct{}{}
+	}
+
+	if lastEventID == "" {
+		return subscription, nil, true
+	}
+	index := slices.IndexFunc(pss.buffer, func(change models.ProductChange) bool { return change.ID == lastEventID })
+	if index < 0 {
+		return subscription, nil, false
+	}
+	return subscription, slices.Clone(pss.buffer[index+1:]), true
+}
+
+// Unsubscribe ends the subscription
+func (pss *ProductStreamService) Unsubscribe(subscription *ProductSubscription) {
+	pss.mu.Lock()
+	defer pss.mu.Unlock()
+
+	if _, ok := pss.subscribers[subscription]; ok {
+		delete(pss.subscribers, subscription)
+		close(subscription.changes)
+	}
+}
+
+// Publish buffers the change and sends it to the subscribers. Changes that
+// are already buffered are dropped, the event bus may dispatch an event twice.
+func (pss *ProductStreamService) Publish(change models.ProductChange) {
+	pss.mu.Lock()
+	defer pss.mu.Unlock()
+
+	if pss.closed || slices.ContainsFunc(pss.buffer, func(buffered models.ProductChange) bool { return buffered.ID == change.ID }) {
+		return
+	}
+	if len(pss.buffer) >= pss.config.ReplayBuffer {
+		pss.buffer = slices.Delete(pss.buffer, 0, len(pss.buffer)-pss.config.ReplayBuffer+1)
+	}
+	pss.buffer = append(pss.buffer, change)
+
+	for subscription := range pss.subscribers {
+		select {
+		case subscription.changes <- change:
+		default:
+			// Too far behind, blocking here would hold up every other client
+			delete(pss.subscribers, subscription)
+			close(subscription.changes)
+		}
+	}
+}
+
+// HandleEvent publishes a product event from the event bus, it's the
+// stream's source when change streams aren't available. The outbox relay
+// dispatches each event on one instance only, so this source is only
+// complete when a single instance of the API is running.
+func (pss *ProductStreamService) HandleEvent(ctx context.Context, event models.Event) error {
+	change := models.ProductChange{ID: event.ID.Hex(), Type: event.Type}
+	if event.Type == models.EventProductDeleted {
+		var deleted struct {
+			ID primitive.ObjectID `json:"id"`
+		}
+		if err := json.Unmarshal(event.Data, &deleted); err != nil {
+			return err
+		}
+		change.ProductID = deleted.ID
+	} else {
+		var product models.Product
+		if err := json.Unmarshal(event.Data, &product); err != nil {
+			return err
+		}
+		change.ProductID = product.ID
+		change.Product = &product
+	}
+	pss.Publish(change)
+	return nil
+}
+
+// Watch starts publishing the changes of the products collection's change stream
+func (pss *ProductStreamService) Watch() {
+	pss.watcher.Add(1)
+	go pss.watch()
+}
+
+// watch follows the change stream, resuming after the last change it saw when the stream fails
+func (pss *ProductStreamService) watch() {
+	defer pss.watcher.Done()
+
+	var resumeAfter bson.Raw
+	failures := 0
+	for {
+		err := pss.productRepository.WatchProducts(pss.ctx, resumeAfter, func(change models.ProductChange) {
+			resumeAfter = change.Token
+			failures = 0
+			pss.Publish(change)
+		})
+		if pss.ctx.Err() != nil {
+			return
+		}
+
+		failures++
+		log.Println("Product change stream stopped:", err)
+		if errors.Is(err, repositories.ErrChangeHistoryLost) {
+			// The changes in between are lost, so clients resuming from the
+			// buffer are told to reload instead
+			resumeAfter = nil
+			pss.mu.Lock()
+			pss.buffer = nil
+			pss.mu.Unlock()
+		}
+
+		select {
+		case <-pss.ctx.Done():
+			return
+		case <-time.After(utils.Backoff(failures, time.Second, time.Minute)):
+		}
+	}
+}
+
+// Close ends every subscription and stops the change stream, it's called
+// when the server starts shutting down so open streams don't delay it
+func (pss *ProductStreamService) Close() {
+	pss.cancel()
+
+	pss.mu.Lock()
+	defer pss.mu.Unlock()
+	if pss.closed {
+		return
+	}
+	pss.closed = true
+	for subscription := range pss.subscribers {
+		close(subscription.changes)
+	}
+	clear(pss.subscribers)
+}
+
+// Shutdown closes the service and waits for the change stream to stop
+func (pss *ProductStreamService) Shutdown(ctx context.Context) error {
+	pss.Close()
+
+	done := make(chan struct{})
+	go func() {
+		pss.watcher.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
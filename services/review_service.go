@@ -0,0 +1,328 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobRecomputeRatings is the job that recomputes the product ratings from
+// their reviews, it runs daily
+const JobRecomputeRatings = "reviews.recompute_ratings"
+
+const (
+	ErrReviewNotFoundMessage  = "Review not found"
+	ErrInvalidReviewIdMessage = "Invalid review ID"
+)
+
+// ReviewService keeps the product ratings in step with the reviews. A review
+// and its product's rating are written in one transaction when the deployment
+// supports them, JobRecomputeRatings repairs the ratings otherwise.
+type ReviewService struct {
+	reviewRepository  *repositories.ReviewRepository
+	productRepository *repositories.ProductRepository
+	transactions      *repositories.Transactions
+}
+
+func NewReviewService(reviewRepository *repositories.ReviewRepository, productRepository *repositories.ProductRepository, transactions *repositories.Transactions) *ReviewService {
+	return &ReviewService{
+		reviewRepository:  reviewRepository,
+		productRepository: productRepository,
+		transactions:      transactions,
+	}
+}
+
+// CreateReview publishes the user's review of a product, users can review every product once
+func (rs *ReviewService) CreateReview(productID string, request models.ReviewRequest, claims *utils.Claims) (*models.Review, error) {
+	product, err := findProduct(rs.productRepository, productID)
+	if err != nil {
+		return nil, err
+	}
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidUserId, err)
+	}
+
+	review := &models.Review{
+		ProductID: product.ID,
+		UserID:    userID,
+		Rating:    request.Rating,
+		Title:     request.Title,
+		Body:      request.Body,
+		Status:    models.ReviewPublished,
+	}
+	err = rs.transactions.RunIfSupported(context.Background(), func(ctx context.Context) error {
+		if err := rs.reviewRepository.CreateReview(ctx, review); err != nil {
+			if errors.Is(err, repositories.ErrReviewExists) {
+				return utils.NewCustomError(409, "You already reviewed this product", err)
+			}
+			return utils.NewCustomError(500, "Error creating review", err)
+		}
+		return rs.applyRatingChange(ctx, product.ID, nil, review)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// ListReviews lists the published reviews of a product. Admins can also list
+// hidden reviews, or reviews of any status with "all".
+func (rs *ReviewService) ListReviews(productID string, status string, claims *utils.Claims, pagination utils.Pagination) (utils.PaginatedResponse, error) {
+	product, err := findProduct(rs.productRepository, productID)
+	if err != nil {
+		return utils.PaginatedResponse{}, err
+	}
+
+	filter := models.ReviewFilter{ProductID: &product.ID, Status: models.ReviewPublished}
+	if claims.Role == "admin" {
+		switch status {
+		case "", models.ReviewPublished:
+		case models.ReviewHidden:
+			filter.Status = models.ReviewHidden
+		case "all":
+			filter.Status = ""
+		default:
+			return utils.PaginatedResponse{}, utils.NewCustomError(400, "Invalid review status", status)
+		}
+	}
+
+	return rs.listReviews(filter, claims, pagination)
+}
+
+// ListReportedReviews lists the reviews of all products that were reported as abusive
+func (rs *ReviewService) ListReportedReviews(claims *utils.Claims, pagination utils.Pagination) (utils.PaginatedResponse, error) {
+	return rs.listReviews(models.ReviewFilter{Reported: true}, claims, pagination)
+}
+
+// UpdateReview lets authors edit their own reviews
+func (rs *ReviewService) UpdateReview(productID, id string, request models.ReviewUpdateRequest, claims *utils.Claims) (*models.Review, error) {
+	review, err := rs.getReview(productID, id, claims)
+	if err != nil {
+		return nil, err
+	}
+	if review.UserID.Hex() != claims.UserID {
+		return nil, utils.NewCustomError(403, "You can only edit your own reviews", nil)
+	}
+
+	update := bson.M{}
+	if request.Rating != nil {
+		update["rating"] = *request.Rating
+	}
+	if request.Title != nil {
+		update["title"] = *request.Title
+	}
+	if request.Body != nil {
+		update["body"] = *request.Body
+	}
+	if len(update) == 0 {
+		return review, nil
+	}
+
+	var after models.Review
+	err = rs.transactions.RunIfSupported(context.Background(), func(ctx context.Context) error {
+		before, err := rs.reviewRepository.UpdateReview(ctx, review.ProductID, review.ID, update)
+		if err != nil {
+			return reviewWriteError(err, "Error updating review")
+		}
+
+		after = *before
+		if request.Rating != nil {
+			after.Rating = *request.Rating
+		}
+		if request.Title != nil {
+			after.Title = *request.Title
+		}
+		if request.Body != nil {
+			after.Body = *request.Body
+		}
+		after.UpdatedAt = time.Now()
+
+		return rs.applyRatingChange(ctx, after.ProductID, before, &after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &after, nil
+}
+
+// ModerateReview hides a review, or approves it, which publishes it and dismisses its reports
+func (rs *ReviewService) ModerateReview(productID, id string, status string) (*models.Review, error) {
+	productObjectID, reviewID, err := parseReviewIDs(productID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var after models.Review
+	err = rs.transactions.RunIfSupported(context.Background(), func(ctx context.Context) error {
+		var before *models.Review
+		var err error
+		if status == models.ReviewPublished {
+			before, err = rs.reviewRepository.ApproveReview(ctx, productObjectID, reviewID)
+		} else {
+			before, err = rs.reviewRepository.UpdateReview(ctx, productObjectID, reviewID, bson.M{"status": status})
+		}
+		if err != nil {
+			return reviewWriteError(err, "Error moderating review")
+		}
+
+		after = *before
+		after.Status = status
+		if status == models.ReviewPublished {
+			after.ReportCount = 0
+			after.Reports = nil
+		}
+		after.UpdatedAt = time.Now()
+
+		return rs.applyRatingChange(ctx, after.ProductID, before, &after)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &after, nil
+}
+
+// DeleteReview lets authors delete their own reviews, and admins any review
+func (rs *ReviewService) DeleteReview(productID, id string, claims *utils.Claims) error {
+	review, err := rs.getReview(productID, id, claims)
+	if err != nil {
+		return err
+	}
+	if claims.Role != "admin" && review.UserID.Hex() != claims.UserID {
+		return utils.NewCustomError(403, "You can only delete your own reviews", nil)
+	}
+
+	return rs.transactions.RunIfSupported(context.Background(), func(ctx context.Context) error {
+		deleted, err := rs.reviewRepository.DeleteReview(ctx, review.ProductID, review.ID)
+		if err != nil {
+			return reviewWriteError(err, "Error deleting review")
+		}
+		return rs.applyRatingChange(ctx, deleted.ProductID, deleted, nil)
+	})
+}
+
+// ReportReview flags another user's review as abusive for the admins to moderate
+func (rs *ReviewService) ReportReview(productID, id string, reason string, claims *utils.Claims) error {
+	review, err := rs.getReview(productID, id, claims)
+	if err != nil {
+		return err
+	}
+	userID, err := primitive.ObjectIDFromHex(claims.UserID)
+	if err != nil {
+		return utils.NewCustomError(400, ErrInvalidUserId, err)
+	}
+	if review.UserID == userID {
+		return utils.NewCustomError(400, "You can't report your own review", nil)
+	}
+
+	report := models.ReviewReport{UserID: userID, Reason: reason, CreatedAt: time.Now()}
+	if err := rs.reviewRepository.AddReport(context.Background(), review.ProductID, review.ID, report); err != nil {
+		if errors.Is(err, repositories.ErrAlreadyReported) {
+			return utils.NewCustomError(409, "You already reported this review", err)
+		}
+		return reviewWriteError(err, "Error reporting review")
+	}
+	return nil
+}
+
+// getReview loads a review. Hidden reviews are only visible to their author and admins.
+func (rs *ReviewService) getReview(productID, id string, claims *utils.Claims) (*models.Review, error) {
+	productObjectID, reviewID, err := parseReviewIDs(productID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	review, err := rs.reviewRepository.GetReview(context.Background(), productObjectID, reviewID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrReviewNotFound) {
+			return nil, utils.NewCustomError(404, ErrReviewNotFoundMessage, err)
+		}
+		return nil, utils.NewCustomError(500, "Error retrieving review", err)
+	}
+
+	if review.Status != models.ReviewPublished && claims.Role != "admin" && review.UserID.Hex() != claims.UserID {
+		return nil, utils.NewCustomError(404, ErrReviewNotFoundMessage, nil)
+	}
+	return review, nil
+}
+
+func (rs *ReviewService) listReviews(filter models.ReviewFilter, claims *utils.Claims, pagination utils.Pagination) (utils.PaginatedResponse, error) {
+	reviews, totalRows, err := rs.reviewRepository.ListReviews(
+		context.Background(),
+		filter,
+		pagination.GetLimit(),
+		pagination.GetOffset(),
+		pagination.GetSort(),
+	)
+	if err != nil {
+		if errors.Is(err, repositories.ErrInvalidSort) {
+			return utils.PaginatedResponse{}, utils.NewCustomError(400, "Invalid sort parameter", err.Error())
+		}
+		return utils.PaginatedResponse{}, utils.NewCustomError(500, "Error listing reviews", err)
+	}
+
+	// Only admins see who reported a review
+	if claims.Role != "admin" {
+		for _, review := range reviews {
+			review.Reports = nil
+		}
+	}
+
+	return pagination.GenerateResponse(reviews, totalRows), nil
+}
+
+// RecomputeRatings sets the rating of every product to the totals of its
+// published reviews, it's the handler of JobRecomputeRatings. Without
+// transactions a failure between a review write and its rating change leaves
+// the rating out of step until it runs.
+func (rs *ReviewService) RecomputeRatings(ctx context.Context, job *models.Job) error {
+	totals, err := rs.reviewRepository.RatingTotals(ctx)
+	if err != nil {
+		return err
+	}
+	repaired, err := rs.productRepository.SetRatings(ctx, totals)
+	if err != nil {
+		return err
+	}
+	if repaired > 0 {
+		log.Printf("Recomputed the rating of %d products\n", repaired)
+	}
+	return nil
+}
+
+// applyRatingChange moves the product rating by the difference between the
+// review before and after a change
+func (rs *ReviewService) applyRatingChange(ctx context.Context, productID primitive.ObjectID, before, after *models.Review) error {
+	sum, count := models.RatingChange(before, after)
+	err := rs.productRepository.ApplyRatingChange(ctx, productID, sum, count)
+	if err != nil && !errors.Is(err, repositories.ErrProductNotFound) {
+		return utils.NewCustomError(500, "Error updating product rating", err)
+	}
+	return nil
+}
+
+func parseReviewIDs(productID, id string) (primitive.ObjectID, primitive.ObjectID, error) {
+	productObjectID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, utils.NewCustomError(400, ErrInvalidIdMessage, err)
+	}
+	reviewID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, utils.NewCustomError(400, ErrInvalidReviewIdMessage, err)
+	}
+	return productObjectID, reviewID, nil
+}
+
+func reviewWriteError(err error, message string) error {
+	if errors.Is(err, repositories.ErrReviewNotFound) {
+		return utils.NewCustomError(404, ErrReviewNotFoundMessage, err)
+	}
+	return utils.NewCustomError(500, message, err)
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/events"
 	"github.com/harsh-solanki21/golang-gin-crud-api/models"
 	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
 	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
@@ -20,11 +22,15 @@ const (
 
 type UserService struct {
 	userRepository *repositories.UserRepository
+	outbox         *events.Outbox
+	config         configs.AuthConfig
 }
 
-func NewUserService(userRepository *repositories.UserRepository) *UserService {
+func NewUserService(userRepository *repositories.UserRepository, outbox *events.Outbox, config configs.AuthConfig) *UserService {
 	return &UserService{
 		userRepository: userRepository,
+		outbox:         outbox,
+		config:         config,
 	}
 }
 
@@ -33,13 +39,18 @@ func (us *UserService) CreateUser(user *models.User) error {
 		return fmt.Errorf("validation error: %v", validationErrors)
 	}
 
-	hashedPassword, err := utils.HashPassword(user.Password)
+	hashedPassword, err := utils.HashPassword(user.Password, us.config.BcryptCost)
 	if err != nil {
 		return err
 	}
 	user.Password = hashedPassword
 
-	return us.userRepository.CreateUser(context.Background(), user)
+	return us.outbox.Run(context.Background(), func(ctx context.Context) error {
+		if err := us.userRepository.CreateUser(ctx, user); err != nil {
+			return err
+		}
+		return recordEvent(ctx, us.outbox, models.EventUserRegistered, userData(user))
+	})
 }
 
 func (us *UserService) GetUser(id string) (*models.User, error) {
@@ -59,17 +70,22 @@ func (us *UserService) GetUser(id string) (*models.User, error) {
 	return user, nil
 }
 
+// GetUsersByIDs looks up several users with one query, e.g. the owners of a
+// page of products. Users that don't exist are left out.
+func (us *UserService) GetUsersByIDs(ids []primitive.ObjectID) ([]*models.User, error) {
+	users, err := us.userRepository.GetUsersByIDs(context.Background(), ids)
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error retrieving users", err)
+	}
+	return users, nil
+}
+
 func (us *UserService) UpdateUser(id string, user *models.User) (*models.User, error) {
 	objectID, err := primitive.ObjectIDFromHex(id)
 	if err != nil {
 		return nil, utils.NewCustomError(400, ErrInvalidIdMessage, err)
 	}
 
-	validationErrors := validations.ValidateUserUpdate(user)
-	if validationErrors != nil {
-		return nil, fmt.Errorf("validation error: %v", validationErrors)
-	}
-
 	update := bson.M{}
 	if user.Name != "" {
 		update["name"] = user.Name
@@ -78,12 +94,20 @@ func (us *UserService) UpdateUser(id string, user *models.User) (*models.User, e
 		update["email"] = user.Email
 	}
 
-	updatedUser, err := us.userRepository.UpdateUser(context.Background(), objectID, update)
-	if err != nil {
-		if errors.Is(err, repositories.ErrUserNotFound) {
-			return nil, utils.NewCustomError(404, ErrUserNotFoundMessage, err)
+	var updatedUser *models.User
+	err = us.outbox.Run(context.Background(), func(ctx context.Context) error {
+		var err error
+		updatedUser, err = us.userRepository.UpdateUser(ctx, objectID, update)
+		if err != nil {
+			if errors.Is(err, repositories.ErrUserNotFound) {
+				return utils.NewCustomError(404, ErrUserNotFoundMessage, err)
+			}
+			return utils.NewCustomError(500, "Error updating user", err)
 		}
-		return nil, utils.NewCustomError(500, "Error updating user", err)
+		return recordEvent(ctx, us.outbox, models.EventUserUpdated, userData(updatedUser))
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return updatedUser, nil
@@ -95,15 +119,16 @@ func (us *UserService) DeleteUser(id string) error {
 		return utils.NewCustomError(400, ErrInvalidIdMessage, err)
 	}
 
-	err = us.userRepository.DeleteUser(context.Background(), objectID)
-	if err != nil {
-		if errors.Is(err, repositories.ErrUserNotFound) {
-			return utils.NewCustomError(404, ErrUserNotFoundMessage, err)
+	return us.outbox.Run(context.Background(), func(ctx context.Context) error {
+		err := us.userRepository.DeleteUser(ctx, objectID)
+		if err != nil {
+			if errors.Is(err, repositories.ErrUserNotFound) {
+				return utils.NewCustomError(404, ErrUserNotFoundMessage, err)
+			}
+			return utils.NewCustomError(500, "Error deleting user", err)
 		}
-		return utils.NewCustomError(500, "Error deleting user", err)
-	}
-
-	return nil
+		return recordEvent(ctx, us.outbox, models.EventUserDeleted, deletedData(objectID))
+	})
 }
 
 func (us *UserService) ListUsers(pagination utils.Pagination) (utils.PaginatedResponse, error) {
@@ -119,3 +144,11 @@ func (us *UserService) ListUsers(pagination utils.Pagination) (utils.PaginatedRe
 
 	return pagination.GenerateResponse(users, totalRows), nil
 }
+
+// ExportUsers calls write for every user, the users never carry their password hash
+func (us *UserService) ExportUsers(write func(*models.User) error) error {
+	if err := us.userRepository.StreamUsers(context.Background(), write); err != nil {
+		return utils.NewCustomError(500, "Error exporting users", err)
+	}
+	return nil
+}
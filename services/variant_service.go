@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/harsh-solanki21/golang-gin-crud-api/validations"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	ErrVariantNotFoundMessage  = "Variant not found"
+	ErrInvalidVariantIdMessage = "Invalid variant ID"
+)
+
+type VariantService struct {
+	variantRepository *repositories.VariantRepository
+	productRepository *repositories.ProductRepository
+}
+
+func NewVariantService(variantRepository *repositories.VariantRepository, productRepository *repositories.ProductRepository) *VariantService {
+	return &VariantService{
+		variantRepository: variantRepository,
+		productRepository: productRepository,
+	}
+}
+
+func (vs *VariantService) CreateVariant(productID string, variant *models.Variant) error {
+	ctx := context.Background()
+
+	product, err := findProduct(vs.productRepository, productID)
+	if err != nil {
+		return err
+	}
+
+	if validationErrors := validations.ValidateVariant(variant); validationErrors != nil {
+		return utils.NewCustomError(400, "Validation error", validationErrors)
+	}
+	if len(product.Options) == 0 {
+		return utils.NewCustomError(400, "Product has no options to create variants for", nil)
+	}
+	if err := checkVariant(product, variant); err != nil {
+		return err
+	}
+
+	variant.ProductID = product.ID
+	// Reservations are only made through the inventory endpoints
+	variant.Reserved = 0
+
+	if err := vs.variantRepository.CreateVariant(ctx, variant); err != nil {
+		return variantWriteError(err, "Error creating variant")
+	}
+
+	if err := vs.productRepository.IncrementVariantCount(ctx, product.ID, 1); err != nil {
+		log.Printf("Error updating variant count of product %s: %v", product.ID.Hex(), err)
+	}
+
+	return nil
+}
+
+func (vs *VariantService) ListVariants(productID string) ([]*models.Variant, error) {
+	product, err := findProduct(vs.productRepository, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	variants, err := vs.variantRepository.ListVariants(context.Background(), product.ID)
+	if err != nil {
+		return nil, utils.NewCustomError(500, "Error listing variants", err)
+	}
+	return variants, nil
+}
+
+func (vs *VariantService) GetVariant(productID, id string) (*models.Variant, error) {
+	productObjectID, variantID, err := parseVariantIDs(productID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	variant, err := vs.variantRepository.GetVariant(context.Background(), productObjectID, variantID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrVariantNotFound) {
+			return nil, utils.NewCustomError(404, ErrVariantNotFoundMessage, err)
+		}
+		return nil, utils.NewCustomError(500, "Error retrieving variant", err)
+	}
+	return variant, nil
+}
+
+// UpdateVariant changes the SKU, options, price override or barcode of a
+// variant. Stock is only changed through the inventory endpoints.
+func (vs *VariantService) UpdateVariant(productID, id string, variant *models.Variant) (*models.Variant, error) {
+	product, err := findProduct(vs.productRepository, productID)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := vs.GetVariant(productID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if validationErrors := validations.ValidateVariantUpdate(variant); validationErrors != nil {
+		return nil, utils.NewCustomError(400, "Validation error", validationErrors)
+	}
+
+	update := bson.M{}
+	if variant.SKU != "" {
+		update["sku"] = variant.SKU
+	}
+	if variant.Options != nil {
+		if err := models.CheckVariantOptions(product.Options, variant.Options); err != nil {
+			return nil, utils.NewCustomError(400, "Invalid variant options", err.Error())
+		}
+		update["options"] = variant.Options
+		update["option_key"] = models.VariantOptionKey(variant.Options)
+	}
+	if variant.Price != nil {
+		if variant.Price.Currency != product.Price.Currency {
+			return nil, utils.NewCustomError(400, "Variant price must be in the product's currency", product.Price.Currency)
+		}
+		update["price"] = variant.Price
+	}
+	if variant.Barcode != "" {
+		update["barcode"] = variant.Barcode
+	}
+
+	updatedVariant, err := vs.variantRepository.UpdateVariant(context.Background(), product.ID, existing.ID, update)
+	if err != nil {
+		if errors.Is(err, repositories.ErrVariantNotFound) {
+			return nil, utils.NewCustomError(404, ErrVariantNotFoundMessage, err)
+		}
+		return nil, variantWriteError(err, "Error updating variant")
+	}
+
+	return updatedVariant, nil
+}
+
+func (vs *VariantService) DeleteVariant(productID, id string) error {
+	ctx := context.Background()
+
+	variant, err := vs.GetVariant(productID, id)
+	if err != nil {
+		return err
+	}
+	if variant.Reserved > 0 {
+		return utils.NewCustomError(409, "Variant has reserved stock", map[string]int{"reserved": variant.Reserved})
+	}
+
+	if err := vs.variantRepository.DeleteVariant(ctx, variant.ProductID, variant.ID); err != nil {
+		if errors.Is(err, repositories.ErrVariantNotFound) {
+			return utils.NewCustomError(404, ErrVariantNotFoundMessage, err)
+		}
+		return utils.NewCustomError(500, "Error deleting variant", err)
+	}
+
+	if err := vs.productRepository.IncrementVariantCount(ctx, variant.ProductID, -1); err != nil {
+		log.Printf("Error updating variant count of product %s: %v", variant.ProductID.Hex(), err)
+	}
+
+	return nil
+}
+
+// checkVariant checks a new variant against the product's option axes and currency
+func checkVariant(product *models.Product, variant *models.Variant) error {
+	if err := models.CheckVariantOptions(product.Options, variant.Options); err != nil {
+		return utils.NewCustomError(400, "Invalid variant options", err.Error())
+	}
+	if variant.Price != nil && variant.Price.Currency != product.Price.Currency {
+		return utils.NewCustomError(400, "Variant price must be in the product's currency", product.Price.Currency)
+	}
+	return nil
+}
+
+func parseVariantIDs(productID, id string) (primitive.ObjectID, primitive.ObjectID, error) {
+	productObjectID, err := primitive.ObjectIDFromHex(productID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, utils.NewCustomError(400, ErrInvalidIdMessage, err)
+	}
+	variantID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, utils.NewCustomError(400, ErrInvalidVariantIdMessage, err)
+	}
+	return productObjectID, variantID, nil
+}
+
+func variantWriteError(err error, message string) error {
+	switch {
+	case errors.Is(err, repositories.ErrSKUTaken):
+		return utils.NewCustomError(409, "SKU already exists", err)
+	case errors.Is(err, repositories.ErrVariantExists):
+		return utils.NewCustomError(409, "A variant with these options already exists", err)
+	}
+	return utils.NewCustomError(500, message, err)
+}
@@ -0,0 +1,459 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// JobPruneDeliveries is the job that deletes old finished deliveries, it runs daily
+const JobPruneDeliveries = "webhooks.prune_deliveries"
+
+const (
+	ErrWebhookNotFoundMessage  = "Webhook not found"
+	ErrInvalidWebhookId        = "Invalid webhook ID"
+	ErrDeliveryNotFoundMessage = "Webhook delivery not found"
+	ErrInvalidDeliveryId       = "Invalid delivery ID"
+)
+
+// deliveryLeaseMargin is added to the request timeout while a delivery is
+// being sent, so it's only picked up again if its worker stopped
+const deliveryLeaseMargin = 30 * time.Second
+
+// WebhookService manages webhooks and sends their deliveries. Deliveries are
+// stored before they're sent, so retries survive restarts, and are sent by
+// a pool of workers shared with the other instances of the API.
+type WebhookService struct {
+	webhookRepository  *repositories.WebhookRepository
+	deliveryRepository *repositories.WebhookDeliveryRepository
+	client             *http.Client
+	config             configs.WebhooksConfig
+
+	// wake is signalled when deliveries are created, so they're sent without waiting for the next poll
+	wake chan struct{}
+	// ctx is cancelled on shutdown, workers stop after their current delivery
+	ctx     context.Context
+	cancel  context.CancelFunc
+	workers sync.WaitGroup
+}
+
+func NewWebhookService(webhookRepository *repositories.WebhookRepository, deliveryRepository *repositories.WebhookDeliveryRepository, config configs.WebhooksConfig) *WebhookService {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &WebhookService{
+		webhookRepository:  webhookRepository,
+		deliveryRepository: deliveryRepository,
+		client:             utils.NewWebhookClient(config.Timeout, config.AllowPrivateNetworks),
+		config:             config,
+		wake:               make(chan struct{}, 1),
+		ctx:                ctx,
+		cancel:             cancel,
+	}
+}
+
+func (ws *WebhookService) CreateWebhook(request models.WebhookRequest) (*models.CreatedWebhook, error) {
+	if err := ws.validateWebhookURL(request.URL); err != nil {
+		return nil, err
+	}
+
+	secret := request.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newWebhookSecret(); err != nil {
+			return nil, utils.NewCustomError(500, "Error generating webhook secret", err)
+		}
+	}
+
+	now := time.Now()
+	webhook := &models.Webhook{
+		URL:       request.URL,
+		Secret:    secret,
+		Events:    normalizeEvents(request.Events),
+		Active:    request.Active == nil || *request.Active,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := ws.webhookRepository.CreateWebhook(context.Background(), webhook); err != nil {
+		return nil, utils.NewCustomError(500, "Error creating webhook", err)
+	}
+
+	return &models.CreatedWebhook{Webhook: webhook, Secret: webhook.Secret}, nil
+}
+
+func (ws *WebhookService) GetWebhook(id string) (*models.Webhook, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidWebhookId, err)
+	}
+	return ws.getWebhook(objectID)
+}
+
+func (ws *WebhookService) ListWebhooks(pagination utils.Pagination) (utils.PaginatedResponse, error) {
+	webhooks, totalRows, err := ws.webhookRepository.ListWebhooks(context.Background(), pagination.GetLimit(), pagination.GetOffset())
+	if err != nil {
+		return utils.PaginatedResponse{}, utils.NewCustomError(500, "Error listing webhooks", err)
+	}
+	return pagination.GenerateResponse(webhooks, totalRows), nil
+}
+
+func (ws *WebhookService) UpdateWebhook(id string, request models.WebhookUpdateRequest) (*models.Webhook, error) {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, utils.NewCustomError(400, ErrInvalidWebhookId, err)
+	}
+
+	update := bson.M{}
+	if request.URL != nil {
+		if err := ws.validateWebhookURL(*request.URL); err != nil {
+			return nil, err
+		}
+		update["url"] = *request.URL
+	}
+	if request.Secret != nil {
+		update["secret"] = *request.Secret
+	}
+	if request.Events != nil {
+		update["events"] = normalizeEvents(request.Events)
+	}
+	if request.Active != nil {
+		update["active"] = *request.Active
+	}
+
+	webhook, err := ws.webhookRepository.UpdateWebhook(context.Background(), objectID, update)
+	if err != nil {
+		if errors.Is(err, repositories.ErrWebhookNotFound) {
+			return nil, utils.NewCustomError(404, ErrWebhookNotFoundMessage, err)
+		}
+		return nil, utils.NewCustomError(500, "Error updating webhook", err)
+	}
+	return webhook, nil
+}
+
+// DeleteWebhook deletes the webhook along with its delivery log
+func (ws *WebhookService) DeleteWebhook(id string) error {
+	objectID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return utils.NewCustomError(400, ErrInvalidWebhookId, err)
+	}
+
+	if err := ws.webhookRepository.DeleteWebhook(context.Background(), objectID); err != nil {
+		if errors.Is(err, repositories.ErrWebhookNotFound) {
+			return utils.NewCustomError(404, ErrWebhookNotFoundMessage, err)
+		}
+		return utils.NewCustomError(500, "Error deleting webhook", err)
+	}
+	if err := ws.deliveryRepository.DeleteWebhookDeliveries(context.Background(), objectID); err != nil {
+		return utils.NewCustomError(500, "Error deleting webhook deliveries", err)
+	}
+	return nil
+}
+
+// ListDeliveries returns the webhook's delivery log, optionally only the deliveries with status
+func (ws *WebhookService) ListDeliveries(webhookID, status string, pagination utils.Pagination) (utils.PaginatedResponse, error) {
+	objectID, err := primitive.ObjectIDFromHex(webhookID)
+	if err != nil {
+		return utils.PaginatedResponse{}, utils.NewCustomError(400, ErrInvalidWebhookId, err)
+	}
+	if _, err := ws.getWebhook(objectID); err != nil {
+		return utils.PaginatedResponse{}, err
+	}
+
+	deliveries, totalRows, err := ws.deliveryRepository.ListDeliveries(context.Background(), objectID, status, pagination.GetLimit(), pagination.GetOffset())
+	if err != nil {
+		return utils.PaginatedResponse{}, utils.NewCustomError(500, "Error listing webhook deliveries", err)
+	}
+	return pagination.GenerateResponse(deliveries, totalRows), nil
+}
+
+func (ws *WebhookService) GetDelivery(webhookID, id string) (*models.WebhookDelivery, error) {
+	webhookObjectID, deliveryID, err := parseDeliveryIDs(webhookID, id)
+	if err != nil {
+		return nil, err
+	}
+	return ws.getDelivery(webhookObjectID, deliveryID)
+}
+
+// Redeliver sends a delivery's event again as a new delivery, the original keeps its log
+func (ws *WebhookService) Redeliver(webhookID, id string) (*models.WebhookDelivery, error) {
+	webhookObjectID, deliveryID, err := parseDeliveryIDs(webhookID, id)
+	if err != nil {
+		return nil, err
+	}
+	webhook, err := ws.getWebhook(webhookObjectID)
+	if err != nil {
+		return nil, err
+	}
+	if !webhook.Active {
+		return nil, utils.NewCustomError(409, "Webhook is not active", nil)
+	}
+	original, err := ws.getDelivery(webhookObjectID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := newDelivery(webhookObjectID, original.EventID, original.Event, original.Payload)
+	delivery.RedeliveryOf = &original.ID
+	if err := ws.deliveryRepository.CreateDeliveries(context.Background(), []*models.WebhookDelivery{delivery}); err != nil {
+		return nil, utils.NewCustomError(500, "Error creating webhook delivery", err)
+	}
+	ws.wakeWorkers()
+
+	return delivery, nil
+}
+
+// HandleEvent queues a delivery of the event to every active webhook
+// subscribed to it, it's the webhooks' subscriber on the event bus. Webhooks
+// that already have a delivery of the event are skipped, so an event that is
+// dispatched again isn't sent twice.
+func (ws *WebhookService) HandleEvent(ctx context.Context, event models.Event) error {
+	webhooks, err := ws.webhookRepository.ListSubscribers(ctx, event.Type)
+	if err != nil {
+		return err
+	}
+	if len(webhooks) == 0 {
+		return nil
+	}
+
+	eventID := event.ID.Hex()
+	queued, err := ws.deliveryRepository.EventWebhookIDs(ctx, eventID)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(models.WebhookEvent{ID: eventID, Type: event.Type, CreatedAt: event.OccurredAt, Data: event.Data})
+	if err != nil {
+		return err
+	}
+
+	var deliveries []*models.WebhookDelivery
+	for _, webhook := range webhooks {
+		if !slices.Contains(queued, webhook.ID) {
+			deliveries = append(deliveries, newDelivery(webhook.ID, eventID, event.Type, string(payload)))
+		}
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+	if err := ws.deliveryRepository.CreateDeliveries(ctx, deliveries); err != nil {
+		return err
+	}
+	ws.wakeWorkers()
+	return nil
+}
+
+// PruneDeliveries deletes the succeeded and failed deliveries older than the
+// delivery retention, it's the handler of JobPruneDeliveries
+func (ws *WebhookService) PruneDeliveries(ctx context.Context, job *models.Job) error {
+	deleted, err := ws.deliveryRepository.DeleteFinishedBefore(ctx, time.Now().Add(-ws.config.DeliveryRetention))
+	if err != nil {
+		return err
+	}
+	if deleted > 0 {
+		log.Printf("Pruned %d webhook deliveries\n", deleted)
+	}
+	return nil
+}
+
+// Start starts the delivery workers
+func (ws *WebhookService) Start() {
+	for range ws.config.Workers {
+		ws.workers.Add(1)
+		go ws.work()
+	}
+}
+
+// Shutdown stops the workers and waits for the deliveries being sent
+func (ws *WebhookService) Shutdown(ctx context.Context) error {
+	ws.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		ws.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (ws *WebhookService) work() {
+	defer ws.workers.Done()
+
+	ticker := time.NewTicker(ws.config.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		for ws.ctx.Err() == nil && ws.deliverNext() {
+		}
+
+		select {
+		case <-ws.ctx.Done():
+			return
+		case <-ws.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// deliverNext sends the next due delivery, it reports whether there was one
+func (ws *WebhookService) deliverNext() bool {
+	delivery, err := ws.deliveryRepository.ClaimDue(context.Background(), ws.config.Timeout+deliveryLeaseMargin)
+	if err != nil {
+		log.Println("Error claiming webhook delivery:", err)
+		return false
+	}
+	if delivery == nil {
+		return false
+	}
+
+	attempt, status, nextAttemptAt := ws.send(delivery)
+	if err := ws.deliveryRepository.RecordAttempt(context.Background(), delivery.ID, attempt, status, nextAttemptAt); err != nil {
+		log.Printf("Error recording attempt of webhook delivery %s: %v\n", delivery.ID.Hex(), err)
+	}
+	return true
+}
+
+// send makes one attempt at the delivery and returns its outcome, the
+// delivery's new status and when to retry it if it failed
+func (ws *WebhookService) send(delivery *models.WebhookDelivery) (models.DeliveryAttempt, string, *time.Time) {
+	start := time.Now()
+	attempt := models.DeliveryAttempt{AttemptedAt: start}
+
+	webhook, err := ws.webhookRepository.GetWebhook(context.Background(), delivery.WebhookID)
+	if errors.Is(err, repositories.ErrWebhookNotFound) || (err == nil && !webhook.Active) {
+		// Deliveries of deleted or disabled webhooks aren't retried
+		attempt.Error = "webhook was deleted or disabled"
+		return attempt, models.DeliveryFailed, nil
+	}
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), ws.config.Timeout)
+		attempt.StatusCode, err = utils.SendWebhook(ctx, ws.client, utils.WebhookMessage{
+			URL:        webhook.URL,
+			Secret:     webhook.Secret,
+			DeliveryID: delivery.ID.Hex(),
+			Event:      delivery.Event,
+			Payload:    []byte(delivery.Payload),
+		})
+		cancel()
+		attempt.DurationMS = time.Since(start).Milliseconds()
+		if err == nil {
+			return attempt, models.DeliverySucceeded, nil
+		}
+	}
+	attempt.Error = err.Error()
+
+	attempts := delivery.AttemptCount + 1
+	if attempts >= ws.config.MaxAttempts {
+		return attempt, models.DeliveryFailed, nil
+	}
+	nextAttemptAt := time.Now().Add(utils.Backoff(attempts, ws.config.InitialBackoff, ws.config.MaxBackoff))
+	return attempt, models.DeliveryPending, &nextAttemptAt
+}
+
+func (ws *WebhookService) wakeWorkers() {
+	select {
+	case ws.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (ws *WebhookService) getWebhook(id primitive.ObjectID) (*models.Webhook, error) {
+	webhook, err := ws.webhookRepository.GetWebhook(context.Background(), id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrWebhookNotFound) {
+			return nil, utils.NewCustomError(404, ErrWebhookNotFoundMessage, err)
+		}
+		return nil, utils.NewCustomError(500, "Error retrieving webhook", err)
+	}
+	return webhook, nil
+}
+
+func (ws *WebhookService) getDelivery(webhookID, id primitive.ObjectID) (*models.WebhookDelivery, error) {
+	delivery, err := ws.deliveryRepository.GetDelivery(context.Background(), webhookID, id)
+	if err != nil {
+		if errors.Is(err, repositories.ErrDeliveryNotFound) {
+			return nil, utils.NewCustomError(404, ErrDeliveryNotFoundMessage, err)
+		}
+		return nil, utils.NewCustomError(500, "Error retrieving webhook delivery", err)
+	}
+	return delivery, nil
+}
+
+func newDelivery(webhookID primitive.ObjectID, eventID, event, payload string) *models.WebhookDelivery {
+	now := time.Now()
+	return &models.WebhookDelivery{
+		WebhookID:     webhookID,
+		EventID:       eventID,
+		Event:         event,
+		Payload:       payload,
+		Status:        models.DeliveryPending,
+		Attempts:      []models.DeliveryAttempt{},
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+}
+
+func parseDeliveryIDs(webhookID, id string) (primitive.ObjectID, primitive.ObjectID, error) {
+	webhookObjectID, err := primitive.ObjectIDFromHex(webhookID)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, utils.NewCustomError(400, ErrInvalidWebhookId, err)
+	}
+	deliveryID, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return primitive.NilObjectID, primitive.NilObjectID, utils.NewCustomError(400, ErrInvalidDeliveryId, err)
+	}
+	return webhookObjectID, deliveryID, nil
+}
+
+// validateWebhookURL only allows http and https URLs with a host. Hosts that
+// are obviously internal are rejected up front, hostnames resolving to them
+// are refused when deliveries are sent.
+func (ws *WebhookService) validateWebhookURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return utils.NewCustomError(400, "Validation error", []map[string]string{{"field": "url", "message": "url must be an http or https URL"}})
+	}
+	if ws.config.AllowPrivateNetworks {
+		return nil
+	}
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	addr, err := netip.ParseAddr(host)
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") || (err == nil && !utils.IsPublicAddr(addr)) {
+		return utils.NewCustomError(400, "Validation error", []map[string]string{{"field": "url", "message": "url must point to a public address"}})
+	}
+	return nil
+}
+
+func normalizeEvents(events []string) []string {
+	events = slices.Clone(events)
+	slices.Sort(events)
+	return slices.Compact(events)
+}
+
+func newWebhookSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps binary files such as product images under string keys.
+// Keys may contain slashes to group related files.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Open returns the content of a blob, the caller must close it
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes a blob, deleting a missing blob is not an error
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GridFSStore keeps blobs in a MongoDB GridFS bucket, using the key as the file name
+type GridFSStore struct {
+	bucket *gridfs.Bucket
+}
+
+func NewGridFSStore(client *mongo.Client, config configs.MongoConfig, bucketName string) (*GridFSStore, error) {
+	bucket, err := gridfs.NewBucket(
+		client.Database(config.Database),
+		options.GridFSBucket().SetName(bucketName),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &GridFSStore{bucket: bucket}, nil
+}
+
+func (gs *GridFSStore) Put(ctx context.Context, key string, r io.Reader) error {
+	// Replace an existing blob instead of keeping several revisions
+	if err := gs.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	stream, err := gs.bucket.OpenUploadStream(key)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(stream, r); err != nil {
+		stream.Abort()
+		return err
+	}
+	return stream.Close()
+}
+
+func (gs *GridFSStore) Open(ctx context.Context, key string) (io.ReadCloser, error) {
+	stream, err := gs.bucket.OpenDownloadStreamByName(key)
+	if errors.Is(err, gridfs.ErrFileNotFound) {
+		return nil, ErrBlobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+func (gs *GridFSStore) Delete(ctx context.Context, key string) error {
+	cursor, err := gs.bucket.FindContext(ctx, bson.M{"filename": key})
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var file struct {
+			ID interface{} `bson:"_id"`
+		}
+		if err := cursor.Decode(&file); err != nil {
+			return err
+		}
+		if err := gs.bucket.DeleteContext(ctx, file.ID); err != nil && !errors.Is(err, gridfs.ErrFileNotFound) {
+			return err
+		}
+	}
+	return cursor.Err()
+}
@@ -0,0 +1,100 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProductBatchRequestValidation(t *testing.T) {
+	operations := func(count int) string {
+		items := make([]string, count)
+		for i := range items {
+			items[i] = `{"op":"delete","id":"66b1f0c2a1b2c3d4e5f60718"}`
+		}
+		return fmt.Sprintf(`{"operations":[%s]}`, strings.Join(items, ","))
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{name: "Mixed Operations", body: `{"mode":"atomic","operations":[{"op":"create","product":{"name":"Desk"}},{"op":"update","id":"66b1f0c2a1b2c3d4e5f60718","product":{"name":"Lamp"}},{"op":"delete","id":"66b1f0c2a1b2c3d4e5f60719"}]}`},
+		{name: "Default Mode", body: operations(1)},
+		{name: "Largest Batch", body: operations(100)},
+		{name: "Empty Batch", body: `{"operations":[]}`, wantErr: true},
+		{name: "Too Many Operations", body: operations(101), wantErr: true},
+		{name: "Unknown Mode", body: `{"mode":"eventual","operations":[{"op":"delete","id":"66b1f0c2a1b2c3d4e5f60718"}]}`, wantErr: true},
+		{name: "Unknown Operation", body: `{"operations":[{"op":"upsert","id":"66b1f0c2a1b2c3d4e5f60718"}]}`, wantErr: true},
+		{name: "Create Without Product", body: `{"operations":[{"op":"create"}]}`, wantErr: true},
+		{name: "Update Without ID", body: `{"operations":[{"op":"update","product":{"name":"Lamp"}}]}`, wantErr: true},
+		{name: "Delete Without ID", body: `{"operations":[{"op":"delete"}]}`, wantErr: true},
+		{name: "Update Without Product", body: `{"operations":[{"op":"update","id":"66b1f0c2a1b2c3d4e5f60718"}]}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request models.ProductBatchRequest
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &request))
+
+			err := binding.Validator.ValidateStruct(&request)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestProductBatchOperationInputs(t *testing.T) {
+	var request models.ProductBatchRequest
+	body := `{"operations":[{"op":"create","product":{"name":"Desk","stock_quantity":3}},{"op":"update","id":"66b1f0c2a1b2c3d4e5f60718","product":{"low_stock_threshold":5}},{"op":"delete","id":"66b1f0c2a1b2c3d4e5f60719","product":{"name":"Lamp"}}]}`
+	require.NoError(t, json.Unmarshal([]byte(body), &request))
+	require.Len(t, request.Operations, 3)
+
+	create := request.Operations[0]
+	require.NotNil(t, create.Create)
+	assert.Nil(t, create.Update)
+	assert.Equal(t, "Desk", create.Create.Name)
+	assert.Equal(t, 3, create.Create.StockQuantity)
+
+	update := request.Operations[1]
+	require.NotNil(t, update.Update)
+	assert.Nil(t, update.Create)
+	require.NotNil(t, update.Update.LowStockThreshold)
+	assert.Equal(t, 5, *update.Update.LowStockThreshold)
+
+	remove := request.Operations[2]
+	assert.Nil(t, remove.Create)
+	assert.Nil(t, remove.Update, "deletes ignore the product")
+}
+
+func TestAtomicBatchWithoutTransactions(t *testing.T) {
+	// Transaction support is checked before any operation is applied
+	transactions := repositories.NewTransactions(nil, configs.MongoConfig{Transactions: "off"})
+	batchService := services.NewProductBatchService(nil, transactions)
+
+	request := models.ProductBatchRequest{
+		Mode:       models.BatchAtomic,
+		Operations: []models.ProductBatchOperation{{Op: models.BatchDelete, ID: "66b1f0c2a1b2c3d4e5f60718"}},
+	}
+	_, err := batchService.RunBatch(request, nil)
+
+	var customError *utils.CustomError
+	require.ErrorAs(t, err, &customError)
+	assert.Equal(t, http.StatusNotImplemented, customError.StatusCode)
+	assert.Equal(t, services.ErrCodeTransactionsUnsupported, customError.Details.(map[string]string)["code"])
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/harsh-solanki21/golang-gin-crud-api/validations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"Shoes":           "shoes",
+		"shoes":           "shoes",
+		"  Shoes & Boots": "shoes-boots",
+		"Café Crème":      "cafe-creme",
+		"T-Shirts (Kids)": "t-shirts-kids",
+		"!!!":             "",
+	}
+
+	for name, want := range tests {
+		assert.Equal(t, want, utils.Slugify(name), name)
+	}
+}
+
+func TestCategoryValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		category models.Category
+		want     bool
+	}{
+		{name: "Valid Category", category: models.Category{Name: "Running Shoes", Slug: "running-shoes"}, want: true},
+		{name: "Upper-case Slug", category: models.Category{Name: "Running Shoes", Slug: "Running-Shoes"}, want: false},
+		{name: "Double Dash", category: models.Category{Name: "Running Shoes", Slug: "running--shoes"}, want: false},
+		{name: "Short Name", category: models.Category{Name: "R", Slug: "r"}, want: false},
+		{name: "Negative Position", category: models.Category{Name: "Running Shoes", Slug: "running-shoes", Position: -1}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errors := validations.ValidateCategory(&tt.category)
+			assert.Equal(t, tt.want, len(errors) == 0)
+		})
+	}
+}
+
+func TestBuildCategoryTree(t *testing.T) {
+	clothing := &models.Category{ID: primitive.NewObjectID(), Name: "Clothing"}
+	footwear := &models.Category{ID: primitive.NewObjectID(), Name: "Footwear"}
+	shoes := &models.Category{ID: primitive.NewObjectID(), Name: "Shoes", ParentID: &footwear.ID}
+	boots := &models.Category{ID: primitive.NewObjectID(), Name: "Boots", ParentID: &footwear.ID}
+	running := &models.Category{ID: primitive.NewObjectID(), Name: "Running", ParentID: &shoes.ID}
+	missingParent := primitive.NewObjectID()
+	orphan := &models.Category{ID: primitive.NewObjectID(), Name: "Orphan", ParentID: &missingParent}
+
+	counts := map[primitive.ObjectID]int64{
+		footwear.ID: 1,
+		shoes.ID:    2,
+		boots.ID:    3,
+		running.ID:  4,
+	}
+
+	tree := models.BuildCategoryTree([]*models.Category{clothing, footwear, running, shoes, boots, orphan}, counts)
+	require.Len(t, tree, 3)
+
+	assert.Equal(t, "Clothing", tree[0].Name)
+	assert.Empty(t, tree[0].Children)
+	assert.Zero(t, tree[0].TotalProductCount)
+
+	// Children keep the given order and counts include all subcategories
+	root := tree[1]
+	assert.Equal(t, "Footwear", root.Name)
+	require.Len(t, root.Children, 2)
+	assert.Equal(t, "Shoes", root.Children[0].Name)
+	assert.Equal(t, "Boots", root.Children[1].Name)
+	assert.Equal(t, int64(1), root.ProductCount)
+	assert.Equal(t, int64(10), root.TotalProductCount)
+	assert.Equal(t, int64(6), root.Children[0].TotalProductCount)
+	assert.Equal(t, int64(4), root.Children[0].Children[0].TotalProductCount)
+
+	assert.Equal(t, "Orphan", tree[2].Name)
+}
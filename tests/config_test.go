@@ -0,0 +1,223 @@
+package tests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testAccessSecret  = strings.Repeat("a", configs.MinSecretLength)
+	testRefreshSecret = strings.Repeat("r", configs.MinSecretLength)
+)
+
+func envLookup(env map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		value, ok := env[key]
+		return value, ok
+	}
+}
+
+func validEnv() map[string]string {
+	return map[string]string{
+		"MONGO_URI":            "mongodb://localhost:27017",
+		"MONGO_DB_NAME":        "shop",
+		"ACCESS_TOKEN_SECRET":  testAccessSecret,
+		"REFRESH_TOKEN_SECRET": testRefreshSecret,
+		"CSRF_SECRET":          strings.Repeat("c", configs.MinSecretLength),
+	}
+}
+
+func TestConfigDefaults(t *testing.T) {
+	config, err := configs.LoadFrom(nil, envLookup(validEnv()))
+	require.NoError(t, err)
+
+	assert.Equal(t, "5000", config.Server.Port)
+	assert.Equal(t, 15*time.Second, config.Server.ReadTimeout)
+	assert.Equal(t, 15*time.Minute, config.Auth.AccessTokenTTL)
+	assert.Equal(t, "shop", config.Mongo.Database)
+}
+
+func TestConfigPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+gin_mode: release
+trusted_proxies: [10.0.0.1, 10.0.0.2]
+server:
+  port: 6000
+  read_timeout: 20s
+  write_timeout: 40s
+mongo:
+  database: from_file
+`), 0o600))
+
+	env := validEnv()
+	delete(env, "MONGO_DB_NAME")
+	env["CONFIG_FILE"] = file
+	env["SERVER_READ_TIMEOUT"] = "25s"
+
+	config, err := configs.LoadFrom([]string{"-server.write_timeout=45s"}, envLookup(env))
+	require.NoError(t, err)
+
+	assert.Equal(t, "release", config.GinMode)
+	assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, config.TrustedProxies)
+	assert.Equal(t, "6000", config.Server.Port)
+	assert.Equal(t, "from_file", config.Mongo.Database)
+	assert.Equal(t, 25*time.Second, config.Server.ReadTimeout)
+	assert.Equal(t, 45*time.Second, config.Server.WriteTimeout)
+}
+
+func TestConfigTOMLFile(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config.toml")
+	require.NoError(t, os.WriteFile(file, []byte(`
+[server]
+port = "7000"
+max_header_bytes = 2048
+
+[auth]
+bcrypt_cost = 10
+
+[images]
+thumbnail_sizes = [100, 300]
+`), 0o600))
+
+	config, err := configs.LoadFrom([]string{"-config", file}, envLookup(validEnv()))
+	require.NoError(t, err)
+
+	assert.Equal(t, "7000", config.Server.Port)
+	assert.Equal(t, 2048, config.Server.MaxHeaderBytes)
+	assert.Equal(t, 10, config.Auth.BcryptCost)
+	assert.Equal(t, []int{100, 300}, config.Images.ThumbnailSizes)
+}
+
+func TestConfigValidation(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+		args []string
+		want string
+	}{
+		{
+			name: "Short Secret",
+			env:  map[string]string{"ACCESS_TOKEN_SECRET": "short"},
+			want: "auth.access_token_secret",
+		},
+		{
+			name: "Invalid Mongo URI",
+			env:  map[string]string{"MONGO_URI": "http://localhost"},
+			want: "mongo.uri",
+		},
+		{
+			name: "Missing Database",
+			env:  map[string]string{"MONGO_DB_NAME": ""},
+			args: []string{"-mongo.database="},
+			want: "mongo.database",
+		},
+		{
+			name: "Invalid Transactions",
+			env:  map[string]string{"MONGO_TRANSACTIONS": "always"},
+			want: "mongo.transactions",
+		},
+		{
+			name: "Zero Shutdown Hook Timeout",
+			env:  map[string]string{"SERVER_SHUTDOWN_HOOK_TIMEOUT": "0s"},
+			want: "server.shutdown_hook_timeout",
+		},
+		{
+			name: "Invalid Duration",
+			env:  map[string]string{"SERVER_IDLE_TIMEOUT": "soon"},
+			want: "SERVER_IDLE_TIMEOUT",
+		},
+		{
+			name: "Invalid Image Store",
+			env:  map[string]string{"IMAGES_STORE": "s3"},
+			want: "images.store",
+		},
+		{
+			name: "Invalid Image Dimensions",
+			env:  map[string]string{"IMAGES_MAX_PIXELS": "0"},
+			want: "images.max_pixels",
+		},
+		{
+			name: "Invalid Import Batch Size",
+			env:  map[string]string{"IMPORTS_BATCH_SIZE": "0"},
+			want: "imports.batch_size",
+		},
+		{
+			name: "Invalid Idempotency Store",
+			env:  map[string]string{"IDEMPOTENCY_STORE": "redis"},
+			want: "idempotency.store",
+		},
+		{
+			name: "Invalid Webhook Backoff",
+			env:  map[string]string{"WEBHOOKS_INITIAL_BACKOFF": "2h"},
+			want: "webhooks.max_backoff",
+		},
+		{
+			name: "Invalid Event Retention",
+			env:  map[string]string{"EVENTS_RETENTION": "0s"},
+			want: "events.retention",
+		},
+		{
+			name: "Invalid Product Events Buffer",
+			env:  map[string]string{"PRODUCT_EVENTS_REPLAY_BUFFER": "0"},
+			want: "product_events.replay_buffer",
+		},
+		{
+			name: "Short Job Lease",
+			env:  map[string]string{"JOBS_LEASE": "1s"},
+			want: "jobs.lease",
+		},
+		{
+			name: "Webhooks Private Networks In Release",
+			env:  map[string]string{"GIN_MODE": "release", "WEBHOOKS_ALLOW_PRIVATE_NETWORKS": "true"},
+			want: "webhooks.allow_private_networks",
+		},
+		{
+			name: "GraphQL Playground In Release",
+			env:  map[string]string{"GIN_MODE": "release", "GRAPHQL_PLAYGROUND": "true"},
+			want: "graphql.playground",
+		},
+		{
+			name: "gRPC Reflection In Release",
+			env:  map[string]string{"GIN_MODE": "release", "GRPC_REFLECTION": "true"},
+			want: "grpc.reflection",
+		},
+		{
+			name: "gRPC Port Taken",
+			env:  map[string]string{"PORT": "5000", "GRPC_PORT": "5000"},
+			want: "grpc.port",
+		},
+		{
+			name: "Response Validation In Release",
+			env:  map[string]string{"GIN_MODE": "release", "VALIDATION_RESPONSES": "true"},
+			want: "validation.responses",
+		},
+		{
+			name: "Unknown Flag",
+			args: []string{"-server.color=blue"},
+			want: "server.color",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := validEnv()
+			for key, value := range tt.env {
+				env[key] = value
+			}
+
+			_, err := configs.LoadFrom(tt.args, envLookup(env))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.want)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/middlewares"
+	"github.com/stretchr/testify/assert"
+)
+
+func newCORSRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	config := configs.Default()
+	config.CORS.AllowedOrigins = []string{"https://app.example.com", "https://*.preview.example.com"}
+	config.CORS.AllowCredentials = true
+
+	router := gin.New()
+	router.Use(middlewares.SecurityHeadersMiddleware(config.Security))
+	router.Use(middlewares.CORSMiddleware(config.CORS))
+	router.GET("/products", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestCORSPreflight(t *testing.T) {
+	router := newCORSRouter()
+
+	req := httptest.NewRequest(http.MethodOptions, "/products", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "DELETE")
+	req.Header.Set("Access-Control-Request-Headers", "content-type, x-csrf-token")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Methods"), "DELETE")
+	// Cookie clients send the CSRF token on unsafe requests
+	assert.Contains(t, w.Header().Get("Access-Control-Allow-Headers"), middlewares.CSRFHeaderName)
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+}
+
+func TestCORSOrigins(t *testing.T) {
+	tests := []struct {
+		name   string
+		origin string
+		want   string
+	}{
+		{name: "Allowed Origin", origin: "https://app.example.com", want: "https://app.example.com"},
+		{name: "Wildcard Subdomain", origin: "https://pr-1.preview.example.com", want: "https://pr-1.preview.example.com"},
+		{name: "Unknown Origin", origin: "https://evil.com", want: ""},
+		{name: "Wrong Scheme", origin: "http://app.example.com", want: ""},
+	}
+
+	router := newCORSRouter()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/products", nil)
+			req.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.want, w.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
+
+func TestSecurityHeaders(t *testing.T) {
+	router := newCORSRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/products", nil))
+
+	assert.Equal(t, "nosniff", w.Header().Get("X-Content-Type-Options"))
+	assert.Equal(t, "DENY", w.Header().Get("X-Frame-Options"))
+	assert.Equal(t, "max-age=31536000; includeSubDomains", w.Header().Get("Strict-Transport-Security"))
+	assert.Contains(t, w.Header().Get("Content-Security-Policy"), "default-src 'none'")
+}
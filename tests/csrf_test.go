@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/middlewares"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newCSRFRouter(csrfManager *utils.CSRFManager, authMethod string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set("auth_method", authMethod)
+	})
+	router.Use(middlewares.CSRFMiddleware(csrfManager))
+	router.GET("/products", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.POST("/products", func(c *gin.Context) {
+		c.Status(http.StatusCreated)
+	})
+	return router
+}
+
+func TestCSRFMiddleware(t *testing.T) {
+	csrfManager := utils.NewCSRFManager(strings.Repeat("s", 32))
+	token, err := csrfManager.GenerateToken("session")
+	require.NoError(t, err)
+
+	otherToken, err := utils.NewCSRFManager(strings.Repeat("o", 32)).GenerateToken("session")
+	require.NoError(t, err)
+
+	// Anyone can fetch a token for their own session and plant it in the cookie
+	attackerToken, err := csrfManager.GenerateToken("attacker-session")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		method      string
+		authMethod  string
+		cookieToken string
+		headerToken string
+		wantStatus  int
+		wantCode    string
+	}{
+		{name: "Safe Method", method: http.MethodGet, authMethod: middlewares.AuthMethodCookie, wantStatus: http.StatusOK},
+		{name: "Bearer Exempt", method: http.MethodPost, authMethod: middlewares.AuthMethodBearer, wantStatus: http.StatusCreated},
+		{name: "Valid Token", method: http.MethodPost, authMethod: middlewares.AuthMethodCookie, cookieToken: token, headerToken: token, wantStatus: http.StatusCreated},
+		{name: "Missing Token", method: http.MethodPost, authMethod: middlewares.AuthMethodCookie, cookieToken: token, wantStatus: http.StatusForbidden, wantCode: "csrf_token_missing"},
+		{name: "Mismatched Token", method: http.MethodPost, authMethod: middlewares.AuthMethodCookie, cookieToken: token, headerToken: otherToken, wantStatus: http.StatusForbidden, wantCode: "csrf_token_mismatch"},
+		{name: "Forged Token", method: http.MethodPost, authMethod: middlewares.AuthMethodCookie, cookieToken: otherToken, headerToken: otherToken, wantStatus: http.StatusForbidden, wantCode: "csrf_token_invalid"},
+		{name: "Token Of Another Session", method: http.MethodPost, authMethod: middlewares.AuthMethodCookie, cookieToken: attackerToken, headerToken: attackerToken, wantStatus: http.StatusForbidden, wantCode: "csrf_token_invalid"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newCSRFRouter(csrfManager, tt.authMethod)
+
+			req := httptest.NewRequest(tt.method, "/products", nil)
+			req.AddCookie(&http.Cookie{Name: "refresh_token", Value: "session"})
+			if tt.cookieToken != "" {
+				req.AddCookie(&http.Cookie{Name: middlewares.CSRFCookieName, Value: tt.cookieToken})
+			}
+			if tt.headerToken != "" {
+				req.Header.Set(middlewares.CSRFHeaderName, tt.headerToken)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantCode != "" {
+				assert.Contains(t, w.Body.String(), tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestCSRFSessionMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	csrfManager := utils.NewCSRFManager(strings.Repeat("s", 32))
+	router := gin.New()
+	router.POST("/refresh", middlewares.CSRFSessionMiddleware(csrfManager), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	token, err := csrfManager.GenerateToken("session")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		session    string
+		token      string
+		wantStatus int
+	}{
+		{name: "Without Session", wantStatus: http.StatusOK},
+		{name: "Session Without Token", session: "session", wantStatus: http.StatusForbidden},
+		{name: "Session With Token", session: "session", token: token, wantStatus: http.StatusOK},
+		{name: "Token Of Another Session", session: "other-session", token: token, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+			if tt.session != "" {
+				req.AddCookie(&http.Cookie{Name: "refresh_token", Value: tt.session})
+			}
+			if tt.token != "" {
+				req.AddCookie(&http.Cookie{Name: middlewares.CSRFCookieName, Value: tt.token})
+				req.Header.Set(middlewares.CSRFHeaderName, tt.token)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/events"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestNewEvent(t *testing.T) {
+	productID := primitive.NewObjectID()
+	change := models.PriceChange{
+		ID:       productID,
+		OldPrice: models.Money{Amount: 1000, Currency: "USD"},
+		NewPrice: models.Money{Amount: 1250, Currency: "USD"},
+	}
+
+	event, err := models.NewEvent(models.EventProductPriceChanged, change)
+	require.NoError(t, err)
+	assert.False(t, event.ID.IsZero())
+	assert.Equal(t, models.EventProductPriceChanged, event.Type)
+	assert.False(t, event.OccurredAt.IsZero())
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal(event.Data, &data))
+	assert.Equal(t, productID.Hex(), data["id"])
+	assert.Equal(t, "10.00", data["old_price"].(map[string]interface{})["amount"])
+	assert.Equal(t, "12.50", data["new_price"].(map[string]interface{})["amount"])
+}
+
+func TestBusDispatch(t *testing.T) {
+	bus := events.NewBus()
+	var calls []string
+	bus.Subscribe("audit", func(ctx context.Context, event models.Event) error {
+		calls = append(calls, "audit")
+		return nil
+	})
+	bus.Subscribe("search", func(ctx context.Context, event models.Event) error {
+		calls = append(calls, "search")
+		return nil
+	}, models.EventProductCreated, models.EventProductUpdated)
+	bus.Subscribe("mailer", func(ctx context.Context, event models.Event) error {
+		calls = append(calls, "mailer")
+		return nil
+	}, models.EventUserRegistered)
+
+	event, err := models.NewEvent(models.EventProductCreated, map[string]string{"name": "Desk"})
+	require.NoError(t, err)
+
+	t.Run("Subscribers Of The Event In Order", func(t *testing.T) {
+		calls = nil
+		delivered, err := bus.Dispatch(context.Background(), event, nil)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"audit", "search"}, calls)
+		assert.Equal(t, []string{"audit", "search"}, delivered)
+	})
+
+	t.Run("Skips Delivered Subscribers", func(t *testing.T) {
+		calls = nil
+		delivered, err := bus.Dispatch(context.Background(), event, []string{"audit"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"search"}, calls)
+		assert.Equal(t, []string{"audit", "search"}, delivered)
+	})
+}
+
+func TestBusDispatchFailures(t *testing.T) {
+	bus := events.NewBus()
+	bus.Subscribe("failing", func(ctx context.Context, event models.Event) error {
+		return errors.New("index unavailable")
+	})
+	bus.Subscribe("panicking", func(ctx context.Context, event models.Event) error {
+		panic("nil map")
+	})
+	handled := 0
+	bus.Subscribe("working", func(ctx context.Context, event models.Event) error {
+		handled++
+		return nil
+	})
+
+	event, err := models.NewEvent(models.EventUserDeleted, map[string]string{"id": "1"})
+	require.NoError(t, err)
+
+	// Failures don't keep the event from the other subscribers, only the ones
+	// that handled it count as delivered so a retry skips them
+	delivered, err := bus.Dispatch(context.Background(), event, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failing: index unavailable")
+	assert.Contains(t, err.Error(), "panicking: panic: nil map")
+	assert.Equal(t, []string{"working"}, delivered)
+	assert.Equal(t, 1, handled)
+
+	_, err = bus.Dispatch(context.Background(), event, delivered)
+	require.Error(t, err)
+	assert.Equal(t, 1, handled)
+}
+
+func TestBusSubscribeDuplicateName(t *testing.T) {
+	bus := events.NewBus()
+	handler := func(ctx context.Context, event models.Event) error { return nil }
+	bus.Subscribe("webhooks", handler)
+	assert.Panics(t, func() { bus.Subscribe("webhooks", handler) })
+}
@@ -0,0 +1,136 @@
+package tests
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var exportRows = [][]interface{}{
+	{"Desk, oak", json.Number("149.90"), 3, true, time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+	{"<Lamp>", json.Number("5.00"), 0, false, time.Time{}},
+	{"=HYPERLINK(\"http://evil.example\")", json.Number("-1.50"), -2, false, time.Time{}},
+}
+
+func writeExport(t *testing.T, format string) []byte {
+	var buffer bytes.Buffer
+	writer, err := utils.NewExportWriter(&buffer, format, []string{"name", "price", "stock", "in_stock", "created_at"})
+	require.NoError(t, err)
+	for _, row := range exportRows {
+		require.NoError(t, writer.WriteRow(row))
+	}
+	require.NoError(t, writer.Close())
+	return buffer.Bytes()
+}
+
+func TestCSVExport(t *testing.T) {
+	want := "name,price,stock,in_stock,created_at\n" +
+		"\"Desk, oak\",149.90,3,true,2024-05-01T12:00:00Z\n" +
+		"<Lamp>,5.00,0,false,\n" +
+		"\"'=HYPERLINK(\"\"http://evil.example\"\")\",-1.50,-2,false,\n"
+	assert.Equal(t, want, string(writeExport(t, utils.ExportCSV)))
+}
+
+func TestNDJSONExport(t *testing.T) {
+	want := `{"name":"Desk, oak","price":149.90,"stock":3,"in_stock":true,"created_at":"2024-05-01T12:00:00Z"}` + "\n" +
+		`{"name":"<Lamp>","price":5.00,"stock":0,"in_stock":false,"created_at":null}` + "\n" +
+		`{"name":"=HYPERLINK(\"http://evil.example\")","price":-1.50,"stock":-2,"in_stock":false,"created_at":null}` + "\n"
+	assert.Equal(t, want, string(writeExport(t, utils.ExportNDJSON)))
+}
+
+func TestXLSXExport(t *testing.T) {
+	data := writeExport(t, utils.ExportXLSX)
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+
+	var sheet []byte
+	for _, file := range archive.File {
+		if file.Name == "xl/worksheets/sheet1.xml" {
+			reader, err := file.Open()
+			require.NoError(t, err)
+			sheet, err = io.ReadAll(reader)
+			require.NoError(t, err)
+		}
+	}
+	require.NotNil(t, sheet)
+
+	var worksheet struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	require.NoError(t, xml.Unmarshal(sheet, &worksheet))
+	require.Len(t, worksheet.Rows, 4)
+
+	assert.Equal(t, "name", worksheet.Rows[0].Cells[0].Inline)
+	desk := worksheet.Rows[1].Cells
+	assert.Equal(t, "Desk, oak", desk[0].Inline)
+	// Prices and quantities are numeric cells
+	assert.Equal(t, "B2", desk[1].Ref)
+	assert.Equal(t, "", desk[1].Type)
+	assert.Equal(t, "149.90", desk[1].Value)
+	assert.Equal(t, "b", desk[3].Type)
+	assert.Equal(t, "1", desk[3].Value)
+	assert.Equal(t, "<Lamp>", worksheet.Rows[2].Cells[0].Inline)
+	// Empty values are left out
+	assert.Len(t, worksheet.Rows[2].Cells, 4)
+	// Inline strings are never run as formulas, so they're written as they are
+	formula := worksheet.Rows[3].Cells
+	assert.Equal(t, "inlineStr", formula[0].Type)
+	assert.Equal(t, `=HYPERLINK("http://evil.example")`, formula[0].Inline)
+	assert.Equal(t, "-1.50", formula[1].Value)
+}
+
+func TestExportFormulaRoundTrip(t *testing.T) {
+	var buffer bytes.Buffer
+	writer, err := utils.NewExportWriter(&buffer, utils.ExportCSV, []string{"name", "description"})
+	require.NoError(t, err)
+	require.NoError(t, writer.WriteRow([]interface{}{"@SUM(A1)", "+1 for oak"}))
+	require.NoError(t, writer.WriteRow([]interface{}{"'quoted'", "-"}))
+	require.NoError(t, writer.WriteRow([]interface{}{"'=x", "''-1"}))
+	require.NoError(t, writer.Close())
+	assert.Equal(t, "name,description\n'@SUM(A1),'+1 for oak\n'quoted','-\n''=x,'''-1\n", buffer.String())
+
+	// Imports remove exactly the quotes the export added
+	records, err := utils.ReadImportRecords(&buffer, utils.ImportCSV)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, map[string]string{"name": "@SUM(A1)", "description": "+1 for oak"}, records[0].Fields)
+	assert.Equal(t, map[string]string{"name": "'quoted'", "description": "-"}, records[1].Fields)
+	assert.Equal(t, map[string]string{"name": "'=x", "description": "''-1"}, records[2].Fields)
+}
+
+func TestSelectColumns(t *testing.T) {
+	columns, err := utils.SelectColumns("", models.UserExportColumns)
+	require.NoError(t, err)
+	assert.Equal(t, models.UserExportColumns, columns)
+
+	columns, err = utils.SelectColumns("email, name,email", models.UserExportColumns)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"email", "name"}, columns)
+
+	_, err = utils.SelectColumns("name,password", models.UserExportColumns)
+	assert.Error(t, err)
+}
+
+func TestProductExportValues(t *testing.T) {
+	product := models.Product{Name: "Desk", Price: models.Money{Amount: 14990, Currency: "EUR"}, StockQuantity: 4}
+
+	values := product.ExportValues([]string{"name", "price", "currency", "stock_quantity"})
+	assert.Equal(t, []interface{}{"Desk", json.Number("149.90"), "EUR", 4}, values)
+}
@@ -0,0 +1,161 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/graph"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestGraphQLLimits(t *testing.T) {
+	config := configs.Default().GraphQL
+	config.MaxDepth = 3
+	config.MaxComplexity = 100
+	server, err := graph.NewServer(nil, nil, nil, config)
+	require.NoError(t, err)
+	claims := &utils.Claims{UserID: primitive.NewObjectID().Hex(), Role: "user"}
+
+	tests := []struct {
+		name      string
+		request   models.GraphQLRequest
+		wantError string
+	}{
+		{
+			name:      "Too Deep",
+			request:   models.GraphQLRequest{Query: `{ products { data { owner { name } } } }`},
+			wantError: "Query depth 4 exceeds the limit of 3",
+		},
+		{
+			name:      "Too Deep Through Fragment",
+			request:   models.GraphQLRequest{Query: `{ products { ...page } } fragment page on ProductPage { data { category { name } } }`},
+			wantError: "Query depth 4 exceeds the limit of 3",
+		},
+		{
+			// 1 + 100 * (data + id + name)
+			name:      "Too Complex",
+			request:   models.GraphQLRequest{Query: `{ products(limit: 100) { data { id name } } }`},
+			wantError: "Query complexity 301 exceeds the limit of 100",
+		},
+		{
+			name: "Too Complex With Variable",
+			request: models.GraphQLRequest{
+				Query:     `query List($limit: Int) { products(limit: $limit) { data { id } } }`,
+				Variables: map[string]interface{}{"limit": float64(60)},
+			},
+			wantError: "Query complexity 121 exceeds the limit of 100",
+		},
+		{
+			name:      "Too Complex With Default Variable",
+			request:   models.GraphQLRequest{Query: `query List($limit: Int = 60) { products(limit: $limit) { data { id } } }`},
+			wantError: "Query complexity 121 exceeds the limit of 100",
+		},
+		{
+			name:      "Unknown Field",
+			request:   models.GraphQLRequest{Query: `{ products { data { password } } }`},
+			wantError: `Cannot query field "password" on type "Product".`,
+		},
+		{
+			name:      "Syntax Error",
+			request:   models.GraphQLRequest{Query: `{ products {`},
+			wantError: "Syntax Error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := server.Execute(context.Background(), claims, tt.request)
+			require.NotEmpty(t, result.Errors)
+			assert.Contains(t, result.Errors[0].Message, tt.wantError)
+			assert.Nil(t, result.Data)
+		})
+	}
+
+	// Introspection is free, so GraphiQL can load the schema
+	result := server.Execute(context.Background(), claims, models.GraphQLRequest{Query: `{ __schema { queryType { fields { name args { name type { name } } } } } }`})
+	assert.Empty(t, result.Errors)
+	assert.NotNil(t, result.Data)
+}
+
+func TestGraphQLAuthorization(t *testing.T) {
+	server, err := graph.NewServer(nil, nil, nil, configs.Default().GraphQL)
+	require.NoError(t, err)
+	claims := &utils.Claims{UserID: primitive.NewObjectID().Hex(), Role: "user"}
+
+	result := server.Execute(context.Background(), claims, models.GraphQLRequest{Query: `{ users { data { id email } } }`})
+	require.Len(t, result.Errors, 1)
+	assert.Equal(t, "Access denied", result.Errors[0].Message)
+	assert.Equal(t, 403, result.Errors[0].Extensions["status"])
+	assert.Equal(t, []interface{}{"users"}, result.Errors[0].Path)
+}
+
+func TestGraphQLUpdateValidation(t *testing.T) {
+	// Updates are validated like the REST endpoints before the services are called
+	server, err := graph.NewServer(nil, nil, nil, configs.Default().GraphQL)
+	require.NoError(t, err)
+	claims := &utils.Claims{UserID: primitive.NewObjectID().Hex(), Role: "admin"}
+	id := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "Short Product Name", query: `mutation { updateProduct(id: "` + id + `", input: {name: "x"}) { id } }`},
+		{name: "Negative Threshold", query: `mutation { updateProduct(id: "` + id + `", input: {lowStockThreshold: -5}) { id } }`},
+		{name: "Invalid Email", query: `mutation { updateUser(id: "` + id + `", input: {email: "not-an-email"}) { id } }`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := server.Execute(context.Background(), claims, models.GraphQLRequest{Query: tt.query})
+			require.Len(t, result.Errors, 1)
+			assert.Equal(t, "Validation error", result.Errors[0].Message)
+			assert.Equal(t, 400, result.Errors[0].Extensions["status"])
+		})
+	}
+}
+
+func TestGraphQLLoader(t *testing.T) {
+	first, second, missing := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+
+	var batches [][]primitive.ObjectID
+	loader := graph.NewLoader(func(ids []primitive.ObjectID) (map[primitive.ObjectID]interface{}, error) {
+		batches = append(batches, ids)
+		return map[primitive.ObjectID]interface{}{first: "first", second: "second"}, nil
+	})
+
+	thunks := []func() (interface{}, error){loader.Load(first), loader.Load(second), loader.Load(first), loader.Load(missing)}
+	var values []interface{}
+	for _, thunk := range thunks {
+		value, err := thunk()
+		require.NoError(t, err)
+		values = append(values, value)
+	}
+
+	assert.Equal(t, []interface{}{"first", "second", "first", nil}, values)
+	assert.Equal(t, [][]primitive.ObjectID{{first, second, missing}}, batches)
+
+	// Ids that were already loaded aren't looked up again
+	value, err := loader.Load(second)()
+	require.NoError(t, err)
+	assert.Equal(t, "second", value)
+	assert.Len(t, batches, 1)
+}
+
+func TestGraphQLLoaderError(t *testing.T) {
+	loader := graph.NewLoader(func(ids []primitive.ObjectID) (map[primitive.ObjectID]interface{}, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	first, second := loader.Load(primitive.NewObjectID()), loader.Load(primitive.NewObjectID())
+	_, err := first()
+	assert.EqualError(t, err, "connection refused")
+	_, err = second()
+	assert.EqualError(t, err, "connection refused")
+}
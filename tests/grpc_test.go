@@ -0,0 +1,150 @@
+package tests
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/rpc"
+	"github.com/harsh-solanki21/golang-gin-crud-api/rpc/pb"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCClient serves the gRPC API without services in memory, so only
+// calls rejected before reaching the database can be made
+func newGRPCClient(t *testing.T, jwtManager *utils.JWTManager) *grpc.ClientConn {
+	t.Helper()
+
+	server := rpc.NewServer(nil, nil, jwtManager, configs.Default().GRPC)
+	listener := bufconn.Listen(1024 * 1024)
+	go server.Serve(listener)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+	})
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func withToken(t *testing.T, jwtManager *utils.JWTManager, role string) context.Context {
+	t.Helper()
+	token, err := jwtManager.GenerateAccessToken(primitive.NewObjectID().Hex(), role)
+	require.NoError(t, err)
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+}
+
+func TestGRPCAuthentication(t *testing.T) {
+	jwtManager := utils.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Minute)
+	conn := newGRPCClient(t, jwtManager)
+	products := pb.NewProductServiceClient(conn)
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+	}{
+		{name: "Missing Token", ctx: context.Background()},
+		{name: "Wrong Scheme", ctx: metadata.AppendToOutgoingContext(context.Background(), "authorization", "Basic abc")},
+		{name: "Invalid Token", ctx: metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer abc")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := products.GetProduct(tt.ctx, &pb.GetProductRequest{Id: primitive.NewObjectID().Hex()})
+			st := status.Convert(err)
+			assert.Equal(t, codes.Unauthenticated, st.Code())
+			assert.Equal(t, "Authentication failed", st.Message())
+		})
+	}
+
+	// Health checks don't need a token
+	response, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{Service: "api.v1.ProductService"})
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, response.Status)
+}
+
+func TestGRPCErrors(t *testing.T) {
+	jwtManager := utils.NewJWTManager("access-secret", "refresh-secret", time.Minute, time.Minute)
+	conn := newGRPCClient(t, jwtManager)
+	products := pb.NewProductServiceClient(conn)
+	ctx := withToken(t, jwtManager, "user")
+
+	_, err := products.GetProduct(ctx, &pb.GetProductRequest{Id: "not-an-id"})
+	st := status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, "Invalid product ID", st.Message())
+
+	_, err = pb.NewUserServiceClient(conn).ListUsers(ctx, &pb.ListUsersRequest{})
+	st = status.Convert(err)
+	assert.Equal(t, codes.PermissionDenied, st.Code())
+	assert.Equal(t, "Access denied", st.Message())
+
+	// Validation errors list the invalid fields
+	_, err = products.CreateProduct(ctx, &pb.CreateProductRequest{Price: &pb.Money{Amount: "10.00", Currency: "USD"}})
+	st = status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, "Validation error", st.Message())
+	require.Len(t, st.Details(), 1)
+	badRequest, ok := st.Details()[0].(*errdetails.BadRequest)
+	require.True(t, ok)
+	require.NotEmpty(t, badRequest.FieldViolations)
+	assert.Equal(t, "name", badRequest.FieldViolations[0].Field)
+
+	// Updates are validated like the REST endpoints
+	adminCtx := withToken(t, jwtManager, "admin")
+	threshold := int32(-5)
+	_, err = products.UpdateProduct(adminCtx, &pb.UpdateProductRequest{Id: primitive.NewObjectID().Hex(), LowStockThreshold: &threshold})
+	st = status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, "Validation error", st.Message())
+
+	email := "not-an-email"
+	_, err = pb.NewUserServiceClient(conn).UpdateUser(adminCtx, &pb.UpdateUserRequest{Id: primitive.NewObjectID().Hex(), Email: &email})
+	st = status.Convert(err)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+	assert.Equal(t, "Validation error", st.Message())
+}
+
+func TestGRPCStatusCode(t *testing.T) {
+	tests := []struct {
+		httpStatus int
+		want       codes.Code
+	}{
+		{400, codes.InvalidArgument},
+		{401, codes.Unauthenticated},
+		{403, codes.PermissionDenied},
+		{404, codes.NotFound},
+		{409, codes.Aborted},
+		{412, codes.FailedPrecondition},
+		{422, codes.InvalidArgument},
+		{429, codes.ResourceExhausted},
+		{418, codes.FailedPrecondition},
+		{500, codes.Internal},
+		{503, codes.Unavailable},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, rpc.StatusCode(tt.httpStatus), "status %d", tt.httpStatus)
+	}
+}
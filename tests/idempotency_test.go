@@ -0,0 +1,185 @@
+package tests
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/middlewares"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func newIdempotentRouter(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	config := configs.Default().Idempotency
+	config.WaitTimeout = 5 * time.Second
+	idempotency := middlewares.NewIdempotency(utils.NewMemoryIdempotencyStore(), config)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-User"); userID != "" {
+			c.Set("claims", &utils.Claims{UserID: userID, Role: "user"})
+		}
+	})
+	router.POST("/products", idempotency.Handle(), handler)
+	return router
+}
+
+func postIdempotent(router *gin.Engine, key, user, body string) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/products", strings.NewReader(body))
+	if key != "" {
+		request.Header.Set(middlewares.IdempotencyKeyHeader, key)
+	}
+	if user != "" {
+		request.Header.Set("X-User", user)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, request)
+	return w
+}
+
+func TestIdempotencyReplay(t *testing.T) {
+	var calls atomic.Int32
+	router := newIdempotentRouter(func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		n := calls.Add(1)
+		c.Header("Location", "/products/1")
+		c.JSON(http.StatusCreated, gin.H{"call": n, "body": string(body)})
+	})
+
+	first := postIdempotent(router, "key-1", "user-1", `{"name":"Desk"}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Empty(t, first.Header().Get(middlewares.IdempotentReplayedHeader))
+
+	retry := postIdempotent(router, "key-1", "user-1", `{"name":"Desk"}`)
+	assert.Equal(t, http.StatusCreated, retry.Code)
+	assert.Equal(t, first.Body.String(), retry.Body.String())
+	assert.Equal(t, "/products/1", retry.Header().Get("Location"))
+	assert.Equal(t, "true", retry.Header().Get(middlewares.IdempotentReplayedHeader))
+	assert.Equal(t, int32(1), calls.Load())
+
+	// Keys are scoped to the user, and requests without a key always run
+	assert.Equal(t, http.StatusCreated, postIdempotent(router, "key-1", "user-2", `{"name":"Desk"}`).Code)
+	assert.Equal(t, http.StatusCreated, postIdempotent(router, "", "user-1", `{"name":"Desk"}`).Code)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestIdempotencyKeyReused(t *testing.T) {
+	router := newIdempotentRouter(func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	// The handler doesn't read the body, it's still part of the fingerprint
+	assert.Equal(t, http.StatusCreated, postIdempotent(router, "key-1", "user-1", `{"name":"Desk"}`).Code)
+	w := postIdempotent(router, "key-1", "user-1", `{"name":"Lamp"}`)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "idempotency_key_reused")
+}
+
+func TestIdempotencyConcurrentRequests(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	router := newIdempotentRouter(func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+		}
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	var wg sync.WaitGroup
+	responses := make([]*httptest.ResponseRecorder, 2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		responses[0] = postIdempotent(router, "key-1", "user-1", `{}`)
+	}()
+	<-started
+
+	// The retry waits for the original request instead of running again
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		responses[1] = postIdempotent(router, "key-1", "user-1", `{}`)
+	}()
+	time.Sleep(200 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, http.StatusCreated, responses[0].Code)
+	assert.Equal(t, http.StatusCreated, responses[1].Code)
+	assert.Equal(t, "true", responses[1].Header().Get(middlewares.IdempotentReplayedHeader))
+}
+
+func TestIdempotencyServerErrorsAreRetried(t *testing.T) {
+	var calls atomic.Int32
+	router := newIdempotentRouter(func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			c.JSON(http.StatusInternalServerError, gin.H{})
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, postIdempotent(router, "key-1", "user-1", `{}`).Code)
+	assert.Equal(t, http.StatusCreated, postIdempotent(router, "key-1", "user-1", `{}`).Code)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestIdempotencyOversizedResponse(t *testing.T) {
+	var calls atomic.Int32
+	router := newIdempotentRouter(func(c *gin.Context) {
+		calls.Add(1)
+		c.String(http.StatusCreated, strings.Repeat("x", 2<<20))
+	})
+
+	first := postIdempotent(router, "key-1", "user-1", `{}`)
+	assert.Equal(t, http.StatusCreated, first.Code)
+	assert.Equal(t, 2<<20, first.Body.Len())
+
+	// The request isn't run again, but its response can't be replayed either
+	retry := postIdempotent(router, "key-1", "user-1", `{}`)
+	assert.Equal(t, http.StatusConflict, retry.Code)
+	assert.Contains(t, retry.Body.String(), "idempotency_response_too_large")
+	assert.Equal(t, http.StatusUnprocessableEntity, postIdempotent(router, "key-1", "user-1", `{"other":true}`).Code)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestMemoryIdempotencyStoreLockTimeout(t *testing.T) {
+	store := utils.NewMemoryIdempotencyStore()
+	now := time.Now()
+	store.SetClock(func() time.Time { return now })
+	ctx := context.Background()
+
+	_, locked, err := store.Lock(ctx, "key", "first", time.Minute, time.Hour)
+	assert.NoError(t, err)
+	assert.True(t, locked)
+
+	record, locked, _ := store.Lock(ctx, "key", "second", time.Minute, time.Hour)
+	assert.False(t, locked)
+	assert.False(t, record.Completed)
+
+	// A request that never finished gives up its key after the lock timeout
+	now = now.Add(2 * time.Minute)
+	_, locked, _ = store.Lock(ctx, "key", "second", time.Minute, time.Hour)
+	assert.True(t, locked)
+	assert.ErrorIs(t, store.Complete(ctx, "key", "first", utils.IdempotencyRecord{}), utils.ErrIdempotencyLockLost)
+	assert.NoError(t, store.Complete(ctx, "key", "second", utils.IdempotencyRecord{StatusCode: http.StatusCreated}))
+
+	record, locked, _ = store.Lock(ctx, "key", "third", time.Minute, time.Hour)
+	assert.False(t, locked)
+	assert.True(t, record.Completed)
+	assert.Equal(t, http.StatusCreated, record.StatusCode)
+}
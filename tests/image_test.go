@@ -0,0 +1,117 @@
+package tests
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/storage"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		img.Set(x, 0, color.RGBA{R: 255, A: 255})
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestSniffImageType(t *testing.T) {
+	contentType, err := utils.SniffImageType(testPNG(t, 4, 4))
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+
+	// The type comes from the content, not from what the client claims
+	_, err = utils.SniffImageType([]byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+	assert.ErrorIs(t, err, utils.ErrUnsupportedImageType)
+}
+
+func TestThumbnail(t *testing.T) {
+	img, err := utils.DecodeImage(testPNG(t, 400, 200), "image/png", 1000, 1_000_000)
+	require.NoError(t, err)
+
+	thumbnail := utils.Thumbnail(img, 160)
+	assert.Equal(t, 160, thumbnail.Bounds().Dx())
+	assert.Equal(t, 80, thumbnail.Bounds().Dy())
+
+	// Small images aren't scaled up
+	assert.Equal(t, img.Bounds(), utils.Thumbnail(img, 1000).Bounds())
+
+	data, contentType, err := utils.EncodeThumbnail(thumbnail, "image/png")
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", contentType)
+	assert.NotEmpty(t, data)
+
+	_, contentType, err = utils.EncodeThumbnail(thumbnail, "image/webp")
+	require.NoError(t, err)
+	assert.Equal(t, "image/jpeg", contentType)
+
+	_, err = utils.DecodeImage([]byte("not an image"), "image/png", 1000, 1_000_000)
+	assert.ErrorIs(t, err, utils.ErrInvalidImage)
+}
+
+// declaredPNG is a 1x1 PNG whose header claims width x height pixels
+func declaredPNG(t *testing.T, width, height uint32) []byte {
+	data := testPNG(t, 1, 1)
+	// The IHDR chunk follows the 8 byte signature: length, type, data, CRC
+	ihdr := data[8+8 : 8+8+13]
+	binary.BigEndian.PutUint32(ihdr[0:4], width)
+	binary.BigEndian.PutUint32(ihdr[4:8], height)
+	binary.BigEndian.PutUint32(data[8+8+13:], crc32.ChecksumIEEE(data[8+4:8+8+13]))
+	return data
+}
+
+func TestDecodeImageDimensions(t *testing.T) {
+	// A few dozen bytes that would decode to a 50000x50000 canvas
+	bomb := declaredPNG(t, 50000, 50000)
+	assert.Less(t, len(bomb), 100)
+	_, err := utils.DecodeImage(bomb, "image/png", 10000, 40_000_000)
+	assert.ErrorIs(t, err, utils.ErrImageTooLarge)
+
+	// Within each dimension but over the pixel count
+	_, err = utils.DecodeImage(declaredPNG(t, 9000, 9000), "image/png", 10000, 40_000_000)
+	assert.ErrorIs(t, err, utils.ErrImageTooLarge)
+
+	_, err = utils.DecodeImage(testPNG(t, 20, 10), "image/png", 16, 1000)
+	assert.ErrorIs(t, err, utils.ErrImageTooLarge)
+
+	img, err := utils.DecodeImage(testPNG(t, 16, 10), "image/png", 16, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, 16, img.Bounds().Dx())
+}
+
+func TestFileSystemStore(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewFileSystemStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "products/1/images/2/original", bytes.NewReader([]byte("image data"))))
+
+	file, err := store.Open(ctx, "products/1/images/2/original")
+	require.NoError(t, err)
+	data, err := io.ReadAll(file)
+	require.NoError(t, file.Close())
+	require.NoError(t, err)
+	assert.Equal(t, "image data", string(data))
+
+	require.NoError(t, store.Delete(ctx, "products/1/images/2/original"))
+	_, err = store.Open(ctx, "products/1/images/2/original")
+	assert.ErrorIs(t, err, storage.ErrBlobNotFound)
+	assert.NoError(t, store.Delete(ctx, "products/1/images/2/original"))
+
+	// Keys can't escape the store's directory
+	assert.Error(t, store.Put(ctx, "../outside", bytes.NewReader(nil)))
+	assert.Error(t, store.Put(ctx, "/etc/passwd", bytes.NewReader(nil)))
+}
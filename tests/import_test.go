@@ -0,0 +1,153 @@
+package tests
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportFormat(t *testing.T) {
+	tests := []struct {
+		format   string
+		filename string
+		want     string
+		wantErr  bool
+	}{
+		{filename: "products.csv", want: utils.ImportCSV},
+		{filename: "products.NDJSON", want: utils.ImportNDJSON},
+		{filename: "products.jsonl", want: utils.ImportNDJSON},
+		{format: "csv", filename: "products.txt", want: utils.ImportCSV},
+		{filename: "products.xlsx", wantErr: true},
+		{format: "xml", filename: "products.csv", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format+" "+tt.filename, func(t *testing.T) {
+			format, err := utils.ImportFormat(tt.format, tt.filename)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, utils.ErrUnsupportedImportFormat)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, format)
+		})
+	}
+}
+
+func TestReadCSVImportRecords(t *testing.T) {
+	file := "\ufeffname,price, currency\n" +
+		"Desk,149.99,USD\n" +
+		"\"Chair, folding\",\"19.90\",EUR\n" +
+		"Lamp,5\n"
+
+	records, err := utils.ReadImportRecords(strings.NewReader(file), utils.ImportCSV)
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+
+	assert.Equal(t, 2, records[0].Line)
+	assert.Equal(t, map[string]string{"name": "Desk", "price": "149.99", "currency": "USD"}, records[0].Fields)
+	assert.Equal(t, "Chair, folding", records[1].Fields["name"])
+
+	// A short row is reported on its own line without failing the file
+	assert.Equal(t, 4, records[2].Line)
+	assert.Error(t, records[2].Err)
+
+	_, err = utils.ReadImportRecords(strings.NewReader("name,name\nDesk,Chair\n"), utils.ImportCSV)
+	assert.Error(t, err)
+}
+
+func TestReadNDJSONImportRecords(t *testing.T) {
+	file := `{"name": "Desk", "price": 1.15, "currency": "USD"}` + "\n" +
+		"\n" +
+		`{"name": "Chair", "price": {"amount": "1.00"}}` + "\n" +
+		`not json` + "\n" +
+		`{"name": "Lamp", "stock_quantity": 3, "description": null}` + "\n"
+
+	records, err := utils.ReadImportRecords(strings.NewReader(file), utils.ImportNDJSON)
+	require.NoError(t, err)
+	require.Len(t, records, 4)
+
+	// Numbers keep their literal text
+	assert.Equal(t, "1.15", records[0].Fields["price"])
+	assert.Equal(t, 3, records[1].Line)
+	assert.Error(t, records[1].Err)
+	assert.Error(t, records[2].Err)
+	assert.Equal(t, map[string]string{"name": "Lamp", "stock_quantity": "3", "description": ""}, records[3].Fields)
+}
+
+func TestCheckImport(t *testing.T) {
+	importService := services.NewImportService(nil, nil, nil, nil, nil, nil, nil, nil, configs.Default().Imports)
+
+	file := strings.NewReader("name,price\nDesk,10\nChair\n")
+	options := models.ImportOptions{}
+	rows, err := importService.CheckImport(file, "products.csv", &options)
+	require.NoError(t, err)
+	assert.Equal(t, 2, rows)
+	assert.Equal(t, utils.ImportCSV, options.Format)
+	assert.Equal(t, models.ImportDryRun, options.Mode)
+
+	// The file is rewound for the import
+	records, err := utils.ReadImportRecords(file, options.Format)
+	require.NoError(t, err)
+	assert.Len(t, records, 2)
+
+	for name, content := range map[string]string{
+		"No Rows":       "name,price\n",
+		"Empty":         "",
+		"Broken Quotes": "name\n\"Desk\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := importService.CheckImport(strings.NewReader(content), "products.csv", &models.ImportOptions{})
+			var customErr *utils.CustomError
+			require.ErrorAs(t, err, &customErr)
+			assert.Equal(t, 400, customErr.StatusCode)
+		})
+	}
+}
+
+func TestMatchImportProduct(t *testing.T) {
+	desk := &models.Product{Name: "Desk"}
+	lamp := &models.Product{Name: "Lamp"}
+	productsBySKU := map[string]*models.Product{"DESK-OAK": desk}
+	productsByName := map[string][]*models.Product{
+		"Desk":  {desk},
+		"Chair": {{Name: "Chair"}, {Name: "Chair"}},
+		"Lamp":  {lamp},
+	}
+
+	tests := []struct {
+		name      string
+		sku       string
+		rowName   string
+		want      *models.Product
+		wantField string
+	}{
+		{name: "Known SKU", sku: "DESK-OAK", rowName: "Lamp", want: desk},
+		{name: "Unknown SKU Matches Name", sku: "LAMP-1", rowName: "Lamp", want: lamp},
+		{name: "Unknown SKU Creates Product", sku: "SOFA-1", rowName: "Sofa"},
+		{name: "Unknown SKU Without Name", sku: "SOFA-1", wantField: "sku"},
+		{name: "Name", rowName: "Desk", want: desk},
+		{name: "New Name", rowName: "Sofa"},
+		{name: "Ambiguous Name", rowName: "Chair", wantField: "name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product, err := models.MatchImportProduct(tt.sku, tt.rowName, productsBySKU, productsByName)
+			if tt.wantField != "" {
+				var matchErr *models.ImportMatchError
+				require.ErrorAs(t, err, &matchErr)
+				assert.Equal(t, tt.wantField, matchErr.Field)
+				return
+			}
+			require.NoError(t, err)
+			assert.Same(t, tt.want, product)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package tests
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/jobs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronNext(t *testing.T) {
+	// Wednesday
+	after := time.Date(2026, time.January, 14, 10, 30, 15, 0, time.UTC)
+
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{spec: "* * * * *", want: time.Date(2026, time.January, 14, 10, 31, 0, 0, time.UTC)},
+		{spec: "*/15 * * * *", want: time.Date(2026, time.January, 14, 10, 45, 0, 0, time.UTC)},
+		{spec: "0 9-17 * * 1-5", want: time.Date(2026, time.January, 14, 11, 0, 0, 0, time.UTC)},
+		{spec: "30 2 * * *", want: time.Date(2026, time.January, 15, 2, 30, 0, 0, time.UTC)},
+		{spec: "0 0 * * 7", want: time.Date(2026, time.January, 18, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 1,15 * *", want: time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 31 * *", want: time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 29 2 *", want: time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		// Restricting both days matches either of them, like cron
+		{spec: "0 0 20 * 5", want: time.Date(2026, time.January, 16, 0, 0, 0, 0, time.UTC)},
+		{spec: "@daily", want: time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)},
+		{spec: "@monthly", want: time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "@yearly", want: time.Date(2027, time.January, 1, 0, 0, 0, 0, time.UTC)},
+		{spec: "0 0 30 2 *", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := utils.ParseCron(tt.spec)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, schedule.Next(after))
+		})
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *", "@often"} {
+		t.Run(spec, func(t *testing.T) {
+			_, err := utils.ParseCron(spec)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestJobJSON(t *testing.T) {
+	job := models.Job{Type: "webhooks.prune_deliveries", Payload: `{"days":30}`, Status: models.JobDead, LastError: "timeout"}
+	data, err := json.Marshal(job)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, map[string]interface{}{"days": float64(30)}, decoded["payload"])
+	assert.Equal(t, models.JobDead, decoded["status"])
+	assert.Equal(t, "timeout", decoded["last_error"])
+
+	var payload struct {
+		Days int `json:"days"`
+	}
+	require.NoError(t, job.DecodePayload(&payload))
+	assert.Equal(t, 30, payload.Days)
+}
+
+func TestPermanentJobError(t *testing.T) {
+	cause := errors.New("product not found")
+	err := jobs.Permanent(cause)
+	assert.True(t, jobs.IsPermanent(err))
+	assert.ErrorIs(t, err, cause)
+	assert.Equal(t, "product not found", err.Error())
+
+	assert.False(t, jobs.IsPermanent(cause))
+	assert.Nil(t, jobs.Permanent(nil))
+}
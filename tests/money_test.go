@@ -0,0 +1,105 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/validations"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+		want     models.Money
+		wantErr  bool
+	}{
+		{name: "Two Decimals", amount: "19.99", currency: "USD", want: models.Money{Amount: 1999, Currency: "USD"}},
+		{name: "One Decimal", amount: "19.9", currency: "EUR", want: models.Money{Amount: 1990, Currency: "EUR"}},
+		{name: "Whole Amount", amount: "20", currency: "USD", want: models.Money{Amount: 2000, Currency: "USD"}},
+		{name: "Zero Decimal Currency", amount: "1500", currency: "JPY", want: models.Money{Amount: 1500, Currency: "JPY"}},
+		{name: "Three Decimal Currency", amount: "1.234", currency: "KWD", want: models.Money{Amount: 1234, Currency: "KWD"}},
+		{name: "Lower Case Currency", amount: "1.00", currency: "usd", want: models.Money{Amount: 100, Currency: "USD"}},
+		{name: "Too Many Decimals", amount: "19.999", currency: "USD", wantErr: true},
+		{name: "Decimals For Yen", amount: "1500.5", currency: "JPY", wantErr: true},
+		{name: "Exponent Notation", amount: "1e3", currency: "USD", wantErr: true},
+		{name: "Unknown Currency", amount: "1.00", currency: "XYZ", wantErr: true},
+		{name: "Empty Amount", amount: "", currency: "USD", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			money, err := models.ParseMoney(tt.amount, tt.currency)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, money)
+		})
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	assert.Equal(t, "19.99", models.Money{Amount: 1999, Currency: "USD"}.String())
+	assert.Equal(t, "0.05", models.Money{Amount: 5, Currency: "USD"}.String())
+	assert.Equal(t, "-1.50", models.Money{Amount: -150, Currency: "EUR"}.String())
+	assert.Equal(t, "1500", models.Money{Amount: 1500, Currency: "JPY"}.String())
+	assert.Equal(t, "0.001", models.Money{Amount: 1, Currency: "BHD"}.String())
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var product struct {
+		Price models.Money `json:"price"`
+	}
+
+	require.NoError(t, json.Unmarshal([]byte(`{"price": {"amount": "0.10", "currency": "USD"}}`), &product))
+	assert.Equal(t, models.Money{Amount: 10, Currency: "USD"}, product.Price)
+
+	// Numbers are parsed from their literal text, not through float64
+	require.NoError(t, json.Unmarshal([]byte(`{"price": {"amount": 1.15, "currency": "USD"}}`), &product))
+	assert.Equal(t, models.Money{Amount: 115, Currency: "USD"}, product.Price)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"price": {"amount": "1.155", "currency": "USD"}}`), &product))
+	assert.Error(t, json.Unmarshal([]byte(`{"price": {"amount": "1.15"}}`), &product))
+
+	data, err := json.Marshal(product)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"price": {"amount": "1.15", "currency": "USD"}}`, string(data))
+}
+
+func TestMoneyArithmetic(t *testing.T) {
+	price := models.Money{Amount: 10, Currency: "USD"}
+
+	// Ten times 0.10 is exactly 1.00
+	var total models.Money
+	for i := 0; i < 10; i++ {
+		var err error
+		total, err = total.Add(price)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, models.Money{Amount: 100, Currency: "USD"}, total)
+	assert.Equal(t, total, price.Multiply(10))
+
+	_, err := total.Add(models.Money{Amount: 100, Currency: "EUR"})
+	assert.ErrorIs(t, err, models.ErrCurrencyMismatch)
+}
+
+func TestProductPriceValidation(t *testing.T) {
+	product := models.Product{
+		Name:        "Test Product",
+		Description: "This is a test product",
+		CategoryID:  primitive.NewObjectID(),
+	}
+
+	// A price without a currency counts as missing
+	assert.NotEmpty(t, validations.ValidateProduct(&product))
+
+	product.Price = models.Money{Amount: 999, Currency: "USD"}
+	assert.Empty(t, validations.ValidateProduct(&product))
+}
@@ -0,0 +1,125 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/controllers"
+	"github.com/harsh-solanki21/golang-gin-crud-api/middlewares"
+	"github.com/harsh-solanki21/golang-gin-crud-api/openapi"
+	"github.com/harsh-solanki21/golang-gin-crud-api/routes"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newAPIRouter registers every route, with all optional features enabled
+func newAPIRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	routes.SetupRoutes(router, routes.Dependencies{
+		RateLimiter:       middlewares.NewRateLimiter(utils.NewMemoryRateLimitStore(), configs.Default().RateLimit),
+		Idempotency:       middlewares.NewIdempotency(utils.NewMemoryIdempotencyStore(), configs.Default().Idempotency),
+		CSRFManager:       utils.NewCSRFManager(strings.Repeat("s", 32)),
+		GraphQLController: controllers.NewGraphQLController(nil),
+		GraphQLPlayground: true,
+	})
+	return router
+}
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	router := newAPIRouter()
+
+	document, err := routes.OpenAPI(router.Routes())
+	require.NoError(t, err, "every route needs an entry in routes.Documentation")
+
+	var registered, documented, generated []string
+	for _, route := range router.Routes() {
+		registered = append(registered, route.Method+" "+openapi.OpenAPIPath(route.Path))
+	}
+	for _, route := range routes.Documentation() {
+		documented = append(documented, route.Method+" "+openapi.OpenAPIPath(route.Path))
+	}
+	for path, operations := range document.Paths {
+		for method := range operations {
+			generated = append(generated, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(registered)
+	sort.Strings(documented)
+	sort.Strings(generated)
+
+	assert.Equal(t, registered, documented, "routes.Documentation has entries for routes that don't exist")
+	assert.Equal(t, registered, generated)
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	router := newAPIRouter()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var document openapi.Document
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &document))
+	assert.Equal(t, "3.1.0", document.OpenAPI)
+
+	// Constraints come from the validate and binding tags
+	product := document.Components.Schemas["CreateProductInput"]
+	require.NotNil(t, product)
+	assert.ElementsMatch(t, []string{"name", "description", "price", "category_id"}, product.Required)
+	assert.Equal(t, 2, *product.Properties["name"].MinLength)
+	assert.Equal(t, 100, *product.Properties["name"].MaxLength)
+	assert.Equal(t, 0.0, *product.Properties["stock_quantity"].Minimum)
+	assert.Equal(t, "#/components/schemas/Money", product.Properties["price"].Ref)
+	assert.NotContains(t, product.Properties, "reserved")
+	assert.Empty(t, document.Components.Schemas["UpdateProductInput"].Required)
+	assert.NotContains(t, document.Components.Schemas, "Product", "stored products are only exposed through their DTOs")
+	assert.NotContains(t, document.Components.Schemas, "Variant")
+
+	user := document.Components.Schemas["CreateUserInput"]
+	assert.Equal(t, "email", user.Properties["email"].Format)
+	assert.NotContains(t, user.Properties, "role", "new users can't choose their role")
+	assert.NotContains(t, document.Components.Schemas["UserResponse"].Properties, "password")
+
+	// Batch operations take the single-product bodies and return their responses
+	operation := document.Components.Schemas["ProductBatchOperation"]
+	require.NotNil(t, operation)
+	require.Len(t, operation.Properties["product"].AnyOf, 2)
+	assert.Equal(t, "#/components/schemas/CreateProductInput", operation.Properties["product"].AnyOf[0].Ref)
+	assert.Equal(t, "#/components/schemas/UpdateProductInput", operation.Properties["product"].AnyOf[1].Ref)
+	assert.Equal(t, "#/components/schemas/ProductResponse", document.Components.Schemas["ProductBatchResult"].Properties["product"].Ref)
+
+	webhook := document.Components.Schemas["WebhookRequest"]
+	assert.Equal(t, 1, *webhook.Properties["events"].MinItems)
+	assert.Contains(t, webhook.Properties["events"].Items.Enum, "product.created")
+
+	// Recursive types refer to themselves
+	node := document.Components.Schemas["CategoryNode"]
+	assert.Equal(t, "#/components/schemas/CategoryNode", node.Properties["children"].Items.Ref)
+
+	get := document.Paths["/api/v1/products/{id}"]["get"]
+	require.NotNil(t, get)
+	assert.Equal(t, "id", get.Parameters[0].Name)
+	assert.Equal(t, "path", get.Parameters[0].In)
+	response := get.Responses["200"].Content["application/json"].Schema
+	assert.Equal(t, "#/components/schemas/APIResponse", response.AllOf[0].Ref)
+	assert.Equal(t, "#/components/schemas/ProductResponse", response.AllOf[1].Properties["data"].Ref)
+	assert.Nil(t, get.Security, "protected routes use the document's security")
+
+	refresh := document.Paths["/api/v1/refresh"]["post"]
+	require.NotNil(t, refresh)
+	assert.Equal(t, []map[string][]string{{}}, refresh.Security)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "/openapi.json")
+}
@@ -0,0 +1,52 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/repositories"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestOrderStatusTransitions(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{from: models.OrderPending, to: models.OrderPaid, want: true},
+		{from: models.OrderPending, to: models.OrderCancelled, want: true},
+		{from: models.OrderPending, to: models.OrderShipped, want: false},
+		{from: models.OrderPaid, to: models.OrderShipped, want: true},
+		{from: models.OrderPaid, to: models.OrderCancelled, want: true},
+		{from: models.OrderPaid, to: models.OrderPending, want: false},
+		{from: models.OrderShipped, to: models.OrderCancelled, want: false},
+		{from: models.OrderCancelled, to: models.OrderPaid, want: false},
+		{from: "unknown", to: models.OrderPaid, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			assert.Equal(t, tt.want, models.CanTransitionOrder(tt.from, tt.to))
+		})
+	}
+}
+
+func TestListOrdersInvalidSort(t *testing.T) {
+	// The sort is checked before the collection is queried
+	orderService := services.NewOrderService(&repositories.OrderRepository{}, nil, nil, nil, nil)
+	claims := &utils.Claims{UserID: primitive.NewObjectID().Hex(), Role: "user"}
+
+	for _, sort := range []string{"password", "created_at sideways", "$where"} {
+		_, err := orderService.ListOrders(claims, utils.Pagination{Limit: 10, Page: 1, Sort: sort})
+
+		var customError *utils.CustomError
+		require.ErrorAs(t, err, &customError, sort)
+		assert.Equal(t, http.StatusBadRequest, customError.StatusCode, sort)
+	}
+}
@@ -0,0 +1,189 @@
+package tests
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/controllers"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func newProductStream(replayBuffer int) *services.ProductStreamService {
+	return services.NewProductStreamService(nil, configs.ProductEventsConfig{ReplayBuffer: replayBuffer, Heartbeat: time.Minute})
+}
+
+func productChange(id, eventType string) models.ProductChange {
+	productID := primitive.NewObjectID()
+	return models.ProductChange{ID: id, Type: eventType, ProductID: productID, Product: &models.Product{ID: productID, Name: "Desk"}}
+}
+
+func TestProductStreamResume(t *testing.T) {
+	stream := newProductStream(2)
+	stream.Publish(productChange("1", models.EventProductCreated))
+	stream.Publish(productChange("2", models.EventProductUpdated))
+	stream.Publish(productChange("3", models.EventProductDeleted))
+	// Dispatched again by the event bus
+	stream.Publish(productChange("3", models.EventProductDeleted))
+
+	subscription, missed, resumed := stream.Subscribe("2")
+	assert.True(t, resumed)
+	require.Len(t, missed, 1)
+	assert.Equal(t, "3", missed[0].ID)
+	stream.Unsubscribe(subscription)
+
+	// 1 was pushed out of the buffer, the client has to reload
+	_, missed, resumed = stream.Subscribe("1")
+	assert.False(t, resumed)
+	assert.Empty(t, missed)
+
+	_, missed, resumed = stream.Subscribe("")
+	assert.True(t, resumed)
+	assert.Empty(t, missed)
+}
+
+func TestProductStreamSlowSubscriber(t *testing.T) {
+	stream := newProductStream(1000)
+	slow, _, _ := stream.Subscribe("")
+	fast, _, _ := stream.Subscribe("")
+
+	received := 0
+	for i := range 100 {
+		stream.Publish(productChange(primitive.NewObjectID().Hex(), models.EventProductUpdated))
+		if i%10 == 0 {
+			for len(fast.Changes) > 0 {
+				<-fast.Changes
+				received++
+			}
+		}
+	}
+
+	// The slow subscriber is dropped once its buffer is full and can resume
+	// from its last change, the others keep receiving
+	buffered := 0
+	for range slow.Changes {
+		buffered++
+	}
+	assert.Equal(t, 64, buffered)
+	for len(fast.Changes) > 0 {
+		<-fast.Changes
+		received++
+	}
+	assert.Equal(t, 100, received)
+}
+
+func TestProductStreamHandleEvent(t *testing.T) {
+	stream := newProductStream(10)
+	subscription, _, _ := stream.Subscribe("")
+
+	product := &models.Product{ID: primitive.NewObjectID(), Name: "Desk", Price: models.Money{Amount: 12500, Currency: "USD"}}
+	created, err := models.NewEvent(models.EventProductCreated, product)
+	require.NoError(t, err)
+	require.NoError(t, stream.HandleEvent(context.Background(), created))
+
+	deleted, err := models.NewEvent(models.EventProductDeleted, map[string]string{"id": product.ID.Hex()})
+	require.NoError(t, err)
+	require.NoError(t, stream.HandleEvent(context.Background(), deleted))
+
+	change := <-subscription.Changes
+	assert.Equal(t, created.ID.Hex(), change.ID)
+	assert.Equal(t, models.EventProductCreated, change.Type)
+	assert.Equal(t, product.ID, change.ProductID)
+	require.NotNil(t, change.Product)
+	assert.Equal(t, product.Price, change.Product.Price)
+
+	change = <-subscription.Changes
+	assert.Equal(t, models.EventProductDeleted, change.Type)
+	assert.Equal(t, product.ID, change.ProductID)
+	assert.Nil(t, change.Product)
+}
+
+func TestStreamProductEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	stream := newProductStream(10)
+	stream.Publish(productChange("1", models.EventProductCreated))
+	stream.Publish(productChange("2", models.EventProductUpdated))
+
+	router := gin.New()
+	router.GET("/events", controllers.NewProductStreamController(stream).StreamEvents)
+	server := httptest.NewServer(router)
+	defer server.Close()
+
+	// readEvent returns the next event's fields, skipping comments
+	readEvent := func(reader *bufio.Reader) map[string]string {
+		fields := map[string]string{}
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			line = strings.TrimSuffix(line, "\n")
+			if line == "" {
+				if len(fields) > 0 {
+					return fields
+				}
+				continue
+			}
+			if strings.HasPrefix(line, ":") {
+				continue
+			}
+			name, value, _ := strings.Cut(line, ": ")
+			fields[name] = value
+		}
+	}
+
+	t.Run("Resume", func(t *testing.T) {
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+		require.NoError(t, err)
+		request.Header.Set("Last-Event-ID", "1")
+		response, err := server.Client().Do(request)
+		require.NoError(t, err)
+		defer response.Body.Close()
+		assert.Equal(t, "text/event-stream", response.Header.Get("Content-Type"))
+
+		reader := bufio.NewReader(response.Body)
+		event := readEvent(reader)
+		assert.Equal(t, "2", event["id"])
+		assert.Equal(t, models.EventProductUpdated, event["event"])
+		assert.Contains(t, event["data"], `"type":"product.updated"`)
+		assert.Contains(t, event["data"], `"name":"Desk"`)
+
+		stream.Publish(productChange("3", models.EventProductDeleted))
+		event = readEvent(reader)
+		assert.Equal(t, "3", event["id"])
+		assert.Equal(t, models.EventProductDeleted, event["event"])
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		request, err := http.NewRequest(http.MethodGet, server.URL+"/events", nil)
+		require.NoError(t, err)
+		request.Header.Set("Last-Event-ID", "unknown")
+		response, err := server.Client().Do(request)
+		require.NoError(t, err)
+		defer response.Body.Close()
+
+		event := readEvent(bufio.NewReader(response.Body))
+		assert.Equal(t, "reset", event["event"])
+		assert.Equal(t, "", event["id"])
+	})
+
+	t.Run("Closed On Shutdown", func(t *testing.T) {
+		response, err := server.Client().Get(server.URL + "/events")
+		require.NoError(t, err)
+		defer response.Body.Close()
+
+		require.NoError(t, stream.Shutdown(context.Background()))
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		assert.Equal(t, ": connected\n\n", string(body))
+	})
+}
@@ -1,12 +1,15 @@
 package tests
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/harsh-solanki21/golang-gin-crud-api/models"
 	"github.com/harsh-solanki21/golang-gin-crud-api/validations"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -21,8 +24,8 @@ func TestProductValidation(t *testing.T) {
 			product: models.Product{
 				Name:        "Test Product 1",
 				Description: "This is a test product 1",
-				Price:       9.99,
-				Category:    "Test Category 1",
+				Price:       models.Money{Amount: 999, Currency: "USD"},
+				CategoryID:  primitive.NewObjectID(),
 				InStock:     true,
 			},
 			want: true,
@@ -32,8 +35,8 @@ func TestProductValidation(t *testing.T) {
 			product: models.Product{
 				Name:        "T",
 				Description: "This is a test product 2",
-				Price:       9.99,
-				Category:    "Test Category 2",
+				Price:       models.Money{Amount: 999, Currency: "USD"},
+				CategoryID:  primitive.NewObjectID(),
 				InStock:     true,
 			},
 			want: false,
@@ -43,8 +46,8 @@ func TestProductValidation(t *testing.T) {
 			product: models.Product{
 				Name:        string(make([]rune, 101)),
 				Description: "This is a test product 3",
-				Price:       9.99,
-				Category:    "Test Category 3",
+				Price:       models.Money{Amount: 999, Currency: "USD"},
+				CategoryID:  primitive.NewObjectID(),
 				InStock:     true,
 			},
 			want: false,
@@ -54,8 +57,8 @@ func TestProductValidation(t *testing.T) {
 			product: models.Product{
 				Name:        "Test Product 4",
 				Description: string(make([]rune, 501)),
-				Price:       9.99,
-				Category:    "Test Category 4",
+				Price:       models.Money{Amount: 999, Currency: "USD"},
+				CategoryID:  primitive.NewObjectID(),
 				InStock:     true,
 			},
 			want: false,
@@ -65,8 +68,8 @@ func TestProductValidation(t *testing.T) {
 			product: models.Product{
 				Name:        "Test Product 5",
 				Description: "This is a test product 5",
-				Price:       -1.00,
-				Category:    "Test Category 5",
+				Price:       models.Money{Amount: -100, Currency: "USD"},
+				CategoryID:  primitive.NewObjectID(),
 				InStock:     true,
 			},
 			want: false,
@@ -76,8 +79,8 @@ func TestProductValidation(t *testing.T) {
 			product: models.Product{
 				Name:        "Test Product 6",
 				Description: "This is a test product 6",
-				Price:       9.99,
-				Category:    "",
+				Price:       models.Money{Amount: 999, Currency: "USD"},
+				CategoryID:  primitive.NilObjectID,
 				InStock:     true,
 			},
 			want: false,
@@ -96,8 +99,8 @@ func TestProductMarshalBSON(t *testing.T) {
 	product := &models.Product{
 		Name:        "Test Product",
 		Description: "This is a test product",
-		Price:       9.99,
-		Category:    "Test Category",
+		Price:       models.Money{Amount: 999, Currency: "USD"},
+		CategoryID:  primitive.NewObjectID(),
 		InStock:     true,
 	}
 
@@ -109,25 +112,84 @@ func TestProductMarshalBSON(t *testing.T) {
 	assert.True(t, product.UpdatedAt.After(product.CreatedAt) || product.UpdatedAt.Equal(product.CreatedAt))
 }
 
-func TestProductToJSON(t *testing.T) {
+func TestProductResponse(t *testing.T) {
+	ownerID := primitive.NewObjectID()
 	product := &models.Product{
 		ID:          primitive.NewObjectID(),
 		Name:        "Test Product",
 		Description: "This is a test product",
-		Price:       9.99,
-		Category:    "Test Category",
+		Price:       models.Money{Amount: 999, Currency: "USD"},
+		CategoryID:  primitive.NewObjectID(),
+		OwnerID:     &ownerID,
+		RatingSum:   9,
+		Variants:    []*models.Variant{{ID: primitive.NewObjectID(), SKU: "TP-1", OptionKey: "size=m"}},
 		InStock:     true,
 		CreatedAt:   time.Now(),
 		UpdatedAt:   time.Now(),
 	}
 
-	json := product.ToJSON()
+	response := models.NewProductResponse(product)
 
-	assert.Equal(t, product.Name, json["name"])
-	assert.Equal(t, product.Description, json["description"])
-	assert.Equal(t, product.Price, json["price"])
-	assert.Equal(t, product.Category, json["category"])
-	assert.Equal(t, product.InStock, json["in_stock"])
-	assert.NotContains(t, json, "created_at")
-	assert.NotContains(t, json, "updated_at")
+	assert.Equal(t, product.ID, response.ID)
+	assert.Equal(t, product.Name, response.Name)
+	assert.Equal(t, product.Price, response.Price)
+	assert.Equal(t, product.CategoryID, response.CategoryID)
+	assert.Equal(t, product.OwnerID, response.OwnerID)
+	assert.Equal(t, product.InStock, response.InStock)
+	assert.Equal(t, product.CreatedAt, response.CreatedAt)
+	assert.Equal(t, product.UpdatedAt, response.UpdatedAt)
+	require.Len(t, response.Variants, 1)
+	assert.Equal(t, "TP-1", response.Variants[0].SKU)
+	assert.Nil(t, models.NewProductResponse(&models.Product{}).Variants, "listed products have no variants")
+}
+
+func TestUpdateProductInput(t *testing.T) {
+	input := models.UpdateProductInput{Name: "Renamed"}
+	assert.Nil(t, validations.ValidateUpdateProductInput(&input), "updates only validate the fields they set")
+
+	// A threshold of 0 is an update, leaving it out isn't
+	var reset models.UpdateProductInput
+	require.NoError(t, json.Unmarshal([]byte(`{"low_stock_threshold":0}`), &reset))
+	require.NotNil(t, reset.LowStockThreshold)
+	assert.Equal(t, 0, *reset.LowStockThreshold)
+	assert.Nil(t, validations.ValidateUpdateProductInput(&reset))
+	assert.Nil(t, input.LowStockThreshold)
+
+	invalid := models.UpdateProductInput{Name: "R", Price: models.Money{Amount: -1, Currency: "USD"}}
+	assert.Len(t, validations.ValidateUpdateProductInput(&invalid), 2)
+}
+
+func TestProductStockStatus(t *testing.T) {
+	tests := []struct {
+		name      string
+		stock     int
+		reserved  int
+		available int
+		inStock   bool
+	}{
+		{name: "Available Stock", stock: 10, reserved: 3, available: 7, inStock: true},
+		{name: "Fully Reserved", stock: 5, reserved: 5, available: 0, inStock: false},
+		{name: "No Stock", stock: 0, reserved: 0, available: 0, inStock: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			product := &models.Product{
+				Name:          "Test Product",
+				StockQuantity: tt.stock,
+				Reserved:      tt.reserved,
+				// in_stock is derived, a stale value must be ignored
+				InStock: !tt.inStock,
+			}
+
+			data, err := product.MarshalBSON()
+			assert.NoError(t, err)
+			assert.Equal(t, tt.available, product.Available())
+			assert.Equal(t, tt.inStock, product.InStock)
+
+			var decoded models.Product
+			assert.NoError(t, bson.Unmarshal(data, &decoded))
+			assert.Equal(t, tt.inStock, decoded.InStock)
+		})
+	}
 }
@@ -0,0 +1,92 @@
+package tests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/middlewares"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryRateLimitStore(t *testing.T) {
+	store := utils.NewMemoryRateLimitStore()
+	now := time.Now()
+	store.SetClock(func() time.Time { return now })
+
+	policy := utils.RateLimitPolicy{Name: "test", Limit: 60, Burst: 2, Period: time.Minute}
+
+	first, err := store.Take(context.Background(), "key", policy)
+	require.NoError(t, err)
+	assert.True(t, first.Allowed)
+	assert.Equal(t, 1, first.Remaining)
+
+	second, _ := store.Take(context.Background(), "key", policy)
+	assert.True(t, second.Allowed)
+	assert.Equal(t, 0, second.Remaining)
+
+	third, _ := store.Take(context.Background(), "key", policy)
+	assert.False(t, third.Allowed)
+	assert.Equal(t, time.Second, third.RetryAfter)
+
+	// Other keys have their own bucket
+	other, _ := store.Take(context.Background(), "other", policy)
+	assert.True(t, other.Allowed)
+
+	// One token is refilled every second
+	now = now.Add(time.Second)
+	fourth, _ := store.Take(context.Background(), "key", policy)
+	assert.True(t, fourth.Allowed)
+}
+
+func newRateLimitedRouter(role string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	config := configs.Default().RateLimit
+	config.Auth = configs.RateLimitPolicyConfig{Limit: 1, Burst: 1, Period: time.Minute}
+	limiter := middlewares.NewRateLimiter(utils.NewMemoryRateLimitStore(), config)
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		if role != "" {
+			c.Set("claims", &utils.Claims{UserID: "user-1", Role: role})
+		}
+	})
+	router.GET("/", limiter.Auth(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	router := newRateLimitedRouter("user")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("RateLimit-Reset"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+}
+
+func TestRateLimitMiddlewareExemptRole(t *testing.T) {
+	router := newRateLimitedRouter("admin")
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+	}
+}
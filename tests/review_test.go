@@ -0,0 +1,58 @@
+package tests
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRatingChange(t *testing.T) {
+	published := func(rating int) *models.Review {
+		return &models.Review{Rating: rating, Status: models.ReviewPublished}
+	}
+	hidden := func(rating int) *models.Review {
+		return &models.Review{Rating: rating, Status: models.ReviewHidden}
+	}
+
+	tests := []struct {
+		name      string
+		before    *models.Review
+		after     *models.Review
+		wantSum   int
+		wantCount int
+	}{
+		{name: "Created", before: nil, after: published(4), wantSum: 4, wantCount: 1},
+		{name: "Rating Edited", before: published(4), after: published(2), wantSum: -2, wantCount: 0},
+		{name: "Text Edited", before: published(3), after: published(3), wantSum: 0, wantCount: 0},
+		{name: "Hidden", before: published(5), after: hidden(5), wantSum: -5, wantCount: -1},
+		{name: "Approved", before: hidden(1), after: published(1), wantSum: 1, wantCount: 1},
+		{name: "Hidden Review Edited", before: hidden(1), after: hidden(5), wantSum: 0, wantCount: 0},
+		{name: "Deleted", before: published(3), after: nil, wantSum: -3, wantCount: -1},
+		{name: "Hidden Review Deleted", before: hidden(3), after: nil, wantSum: 0, wantCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sum, count := models.RatingChange(tt.before, tt.after)
+			assert.Equal(t, tt.wantSum, sum)
+			assert.Equal(t, tt.wantCount, count)
+		})
+	}
+}
+
+func TestProductRatingJSON(t *testing.T) {
+	product := models.Product{AverageRating: 4.5, ReviewCount: 2, RatingSum: 9}
+
+	data, err := json.Marshal(product)
+	require.NoError(t, err)
+
+	var fields map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, 4.5, fields["average_rating"])
+	assert.Equal(t, float64(2), fields["review_count"])
+	// The running sum is an implementation detail of the average
+	assert.NotContains(t, fields, "rating_sum")
+}
@@ -0,0 +1,133 @@
+package tests
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/server"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServerGracefulShutdown(t *testing.T) {
+	started := make(chan struct{})
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		time.Sleep(200 * time.Millisecond)
+		w.Write([]byte("done"))
+	})
+
+	srv := server.New(handler, server.Options{ShutdownTimeout: 5 * time.Second})
+
+	var mu sync.Mutex
+	var order []string
+	srv.OnShutdown(func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, "workers")
+		return nil
+	})
+	srv.OnShutdown(func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, "database")
+		return nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ctx, listener)
+	}()
+
+	// Start an in-flight request and shut down while it is being handled
+	type result struct {
+		body string
+		err  error
+	}
+	response := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String())
+		if err != nil {
+			response <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		response <- result{body: string(body), err: err}
+	}()
+
+	<-started
+	cancel()
+
+	res := <-response
+	require.NoError(t, res.err)
+	assert.Equal(t, "done", res.body)
+	assert.NoError(t, <-serveErr)
+	assert.Equal(t, []string{"workers", "database"}, order)
+}
+
+func TestServerShutdownHookErrors(t *testing.T) {
+	srv := server.New(http.NotFoundHandler(), server.Options{ShutdownTimeout: time.Second})
+
+	hookErr := errors.New("disconnect failed")
+	srv.OnShutdown(func(ctx context.Context) error {
+		return hookErr
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err = srv.Serve(ctx, listener)
+	assert.ErrorIs(t, err, hookErr)
+
+	// Hooks only run once
+	assert.NoError(t, srv.Shutdown())
+}
+
+func TestServerShutdownHookTimeout(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+	defer close(release)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-release
+	})
+
+	srv := server.New(handler, server.Options{ShutdownTimeout: 100 * time.Millisecond, HookTimeout: time.Second})
+
+	// A request that outlives the shutdown timeout doesn't use up the hooks' time
+	var hookErr error
+	srv.OnShutdown(func(ctx context.Context) error {
+		hookErr = ctx.Err()
+		return nil
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.Serve(ctx, listener)
+	}()
+
+	go http.Get("http://" + listener.Addr().String())
+	<-started
+	cancel()
+
+	assert.ErrorIs(t, <-serveErr, context.DeadlineExceeded, "the request didn't drain in time")
+	assert.NoError(t, hookErr)
+}
@@ -1,12 +1,14 @@
 package tests
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
 	"github.com/harsh-solanki21/golang-gin-crud-api/models"
 	"github.com/harsh-solanki21/golang-gin-crud-api/validations"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -98,25 +100,42 @@ func TestUserMarshalBSON(t *testing.T) {
 	assert.True(t, user.UpdatedAt.After(user.CreatedAt) || user.UpdatedAt.Equal(user.CreatedAt))
 }
 
-func TestUserToJSON(t *testing.T) {
+func TestCreateUserInput(t *testing.T) {
+	input := models.CreateUserInput{Name: "Test User", Email: "test@example.com", Password: "password123", Age: 25}
+	assert.Nil(t, validations.ValidateCreateUserInput(&input))
+
+	user := input.ToUser()
+	assert.Equal(t, "user", user.Role, "new users never choose their role")
+	assert.Equal(t, input.Email, user.Email)
+
+	invalid := models.CreateUserInput{Name: "T", Email: "not-an-email"}
+	assert.Len(t, validations.ValidateCreateUserInput(&invalid), 3)
+}
+
+func TestUserResponse(t *testing.T) {
 	user := &models.User{
 		ID:        primitive.NewObjectID(),
 		Name:      "Test User",
 		Email:     "test@example.com",
-		Password:  "password123",
+		Password:  "$2a$10$hash",
 		Age:       25,
 		Role:      "user",
 		CreatedAt: time.Now(),
 		UpdatedAt: time.Now(),
 	}
 
-	json := user.ToJSON()
+	data, err := json.Marshal(models.NewUserResponse(user))
+	require.NoError(t, err)
+	var response map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &response))
+
+	assert.Equal(t, user.ID.Hex(), response["id"])
+	assert.Equal(t, user.Email, response["email"])
+	assert.Contains(t, response, "created_at")
+	assert.Contains(t, response, "updated_at")
+	assert.NotContains(t, response, "password")
+	assert.NotContains(t, string(data), user.Password)
 
-	assert.Equal(t, user.Name, json["name"])
-	assert.Equal(t, user.Email, json["email"])
-	assert.Equal(t, user.Role, json["role"])
-	assert.NotContains(t, json, "password")
-	assert.NotContains(t, json, "age")
-	assert.NotContains(t, json, "created_at")
-	assert.NotContains(t, json, "updated_at")
+	assert.Empty(t, models.NewUserResponses(nil))
+	assert.NotNil(t, models.NewUserResponses(nil), "empty lists are encoded as []")
 }
@@ -0,0 +1,222 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/middlewares"
+	"github.com/harsh-solanki21/golang-gin-crud-api/openapi"
+	"github.com/harsh-solanki21/golang-gin-crud-api/routes"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type widget struct {
+	ID     primitive.ObjectID `json:"id"`
+	Name   string             `json:"name" validate:"required,min=2"`
+	Count  int                `json:"count" validate:"gte=0"`
+	Secret string             `json:"secret,omitempty"`
+}
+
+// newValidatedRouter documents and serves a widget API, GET /widgets/:id responds with response
+func newValidatedRouter(validation *middlewares.Validation, response interface{}) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	schemas := openapi.NewSchemas()
+	schemas.ReadOnly(widget{}, "id")
+	schemas.WriteOnly(widget{}, "secret")
+	generator := &openapi.Generator{Schemas: schemas, Envelope: utils.APIResponse{}, Page: utils.PaginatedResponse{}}
+	spec := openapi.NewSpec(func() (*openapi.Document, error) {
+		return generator.Generate(router.Routes(), []openapi.Route{
+			{Method: http.MethodPost, Path: "/widgets", Access: openapi.Public, Body: widget{}, Status: http.StatusCreated, Data: widget{}},
+			{Method: http.MethodGet, Path: "/widgets/:id", Access: openapi.Public, Data: widget{}, Parameters: []*openapi.Parameter{
+				{Name: "fields", In: "query", Schema: &openapi.Schema{Type: openapi.SchemaType{"integer"}, Minimum: float64Ptr(1)}},
+			}},
+		})
+	})
+
+	router.Use(validation.Handle(spec))
+	router.POST("/widgets", func(c *gin.Context) {
+		var body widget
+		if err := c.ShouldBindJSON(&body); err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid request body", err)
+			return
+		}
+		body.ID = primitive.NewObjectID()
+		utils.RespondWithSuccess(c, http.StatusCreated, "Widget created", body)
+	})
+	router.GET("/widgets/:id", func(c *gin.Context) {
+		utils.RespondWithSuccess(c, http.StatusOK, "Widget retrieved", response)
+	})
+	return router
+}
+
+func float64Ptr(value float64) *float64 {
+	return &value
+}
+
+func validationErrors(t *testing.T, w *httptest.ResponseRecorder) map[string]string {
+	var response struct {
+		Message string                    `json:"message"`
+		Error   []openapi.ValidationError `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Validation error", response.Message)
+
+	byField := map[string]string{}
+	for _, err := range response.Error {
+		byField[err.In+":"+err.Field] = err.Message
+	}
+	return byField
+}
+
+func TestValidationRequests(t *testing.T) {
+	router := newValidatedRouter(middlewares.NewValidation(configs.ValidationConfig{Requests: true}), widget{Name: "gear"})
+	id := primitive.NewObjectID().Hex()
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		errors map[string]string
+	}{
+		{
+			name:   "Valid Body",
+			method: http.MethodPost,
+			path:   "/widgets",
+			body:   `{"name":"gear","count":3,"secret":"s"}`,
+		},
+		{
+			name:   "Valid Parameters",
+			method: http.MethodGet,
+			path:   "/widgets/" + id + "?fields=2",
+		},
+		{
+			name:   "Unknown And Read-Only Fields",
+			method: http.MethodPost,
+			path:   "/widgets",
+			body:   `{"id":"` + id + `","name":"gear","color":"red"}`,
+			errors: map[string]string{"body:id": "id is read-only", "body:color": "color is not allowed"},
+		},
+		{
+			name:   "Invalid Fields",
+			method: http.MethodPost,
+			path:   "/widgets",
+			body:   `{"name":"g","count":-1}`,
+			errors: map[string]string{
+				"body:name":  "name must be at least 2 characters long",
+				"body:count": "count must be at least 0",
+			},
+		},
+		{
+			name:   "Wrong Type",
+			method: http.MethodPost,
+			path:   "/widgets",
+			body:   `{"name":"gear","count":"3"}`,
+			errors: map[string]string{"body:count": "count must be an integer"},
+		},
+		{
+			name:   "Missing Body",
+			method: http.MethodPost,
+			path:   "/widgets",
+			errors: map[string]string{"body:": "request body is required"},
+		},
+		{
+			name:   "Invalid Parameters",
+			method: http.MethodGet,
+			path:   "/widgets/abc?fields=0",
+			errors: map[string]string{"path:id": "id is invalid", "query:fields": "fields must be at least 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if tt.errors == nil {
+				assert.Less(t, w.Code, 300, w.Body.String())
+				return
+			}
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, tt.errors, validationErrors(t, w))
+		})
+	}
+}
+
+func TestValidationResponses(t *testing.T) {
+	tests := []struct {
+		name     string
+		response interface{}
+		errors   []string
+	}{
+		{name: "Matching Response", response: widget{Name: "gear"}},
+		{name: "Write-Only Field", response: widget{Name: "gear", Secret: "s"}, errors: []string{"data.secret is write-only"}},
+		{name: "Undocumented Field", response: gin.H{"id": primitive.NewObjectID(), "name": "gear", "count": 1, "color": "red"}, errors: []string{"data.color is not allowed"}},
+		{name: "Missing Field", response: gin.H{"id": primitive.NewObjectID(), "count": 1}, errors: []string{"data.name is required"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var errors []string
+			validation := middlewares.NewValidation(configs.ValidationConfig{Responses: true})
+			validation.OnResponseError = func(c *gin.Context, problems []openapi.ValidationError) {
+				for _, problem := range problems {
+					errors = append(errors, problem.Message)
+				}
+			}
+			router := newValidatedRouter(validation, tt.response)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/widgets/"+primitive.NewObjectID().Hex(), nil))
+
+			assert.Equal(t, http.StatusOK, w.Code, "responses are sent as they are")
+			assert.Equal(t, tt.errors, errors)
+		})
+	}
+}
+
+func TestValidationDisabled(t *testing.T) {
+	router := newValidatedRouter(middlewares.NewValidation(configs.Default().Validation), nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/widgets", strings.NewReader(`{"name":"gear","color":"red"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+}
+
+func TestValidationRegister(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	routes.SetupRoutes(router, routes.Dependencies{
+		RateLimiter: middlewares.NewRateLimiter(utils.NewMemoryRateLimitStore(), configs.Default().RateLimit),
+		Validation:  middlewares.NewValidation(configs.ValidationConfig{Requests: true}),
+	})
+
+	body := `{"name":"Mallory","email":"mallory@example.com","password":"password123","role":"admin","verified":true}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/register", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, map[string]string{
+		"body:role":     "role is not allowed",
+		"body:verified": "verified is not allowed",
+	}, validationErrors(t, w))
+}
@@ -0,0 +1,68 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/validations"
+	"github.com/stretchr/testify/assert"
+)
+
+var tshirtOptions = []models.ProductOption{
+	{Name: "size", Values: []string{"S", "M", "L"}},
+	{Name: "color", Values: []string{"red", "blue"}},
+}
+
+func TestVariantOptionKey(t *testing.T) {
+	key := models.VariantOptionKey(map[string]string{"size": "M", "color": "Red"})
+	assert.Equal(t, "color=red;size=m", key)
+	assert.Equal(t, key, models.VariantOptionKey(map[string]string{"color": "red", "size": "m"}))
+	assert.Empty(t, models.VariantOptionKey(nil))
+}
+
+func TestCheckVariantOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		options map[string]string
+		want    bool
+	}{
+		{name: "Valid Options", options: map[string]string{"size": "M", "color": "red"}, want: true},
+		{name: "Missing Axis", options: map[string]string{"size": "M"}, want: false},
+		{name: "Unknown Value", options: map[string]string{"size": "XL", "color": "red"}, want: false},
+		{name: "Unknown Axis", options: map[string]string{"size": "M", "fit": "slim"}, want: false},
+		{name: "Extra Axis", options: map[string]string{"size": "M", "color": "red", "fit": "slim"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := models.CheckVariantOptions(tshirtOptions, tt.options)
+			assert.Equal(t, tt.want, err == nil)
+		})
+	}
+}
+
+func TestVariantUnitPrice(t *testing.T) {
+	product := &models.Product{Price: models.Money{Amount: 1999, Currency: "USD"}}
+
+	variant := &models.Variant{SKU: "TS-M-RED"}
+	assert.Equal(t, product.Price, variant.UnitPrice(product))
+
+	variant.Price = &models.Money{Amount: 2499, Currency: "USD"}
+	assert.Equal(t, *variant.Price, variant.UnitPrice(product))
+}
+
+func TestVariantValidation(t *testing.T) {
+	variant := models.Variant{
+		SKU:     "TS-M-RED",
+		Options: map[string]string{"size": "M", "color": "red"},
+	}
+	assert.Empty(t, validations.ValidateVariant(&variant))
+
+	variant.SKU = ""
+	assert.NotEmpty(t, validations.ValidateVariant(&variant))
+
+	// Updates may leave the SKU out but not set a negative price
+	assert.Empty(t, validations.ValidateVariantUpdate(&variant))
+	variant.Price = &models.Money{Amount: -1, Currency: "USD"}
+	assert.NotEmpty(t, validations.ValidateVariantUpdate(&variant))
+}
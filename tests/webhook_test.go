@@ -0,0 +1,188 @@
+package tests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin/binding"
+	"github.com/harsh-solanki21/golang-gin-crud-api/configs"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+	"github.com/harsh-solanki21/golang-gin-crud-api/services"
+	"github.com/harsh-solanki21/golang-gin-crud-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendWebhook(t *testing.T) {
+	const secret = "a-webhook-secret-of-some-length"
+	payload := []byte(`{"id":"1","type":"product.created","data":{"name":"Desk"}}`)
+
+	received := make(chan *http.Request, 1)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, payload, body)
+		assert.True(t, utils.VerifyWebhookSignature(secret, r.Header.Get(utils.WebhookTimestampHeader), r.Header.Get(utils.WebhookSignatureHeader), body))
+		assert.False(t, utils.VerifyWebhookSignature("another-secret-of-some-length", r.Header.Get(utils.WebhookTimestampHeader), r.Header.Get(utils.WebhookSignatureHeader), body))
+		received <- r
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	status, err := utils.SendWebhook(context.Background(), receiver.Client(), utils.WebhookMessage{
+		URL:        receiver.URL,
+		Secret:     secret,
+		DeliveryID: "delivery-1",
+		Event:      models.EventProductCreated,
+		Payload:    payload,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, status)
+
+	request := <-received
+	assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+	assert.Equal(t, "delivery-1", request.Header.Get(utils.WebhookIDHeader))
+	assert.Equal(t, models.EventProductCreated, request.Header.Get(utils.WebhookEventHeader))
+}
+
+func TestSendWebhookFailure(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "try again later", http.StatusServiceUnavailable)
+	}))
+	defer receiver.Close()
+
+	status, err := utils.SendWebhook(context.Background(), receiver.Client(), utils.WebhookMessage{URL: receiver.URL, Secret: "secret", Payload: []byte(`{}`)})
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "try again later")
+
+	// Unreachable receivers are failures too
+	receiver.Close()
+	status, err = utils.SendWebhook(context.Background(), http.DefaultClient, utils.WebhookMessage{URL: receiver.URL, Secret: "secret", Payload: []byte(`{}`)})
+	assert.Equal(t, 0, status)
+	assert.Error(t, err)
+}
+
+func TestWebhookClientAddresses(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+	message := utils.WebhookMessage{URL: receiver.URL, Secret: "secret", Payload: []byte(`{}`)}
+
+	status, err := utils.SendWebhook(context.Background(), utils.NewWebhookClient(time.Second, false), message)
+	assert.Equal(t, 0, status)
+	assert.ErrorIs(t, err, utils.ErrWebhookAddressBlocked)
+
+	status, err = utils.SendWebhook(context.Background(), utils.NewWebhookClient(time.Second, true), message)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusNoContent, status)
+
+	for addr, public := range map[string]bool{
+		"127.0.0.1":       false,
+		"10.1.2.3":        false,
+		"172.16.0.1":      false,
+		"192.168.1.1":     false,
+		"169.254.169.254": false,
+		"100.64.0.1":      false,
+		"0.0.0.0":         false,
+		"::1":             false,
+		"fe80::1":         false,
+		"fd00::1":         false,
+		"::ffff:10.0.0.1": false,
+		"93.184.216.34":   true,
+		"2606:4700::1111": true,
+	} {
+		assert.Equal(t, public, utils.IsPublicAddr(netip.MustParseAddr(addr)), addr)
+	}
+}
+
+func TestWebhookClientRedirects(t *testing.T) {
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the redirect was followed")
+	}))
+	defer target.Close()
+	receiver := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusFound))
+	defer receiver.Close()
+
+	status, err := utils.SendWebhook(context.Background(), utils.NewWebhookClient(time.Second, true), utils.WebhookMessage{URL: receiver.URL, Secret: "secret", Payload: []byte(`{}`)})
+	assert.Equal(t, http.StatusFound, status)
+	assert.Error(t, err)
+}
+
+func TestWebhookURLValidation(t *testing.T) {
+	webhookService := services.NewWebhookService(nil, nil, configs.Default().Webhooks)
+	for _, url := range []string{"ftp://partner.example.com", "http://localhost:8080/hooks", "http://127.0.0.1/hooks", "http://[::1]/hooks", "http://169.254.169.254/latest/meta-data", "https://10.0.0.5/hooks"} {
+		_, err := webhookService.CreateWebhook(models.WebhookRequest{URL: url, Events: []string{models.EventProductCreated}})
+		var customErr *utils.CustomError
+		require.ErrorAs(t, err, &customErr, url)
+		assert.Equal(t, 400, customErr.StatusCode, url)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	timestamp := time.Unix(1700000000, 0)
+	signature := utils.SignWebhook("secret", timestamp, []byte(`{}`))
+
+	assert.True(t, utils.VerifyWebhookSignature("secret", "1700000000", signature, []byte(`{}`)))
+	assert.False(t, utils.VerifyWebhookSignature("secret", "1700000001", signature, []byte(`{}`)))
+	assert.False(t, utils.VerifyWebhookSignature("secret", "1700000000", signature, []byte(`{"a":1}`)))
+	assert.False(t, utils.VerifyWebhookSignature("secret", "soon", signature, []byte(`{}`)))
+}
+
+func TestBackoff(t *testing.T) {
+	initial, max := 30*time.Second, 5*time.Minute
+
+	assert.Equal(t, 30*time.Second, utils.Backoff(1, initial, max))
+	assert.Equal(t, time.Minute, utils.Backoff(2, initial, max))
+	assert.Equal(t, 4*time.Minute, utils.Backoff(4, initial, max))
+	assert.Equal(t, max, utils.Backoff(5, initial, max))
+	assert.Equal(t, max, utils.Backoff(100, initial, max))
+}
+
+func TestWebhookRequestValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		request models.WebhookRequest
+		wantErr bool
+	}{
+		{name: "Valid", request: models.WebhookRequest{URL: "https://partner.example.com/hooks", Events: []string{models.EventProductCreated, models.EventUserRegistered}}},
+		{name: "Missing URL", request: models.WebhookRequest{Events: []string{models.EventProductCreated}}, wantErr: true},
+		{name: "No Events", request: models.WebhookRequest{URL: "https://partner.example.com/hooks"}, wantErr: true},
+		{name: "Unknown Event", request: models.WebhookRequest{URL: "https://partner.example.com/hooks", Events: []string{"order.created"}}, wantErr: true},
+		{name: "Short Secret", request: models.WebhookRequest{URL: "https://partner.example.com/hooks", Secret: "short", Events: []string{models.EventProductDeleted}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := binding.Validator.ValidateStruct(&tt.request)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWebhookJSON(t *testing.T) {
+	webhook := models.Webhook{URL: "https://partner.example.com/hooks", Secret: "a-webhook-secret-of-some-length"}
+	data, err := json.Marshal(webhook)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), webhook.Secret)
+
+	// Deliveries show their payload as JSON
+	delivery := models.WebhookDelivery{Event: models.EventProductDeleted, Payload: `{"id":"1"}`, Status: models.DeliveryPending}
+	data, err = json.Marshal(delivery)
+	require.NoError(t, err)
+
+	var decoded map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, map[string]interface{}{"id": "1"}, decoded["payload"])
+	assert.Equal(t, models.DeliveryPending, decoded["status"])
+}
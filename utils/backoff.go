@@ -0,0 +1,16 @@
+package utils
+
+import "time"
+
+// Backoff returns how long to wait after the given number of failed attempts:
+// initial after the first, doubling after every further one up to max
+func Backoff(attempts int, initial, max time.Duration) time.Duration {
+	backoff := initial
+	for i := 1; i < attempts; i++ {
+		backoff *= 2
+		if backoff >= max {
+			return max
+		}
+	}
+	return min(backoff, max)
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CookieOptions struct {
+	Domain   string
+	Secure   bool
+	SameSite http.SameSite
+}
+
+// SetCookie sets an HTTP-only cookie on the root path with the configured attributes
+func SetCookie(c *gin.Context, options CookieOptions, name, value string, maxAge int) {
+	c.SetSameSite(options.SameSite)
+	c.SetCookie(name, value, maxAge, "/", options.Domain, options.Secure, true)
+}
+
+// ClearCookie expires a cookie previously set with SetCookie
+func ClearCookie(c *gin.Context, options CookieOptions, name string) {
+	SetCookie(c, options, name, "", -1)
+}
@@ -0,0 +1,319 @@
+package utils
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Export file formats
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+	ExportXLSX   = "xlsx"
+)
+
+// xlsxMaxRows is the number of rows a worksheet can hold, including the header
+const xlsxMaxRows = 1 << 20
+
+// formulaPrefixes are the first characters that make spreadsheets read a cell as a formula
+const formulaPrefixes = "=+-@\t\r"
+
+var ErrUnsupportedExportFormat = errors.New("unsupported export format")
+
+// ExportWriter writes rows of values to an export file. Values are strings,
+// numbers, booleans, times, json.Number for exact decimals, or nil.
+type ExportWriter interface {
+	WriteRow(values []interface{}) error
+	// Close finishes the file, it doesn't close the underlying writer
+	Close() error
+}
+
+// ExportContentType returns the content type of an export format, or an error for unknown formats
+func ExportContentType(format string) (string, error) {
+	switch format {
+	case ExportCSV:
+		return "text/csv; charset=utf-8", nil
+	case ExportNDJSON:
+		return "application/x-ndjson", nil
+	case ExportXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", nil
+	}
+	return "", fmt.Errorf("%w %q, use csv, ndjson or xlsx", ErrUnsupportedExportFormat, format)
+}
+
+// SelectColumns parses a comma separated list of columns, an empty list selects all of them
+func SelectColumns(requested string, available []string) ([]string, error) {
+	if strings.TrimSpace(requested) == "" {
+		return available, nil
+	}
+
+	var columns []string
+	for _, column := range strings.Split(requested, ",") {
+		column = strings.TrimSpace(column)
+		if !slices.Contains(available, column) {
+			return nil, fmt.Errorf("unknown column %q, available columns are %s", column, strings.Join(available, ", "))
+		}
+		if !slices.Contains(columns, column) {
+			columns = append(columns, column)
+		}
+	}
+	return columns, nil
+}
+
+// NewExportWriter starts an export file with the given columns
+func NewExportWriter(w io.Writer, format string, columns []string) (ExportWriter, error) {
+	switch format {
+	case ExportCSV:
+		return newCSVExportWriter(w, columns)
+	case ExportNDJSON:
+		return &ndjsonExportWriter{writer: bufio.NewWriter(w), columns: columns}, nil
+	case ExportXLSX:
+		return newXLSXExportWriter(w, columns)
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnsupportedExportFormat, format)
+}
+
+// exportText formats a value for the text based formats
+func exportText(value interface{}) string {
+	switch value := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	case json.Number:
+		return value.String()
+	case int:
+		return strconv.Itoa(value)
+	case int64:
+		return strconv.FormatInt(value, 10)
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(value)
+	case time.Time:
+		if value.IsZero() {
+			return ""
+		}
+		return value.UTC().Format(time.RFC3339)
+	case fmt.Stringer:
+		return value.String()
+	}
+	return fmt.Sprint(value)
+}
+
+// escapeFormula prefixes text that a spreadsheet would run as a formula with a
+// quote, so user input such as =HYPERLINK(...) in a CSV file is shown as text.
+// Text that already looks escaped, e.g. '=x, gets another quote so CSV imports
+// can remove exactly the quotes that were added.
+func escapeFormula(text string) string {
+	if needsFormulaQuote(text) {
+		return "'" + text
+	}
+	return text
+}
+
+// unescapeFormula reverts escapeFormula
+func unescapeFormula(text string) string {
+	if text != "" && text[0] == '\'' && needsFormulaQuote(text[1:]) {
+		return text[1:]
+	}
+	return text
+}
+
+func needsFormulaQuote(text string) bool {
+	for text != "" && text[0] == '\'' {
+		text = text[1:]
+	}
+	return text != "" && strings.ContainsRune(formulaPrefixes, rune(text[0]))
+}
+
+type csvExportWriter struct {
+	writer *csv.Writer
+	record []string
+}
+
+func newCSVExportWriter(w io.Writer, columns []string) (*csvExportWriter, error) {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(columns); err != nil {
+		return nil, err
+	}
+	return &csvExportWriter{writer: writer, record: make([]string, len(columns))}, nil
+}
+
+func (cw *csvExportWriter) WriteRow(values []interface{}) error {
+	for i, value := range values {
+		// Only text is escaped, numbers such as -5.00 stay numbers
+		if text, ok := value.(string); ok {
+			cw.record[i] = escapeFormula(text)
+			continue
+		}
+		cw.record[i] = exportText(value)
+	}
+	return cw.writer.Write(cw.record)
+}
+
+func (cw *csvExportWriter) Close() error {
+	cw.writer.Flush()
+	return cw.writer.Error()
+}
+
+// ndjsonExportWriter writes one object per row with the keys in column order
+type ndjsonExportWriter struct {
+	writer  *bufio.Writer
+	columns []string
+	buffer  bytes.Buffer
+}
+
+func (nw *ndjsonExportWriter) WriteRow(values []interface{}) error {
+	nw.buffer.Reset()
+	encoder := json.NewEncoder(&nw.buffer)
+	// Exports are files, not HTML, so keep <, > and & readable
+	encoder.SetEscapeHTML(false)
+
+	nw.buffer.WriteByte('{')
+	for i, value := range values {
+		if i > 0 {
+			nw.buffer.WriteByte(',')
+		}
+		if timestamp, ok := value.(time.Time); ok && timestamp.IsZero() {
+			value = nil
+		}
+		if err := encoder.Encode(nw.columns[i]); err != nil {
+			return err
+		}
+		nw.buffer.Truncate(nw.buffer.Len() - 1)
+		nw.buffer.WriteByte(':')
+		if err := encoder.Encode(value); err != nil {
+			return err
+		}
+		nw.buffer.Truncate(nw.buffer.Len() - 1)
+	}
+	nw.buffer.WriteString("}\n")
+
+	_, err := nw.writer.Write(nw.buffer.Bytes())
+	return err
+}
+
+func (nw *ndjsonExportWriter) Close() error {
+	return nw.writer.Flush()
+}
+
+// xlsxExportWriter writes a workbook with a single worksheet. The worksheet is
+// the last entry of the zip archive and is written row by row, so nothing is
+// kept in memory.
+type xlsxExportWriter struct {
+	archive *zip.Writer
+	sheet   *bufio.Writer
+	rows    int
+}
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types"><Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/><Default Extension="xml" ContentType="application/xml"/><Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/><Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/></Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/></Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Export" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships"><Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/></Relationships>`
+
+func newXLSXExportWriter(w io.Writer, columns []string) (*xlsxExportWriter, error) {
+	archive := zip.NewWriter(w)
+	for _, part := range []struct{ name, content string }{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+	} {
+		file, err := archive.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(file, part.content); err != nil {
+			return nil, err
+		}
+	}
+
+	file, err := archive.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	xw := &xlsxExportWriter{archive: archive, sheet: bufio.NewWriter(file)}
+	xw.sheet.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n")
+	xw.sheet.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	header := make([]interface{}, len(columns))
+	for i, column := range columns {
+		header[i] = column
+	}
+	if err := xw.WriteRow(header); err != nil {
+		return nil, err
+	}
+	return xw, nil
+}
+
+func (xw *xlsxExportWriter) WriteRow(values []interface{}) error {
+	if xw.rows >= xlsxMaxRows {
+		return fmt.Errorf("xlsx worksheets are limited to %d rows", xlsxMaxRows)
+	}
+	xw.rows++
+
+	fmt.Fprintf(xw.sheet, `<row r="%d">`, xw.rows)
+	for i, value := range values {
+		ref := xlsxColumnName(i) + strconv.Itoa(xw.rows)
+		switch value := value.(type) {
+		case nil:
+			continue
+		case int, int64, float64, json.Number:
+			fmt.Fprintf(xw.sheet, `<c r="%s"><v>%s</v></c>`, ref, exportText(value))
+		case bool:
+			flag := "0"
+			if value {
+				flag = "1"
+			}
+			fmt.Fprintf(xw.sheet, `<c r="%s" t="b"><v>%s</v></c>`, ref, flag)
+		default:
+			text := exportText(value)
+			if text == "" {
+				continue
+			}
+			fmt.Fprintf(xw.sheet, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">`, ref)
+			if err := xml.EscapeText(xw.sheet, []byte(text)); err != nil {
+				return err
+			}
+			xw.sheet.WriteString(`</t></is></c>`)
+		}
+	}
+	_, err := xw.sheet.WriteString(`</row>`)
+	return err
+}
+
+func (xw *xlsxExportWriter) Close() error {
+	xw.sheet.WriteString(`</sheetData></worksheet>`)
+	if err := xw.sheet.Flush(); err != nil {
+		return err
+	}
+	return xw.archive.Close()
+}
+
+// xlsxColumnName turns a zero based column index into its letters, e.g. 27 into AB
+func xlsxColumnName(index int) string {
+	name := ""
+	for index++; index > 0; index = (index - 1) / 26 {
+		name = string(rune('A'+(index-1)%26)) + name
+	}
+	return name
+}
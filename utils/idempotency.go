@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrIdempotencyLockLost is returned when a request completes a key that it no longer holds
+var ErrIdempotencyLockLost = errors.New("idempotency key lock was lost")
+
+// IdempotencyRecord is the stored outcome of a request made with an Idempotency-Key.
+// Records that aren't Completed belong to a request that is still in flight.
+type IdempotencyRecord struct {
+	Completed   bool
+	Fingerprint string
+	StatusCode  int
+	Header      http.Header
+	Body        []byte
+	// Oversized records only keep the outcome of a request whose response was
+	// too large to store, it can't be replayed
+	Oversized bool
+}
+
+type IdempotencyStore interface {
+	// Lock reserves key for the request identified by token. It returns
+	// false and the key's record if another request holds or completed it.
+	Lock(ctx context.Context, key, token string, lockTimeout, ttl time.Duration) (*IdempotencyRecord, bool, error)
+	// Complete stores the response of the request holding key
+	Complete(ctx context.Context, key, token string, record IdempotencyRecord) error
+	// Release gives up the key without storing a response, so it can be retried
+	Release(ctx context.Context, key, token string) error
+}
+
+type idempotencyEntry struct {
+	record      IdempotencyRecord
+	token       string
+	lockedUntil time.Time
+	expiresAt   time.Time
+}
+
+// MemoryIdempotencyStore keeps records in process memory, so keys are per instance
+type MemoryIdempotencyStore struct {
+	mu        sync.Mutex
+	entries   map[string]*idempotencyEntry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryIdempotencyStore() *MemoryIdempotencyStore {
+	return &MemoryIdempotencyStore{
+		entries: make(map[string]*idempotencyEntry),
+		now:     time.Now,
+	}
+}
+
+// SetClock replaces the time source, used by tests
+func (s *MemoryIdempotencyStore) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+func (s *MemoryIdempotencyStore) Lock(ctx context.Context, key, token string, lockTimeout, ttl time.Duration) (*IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if ok && now.Before(entry.expiresAt) && (entry.record.Completed || now.Before(entry.lockedUntil)) {
+		record := entry.record
+		return &record, false, nil
+	}
+
+	// The key is new, expired or was left locked by a request that never finished
+	s.entries[key] = &idempotencyEntry{
+		token:       token,
+		lockedUntil: now.Add(lockTimeout),
+		expiresAt:   now.Add(ttl),
+	}
+	return nil, true, nil
+}
+
+func (s *MemoryIdempotencyStore) Complete(ctx context.Context, key, token string, record IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.entries[key]
+	if !ok || entry.token != token || entry.record.Completed {
+		return ErrIdempotencyLockLost
+	}
+	record.Completed = true
+	entry.record = record
+	return nil
+}
+
+func (s *MemoryIdempotencyStore) Release(ctx context.Context, key, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if entry, ok := s.entries[key]; ok && entry.token == token && !entry.record.Completed {
+		delete(s.entries, key)
+	}
+	return nil
+}
+
+// sweep drops expired records at most once a minute to keep memory bounded
+func (s *MemoryIdempotencyStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, entry := range s.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
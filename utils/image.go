@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+var (
+	ErrUnsupportedImageType = errors.New("unsupported image type")
+	ErrInvalidImage         = errors.New("invalid image")
+	ErrImageTooLarge        = errors.New("image dimensions too large")
+)
+
+// ThumbnailJPEGQuality is the quality of JPEG thumbnails
+const ThumbnailJPEGQuality = 85
+
+// SniffImageType detects the content type from the data itself instead of
+// trusting the file name or the client's Content-Type header
+func SniffImageType(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	switch contentType {
+	case "image/jpeg", "image/png", "image/gif", "image/webp":
+		return contentType, nil
+	}
+	return "", ErrUnsupportedImageType
+}
+
+// DecodeImage decodes an image of a type returned by SniffImageType. Animated
+// GIFs are decoded to their first frame. The dimensions are read from the
+// header first, images wider or taller than maxDimension or with more than
+// maxPixels pixels are rejected with ErrImageTooLarge without being decoded.
+func DecodeImage(data []byte, contentType string, maxDimension, maxPixels int) (image.Image, error) {
+	var decode func(io.Reader) (image.Image, error)
+	var decodeConfig func(io.Reader) (image.Config, error)
+	switch contentType {
+	case "image/jpeg":
+		decode, decodeConfig = jpeg.Decode, jpeg.DecodeConfig
+	case "image/png":
+		decode, decodeConfig = png.Decode, png.DecodeConfig
+	case "image/gif":
+		decode, decodeConfig = gif.Decode, gif.DecodeConfig
+	case "image/webp":
+		decode, decodeConfig = webp.Decode, webp.DecodeConfig
+	default:
+		return nil, ErrUnsupportedImageType
+	}
+
+	config, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Join(ErrInvalidImage, err)
+	}
+	if config.Width > maxDimension || config.Height > maxDimension || int64(config.Width)*int64(config.Height) > int64(maxPixels) {
+		return nil, ErrImageTooLarge
+	}
+
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, errors.Join(ErrInvalidImage, err)
+	}
+	return img, nil
+}
+
+// Thumbnail scales img down so its longest edge is at most size pixels,
+// keeping the aspect ratio. Images that are already small enough are
+// returned as they are.
+func Thumbnail(img image.Image, size int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= size && height <= size {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*size/width)
+		width = size
+	} else {
+		width = max(1, width*size/height)
+		height = size
+	}
+
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(thumbnail, thumbnail.Bounds(), img, bounds, draw.Over, nil)
+	return thumbnail
+}
+
+// EncodeThumbnail encodes a thumbnail as PNG for PNG sources, to keep
+// transparency, and as JPEG otherwise. It returns the data and its content type.
+func EncodeThumbnail(img image.Image, sourceType string) ([]byte, string, error) {
+	var buf bytes.Buffer
+	if sourceType == "image/png" {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", err
+		}
+		return buf.Bytes(), "image/png", nil
+	}
+
+	// JPEG has no transparency, flatten onto white instead of black
+	flattened := image.NewRGBA(img.Bounds())
+	draw.Draw(flattened, flattened.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flattened, flattened.Bounds(), img, img.Bounds().Min, draw.Over)
+
+	if err := jpeg.Encode(&buf, flattened, &jpeg.Options{Quality: ThumbnailJPEGQuality}); err != nil {
+		return nil, "", err
+	}
+	return buf.Bytes(), "image/jpeg", nil
+}
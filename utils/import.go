@@ -0,0 +1,199 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Import file formats
+const (
+	ImportCSV    = "csv"
+	ImportNDJSON = "ndjson"
+)
+
+// maxImportLineBytes bounds a single NDJSON line
+const maxImportLineBytes = 1 << 20
+
+var ErrUnsupportedImportFormat = errors.New("unsupported import format")
+
+// ImportRecord is one row of an import file with its values by column name.
+// Err is set when the row itself couldn't be read, the rest of the file still is.
+type ImportRecord struct {
+	Line   int
+	Fields map[string]string
+	Err    error
+}
+
+// ImportFormat returns the given format, or detects it from the file name
+func ImportFormat(format, filename string) (string, error) {
+	if format == "" {
+		switch strings.ToLower(filepath.Ext(filename)) {
+		case ".csv":
+			format = ImportCSV
+		case ".ndjson", ".jsonl":
+			format = ImportNDJSON
+		}
+	}
+
+	switch strings.ToLower(format) {
+	case ImportCSV:
+		return ImportCSV, nil
+	case ImportNDJSON, "jsonl":
+		return ImportNDJSON, nil
+	}
+	return "", fmt.Errorf("%w %q, use csv or ndjson", ErrUnsupportedImportFormat, format)
+}
+
+// ImportReader reads the rows of an import file one at a time, so files of
+// any size can be imported without holding them in memory
+type ImportReader struct {
+	next func() (ImportRecord, error)
+}
+
+// NewImportReader starts reading a CSV file with a header row, or one JSON
+// object per line. The CSV header is read and checked right away.
+func NewImportReader(r io.Reader, format string) (*ImportReader, error) {
+	switch format {
+	case ImportCSV:
+		return newCSVImportReader(r)
+	case ImportNDJSON:
+		return newNDJSONImportReader(r), nil
+	}
+	return nil, fmt.Errorf("%w %q", ErrUnsupportedImportFormat, format)
+}
+
+// Read returns the next row, or io.EOF after the last one
+func (ir *ImportReader) Read() (ImportRecord, error) {
+	return ir.next()
+}
+
+// ReadImportRecords reads all the rows of a file, see NewImportReader
+func ReadImportRecords(r io.Reader, format string) ([]ImportRecord, error) {
+	reader, err := NewImportReader(r, format)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []ImportRecord
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+}
+
+func newCSVImportReader(r io.Reader) (*ImportReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.New("the file is empty")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(header))
+	for i, column := range header {
+		// Spreadsheet programs like to start CSV files with a byte order mark
+		column = strings.TrimSpace(strings.TrimPrefix(column, "\ufeff"))
+		if column == "" {
+			return nil, fmt.Errorf("column %d has no name", i+1)
+		}
+		if seen[column] {
+			return nil, fmt.Errorf("column %q appears twice", column)
+		}
+		seen[column] = true
+		header[i] = column
+	}
+
+	return &ImportReader{next: func() (ImportRecord, error) {
+		// Quoting errors leave the reader out of step, so they end the file
+		values, err := reader.Read()
+		if err != nil {
+			return ImportRecord{}, err
+		}
+
+		line, _ := reader.FieldPos(0)
+		record := ImportRecord{Line: line}
+		if len(values) != len(header) {
+			record.Err = fmt.Errorf("expected %d values, got %d", len(header), len(values))
+		} else {
+			record.Fields = make(map[string]string, len(header))
+			for i, value := range values {
+				record.Fields[header[i]] = unescapeFormula(strings.TrimSpace(value))
+			}
+		}
+		return record, nil
+	}}, nil
+}
+
+func newNDJSONImportReader(r io.Reader) *ImportReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxImportLineBytes)
+
+	line := 0
+	return &ImportReader{next: func() (ImportRecord, error) {
+		for scanner.Scan() {
+			line++
+			data := bytes.TrimSpace(scanner.Bytes())
+			if len(data) == 0 {
+				continue
+			}
+
+			record := ImportRecord{Line: line}
+			record.Fields, record.Err = parseNDJSONObject(data)
+			return record, nil
+		}
+		if err := scanner.Err(); err != nil {
+			return ImportRecord{}, err
+		}
+		return ImportRecord{}, io.EOF
+	}}
+}
+
+func parseNDJSONObject(data []byte) (map[string]string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	// Keep numbers as written so prices aren't rounded through float64
+	decoder.UseNumber()
+
+	var object map[string]interface{}
+	if err := decoder.Decode(&object); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if object == nil {
+		return nil, errors.New("expected a JSON object")
+	}
+
+	fields := make(map[string]string, len(object))
+	for key, value := range object {
+		switch value := value.(type) {
+		case nil:
+			fields[key] = ""
+		case string:
+			fields[key] = strings.TrimSpace(value)
+		case json.Number:
+			fields[key] = value.String()
+		case bool:
+			fields[key] = strconv.FormatBool(value)
+		default:
+			return nil, fmt.Errorf("%s must be a string, number or boolean", key)
+		}
+	}
+	return fields, nil
+}
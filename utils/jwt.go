@@ -2,25 +2,43 @@ package utils
 
 import (
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var (
-	accessTokenSecret  = []byte(os.Getenv("ACCESS_TOKEN_SECRET"))
-	refreshTokenSecret = []byte(os.Getenv("REFRESH_TOKEN_SECRET"))
-)
-
 type Claims struct {
 	UserID string `json:"user_id"`
 	Role   string `json:"role"`
 	jwt.RegisteredClaims
 }
 
-func GenerateAccessToken(userID, role string) (string, error) {
-	expirationTime := time.Now().Add(15 * time.Minute)
+type JWTManager struct {
+	accessTokenSecret  []byte
+	refreshTokenSecret []byte
+	accessTokenTTL     time.Duration
+	refreshTokenTTL    time.Duration
+}
+
+func NewJWTManager(accessTokenSecret, refreshTokenSecret string, accessTokenTTL, refreshTokenTTL time.Duration) *JWTManager {
+	return &JWTManager{
+		accessTokenSecret:  []byte(accessTokenSecret),
+		refreshTokenSecret: []byte(refreshTokenSecret),
+		accessTokenTTL:     accessTokenTTL,
+		refreshTokenTTL:    refreshTokenTTL,
+	}
+}
+
+func (m *JWTManager) AccessTokenTTL() time.Duration {
+	return m.accessTokenTTL
+}
+
+func (m *JWTManager) RefreshTokenTTL() time.Duration {
+	return m.refreshTokenTTL
+}
+
+func (m *JWTManager) GenerateAccessToken(userID, role string) (string, error) {
+	expirationTime := time.Now().Add(m.accessTokenTTL)
 	claims := &Claims{
 		UserID: userID,
 		Role:   role,
@@ -29,11 +47,11 @@ func GenerateAccessToken(userID, role string) (string, error) {
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(accessTokenSecret)
+	return token.SignedString(m.accessTokenSecret)
 }
 
-func GenerateRefreshToken(userID string) (string, error) {
-	expirationTime := time.Now().Add(7 * 24 * time.Hour)
+func (m *JWTManager) GenerateRefreshToken(userID string) (string, error) {
+	expirationTime := time.Now().Add(m.refreshTokenTTL)
 	claims := &Claims{
 		UserID: userID,
 		RegisteredClaims: jwt.RegisteredClaims{
@@ -41,15 +59,15 @@ func GenerateRefreshToken(userID string) (string, error) {
 		},
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(refreshTokenSecret)
+	return token.SignedString(m.refreshTokenSecret)
 }
 
-func ValidateAccessToken(tokenString string) (*Claims, error) {
-	return validateToken(tokenString, accessTokenSecret)
+func (m *JWTManager) ValidateAccessToken(tokenString string) (*Claims, error) {
+	return validateToken(tokenString, m.accessTokenSecret)
 }
 
-func ValidateRefreshToken(tokenString string) (*Claims, error) {
-	return validateToken(tokenString, refreshTokenSecret)
+func (m *JWTManager) ValidateRefreshToken(tokenString string) (*Claims, error) {
+	return validateToken(tokenString, m.refreshTokenSecret)
 }
 
 func validateToken(tokenString string, secret []byte) (*Claims, error) {
@@ -66,10 +84,10 @@ func validateToken(tokenString string, secret []byte) (*Claims, error) {
 	return claims, nil
 }
 
-func RefreshAccessToken(refreshToken string) (string, error) {
-	claims, err := ValidateRefreshToken(refreshToken)
+func (m *JWTManager) RefreshAccessToken(refreshToken string) (string, error) {
+	claims, err := m.ValidateRefreshToken(refreshToken)
 	if err != nil {
 		return "", err
 	}
-	return GenerateAccessToken(claims.UserID, claims.Role)
+	return m.GenerateAccessToken(claims.UserID, claims.Role)
 }
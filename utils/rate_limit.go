@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitPolicy is a token bucket that holds up to Burst tokens and refills
+// at Limit tokens per Period. Every request takes one token.
+type RateLimitPolicy struct {
+	Name   string
+	Limit  int
+	Burst  int
+	Period time.Duration
+}
+
+func (p RateLimitPolicy) Capacity() float64 {
+	return float64(p.Burst)
+}
+
+// RefillRate returns the number of tokens added per second
+func (p RateLimitPolicy) RefillRate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	RetryAfter time.Duration
+	ResetAfter time.Duration
+}
+
+func NewRateLimitResult(policy RateLimitPolicy, tokens float64, allowed bool) RateLimitResult {
+	rate := policy.RefillRate()
+	result := RateLimitResult{
+		Allowed:    allowed,
+		Limit:      policy.Burst,
+		Remaining:  int(math.Floor(tokens)),
+		ResetAfter: time.Duration((policy.Capacity() - tokens) / rate * float64(time.Second)),
+	}
+	if !allowed {
+		result.RetryAfter = time.Duration((1 - tokens) / rate * float64(time.Second))
+	}
+	return result
+}
+
+// RefillTokens returns the tokens in a bucket that had the given tokens at last
+func RefillTokens(policy RateLimitPolicy, tokens float64, last, now time.Time) float64 {
+	elapsed := now.Sub(last).Seconds()
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	return math.Min(policy.Capacity(), tokens+elapsed*policy.RefillRate())
+}
+
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error)
+}
+
+type bucket struct {
+	tokens    float64
+	updatedAt time.Time
+	expiresAt time.Time
+}
+
+// MemoryRateLimitStore keeps buckets in process memory, so limits are per instance
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*bucket),
+		now:     time.Now,
+	}
+}
+
+// SetClock replaces the time source, used by tests
+func (s *MemoryRateLimitStore) SetClock(now func() time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.now = now
+}
+
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: policy.Capacity(), updatedAt: now}
+		s.buckets[key] = b
+	}
+
+	b.tokens = RefillTokens(policy, b.tokens, b.updatedAt, now)
+	b.updatedAt = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+
+	// A bucket is full again after ResetAfter, so it can be dropped then
+	result := NewRateLimitResult(policy, b.tokens, allowed)
+	b.expiresAt = now.Add(result.ResetAfter)
+
+	return result, nil
+}
+
+// sweep drops full buckets at most once a minute to keep memory bounded
+func (s *MemoryRateLimitStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < time.Minute {
+		return
+	}
+	s.lastSweep = now
+
+	for key, b := range s.buckets {
+		if now.After(b.expiresAt) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+)
+
+// WriteServerSentEvent writes one event in the text/event-stream format. An
+// empty id clears the client's last event ID. data must be a single line,
+// which JSON from encoding/json always is.
+func WriteServerSentEvent(w io.Writer, id, event string, data []byte) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", id, event, data)
+	return err
+}
+
+// WriteServerSentComment writes a comment line, clients ignore it but it keeps idle connections open
+func WriteServerSentComment(w io.Writer, comment string) error {
+	_, err := fmt.Fprintf(w, ": %s\n\n", comment)
+	return err
+}
@@ -0,0 +1,152 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	WebhookIDHeader        = "X-Webhook-Id"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookTimestampHeader = "X-Webhook-Timestamp"
+	WebhookSignatureHeader = "X-Webhook-Signature"
+
+	// maxWebhookErrorBody is how much of a failed response is kept in the delivery log
+	maxWebhookErrorBody = 512
+)
+
+// ErrWebhookAddressBlocked is returned when a webhook resolves to an address
+// that isn't on the public internet
+var ErrWebhookAddressBlocked = errors.New("webhook address is not public")
+
+// nonPublicPrefixes are the ranges not covered by the netip predicates that
+// must not be reachable from webhooks either
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// IsPublicAddr reports whether addr is a unicast address on the public
+// internet, as opposed to loopback, private, link-local (which includes
+// cloud metadata endpoints such as 169.254.169.254) or reserved ranges
+func IsPublicAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsValid() || !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
+
+// NewWebhookClient returns the client deliveries are sent with. Redirects
+// aren't followed, a 3xx response is a failed delivery. Unless allowPrivate
+// is set, connections to addresses that aren't public are refused when
+// dialing, after DNS resolution, so a hostname can't be pointed at an
+// internal service after the webhook was registered.
+func NewWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return err
+			}
+			if !IsPublicAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", ErrWebhookAddressBlocked, addrPort.Addr())
+			}
+			return nil
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// A proxy would connect to the webhook's address on our behalf, skipping the check above
+	transport.Proxy = nil
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// WebhookMessage is one delivery of an event to a webhook
+type WebhookMessage struct {
+	URL        string
+	Secret     string
+	DeliveryID string
+	Event      string
+	Payload    []byte
+}
+
+// SignWebhook returns the signature of a payload sent at timestamp, the hex
+// HMAC-SHA256 of "<unix timestamp>.<payload>" keyed with the webhook's secret
+func SignWebhook(secret string, timestamp time.Time, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10) + "."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyWebhookSignature checks the signature headers of a received delivery
+func VerifyWebhookSignature(secret, timestamp, signature string, payload []byte) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	expected := SignWebhook(secret, time.Unix(seconds, 0), payload)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// SendWebhook posts the message and returns the response status. Responses
+// other than 2xx are returned as errors along with their status.
+func SendWebhook(ctx context.Context, client *http.Client, message WebhookMessage) (int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, message.URL, bytes.NewReader(message.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "golang-gin-crud-api-webhooks")
+	request.Header.Set(WebhookIDHeader, message.DeliveryID)
+	request.Header.Set(WebhookEventHeader, message.Event)
+	request.Header.Set(WebhookTimestampHeader, strconv.FormatInt(now.Unix(), 10))
+	request.Header.Set(WebhookSignatureHeader, SignWebhook(message.Secret, now, message.Payload))
+
+	response, err := client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, maxWebhookErrorBody))
+		return response.StatusCode, fmt.Errorf("unexpected status %d: %s", response.StatusCode, strings.TrimSpace(string(body)))
+	}
+	// Read a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(response.Body, maxWebhookErrorBody))
+	return response.StatusCode, nil
+}
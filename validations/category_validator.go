@@ -0,0 +1,17 @@
+package validations
+
+import (
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+)
+
+func ValidateCategory(category *models.Category) []map[string]string {
+	return extractValidationErrors(validate.Struct(category))
+}
+
+func ValidateCategoryUpdate(request *models.CategoryUpdateRequest) []map[string]string {
+	return extractValidationErrors(validate.Struct(request))
+}
+
+func ValidateCreateCategoryInput(input *models.CreateCategoryInput) []map[string]string {
+	return extractValidationErrors(validate.Struct(input))
+}
@@ -3,13 +3,17 @@ package validations
 import (
 	"fmt"
 	"reflect"
+	"regexp"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
 )
 
 var validate *validator.Validate
 
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
 func init() {
 	validate = validator.New()
 	validate.RegisterTagNameFunc(func(fld reflect.StructField) string {
@@ -19,6 +23,20 @@ func init() {
 		}
 		return name
 	})
+
+	// Validate money by its minor units, a missing currency counts as no value
+	validate.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		money := field.Interface().(models.Money)
+		if money.Currency == "" {
+			return nil
+		}
+		return money.Amount
+	}, models.Money{})
+
+	// Lower-case words separated by single dashes, e.g. running-shoes
+	validate.RegisterValidation("slug", func(fl validator.FieldLevel) bool {
+		return slugPattern.MatchString(fl.Field().String())
+	})
 }
 
 // Helper function to format validation errors
@@ -56,6 +74,8 @@ func getErrorMsg(field, tag string, value interface{}) string {
 		return fmt.Sprintf("%s must be at least %v characters long", field, value)
 	case "max":
 		return fmt.Sprintf("%s must not be longer than %v characters", field, value)
+	case "slug":
+		return fmt.Sprintf("%s must only contain lower-case letters, digits and dashes", field)
 	default:
 		return fmt.Sprintf("%s is invalid", field)
 	}
@@ -12,6 +12,10 @@ func ValidateProductCreate(product *models.Product) []map[string]string {
 	return extractValidationErrors(validate.Struct(product))
 }
 
-func ValidateProductUpdate(product *models.Product) []map[string]string {
-	return extractValidationErrors(validate.StructPartial(product))
+func ValidateCreateProductInput(input *models.CreateProductInput) []map[string]string {
+	return extractValidationErrors(validate.Struct(input))
+}
+
+func ValidateUpdateProductInput(input *models.UpdateProductInput) []map[string]string {
+	return extractValidationErrors(validate.Struct(input))
 }
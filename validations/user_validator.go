@@ -12,6 +12,10 @@ func ValidateUserCreate(user *models.User) []map[string]string {
 	return extractValidationErrors(validate.Struct(user))
 }
 
-func ValidateUserUpdate(user *models.User) []map[string]string {
-	return extractValidationErrors(validate.StructPartial(user))
+func ValidateCreateUserInput(input *models.CreateUserInput) []map[string]string {
+	return extractValidationErrors(validate.Struct(input))
+}
+
+func ValidateUpdateUserInput(input *models.UpdateUserInput) []map[string]string {
+	return extractValidationErrors(validate.Struct(input))
 }
@@ -0,0 +1,26 @@
+package validations
+
+import (
+	"github.com/harsh-solanki21/golang-gin-crud-api/models"
+)
+
+func ValidateVariant(variant *models.Variant) []map[string]string {
+	return extractValidationErrors(validate.Struct(variant))
+}
+
+// ValidateVariantUpdate only validates the fields an update sets
+func ValidateVariantUpdate(variant *models.Variant) []map[string]string {
+	fields := []string{"Price", "Barcode"}
+	if variant.SKU != "" {
+		fields = append(fields, "SKU")
+	}
+	return extractValidationErrors(validate.StructPartial(variant, fields...))
+}
+
+func ValidateCreateVariantInput(input *models.CreateVariantInput) []map[string]string {
+	return extractValidationErrors(validate.Struct(input))
+}
+
+func ValidateUpdateVariantInput(input *models.UpdateVariantInput) []map[string]string {
+	return extractValidationErrors(validate.Struct(input))
+}